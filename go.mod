@@ -88,10 +88,12 @@ require (
 	github.com/fsnotify/fsnotify v1.7.0
 	github.com/go-git/go-billy/v5 v5.5.0
 	github.com/google/uuid v1.6.0
+	github.com/gorilla/websocket v1.5.1
 	github.com/jedib0t/go-pretty/v6 v6.5.9
 	github.com/prometheus/client_golang v1.19.1
 	github.com/willscott/go-nfs v0.0.2
 	go.etcd.io/etcd/client/v3 v3.5.13
+	golang.org/x/term v0.18.0
 	google.golang.org/grpc v1.59.0
 	k8s.io/cri-api v0.30.0
 )
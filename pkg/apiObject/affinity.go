@@ -0,0 +1,208 @@
+// 描述: Pod调度时使用的亲和性规则
+// 参考：https://kubernetes.io/zh-cn/docs/concepts/scheduling-eviction/assign-pod-node/#affinity-and-anti-affinity
+
+package apiObject
+
+import (
+	"slices"
+	"strconv"
+)
+
+// 节点选择器支持的运算符
+const (
+	NodeSelectorOpIn           = "In"
+	NodeSelectorOpNotIn        = "NotIn"
+	NodeSelectorOpExists       = "Exists"
+	NodeSelectorOpDoesNotExist = "DoesNotExist"
+	NodeSelectorOpGt           = "Gt"
+	NodeSelectorOpLt           = "Lt"
+)
+
+// 标签选择器支持的运算符
+const (
+	LabelSelectorOpIn           = "In"
+	LabelSelectorOpNotIn        = "NotIn"
+	LabelSelectorOpExists       = "Exists"
+	LabelSelectorOpDoesNotExist = "DoesNotExist"
+)
+
+type Affinity struct {
+	// Pod与节点之间的亲和性规则
+	NodeAffinity *NodeAffinity `json:"nodeAffinity" yaml:"nodeAffinity"`
+	// Pod之间的亲和性规则，Pod倾向于与满足条件的Pod运行在同一拓扑域中
+	PodAffinity *PodAffinity `json:"podAffinity" yaml:"podAffinity"`
+	// Pod之间的反亲和性规则，Pod倾向于不与满足条件的Pod运行在同一拓扑域中
+	PodAntiAffinity *PodAntiAffinity `json:"podAntiAffinity" yaml:"podAntiAffinity"`
+}
+
+type NodeAffinity struct {
+	// 调度时必须满足的规则，节点不满足时Pod不会被调度到该节点上，Pod运行后节点标签发生变化不会驱逐Pod
+	RequiredDuringSchedulingIgnoredDuringExecution *NodeSelector `json:"requiredDuringSchedulingIgnoredDuringExecution" yaml:"requiredDuringSchedulingIgnoredDuringExecution"`
+	// 调度时尽量满足的规则，满足的规则权重之和越大，节点得分越高
+	PreferredDuringSchedulingIgnoredDuringExecution []PreferredSchedulingTerm `json:"preferredDuringSchedulingIgnoredDuringExecution" yaml:"preferredDuringSchedulingIgnoredDuringExecution"`
+}
+
+type NodeSelector struct {
+	// 节点选择条件，满足其中任意一个条件即可
+	NodeSelectorTerms []NodeSelectorTerm `json:"nodeSelectorTerms" yaml:"nodeSelectorTerms"`
+}
+
+type NodeSelectorTerm struct {
+	// 节点标签需要满足的表达式，需要同时满足所有表达式
+	MatchExpressions []NodeSelectorRequirement `json:"matchExpressions" yaml:"matchExpressions"`
+}
+
+type NodeSelectorRequirement struct {
+	// 节点标签的键
+	Key string `json:"key" yaml:"key"`
+	// 运算符，包括：In、NotIn、Exists、DoesNotExist、Gt、Lt
+	Operator string `json:"operator" yaml:"operator"`
+	// 标签值的集合
+	// 	In、NotIn时为候选值，Exists、DoesNotExist时必须为空，Gt、Lt时只能有一个整数值
+	Values []string `json:"values" yaml:"values"`
+}
+
+type PreferredSchedulingTerm struct {
+	// 权重，取值范围为 1-100
+	Weight int32 `json:"weight" yaml:"weight"`
+	// 节点选择条件
+	Preference NodeSelectorTerm `json:"preference" yaml:"preference"`
+}
+
+type PodAffinity struct {
+	// 调度时必须满足的规则，需要同时满足所有条件
+	RequiredDuringSchedulingIgnoredDuringExecution []PodAffinityTerm `json:"requiredDuringSchedulingIgnoredDuringExecution" yaml:"requiredDuringSchedulingIgnoredDuringExecution"`
+	// 调度时尽量满足的规则
+	PreferredDuringSchedulingIgnoredDuringExecution []WeightedPodAffinityTerm `json:"preferredDuringSchedulingIgnoredDuringExecution" yaml:"preferredDuringSchedulingIgnoredDuringExecution"`
+}
+
+type PodAntiAffinity struct {
+	// 调度时必须满足的规则，需要同时满足所有条件
+	RequiredDuringSchedulingIgnoredDuringExecution []PodAffinityTerm `json:"requiredDuringSchedulingIgnoredDuringExecution" yaml:"requiredDuringSchedulingIgnoredDuringExecution"`
+	// 调度时尽量满足的规则
+	PreferredDuringSchedulingIgnoredDuringExecution []WeightedPodAffinityTerm `json:"preferredDuringSchedulingIgnoredDuringExecution" yaml:"preferredDuringSchedulingIgnoredDuringExecution"`
+}
+
+type PodAffinityTerm struct {
+	// 选择Pod的标签选择器
+	LabelSelector *LabelSelector `json:"labelSelector" yaml:"labelSelector"`
+	// 在哪些命名空间中选择Pod，为空时表示该Pod所在的命名空间
+	Namespaces []string `json:"namespaces" yaml:"namespaces"`
+	// 拓扑域对应的节点标签，如 kubernetes.io/hostname 表示每个节点为一个拓扑域
+	TopologyKey string `json:"topologyKey" yaml:"topologyKey"`
+}
+
+type WeightedPodAffinityTerm struct {
+	// 权重，取值范围为 1-100
+	Weight int32 `json:"weight" yaml:"weight"`
+	// Pod亲和性条件
+	PodAffinityTerm PodAffinityTerm `json:"podAffinityTerm" yaml:"podAffinityTerm"`
+}
+
+type LabelSelector struct {
+	// 标签需要与之完全相同的键值对
+	MatchLabels map[string]string `json:"matchLabels" yaml:"matchLabels"`
+	// 标签需要满足的表达式
+	MatchExpressions []LabelSelectorRequirement `json:"matchExpressions" yaml:"matchExpressions"`
+}
+
+type LabelSelectorRequirement struct {
+	// 标签的键
+	Key string `json:"key" yaml:"key"`
+	// 运算符，包括：In、NotIn、Exists、DoesNotExist
+	Operator string `json:"operator" yaml:"operator"`
+	// 标签值的集合，In、NotIn时为候选值，Exists、DoesNotExist时必须为空
+	Values []string `json:"values" yaml:"values"`
+}
+
+// Matches 判断标签是否满足该选择器，为nil的选择器不匹配任何对象，空选择器匹配所有对象
+func (s *LabelSelector) Matches(labels map[string]string) bool {
+	if s == nil {
+		return false
+	}
+	for key, value := range s.MatchLabels {
+		if labelValue, ok := labels[key]; !ok || labelValue != value {
+			return false
+		}
+	}
+	for _, requirement := range s.MatchExpressions {
+		value, exists := labels[requirement.Key]
+		switch requirement.Operator {
+		case LabelSelectorOpIn:
+			if !exists || !slices.Contains(requirement.Values, value) {
+				return false
+			}
+		case LabelSelectorOpNotIn:
+			if exists && slices.Contains(requirement.Values, value) {
+				return false
+			}
+		case LabelSelectorOpExists:
+			if !exists {
+				return false
+			}
+		case LabelSelectorOpDoesNotExist:
+			if exists {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Matches 判断节点标签是否满足该表达式
+func (r *NodeSelectorRequirement) Matches(labels map[string]string) bool {
+	value, exists := labels[r.Key]
+	switch r.Operator {
+	case NodeSelectorOpIn:
+		return exists && slices.Contains(r.Values, value)
+	case NodeSelectorOpNotIn:
+		return !exists || !slices.Contains(r.Values, value)
+	case NodeSelectorOpExists:
+		return exists
+	case NodeSelectorOpDoesNotExist:
+		return !exists
+	case NodeSelectorOpGt, NodeSelectorOpLt:
+		if !exists || len(r.Values) != 1 {
+			return false
+		}
+		labelValue, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		target, err := strconv.ParseInt(r.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if r.Operator == NodeSelectorOpGt {
+			return labelValue > target
+		}
+		return labelValue < target
+	default:
+		return false
+	}
+}
+
+// Matches 判断节点标签是否满足该条件中的所有表达式，没有任何表达式的条件不匹配任何节点
+func (t *NodeSelectorTerm) Matches(labels map[string]string) bool {
+	if len(t.MatchExpressions) == 0 {
+		return false
+	}
+	for i := range t.MatchExpressions {
+		if !t.MatchExpressions[i].Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches 判断节点标签是否满足任意一个节点选择条件
+func (s *NodeSelector) Matches(labels map[string]string) bool {
+	for i := range s.NodeSelectorTerms {
+		if s.NodeSelectorTerms[i].Matches(labels) {
+			return true
+		}
+	}
+	return false
+}
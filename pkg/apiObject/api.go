@@ -30,6 +30,13 @@ const (
 	NodeType       = "Node"
 	HpaType        = "Hpa"
 	ContainerType  = "Container"
+
+	ResourceQuotaType = "ResourceQuota"
+	LimitRangeType    = "LimitRange"
+	LeaseType         = "Lease"
+	BindingType       = "Binding"
+	PriorityClassType = "PriorityClass"
+	PodGroupType      = "PodGroup"
 )
 
-var AllTypeList = []string{PodType, ServiceType, ReplicaSetType, NodeType, HpaType, ContainerType}
+var AllTypeList = []string{PodType, ServiceType, ReplicaSetType, NodeType, HpaType, ContainerType, ResourceQuotaType, PriorityClassType, PodGroupType}
@@ -0,0 +1,20 @@
+// 描述: Binding对象的封装，调度器通过创建Pod的binding子资源将Pod绑定到节点上
+// 参考：https://kubernetes.io/docs/reference/kubernetes-api/cluster-resources/binding-v1/
+
+package apiObject
+
+type Binding struct {
+	// 对象的类型元数据
+	TypeMeta
+	// 对象的元数据，名称与命名空间与被绑定的Pod相同
+	Metadata ObjectMeta `json:"metadata" yaml:"metadata"`
+	// 绑定的目标对象
+	Target ObjectReference `json:"target" yaml:"target"`
+}
+
+type ObjectReference struct {
+	// 目标对象的类型，目前只支持Node
+	Kind string `json:"kind" yaml:"kind"`
+	// 目标对象的名称
+	Name string `json:"name" yaml:"name"`
+}
@@ -33,6 +33,8 @@ type Container struct {
 	Ports []ContainerPort `json:"ports" yaml:"ports"`
 	// 容器的环境变量
 	Env []EnvVar `json:"env" yaml:"env"`
+	// 容器的资源请求与限制
+	Resources ResourceRequirements `json:"resources" yaml:"resources"`
 	// 容器的存储卷挂载
 	VolumeMounts []VolumeMount `json:"volumeMounts" yaml:"volumeMounts"`
 	// 容器与主机的挂载
@@ -47,6 +49,13 @@ type Container struct {
 	StdinOnce bool `json:"stdinOnce" yaml:"stdinOnce"`
 	// 是否使用 tty
 	TTY bool `json:"tty" yaml:"tty"`
+
+	// 存活探针，探测失败时kubelet重启容器
+	LivenessProbe *Probe `json:"livenessProbe" yaml:"livenessProbe"`
+	// 就绪探针，探测成功之前Pod不会被Service选为后端
+	ReadinessProbe *Probe `json:"readinessProbe" yaml:"readinessProbe"`
+	// 启动探针，探测成功之前不执行存活探针与就绪探针，探测失败时kubelet重启容器
+	StartupProbe *Probe `json:"startupProbe" yaml:"startupProbe"`
 }
 
 type ContainerPort struct {
@@ -56,20 +65,71 @@ type ContainerPort struct {
 	Metrics string
 	// 容器端口
 	ContainerPort int32 `json:"containerPort" yaml:"containerPort"`
-	// 主机端口
+	// 主机端口，不为0时将主机上的该端口映射到容器端口，同一节点上的Pod不能使用相同的主机端口
 	HostPort int32 `json:"hostPort" yaml:"hostPort"`
-	// 主机IP
+	// 主机端口绑定的主机IP，默认为所有地址
 	HostIP string `json:"hostIP" yaml:"hostIP"`
+	// 端口协议，包括TCP、UDP、SCTP，默认为TCP
+	Protocol Protocol `json:"protocol" yaml:"protocol"`
 }
 
 // Protocol 端口协议
 type Protocol string
 
+const (
+	ProtocolTCP  Protocol = "TCP"
+	ProtocolUDP  Protocol = "UDP"
+	ProtocolSCTP Protocol = "SCTP"
+)
+
 type EnvVar struct {
 	// 环境变量的名称
 	Name string `json:"name" yaml:"name"`
 	// 环境变量的值
 	Value string `json:"value" yaml:"value"`
+	// 环境变量值的来源，不能与value同时指定
+	ValueFrom *EnvVarSource `json:"valueFrom" yaml:"valueFrom"`
+}
+
+// EnvVarSource 环境变量值的来源，fieldRef与resourceFieldRef中只能指定一种
+type EnvVarSource struct {
+	// 引用Pod的字段
+	FieldRef *ObjectFieldSelector `json:"fieldRef" yaml:"fieldRef"`
+	// 引用容器的资源请求或限制
+	ResourceFieldRef *ResourceFieldSelector `json:"resourceFieldRef" yaml:"resourceFieldRef"`
+}
+
+// 环境变量可以引用的Pod字段
+const (
+	FieldPathPodName   = "metadata.name"
+	FieldPathNamespace = "metadata.namespace"
+	FieldPathPodUID    = "metadata.uid"
+	FieldPathNodeName  = "spec.nodeName"
+	FieldPathPodIP     = "status.podIP"
+)
+
+type ObjectFieldSelector struct {
+	// 引用的字段路径，如 metadata.name、status.podIP
+	FieldPath string `json:"fieldPath" yaml:"fieldPath"`
+}
+
+type ResourceFieldSelector struct {
+	// 引用的容器名称，为空时为环境变量所在的容器
+	ContainerName string `json:"containerName" yaml:"containerName"`
+	// 引用的资源，包括：limits.cpu、limits.memory、requests.cpu、requests.memory
+	Resource string `json:"resource" yaml:"resource"`
+	// 资源数量的单位，默认为1，即cpu以核、memory以字节为单位，结果向上取整
+	Divisor string `json:"divisor" yaml:"divisor"`
+}
+
+// ResourceList 资源名称到资源数量的映射，如 cpu: 500m，memory: 128Mi
+type ResourceList map[string]string
+
+type ResourceRequirements struct {
+	// 容器可以使用的资源上限
+	Limits ResourceList `json:"limits" yaml:"limits"`
+	// 容器运行所需的最少资源，用于调度和配额计算
+	Requests ResourceList `json:"requests" yaml:"requests"`
 }
 
 type VolumeMount struct {
@@ -88,6 +148,51 @@ type Mount struct {
 	ReadOnly bool `json:"readOnly" yaml:"readOnly"`
 }
 
+// Probe 容器的探针，exec、httpGet与tcpSocket中只能指定一种探测方式
+type Probe struct {
+	// 在容器中执行命令，退出码为0时探测成功
+	Exec *ExecAction `json:"exec" yaml:"exec"`
+	// 向容器发送HTTP GET请求，状态码在200到399之间时探测成功
+	HTTPGet *HTTPGetAction `json:"httpGet" yaml:"httpGet"`
+	// 与容器的端口建立TCP连接，连接成功时探测成功
+	TCPSocket *TCPSocketAction `json:"tcpSocket" yaml:"tcpSocket"`
+
+	// 容器启动后等待多久开始探测（秒），默认为0
+	InitialDelaySeconds int32 `json:"initialDelaySeconds" yaml:"initialDelaySeconds"`
+	// 单次探测的超时时间（秒），默认为1
+	TimeoutSeconds int32 `json:"timeoutSeconds" yaml:"timeoutSeconds"`
+	// 探测的间隔（秒），默认为10
+	PeriodSeconds int32 `json:"periodSeconds" yaml:"periodSeconds"`
+	// 探测失败后，连续成功多少次才视为成功，默认为1，存活探针与启动探针只能为1
+	SuccessThreshold int32 `json:"successThreshold" yaml:"successThreshold"`
+	// 探测成功后，连续失败多少次才视为失败，默认为3
+	FailureThreshold int32 `json:"failureThreshold" yaml:"failureThreshold"`
+}
+
+// 探针参数的默认值
+const (
+	DefaultProbeTimeoutSeconds   int32 = 1
+	DefaultProbePeriodSeconds    int32 = 10
+	DefaultProbeSuccessThreshold int32 = 1
+	DefaultProbeFailureThreshold int32 = 3
+)
+
+// SetDefaults 为未指定的探针参数填写默认值
+func (p *Probe) SetDefaults() {
+	if p.TimeoutSeconds <= 0 {
+		p.TimeoutSeconds = DefaultProbeTimeoutSeconds
+	}
+	if p.PeriodSeconds <= 0 {
+		p.PeriodSeconds = DefaultProbePeriodSeconds
+	}
+	if p.SuccessThreshold <= 0 {
+		p.SuccessThreshold = DefaultProbeSuccessThreshold
+	}
+	if p.FailureThreshold <= 0 {
+		p.FailureThreshold = DefaultProbeFailureThreshold
+	}
+}
+
 type ExecAction struct {
 	// 执行命令
 	Command []string `json:"command" yaml:"command"`
@@ -98,8 +203,15 @@ type HTTPGetAction struct {
 	Path string `json:"path" yaml:"path"`
 	// 请求端口
 	Port int `json:"port" yaml:"port"`
-	// 请求主机
+	// 请求主机，默认为Pod的IP
 	Host string `json:"host" yaml:"host"`
-	// 请求协议
+	// 请求协议，包括：HTTP、HTTPS，默认为HTTP
 	Scheme string `json:"scheme" yaml:"scheme"`
 }
+
+type TCPSocketAction struct {
+	// 连接的端口
+	Port int `json:"port" yaml:"port"`
+	// 连接的主机，默认为Pod的IP
+	Host string `json:"host" yaml:"host"`
+}
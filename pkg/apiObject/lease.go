@@ -0,0 +1,35 @@
+// 描述: Lease对象的封装，kubelet通过定期续约节点的Lease来表明自己仍然存活
+// 参考：https://kubernetes.io/zh-cn/docs/concepts/architecture/leases/
+//		https://github.com/kubernetes/kubernetes/blob/master/staging/src/k8s.io/api/coordination/v1/types.go
+
+package apiObject
+
+import "time"
+
+// NodeLeaseNamespace 节点心跳Lease所在的命名空间，Lease的名称与节点名称相同
+const NodeLeaseNamespace = "kube-node-lease"
+
+type Lease struct {
+	// 对象的类型元数据
+	TypeMeta
+	// 对象的元数据
+	Metadata ObjectMeta `json:"metadata" yaml:"metadata"`
+	// Lease的规格
+	Spec LeaseSpec `json:"spec" yaml:"spec"`
+}
+
+type LeaseSpec struct {
+	// 当前持有该Lease的对象，对于节点心跳即为节点名称
+	HolderIdentity string `json:"holderIdentity" yaml:"holderIdentity"`
+	// 持有者需要在该时长内续约，否则认为Lease已经过期
+	LeaseDurationSeconds int32 `json:"leaseDurationSeconds" yaml:"leaseDurationSeconds"`
+	// 持有者首次获得该Lease的时间
+	AcquireTime time.Time `json:"acquireTime" yaml:"acquireTime"`
+	// 持有者最近一次续约的时间
+	RenewTime time.Time `json:"renewTime" yaml:"renewTime"`
+}
+
+// Expired 判断Lease在now时刻是否已经过期
+func (l *Lease) Expired(now time.Time) bool {
+	return now.After(l.Spec.RenewTime.Add(time.Duration(l.Spec.LeaseDurationSeconds) * time.Second))
+}
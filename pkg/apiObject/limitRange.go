@@ -0,0 +1,36 @@
+// 描述: LimitRange对象的封装，用于为命名空间内的容器注入默认的资源请求与限制，并约束其最小值与最大值
+// 参考：https://kubernetes.io/zh-cn/docs/concepts/policy/limit-range/
+
+package apiObject
+
+const (
+	// LimitTypeContainer 对命名空间内的每一个容器生效
+	LimitTypeContainer = "Container"
+)
+
+type LimitRange struct {
+	// 对象的类型元数据
+	TypeMeta
+	// 对象的元数据
+	Metadata ObjectMeta `json:"metadata" yaml:"metadata"`
+	// LimitRange的规格
+	Spec LimitRangeSpec `json:"spec" yaml:"spec"`
+}
+
+type LimitRangeSpec struct {
+	// 限制条目
+	Limits []LimitRangeItem `json:"limits" yaml:"limits"`
+}
+
+type LimitRangeItem struct {
+	// 限制的对象类型，目前只支持Container，为空时视为Container
+	Type string `json:"type" yaml:"type"`
+	// 资源请求与限制的最大值
+	Max ResourceList `json:"max" yaml:"max"`
+	// 资源请求与限制的最小值
+	Min ResourceList `json:"min" yaml:"min"`
+	// 未指定资源限制时注入的默认限制
+	Default ResourceList `json:"default" yaml:"default"`
+	// 未指定资源请求时注入的默认请求
+	DefaultRequest ResourceList `json:"defaultRequest" yaml:"defaultRequest"`
+}
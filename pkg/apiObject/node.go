@@ -4,6 +4,8 @@
 
 package apiObject
 
+import "time"
+
 type Node struct {
 	// 对象的类型元数据
 	TypeMeta
@@ -20,17 +22,48 @@ type NodeSpec struct {
 	PodCIDR string `json:"podCIDR" yaml:"podCIDR"`
 	// 云提供商分配的节点ID，格式为：<ProviderName>://<ProviderSpecificNodeID>
 	ProviderID string `json:"providerID" yaml:"providerID"`
-	// 是否可以被调度
+	// 是否不可以被调度，为true时只有容忍node.kubernetes.io/unschedulable污点的Pod才能调度到该Node上
 	Unschedulable bool `json:"unschedulable" yaml:"unschedulable"`
+	// Node的污点，没有容忍这些污点的Pod不会被调度到该Node上
+	Taints []Taint `json:"taints" yaml:"taints"`
+}
+
+const (
+	// TaintNodeNotReady 节点未就绪时由nodeLifecycleController添加的污点
+	TaintNodeNotReady = "node.kubernetes.io/not-ready"
+	// TaintNodeUnschedulable 节点被标记为不可调度时，Pod需要容忍该污点才能调度到该节点上
+	TaintNodeUnschedulable = "node.kubernetes.io/unschedulable"
+
+	// TaintEffectNoSchedule 不再向该节点调度新的Pod
+	TaintEffectNoSchedule = "NoSchedule"
+	// TaintEffectPreferNoSchedule 尽量不向该节点调度新的Pod
+	TaintEffectPreferNoSchedule = "PreferNoSchedule"
+	// TaintEffectNoExecute 不再向该节点调度新的Pod，并驱逐节点上已有的Pod
+	TaintEffectNoExecute = "NoExecute"
+)
+
+type Taint struct {
+	// 污点的键
+	Key string `json:"key" yaml:"key"`
+	// 污点的值
+	Value string `json:"value" yaml:"value"`
+	// 污点的效果，包括：NoSchedule、PreferNoSchedule、NoExecute
+	Effect string `json:"effect" yaml:"effect"`
+	// 添加污点的时间，仅对NoExecute生效
+	TimeAdded time.Time `json:"timeAdded" yaml:"timeAdded"`
 }
 
 type NodeStatus struct {
 	// Node节点的资源容量
-	// 	包括：内存
-	Capacity map[string]string `json:"capacity" yaml:"capacity"`
-	// Node的可分配资源,其数值为使用率
-	// 	包括：内存、CPU
-	Allocatable map[string]string `json:"allocatable" yaml:"allocatable"`
+	// 	包括：cpu（如 4）、memory（如 16318472Ki）、pods（如 110）
+	Capacity ResourceList `json:"capacity" yaml:"capacity"`
+	// Node的可分配资源，即资源容量减去为系统预留的部分，调度器根据其判断Pod能否放入该Node
+	// 	包括：cpu、memory、pods
+	Allocatable ResourceList `json:"allocatable" yaml:"allocatable"`
+	// Node的CPU使用率
+	CpuUsage float64 `json:"cpuUsage" yaml:"cpuUsage"`
+	// Node的内存使用率
+	MemUsage float64 `json:"memUsage" yaml:"memUsage"`
 	// Node最近观测到的生命周期阶段，包括：Pending、Running、Terminating
 	// 	Pending: Node已经被系统创建，但是还没有被配置
 	// 	Running: Node节点已经配置好，并且运行了Kubernetes组件
@@ -55,8 +88,23 @@ type NodeCondition struct {
 	// 	False: 条件不满足
 	// 	Unknown: 状态未知
 	Status string `json:"status" yaml:"status"`
+	// 最近一次收到心跳的时间
+	LastHeartbeatTime time.Time `json:"lastHeartbeatTime" yaml:"lastHeartbeatTime"`
+	// 条件状态最近一次发生变化的时间
+	LastTransitionTime time.Time `json:"lastTransitionTime" yaml:"lastTransitionTime"`
+	// 条件状态变化的原因
+	Reason string `json:"reason" yaml:"reason"`
 }
 
+const (
+	// NodeReady kubelet准备好接受Pod
+	NodeReady = "Ready"
+
+	ConditionTrue    = "True"
+	ConditionFalse   = "False"
+	ConditionUnknown = "Unknown"
+)
+
 type NodeAddress struct {
 	// 地址的类型
 	Type string `json:"type" yaml:"type"`
@@ -68,3 +116,29 @@ type NodeAddress struct {
 func (n *Node) UpdateNodeStatus(status NodeStatus) {
 	n.Status = status
 }
+
+// GetCondition 获取指定类型的条件，不存在时返回nil
+func (n *Node) GetCondition(conditionType string) *NodeCondition {
+	for i := range n.Status.Conditions {
+		if n.Status.Conditions[i].Type == conditionType {
+			return &n.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsReady 判断Node的Ready条件是否为True，没有上报条件的Node视为就绪
+func (n *Node) IsReady() bool {
+	condition := n.GetCondition(NodeReady)
+	return condition == nil || condition.Status == ConditionTrue
+}
+
+// HasTaint 判断Node是否带有指定键与效果的污点
+func (n *Node) HasTaint(key string, effect string) bool {
+	for _, taint := range n.Spec.Taints {
+		if taint.Key == key && taint.Effect == effect {
+			return true
+		}
+	}
+	return false
+}
@@ -5,22 +5,22 @@ package apiObject
 
 import (
 	"time"
-
-	"github.com/docker/docker/api/types"
 )
 
 // 参考：https://kubernetes.io/zh-cn/docs/concepts/workloads/pods/pod-lifecycle/#pod-phase
 //
 //	Pending（悬决）：Pod 已被 Kubernetes 系统接受，但尚未分配至 Node 或者尚未传送至 Containerd 进行创建.
 //	Building (创建中)： Pod 已经被分配到具体的 Node 节点进行创建，此时正在创建 PodSandbox 或者 Containers，或者正在准备镜像
-//	Succeeded（成功创建）：Pod 中的所有容器都已成功创建，但是Pod还未被运行。
+//	Created（已创建）：Pod 中的所有容器都已成功创建，但是Pod还未被运行。
 //	Running（运行中）：Pod 已经成功运行，且正常提供 Pod 功能，有kubelet负责其容错。
+//	Succeeded（成功）：Pod 中的所有容器都已成功终止，并且不会再重启。
 //	Failed（失败）：Pod 中的所有容器都已终止，并且至少有一个容器是因为失败终止。也就是说，容器以非 0 状态退出或者被系统终止。
 //	Unknown（未知）：因为某些原因无法取得 Pod 的状态。这种情况通常是因为与 Pod 所在主机通信失败。
 //	Terminating（需要终止）：Pod 已被请求终止，但是该终止请求还没有被发送到底层容器。Pod 仍然在运行。
 const (
 	PodPending     = "Pending"
 	PodBuilding    = "Building"
+	PodCreated     = "Created"
 	PodRunning     = "Running"
 	PodSucceeded   = "Succeeded"
 	PodFailed      = "Failed"
@@ -54,8 +54,38 @@ type PodSpec struct {
 	// 表明Pod应该被调度到的节点
 	// 	如果为空，则表示Pod可以被调度到任何节点
 	NodeName string `json:"nodeName" yaml:"nodeName"`
+	// Pod的亲和性规则，调度时与NodeSelector同时生效
+	Affinity *Affinity `json:"affinity" yaml:"affinity"`
+	// Pod对节点污点的容忍
+	Tolerations []Toleration `json:"tolerations" yaml:"tolerations"`
+	// Pod在各个拓扑域之间的分布约束，需要同时满足所有约束
+	TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints" yaml:"topologySpreadConstraints"`
+	// 调度该Pod时使用的调度配置，为空时使用default-scheduler
+	SchedulerName string `json:"schedulerName" yaml:"schedulerName"`
+	// Pod使用的优先级类，为空时使用集群中globalDefault的优先级类
+	PriorityClassName string `json:"priorityClassName" yaml:"priorityClassName"`
+	// Pod的优先级，创建Pod时由apiServer根据优先级类填写
+	Priority *int32 `json:"priority" yaml:"priority"`
+	// Pod的抢占策略，创建Pod时由apiServer根据优先级类填写，包括：PreemptLowerPriority、Never
+	PreemptionPolicy string `json:"preemptionPolicy" yaml:"preemptionPolicy"`
+	// 删除Pod时等待容器优雅退出的时间（秒），超时后强制停止容器，未指定时为30秒
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds" yaml:"terminationGracePeriodSeconds"`
 }
 
+// 参考：https://kubernetes.io/zh-cn/docs/concepts/workloads/pods/pod-lifecycle/#restart-policy
+//
+//	Always：容器退出后总是重启
+//	OnFailure：容器以非 0 状态退出时重启
+//	Never：容器退出后不再重启
+const (
+	RestartPolicyAlways    = "Always"
+	RestartPolicyOnFailure = "OnFailure"
+	RestartPolicyNever     = "Never"
+)
+
+// DefaultTerminationGracePeriodSeconds 未指定terminationGracePeriodSeconds时的优雅退出时间
+const DefaultTerminationGracePeriodSeconds int64 = 30
+
 type Volume struct {
 	// 存储卷的名称
 	Name string `json:"name" yaml:"name"`
@@ -99,8 +129,8 @@ type PodStatus struct {
 
 	// Pod的启动时间
 	StartTime time.Time `json:"startTime" yaml:"startTime"`
-	// 容器的状态
-	ContainerStatuses []types.ContainerState `json:"containerStatuses" yaml:"containerStatuses"`
+	// 容器的状态，与Spec中的容器按顺序一一对应
+	ContainerStatuses []PodContainerStatus `json:"containerStatuses" yaml:"containerStatuses"`
 	// 最后更新时间
 	LastUpdateTime time.Time `json:"lastUpdateTime" yaml:"lastUpdateTime"`
 
@@ -108,8 +138,200 @@ type PodStatus struct {
 	CpuUsage float64 `json:"cpuUsage" yaml:"cpuUsage"`
 	// 内存使用率
 	MemUsage float64 `json:"memUsage" yaml:"memUsage"`
+
+	// Pod的状况，如是否已经被调度
+	Conditions []PodCondition `json:"podConditions" yaml:"podConditions"`
+	// 抢占其他Pod后，调度器为该Pod提名的节点，Pod绑定到节点上之后清空
+	NominatedNodeName string `json:"nominatedNodeName" yaml:"nominatedNodeName"`
+	// 根据容器的资源请求与限制得到的服务质量等级，由kubelet在创建Pod时设置
+	QOSClass PodQOSClass `json:"qosClass" yaml:"qosClass"`
+}
+
+// PodQOSClass Pod的服务质量等级，决定了Pod所在的cgroup以及节点内存不足时容器被终止的先后顺序
+//
+//	参考：https://kubernetes.io/zh-cn/docs/concepts/workloads/pods/pod-qos/
+type PodQOSClass string
+
+const (
+	// PodQOSGuaranteed 所有容器都设置了cpu与memory的限制，且请求与限制相等
+	PodQOSGuaranteed PodQOSClass = "Guaranteed"
+	// PodQOSBurstable 至少一个容器设置了cpu或memory的请求或限制，但不满足Guaranteed
+	PodQOSBurstable PodQOSClass = "Burstable"
+	// PodQOSBestEffort 所有容器都没有设置cpu与memory的请求和限制
+	PodQOSBestEffort PodQOSClass = "BestEffort"
+)
+
+// 参考：https://kubernetes.io/zh-cn/docs/concepts/workloads/pods/pod-lifecycle/#pod-conditions
+const (
+	// PodScheduled Pod已经被调度到某个节点上
+	PodScheduled = "PodScheduled"
+	// PodReasonUnschedulable 调度器找不到可以放置Pod的节点
+	PodReasonUnschedulable = "Unschedulable"
+	// ContainersReady Pod中所有的容器都已经就绪
+	ContainersReady = "ContainersReady"
+	// PodReady Pod可以接收请求，会被Service选为后端
+	PodReady = "Ready"
+	// PodReadyToStartContainers kubelet已经为Pod创建好sandbox与容器
+	PodReadyToStartContainers = "PodReadyToStartContainers"
+	// PodReasonCreatePodFailed kubelet创建Pod失败，会在下一次同步时重试
+	PodReasonCreatePodFailed = "CreatePodFailed"
+	// PodDisruptionTarget Pod即将因为抢占等原因被删除
+	PodDisruptionTarget = "DisruptionTarget"
+	// PodReasonPreemptionByScheduler Pod被调度器选为抢占的牺牲者
+	PodReasonPreemptionByScheduler = "PreemptionByScheduler"
+	// PodReasonEvictionByDrain Pod所在的节点正在被kubectl drain清空
+	PodReasonEvictionByDrain = "EvictionByDrain"
+)
+
+// PodContainerStatus Pod中单个容器的状态
+type PodContainerStatus struct {
+	// 容器的名称
+	Name string `json:"name" yaml:"name"`
+	// 容器当前的ID，容器被重启后会发生变化
+	ContainerID string `json:"containerID" yaml:"containerID"`
+	// 容器当前的状态
+	State ContainerState `json:"state" yaml:"state"`
+	// 容器上一次终止时的状态
+	LastTerminationState ContainerState `json:"lastState" yaml:"lastState"`
+	// 容器被kubelet重启的次数
+	RestartCount int32 `json:"restartCount" yaml:"restartCount"`
+}
+
+// ContainerState 容器的状态，Waiting、Running、Terminated中最多只有一个不为空
+type ContainerState struct {
+	// 容器正在等待创建或者重启
+	Waiting *ContainerStateWaiting `json:"waiting" yaml:"waiting"`
+	// 容器正在运行
+	Running *ContainerStateRunning `json:"running" yaml:"running"`
+	// 容器已经终止
+	Terminated *ContainerStateTerminated `json:"terminated" yaml:"terminated"`
+}
+
+type ContainerStateWaiting struct {
+	// 等待的原因，如 CrashLoopBackOff
+	Reason string `json:"reason" yaml:"reason"`
+	// 等待的详细信息
+	Message string `json:"message" yaml:"message"`
+}
+
+type ContainerStateRunning struct {
+	// 容器开始运行的时间
+	StartedAt time.Time `json:"startedAt" yaml:"startedAt"`
+}
+
+type ContainerStateTerminated struct {
+	// 容器的退出码
+	ExitCode int32 `json:"exitCode" yaml:"exitCode"`
+	// 终止的原因，如 Completed、Error
+	Reason string `json:"reason" yaml:"reason"`
+	// 终止的详细信息
+	Message string `json:"message" yaml:"message"`
+	// 容器开始运行的时间
+	StartedAt time.Time `json:"startedAt" yaml:"startedAt"`
+	// 容器终止的时间
+	FinishedAt time.Time `json:"finishedAt" yaml:"finishedAt"`
+	// 终止的容器的ID
+	ContainerID string `json:"containerID" yaml:"containerID"`
+}
+
+const (
+	// ContainerReasonCrashLoopBackOff 容器反复退出，kubelet正在等待退避时间结束后重启容器
+	ContainerReasonCrashLoopBackOff = "CrashLoopBackOff"
+	// ContainerReasonContainerCreating 容器已经创建但还未运行
+	ContainerReasonContainerCreating = "ContainerCreating"
+	// ContainerReasonCompleted 容器以 0 状态退出
+	ContainerReasonCompleted = "Completed"
+	// ContainerReasonError 容器以非 0 状态退出
+	ContainerReasonError = "Error"
+	// ContainerReasonOOMKilled 容器使用的内存超过限制，被内核的OOM killer终止
+	ContainerReasonOOMKilled = "OOMKilled"
+	// ContainerReasonStatusUnknown 容器已经退出，但无法获取其退出状态
+	ContainerReasonStatusUnknown = "ContainerStatusUnknown"
+)
+
+type PodCondition struct {
+	// 状况的类型，如 PodScheduled
+	Type string `json:"type" yaml:"type"`
+	// 状况的状态，包括：True、False、Unknown
+	Status string `json:"status" yaml:"status"`
+	// 状况最近一次发生变化的时间
+	LastTransitionTime time.Time `json:"lastTransitionTime" yaml:"lastTransitionTime"`
+	// 状况变化的原因
+	Reason string `json:"reason" yaml:"reason"`
+	// 状况变化的详细信息
+	Message string `json:"message" yaml:"message"`
 }
 
 func (p *Pod) GetPodUUID() string {
 	return p.Metadata.UUID
 }
+
+// GetPriority 获取Pod的优先级，没有设置时为0
+func (p *Pod) GetPriority() int32 {
+	if p.Spec.Priority == nil {
+		return 0
+	}
+	return *p.Spec.Priority
+}
+
+// GetTerminationGracePeriodSeconds 获取Pod的优雅退出时间，没有设置时为30秒
+func (p *Pod) GetTerminationGracePeriodSeconds() int64 {
+	if p.Spec.TerminationGracePeriodSeconds == nil {
+		return DefaultTerminationGracePeriodSeconds
+	}
+	return *p.Spec.TerminationGracePeriodSeconds
+}
+
+// GetRestartPolicy 获取Pod的重启策略，没有设置时为Always
+func (p *Pod) GetRestartPolicy() string {
+	if p.Spec.RestartPolicy == "" {
+		return RestartPolicyAlways
+	}
+	return p.Spec.RestartPolicy
+}
+
+// GetContainerStatus 获取指定名称的容器的状态，不存在时返回nil
+func (s *PodStatus) GetContainerStatus(name string) *PodContainerStatus {
+	for i := range s.ContainerStatuses {
+		if s.ContainerStatuses[i].Name == name {
+			return &s.ContainerStatuses[i]
+		}
+	}
+	return nil
+}
+
+// GetCondition 获取指定类型的状况，不存在时返回nil
+func (s *PodStatus) GetCondition(conditionType string) *PodCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == conditionType {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsReady 判断Pod是否已经就绪
+func (s *PodStatus) IsReady() bool {
+	condition := s.GetCondition(PodReady)
+	return condition != nil && condition.Status == ConditionTrue
+}
+
+// SetCondition 设置指定类型的状况，状态发生变化时更新 LastTransitionTime，返回状况是否发生了变化
+func (s *PodStatus) SetCondition(condition PodCondition) bool {
+	old := s.GetCondition(condition.Type)
+	if old == nil {
+		condition.LastTransitionTime = time.Now()
+		s.Conditions = append(s.Conditions, condition)
+		return true
+	}
+	if old.Status == condition.Status && old.Reason == condition.Reason && old.Message == condition.Message {
+		return false
+	}
+	if old.Status == condition.Status {
+		condition.LastTransitionTime = old.LastTransitionTime
+	} else {
+		condition.LastTransitionTime = time.Now()
+	}
+	*old = condition
+	return true
+}
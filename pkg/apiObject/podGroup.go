@@ -0,0 +1,28 @@
+// 描述: PodGroup对象的封装，同一组中的Pod要么同时被调度，要么都不被调度
+// 参考：https://github.com/kubernetes-sigs/scheduler-plugins/blob/master/kep/42-podgroup-coscheduling/README.md
+
+package apiObject
+
+// PodGroupLabel Pod通过该标签声明所属的PodGroup，PodGroup与Pod位于同一命名空间
+const PodGroupLabel = "scheduling.x-k8s.io/pod-group"
+
+type PodGroup struct {
+	// 对象的类型元数据
+	TypeMeta
+	// 对象的元数据
+	Metadata ObjectMeta `json:"metadata" yaml:"metadata"`
+	// PodGroup的规格
+	Spec PodGroupSpec `json:"spec" yaml:"spec"`
+}
+
+type PodGroupSpec struct {
+	// 至少需要同时调度的Pod数量，只有足够多的成员都找到节点后才会绑定
+	MinMember int32 `json:"minMember" yaml:"minMember"`
+	// 成员在Permit阶段等待其他成员的最长时间（秒），超时后整组放弃已经预留的节点，为空时使用调度插件的默认值
+	ScheduleTimeoutSeconds *int32 `json:"scheduleTimeoutSeconds" yaml:"scheduleTimeoutSeconds"`
+}
+
+// GetPodGroupName 获取Pod所属的PodGroup名称，不属于任何PodGroup时为空
+func (p *Pod) GetPodGroupName() string {
+	return p.Metadata.Labels[PodGroupLabel]
+}
@@ -0,0 +1,58 @@
+// 描述: PriorityClass对象的封装，定义从优先级类名称到Pod优先级数值的映射
+// 参考：https://kubernetes.io/zh-cn/docs/concepts/scheduling-eviction/pod-priority-preemption/
+
+package apiObject
+
+// 抢占策略
+const (
+	// PreemptLowerPriority Pod无法调度时可以抢占优先级更低的Pod
+	PreemptLowerPriority = "PreemptLowerPriority"
+	// PreemptNever Pod不会抢占其他Pod，只会在调度队列中排在优先级更低的Pod之前
+	PreemptNever = "Never"
+)
+
+// 内置的优先级类，用于保证关键的系统组件优先被调度
+const (
+	SystemClusterCritical = "system-cluster-critical"
+	SystemNodeCritical    = "system-node-critical"
+	// SystemPriorityClassPrefix 内置优先级类名称的前缀，用户不能创建以此开头的优先级类
+	SystemPriorityClassPrefix = "system-"
+
+	// HighestUserDefinablePriority 用户创建的优先级类的最大值，更高的优先级保留给系统组件
+	HighestUserDefinablePriority int32 = 1000000000
+	// SystemCriticalPriority 系统组件的优先级
+	SystemCriticalPriority int32 = 2 * HighestUserDefinablePriority
+)
+
+type PriorityClass struct {
+	// 对象的类型元数据
+	TypeMeta
+	// 对象的元数据，PriorityClass不属于任何命名空间
+	Metadata ObjectMeta `json:"metadata" yaml:"metadata"`
+	// 优先级的数值，数值越大优先级越高
+	Value int32 `json:"value" yaml:"value"`
+	// 为true时，没有指定priorityClassName的Pod使用该优先级类，集群中最多只有一个
+	GlobalDefault bool `json:"globalDefault" yaml:"globalDefault"`
+	// 优先级类的说明
+	Description string `json:"description" yaml:"description"`
+	// 使用该优先级类的Pod的抢占策略，包括：PreemptLowerPriority、Never，默认为PreemptLowerPriority
+	PreemptionPolicy string `json:"preemptionPolicy" yaml:"preemptionPolicy"`
+}
+
+// SystemPriorityClasses 内置的优先级类
+func SystemPriorityClasses() []PriorityClass {
+	return []PriorityClass{
+		{
+			TypeMeta:    TypeMeta{Kind: PriorityClassType, APIVersion: "scheduling.k8s.io/v1"},
+			Metadata:    ObjectMeta{Name: SystemClusterCritical},
+			Value:       SystemCriticalPriority,
+			Description: "Used for system critical pods that must run in the cluster, but can be moved to another node if necessary.",
+		},
+		{
+			TypeMeta:    TypeMeta{Kind: PriorityClassType, APIVersion: "scheduling.k8s.io/v1"},
+			Metadata:    ObjectMeta{Name: SystemNodeCritical},
+			Value:       SystemCriticalPriority + 1000,
+			Description: "Used for system critical pods that must not be moved from their current node.",
+		},
+	}
+}
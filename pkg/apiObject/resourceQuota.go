@@ -0,0 +1,52 @@
+// 描述: ResourceQuota对象的封装，用于限制命名空间内的对象数量与资源请求总量
+// 参考：https://kubernetes.io/zh-cn/docs/concepts/policy/resource-quotas/
+
+package apiObject
+
+// ResourceQuota 与 LimitRange 支持的资源名称
+const (
+	// ResourceCPU 容器的cpu，单位可以为核（如 0.5）或毫核（如 500m）
+	ResourceCPU = "cpu"
+	// ResourceMemory 容器的内存，单位可以为 Ki、Mi、Gi、Ti
+	ResourceMemory = "memory"
+
+	// ResourcePods 命名空间内Pod的数量
+	ResourcePods = "pods"
+	// ResourceServices 命名空间内Service的数量
+	ResourceServices = "services"
+	// ResourceReplicaSets 命名空间内ReplicaSet的数量
+	ResourceReplicaSets = "replicasets"
+	// ResourcePersistentVolumeClaims 命名空间内PersistentVolumeClaim的数量
+	ResourcePersistentVolumeClaims = "persistentvolumeclaims"
+	// ResourceRequestsCPU 命名空间内所有Pod的cpu请求总量
+	ResourceRequestsCPU = "requests.cpu"
+	// ResourceRequestsMemory 命名空间内所有Pod的内存请求总量
+	ResourceRequestsMemory = "requests.memory"
+	// ResourceLimitsCPU 命名空间内所有Pod的cpu限制总量
+	ResourceLimitsCPU = "limits.cpu"
+	// ResourceLimitsMemory 命名空间内所有Pod的内存限制总量
+	ResourceLimitsMemory = "limits.memory"
+)
+
+type ResourceQuota struct {
+	// 对象的类型元数据
+	TypeMeta
+	// 对象的元数据
+	Metadata ObjectMeta `json:"metadata" yaml:"metadata"`
+	// ResourceQuota的规格
+	Spec ResourceQuotaSpec `json:"spec" yaml:"spec"`
+	// ResourceQuota的状态
+	Status ResourceQuotaStatus `json:"status" yaml:"status"`
+}
+
+type ResourceQuotaSpec struct {
+	// 每种资源的硬性上限，cpu 与 memory 等价于 requests.cpu 与 requests.memory
+	Hard ResourceList `json:"hard" yaml:"hard"`
+}
+
+type ResourceQuotaStatus struct {
+	// 当前生效的硬性上限
+	Hard ResourceList `json:"hard" yaml:"hard"`
+	// 命名空间内当前已经使用的资源，由controller定期更新
+	Used ResourceList `json:"used" yaml:"used"`
+}
@@ -5,6 +5,9 @@ const (
 	ServerlessEventTypeFile ServerlessEventType = "file"
 )
 
+// ServerlessFunctionLabel Serverless Function的运行实例带有该标签，值为函数名称
+const ServerlessFunctionLabel = "serverless-function"
+
 type Serverless struct {
 	// 所需的python镜像
 	Image string `json:"image" yaml:"image"`
@@ -14,13 +14,20 @@ type Service struct {
 	Status ServiceStatus `json:"status" yaml:"status"`
 }
 
+const (
+	// ServiceTypeClusterIP 只在集群内部通过ClusterIP访问的Service
+	ServiceTypeClusterIP = "ClusterIP"
+	// ServiceTypeNodePort 通过每个Node上的NodePort对外暴露的Service
+	ServiceTypeNodePort = "NodePort"
+)
+
 type ServiceSpec struct {
 	// 该Service暴露的端口列表，可以对一个服务指定多个端口
 	Ports []ServicePort `json:"ports" yaml:"ports"`
 	// 将service流量路由到具有与此selector匹配的标签键和值的pod。
 	//	如果Type为ExternalName，则忽略此字段
 	Selector map[string]string `json:"selector" yaml:"selector"`
-	// Service的IP地址，通常是随机分配的
+	// Service的IP地址，未指定时从ServiceClusterIPRange中分配
 	ClusterIP string `json:"clusterIP" yaml:"clusterIP"`
 	// Service的类型，包括ClusterIP、NodePort、LoadBalancer、ExternalName，默认为ClusterIP
 	Type string `json:"type" yaml:"type"`
@@ -36,6 +43,7 @@ type ServicePort struct {
 	// 服务所针对的pod上要访问的端口的编号或名称，也就是对应到pod上的端口，如果未指定，则使用port作为targetPort
 	TargetPort int32 `json:"targetPort" yaml:"targetPort"`
 	// 当Type为NodePort或LoadBalancer时，每个Node上的端口，即全局对外提供服务的端口
+	//	未指定时从ServiceNodePortRange中分配
 	NodePort int32 `json:"nodePort" yaml:"nodePort"`
 }
 
@@ -74,4 +82,6 @@ type Condition struct {
 type Endpoint struct {
 	PodUUID string
 	IP      string
+	// Pod是否已经就绪，kubeproxy只将请求转发给就绪的Pod
+	Ready bool
 }
@@ -0,0 +1,52 @@
+// 描述: Pod对节点污点的容忍
+// 参考：https://kubernetes.io/zh-cn/docs/concepts/scheduling-eviction/taint-and-toleration/
+
+package apiObject
+
+const (
+	// TolerationOpExists 只要污点的键相同即可容忍，不比较值
+	TolerationOpExists = "Exists"
+	// TolerationOpEqual 污点的键与值都相同时才能容忍，为空时默认使用该运算符
+	TolerationOpEqual = "Equal"
+)
+
+type Toleration struct {
+	// 容忍的污点的键，为空且运算符为Exists时容忍所有污点
+	Key string `json:"key" yaml:"key"`
+	// 运算符，包括：Exists、Equal，默认为Equal
+	Operator string `json:"operator" yaml:"operator"`
+	// 容忍的污点的值，运算符为Exists时应为空
+	Value string `json:"value" yaml:"value"`
+	// 容忍的污点的效果，为空时容忍所有效果
+	Effect string `json:"effect" yaml:"effect"`
+	// 容忍NoExecute污点的时长，超过该时长后Pod会被驱逐，为空时永久容忍
+	TolerationSeconds *int64 `json:"tolerationSeconds" yaml:"tolerationSeconds"`
+}
+
+// ToleratesTaint 判断该容忍是否能容忍指定的污点
+func (t *Toleration) ToleratesTaint(taint *Taint) bool {
+	if t.Effect != "" && t.Effect != taint.Effect {
+		return false
+	}
+	if t.Key != "" && t.Key != taint.Key {
+		return false
+	}
+	switch t.Operator {
+	case TolerationOpExists:
+		return true
+	case "", TolerationOpEqual:
+		return t.Key != "" && t.Value == taint.Value
+	default:
+		return false
+	}
+}
+
+// FindMatchingToleration 返回第一个能容忍指定污点的容忍，不存在时返回nil
+func FindMatchingToleration(tolerations []Toleration, taint *Taint) *Toleration {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return &tolerations[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+// 描述: Pod调度时使用的拓扑分布约束，使匹配的Pod在各个拓扑域之间均匀分布
+// 参考：https://kubernetes.io/zh-cn/docs/concepts/scheduling-eviction/topology-spread-constraints/
+
+package apiObject
+
+// 不满足拓扑分布约束时的处理方式
+const (
+	// DoNotSchedule 不将Pod调度到会使分布偏差超过maxSkew的节点上
+	DoNotSchedule = "DoNotSchedule"
+	// ScheduleAnyway 仍然调度Pod，但优先选择使分布偏差更小的节点
+	ScheduleAnyway = "ScheduleAnyway"
+)
+
+type TopologySpreadConstraint struct {
+	// 允许的最大分布偏差，即任意拓扑域中匹配的Pod数量与所有拓扑域中最小数量之差，必须大于0
+	MaxSkew int32 `json:"maxSkew" yaml:"maxSkew"`
+	// 节点标签的键，标签值相同的节点属于同一个拓扑域，例如 zone 或 kubernetes.io/hostname
+	TopologyKey string `json:"topologyKey" yaml:"topologyKey"`
+	// 不满足约束时的处理方式，包括：DoNotSchedule、ScheduleAnyway
+	WhenUnsatisfiable string `json:"whenUnsatisfiable" yaml:"whenUnsatisfiable"`
+	// 统计与Pod处于同一命名空间且满足该选择器的Pod
+	LabelSelector *LabelSelector `json:"labelSelector" yaml:"labelSelector"`
+}
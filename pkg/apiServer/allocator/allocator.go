@@ -0,0 +1,221 @@
+// 描述：allocator包实现了基于位图的区间分配器，用于分配Service的ClusterIP与NodePort
+//	位图以快照的形式保存在etcd中，每次分配与释放都通过etcd事务比较修改版本号后写入，保证多个apiServer之间不会重复分配
+// 参考：https://github.com/kubernetes/kubernetes/tree/master/pkg/registry/core/service/allocator
+
+package allocator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+var (
+	// ErrFull 区间内已经没有可以分配的值
+	ErrFull = errors.New("range is full")
+	// ErrAllocated 指定的值已经被分配
+	ErrAllocated = errors.New("provided value is already allocated")
+	// ErrNotInRange 指定的值不在区间内
+	ErrNotInRange = errors.New("provided value is not in the valid range")
+	// ErrConflict 多次重试后仍然与其他写入者冲突
+	ErrConflict = errors.New("too many conflicts when updating allocator")
+)
+
+// maxRetries 与其他写入者冲突时的最大重试次数
+const maxRetries = 10
+
+// Store 分配器快照的持久化存储，由etcd实现
+type Store interface {
+	// GetWithRevision 获取key对应的值以及修改版本号，key不存在时返回空字符串与0
+	GetWithRevision(key string) (string, int64, error)
+	// CompareAndSwap 仅当key的修改版本号仍为revision时写入value，返回是否写入成功
+	CompareAndSwap(key, value string, revision int64) (bool, error)
+}
+
+// RangeSnapshot 保存在etcd中的分配器快照
+type RangeSnapshot struct {
+	// 分配区间的描述，如 10.96.0.0/16、30000-32767
+	Range string `json:"range"`
+	// 位图，第i位为1表示区间中偏移量为i的值已经被分配
+	Data []byte `json:"data"`
+}
+
+// Usage 分配器的使用情况
+type Usage struct {
+	// 分配区间的描述
+	Range string `json:"range"`
+	// 已经分配的数量
+	Used int `json:"used"`
+	// 区间内可以分配的总数
+	Total int `json:"total"`
+}
+
+// RangeAllocator 对 [0, size) 区间内的偏移量进行分配
+type RangeAllocator struct {
+	// 快照在etcd中的key
+	key string
+	// 分配区间的描述
+	rangeDesc string
+	// 区间大小
+	size int
+	// 快照的持久化存储
+	store Store
+	// 返回当前已经被使用的偏移量，快照中的区间与当前区间不一致时用于重建位图
+	existing func() ([]int, error)
+}
+
+// NewRangeAllocator 创建一个区间大小为size的分配器，existing可以为空
+func NewRangeAllocator(key string, rangeDesc string, size int, store Store, existing func() ([]int, error)) *RangeAllocator {
+	return &RangeAllocator{
+		key:       key,
+		rangeDesc: rangeDesc,
+		size:      size,
+		store:     store,
+		existing:  existing,
+	}
+}
+
+// Allocate 分配指定的偏移量
+func (r *RangeAllocator) Allocate(offset int) error {
+	if offset < 0 || offset >= r.size {
+		return ErrNotInRange
+	}
+	return r.update(func(bits []byte) error {
+		if isSet(bits, offset) {
+			return ErrAllocated
+		}
+		set(bits, offset)
+		return nil
+	})
+}
+
+// AllocateNext 从随机位置开始查找并分配一个空闲的偏移量
+func (r *RangeAllocator) AllocateNext() (int, error) {
+	offset := -1
+	err := r.update(func(bits []byte) error {
+		start := rand.Intn(r.size)
+		for i := 0; i < r.size; i++ {
+			candidate := (start + i) % r.size
+			if !isSet(bits, candidate) {
+				set(bits, candidate)
+				offset = candidate
+				return nil
+			}
+		}
+		return ErrFull
+	})
+	if err != nil {
+		return -1, err
+	}
+	return offset, nil
+}
+
+// Release 释放指定的偏移量，释放未分配的偏移量不会报错
+func (r *RangeAllocator) Release(offset int) error {
+	if offset < 0 || offset >= r.size {
+		return ErrNotInRange
+	}
+	return r.update(func(bits []byte) error {
+		unset(bits, offset)
+		return nil
+	})
+}
+
+// Has 判断指定的偏移量是否已经被分配
+func (r *RangeAllocator) Has(offset int) (bool, error) {
+	if offset < 0 || offset >= r.size {
+		return false, nil
+	}
+	bits, _, err := r.load()
+	if err != nil {
+		return false, err
+	}
+	return isSet(bits, offset), nil
+}
+
+// Usage 返回分配器的使用情况
+func (r *RangeAllocator) Usage() (Usage, error) {
+	bits, _, err := r.load()
+	if err != nil {
+		return Usage{}, err
+	}
+	used := 0
+	for i := 0; i < r.size; i++ {
+		if isSet(bits, i) {
+			used++
+		}
+	}
+	return Usage{Range: r.rangeDesc, Used: used, Total: r.size}, nil
+}
+
+// load 从存储中读取位图，快照不存在时返回空位图
+func (r *RangeAllocator) load() ([]byte, int64, error) {
+	bits := make([]byte, (r.size+7)/8)
+	value, revision, err := r.store.GetWithRevision(r.key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if value == "" {
+		return bits, revision, nil
+	}
+	var snapshot RangeSnapshot
+	if err = json.Unmarshal([]byte(value), &snapshot); err != nil {
+		return nil, 0, fmt.Errorf("decode allocator snapshot %s: %v", r.key, err)
+	}
+	if snapshot.Range == r.rangeDesc {
+		copy(bits, snapshot.Data)
+		return bits, revision, nil
+	}
+	// 区间发生变化时，同一偏移量对应的值已经不同，根据当前已经被使用的值重建位图
+	if r.existing == nil {
+		return bits, revision, nil
+	}
+	offsets, err := r.existing()
+	if err != nil {
+		return nil, 0, fmt.Errorf("rebuild allocator snapshot %s: %v", r.key, err)
+	}
+	for _, offset := range offsets {
+		if offset >= 0 && offset < r.size {
+			set(bits, offset)
+		}
+	}
+	return bits, revision, nil
+}
+
+// update 读取位图并执行fn，然后以事务的方式写回，与其他写入者冲突时重新执行
+func (r *RangeAllocator) update(fn func(bits []byte) error) error {
+	for i := 0; i < maxRetries; i++ {
+		bits, revision, err := r.load()
+		if err != nil {
+			return err
+		}
+		if err = fn(bits); err != nil {
+			return err
+		}
+		value, err := json.Marshal(RangeSnapshot{Range: r.rangeDesc, Data: bits})
+		if err != nil {
+			return err
+		}
+		ok, err := r.store.CompareAndSwap(r.key, string(value), revision)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+	return ErrConflict
+}
+
+func isSet(bits []byte, offset int) bool {
+	return bits[offset/8]&(1<<(offset%8)) != 0
+}
+
+func set(bits []byte, offset int) {
+	bits[offset/8] |= 1 << (offset % 8)
+}
+
+func unset(bits []byte, offset int) {
+	bits[offset/8] &^= 1 << (offset % 8)
+}
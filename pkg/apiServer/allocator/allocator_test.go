@@ -0,0 +1,143 @@
+package allocator
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore 基于内存的Store，用于测试
+type memoryStore struct {
+	mu        sync.Mutex
+	value     map[string]string
+	revision  map[string]int64
+	current   int64
+	conflicts int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{value: map[string]string{}, revision: map[string]int64{}}
+}
+
+func (s *memoryStore) GetWithRevision(key string) (string, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.value[key], s.revision[key], nil
+}
+
+func (s *memoryStore) CompareAndSwap(key, value string, revision int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 模拟其他写入者抢先修改
+	if s.conflicts > 0 {
+		s.conflicts--
+		s.current++
+		s.revision[key] = s.current
+		return false, nil
+	}
+	if s.revision[key] != revision {
+		return false, nil
+	}
+	s.current++
+	s.value[key] = value
+	s.revision[key] = s.current
+	return true, nil
+}
+
+func TestIPAllocator(t *testing.T) {
+	store := newMemoryStore()
+	a, err := NewIPAllocator("10.96.0.0/30", "/ips", store, nil)
+	assert.Nil(t, err)
+
+	// /30 网段去掉网络地址与广播地址后只有两个可用地址
+	assert.Equal(t, ErrNotInRange, a.Allocate("10.96.0.0"))
+	assert.Equal(t, ErrNotInRange, a.Allocate("10.96.0.3"))
+	assert.Equal(t, ErrNotInRange, a.Allocate("192.168.0.1"))
+
+	assert.Nil(t, a.Allocate("10.96.0.1"))
+	assert.Equal(t, ErrAllocated, a.Allocate("10.96.0.1"))
+	ip, err := a.AllocateNext()
+	assert.Nil(t, err)
+	assert.Equal(t, "10.96.0.2", ip)
+	_, err = a.AllocateNext()
+	assert.Equal(t, ErrFull, err)
+
+	usage, err := a.Usage()
+	assert.Nil(t, err)
+	assert.Equal(t, Usage{Range: "10.96.0.0/30", Used: 2, Total: 2}, usage)
+
+	assert.Nil(t, a.Release("10.96.0.1"))
+	has, err := a.Has("10.96.0.1")
+	assert.Nil(t, err)
+	assert.False(t, has)
+	ip, err = a.AllocateNext()
+	assert.Nil(t, err)
+	assert.Equal(t, "10.96.0.1", ip)
+}
+
+func TestPortAllocator(t *testing.T) {
+	store := newMemoryStore()
+	a, err := NewPortAllocator("30000-30009", "/ports", store, nil)
+	assert.Nil(t, err)
+
+	assert.Equal(t, ErrNotInRange, a.Allocate(29999))
+	assert.Equal(t, ErrNotInRange, a.Allocate(30010))
+	assert.Nil(t, a.Allocate(30005))
+
+	// 另一个分配器共享同一份快照
+	b, err := NewPortAllocator("30000-30009", "/ports", store, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, ErrAllocated, b.Allocate(30005))
+
+	seen := map[int]bool{30005: true}
+	for i := 0; i < 9; i++ {
+		port, err := b.AllocateNext()
+		assert.Nil(t, err)
+		assert.False(t, seen[port])
+		seen[port] = true
+	}
+	_, err = a.AllocateNext()
+	assert.Equal(t, ErrFull, err)
+
+	_, err = NewPortAllocator("32767-30000", "/ports", store, nil)
+	assert.NotNil(t, err)
+}
+
+func TestPortAllocatorRangeChanged(t *testing.T) {
+	store := newMemoryStore()
+	a, err := NewPortAllocator("30000-30009", "/ports", store, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, a.Allocate(30005))
+
+	// 端口范围变化后根据已有的端口重建位图，而不是按照偏移量复制
+	b, err := NewPortAllocator("30002-30011", "/ports", store, func() ([]int, error) {
+		return []int{30005, 30011, 29999}, nil
+	})
+	assert.Nil(t, err)
+	for port, expected := range map[int]bool{30005: true, 30011: true, 30007: false} {
+		has, err := b.Has(port)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, has)
+	}
+	assert.Equal(t, ErrAllocated, b.Allocate(30005))
+	assert.Nil(t, b.Allocate(30007))
+	usage, err := b.Usage()
+	assert.Nil(t, err)
+	assert.Equal(t, Usage{Range: "30002-30011", Used: 3, Total: 10}, usage)
+}
+
+func TestRangeAllocatorConflict(t *testing.T) {
+	store := newMemoryStore()
+	r := NewRangeAllocator("/range", "test", 4, store, nil)
+
+	// 冲突次数在重试范围内时仍然可以成功
+	store.conflicts = maxRetries - 1
+	assert.Nil(t, r.Allocate(1))
+
+	store.conflicts = maxRetries
+	assert.Equal(t, ErrConflict, r.Allocate(2))
+	has, err := r.Has(2)
+	assert.Nil(t, err)
+	assert.False(t, has)
+}
@@ -0,0 +1,127 @@
+package allocator
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// maxIPRangeSize 单个网段最多可以分配的地址数量，超出部分不参与分配，避免位图过大
+const maxIPRangeSize = 1 << 16
+
+// IPAllocator 在一个IPv4网段内分配地址，网络地址与广播地址不参与分配
+type IPAllocator struct {
+	// 网段
+	cidr *net.IPNet
+	// 第一个可分配地址
+	base uint32
+	// 底层的区间分配器
+	alloc *RangeAllocator
+}
+
+// NewIPAllocator 根据网段（如 10.96.0.0/16）创建IP分配器
+//
+//	existing返回当前已经被使用的地址，etcd中快照的网段与cidr不一致时据此重建快照，可以为空
+func NewIPAllocator(cidr string, key string, store Store, existing func() ([]string, error)) (*IPAllocator, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	if ipNet.IP.To4() == nil {
+		return nil, errors.New("only IPv4 service cidr is supported: " + cidr)
+	}
+	ones, bits := ipNet.Mask.Size()
+	if bits-ones < 2 {
+		return nil, errors.New("service cidr is too small: " + cidr)
+	}
+	total := (1 << (bits - ones)) - 2
+	if total > maxIPRangeSize {
+		total = maxIPRangeSize
+	}
+	a := &IPAllocator{
+		cidr: ipNet,
+		base: binary.BigEndian.Uint32(ipNet.IP.To4()) + 1,
+	}
+	var existingOffsets func() ([]int, error)
+	if existing != nil {
+		existingOffsets = func() ([]int, error) {
+			ips, err := existing()
+			if err != nil {
+				return nil, err
+			}
+			var offsets []int
+			for _, ip := range ips {
+				// 不在网段内的地址不参与分配
+				if offset, err := a.offsetOf(ip); err == nil {
+					offsets = append(offsets, offset)
+				}
+			}
+			return offsets, nil
+		}
+	}
+	a.alloc = NewRangeAllocator(key, ipNet.String(), total, store, existingOffsets)
+	return a, nil
+}
+
+// Allocate 分配指定的地址
+func (a *IPAllocator) Allocate(ip string) error {
+	offset, err := a.offsetOf(ip)
+	if err != nil {
+		return err
+	}
+	return a.alloc.Allocate(offset)
+}
+
+// AllocateNext 分配一个空闲的地址
+func (a *IPAllocator) AllocateNext() (string, error) {
+	offset, err := a.alloc.AllocateNext()
+	if err != nil {
+		return "", err
+	}
+	return a.ipOf(offset), nil
+}
+
+// Release 释放指定的地址
+func (a *IPAllocator) Release(ip string) error {
+	offset, err := a.offsetOf(ip)
+	if err != nil {
+		return err
+	}
+	return a.alloc.Release(offset)
+}
+
+// Has 判断指定的地址是否已经被分配
+func (a *IPAllocator) Has(ip string) (bool, error) {
+	offset, err := a.offsetOf(ip)
+	if err != nil {
+		return false, nil
+	}
+	return a.alloc.Has(offset)
+}
+
+// Usage 返回分配器的使用情况
+func (a *IPAllocator) Usage() (Usage, error) {
+	return a.alloc.Usage()
+}
+
+func (a *IPAllocator) offsetOf(ip string) (int, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.To4() == nil {
+		return -1, fmt.Errorf("invalid IPv4 address %q", ip)
+	}
+	if !a.cidr.Contains(parsed) {
+		return -1, ErrNotInRange
+	}
+	value := binary.BigEndian.Uint32(parsed.To4())
+	if value < a.base || int(value-a.base) >= a.alloc.size {
+		return -1, ErrNotInRange
+	}
+	return int(value - a.base), nil
+}
+
+func (a *IPAllocator) ipOf(offset int) string {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, a.base+uint32(offset))
+	return ip.String()
+}
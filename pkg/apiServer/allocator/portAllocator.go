@@ -0,0 +1,83 @@
+package allocator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PortAllocator 在一个端口范围内分配端口
+type PortAllocator struct {
+	// 范围内的第一个端口
+	base int
+	// 底层的区间分配器
+	alloc *RangeAllocator
+}
+
+// NewPortAllocator 根据端口范围（如 30000-32767）创建端口分配器
+//
+//	existing返回当前已经被使用的端口，etcd中快照的端口范围与portRange不一致时据此重建快照，可以为空
+func NewPortAllocator(portRange string, key string, store Store, existing func() ([]int, error)) (*PortAllocator, error) {
+	parts := strings.Split(portRange, "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid port range %q, expected <min>-<max>", portRange)
+	}
+	min, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid port range %q: %v", portRange, err)
+	}
+	max, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid port range %q: %v", portRange, err)
+	}
+	if min <= 0 || max > 65535 || min > max {
+		return nil, fmt.Errorf("invalid port range %q", portRange)
+	}
+	var existingOffsets func() ([]int, error)
+	if existing != nil {
+		existingOffsets = func() ([]int, error) {
+			ports, err := existing()
+			if err != nil {
+				return nil, err
+			}
+			offsets := make([]int, 0, len(ports))
+			for _, port := range ports {
+				offsets = append(offsets, port-min)
+			}
+			return offsets, nil
+		}
+	}
+	return &PortAllocator{
+		base:  min,
+		alloc: NewRangeAllocator(key, portRange, max-min+1, store, existingOffsets),
+	}, nil
+}
+
+// Allocate 分配指定的端口
+func (a *PortAllocator) Allocate(port int) error {
+	return a.alloc.Allocate(port - a.base)
+}
+
+// AllocateNext 分配一个空闲的端口
+func (a *PortAllocator) AllocateNext() (int, error) {
+	offset, err := a.alloc.AllocateNext()
+	if err != nil {
+		return 0, err
+	}
+	return a.base + offset, nil
+}
+
+// Release 释放指定的端口
+func (a *PortAllocator) Release(port int) error {
+	return a.alloc.Release(port - a.base)
+}
+
+// Has 判断指定的端口是否已经被分配
+func (a *PortAllocator) Has(port int) (bool, error) {
+	return a.alloc.Has(port - a.base)
+}
+
+// Usage 返回分配器的使用情况
+func (a *PortAllocator) Usage() (Usage, error) {
+	return a.alloc.Usage()
+}
@@ -4,6 +4,7 @@
 package apiServer
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -42,9 +43,38 @@ func (a *ApiServer) Run() {
 		}
 	}()
 
-	// 开辟一个协程，用于定时扫描更新Service2Endpoint
-	go ScanServiceStatus()
-	ScanNodeStatus()
+	// 多个apiServer副本通过etcd选举leader，只有leader执行后台扫描任务
+	// 节点的存活状态由kubelet续约的Lease与nodeLifecycleController维护
+	a.runLeaderLoops()
+}
+
+// runLeaderLoops 参与leader选举，当选后执行后台扫描任务，失去领导权后停止任务并重新参与选举
+func (a *ApiServer) runLeaderLoops() {
+	identity := a.Address + ":" + fmt.Sprint(a.Port)
+	for {
+		if etcdclient.EtcdStore == nil {
+			log.ErrorLog("runLeaderLoops: etcd client is not initialized")
+			time.Sleep(10 * time.Second)
+			continue
+		}
+		leadership, err := etcdclient.EtcdStore.Campaign(context.Background(), config.EtcdApiServerElectionPrefix, identity, config.ApiServerElectionTTL)
+		if err != nil {
+			log.WarnLog("runLeaderLoops: " + err.Error())
+			time.Sleep(time.Duration(config.ApiServerElectionTTL) * time.Second)
+			continue
+		}
+		log.InfoLog("ApiServer " + identity + " is elected as leader")
+		handlers.SetLeader(true)
+
+		stopCh := make(chan struct{})
+		// 定时扫描更新Service2Endpoint
+		go ScanServiceStatus(stopCh)
+
+		<-leadership.Done()
+		log.WarnLog("ApiServer " + identity + " lost leadership")
+		handlers.SetLeader(false)
+		close(stopCh)
+	}
 }
 
 // Register 注册路由
@@ -58,6 +88,12 @@ func (a *ApiServer) Register() {
 	// DELETE: 删除
 	// PATCH: 更新部分资源
 
+	// 校验修改类请求的dryRun参数
+	a.Router.Use(handlers.ValidateDryRun)
+
+	// 健康检查，组件据此在多个apiServer副本之间切换
+	a.Router.GET(config.HealthzURI, handlers.Healthz)
+
 	// 获取所有节点
 	a.Router.GET(config.NodesURI, handlers.GetNodes)
 	// 创建节点
@@ -70,14 +106,25 @@ func (a *ApiServer) Register() {
 	// 更新指定节点
 	a.Router.PUT(config.NodeURI, handlers.UpdateNode)
 	// 部分更新指定节点
-	a.Router.PATCH(config.NodeURI, handlers.UpdateNode)
+	a.Router.PATCH(config.NodeURI, handlers.PatchNode)
 	// 删除指定节点
 	a.Router.DELETE(config.NodeURI, handlers.DeleteNode)
 
 	// 获取指定节点的状态
 	a.Router.GET(config.NodeStatusURI, handlers.GetNodeStatus)
-	// 更新指定节点的状态
-	a.Router.PUT(config.NodeStatusURI, handlers.PingNodeStatus)
+	// 更新指定节点的状态，该请求来自于 kubelet
+	a.Router.PUT(config.NodeStatusURI, handlers.UpdateNodeStatus)
+	// 部分更新指定节点的状态，该请求来自于 nodeLifecycleController
+	a.Router.PATCH(config.NodeStatusURI, handlers.PatchNodeStatus)
+
+	// 获取命名空间内的所有Lease
+	a.Router.GET(config.LeasesURI, handlers.GetLeases)
+	// 获取指定Lease
+	a.Router.GET(config.LeaseURI, handlers.GetLease)
+	// 创建或续约Lease，节点心跳的请求来自于 kubelet
+	a.Router.PUT(config.LeaseURI, handlers.PutLease)
+	// 删除指定Lease
+	a.Router.DELETE(config.LeaseURI, handlers.DeleteLease)
 
 	// 获取指定Pod
 	a.Router.GET(config.PodURI, handlers.GetPod)
@@ -92,9 +139,17 @@ func (a *ApiServer) Register() {
 	a.Router.GET(config.PodStatusURI, handlers.GetPodStatus)
 	// 更新Pod的状态，该请求来自于 kubelet
 	a.Router.PUT(config.PodStatusURI, handlers.UpdatePodStatus)
+	// 将Pod绑定到节点上，该请求来自于调度器
+	a.Router.POST(config.PodBindingURI, handlers.CreatePodBinding)
 
 	// 执行指定Pod和container的命令
 	a.Router.POST(config.PodExecURI, handlers.ExecPod)
+	// 获取Pod中容器的日志，请求被代理到Pod所在节点的kubelet
+	a.Router.GET(config.PodLogURI, handlers.GetPodLog)
+	// 在Pod的容器中交互式地执行命令，客户端通过WebSocket连接
+	a.Router.GET(config.PodExecStreamURI, handlers.ExecPodStream)
+	// 连接到Pod中容器的主进程，客户端通过WebSocket连接
+	a.Router.GET(config.PodAttachURI, handlers.AttachPod)
 
 	// 获取所有Pod
 	a.Router.GET(config.PodsURI, handlers.GetPods)
@@ -172,6 +227,59 @@ func (a *ApiServer) Register() {
 	// 获取指定持久化卷声明
 	a.Router.GET(config.PersistentVolumeClaimURI, handlers.GetPVC)
 
+	// 获取全局所有ResourceQuotas
+	a.Router.GET(config.GlobalResourceQuotasURI, handlers.GetGlobalResourceQuotas)
+	// 获取命名空间内的所有ResourceQuotas
+	a.Router.GET(config.ResourceQuotasURI, handlers.GetResourceQuotas)
+	// 创建ResourceQuota
+	a.Router.POST(config.ResourceQuotasURI, handlers.AddResourceQuota)
+	// 获取指定ResourceQuota
+	a.Router.GET(config.ResourceQuotaURI, handlers.GetResourceQuota)
+	// 更新指定ResourceQuota
+	a.Router.PUT(config.ResourceQuotaURI, handlers.UpdateResourceQuota)
+	// 删除指定ResourceQuota
+	a.Router.DELETE(config.ResourceQuotaURI, handlers.DeleteResourceQuota)
+	// 更新指定ResourceQuota的用量，该请求来自于resourceQuotaController
+	a.Router.PUT(config.ResourceQuotaStatusURI, handlers.UpdateResourceQuotaStatus)
+
+	// 获取命名空间内的所有LimitRanges
+	a.Router.GET(config.LimitRangesURI, handlers.GetLimitRanges)
+	// 创建LimitRange
+	a.Router.POST(config.LimitRangesURI, handlers.AddLimitRange)
+	// 获取指定LimitRange
+	a.Router.GET(config.LimitRangeURI, handlers.GetLimitRange)
+	// 更新指定LimitRange
+	a.Router.PUT(config.LimitRangeURI, handlers.UpdateLimitRange)
+	// 删除指定LimitRange
+	a.Router.DELETE(config.LimitRangeURI, handlers.DeleteLimitRange)
+
+	// 获取所有PriorityClass
+	a.Router.GET(config.PriorityClassesURI, handlers.GetPriorityClasses)
+	// 创建PriorityClass
+	a.Router.POST(config.PriorityClassesURI, handlers.AddPriorityClass)
+	// 获取指定PriorityClass
+	a.Router.GET(config.PriorityClassURI, handlers.GetPriorityClass)
+	// 更新指定PriorityClass
+	a.Router.PUT(config.PriorityClassURI, handlers.UpdatePriorityClass)
+	// 删除指定PriorityClass
+	a.Router.DELETE(config.PriorityClassURI, handlers.DeletePriorityClass)
+
+	// 获取全局所有PodGroup
+	a.Router.GET(config.GlobalPodGroupsURI, handlers.GetGlobalPodGroups)
+	// 获取命名空间内的所有PodGroup
+	a.Router.GET(config.PodGroupsURI, handlers.GetPodGroups)
+	// 创建PodGroup
+	a.Router.POST(config.PodGroupsURI, handlers.AddPodGroup)
+	// 获取指定PodGroup
+	a.Router.GET(config.PodGroupURI, handlers.GetPodGroup)
+	// 更新指定PodGroup
+	a.Router.PUT(config.PodGroupURI, handlers.UpdatePodGroup)
+	// 删除指定PodGroup
+	a.Router.DELETE(config.PodGroupURI, handlers.DeletePodGroup)
+
+	// 获取ClusterIP与NodePort分配器的使用情况
+	a.Router.GET(config.AllocatorsURI, handlers.GetAllocatorUsage)
+
 	// 首次注册节点
 	a.Router.PUT(config.MonitorNodeURL, handlers.RegisterNodeMonitor)
 	// 节点失联后，删除相关配置
@@ -183,43 +291,24 @@ func (a *ApiServer) Register() {
 
 }
 
-func ScanNodeStatus() {
-	for {
-		// 获取所有节点
-		res, err := etcdclient.EtcdStore.PrefixGet(config.EtcdNodePrefix)
-		if err != nil {
-			log.WarnLog("ScanNodeStatus: " + err.Error())
-		}
-
-		for _, v := range res {
-			var node apiObject.Node
-			err = json.Unmarshal([]byte(v), &node)
-			if err != nil {
-				log.WarnLog("ScanNodeStatus: " + err.Error())
-			}
-			url := config.APIServerURL() + config.NodeStatusURI
-			url = strings.Replace(url, config.NameReplace, node.Metadata.Name, 1)
-			httprequest.PutObjMsg(url, node)
-		}
-
-		time.Sleep(10 * time.Second)
-	}
-
-}
-
 // NewApiServer 使用配置文件创建并返回一个新的ApiServer
 func NewApiServer() *ApiServer {
+	address, port := config.APIServerBindAddress()
 	return &ApiServer{
-		Address: config.APIServerLocalAddress,
-		Port:    config.APIServerLocalPort,
+		Address: address,
+		Port:    port,
 		Router:  gin.New(),
 	}
 }
 
-func ScanServiceStatus() {
+func ScanServiceStatus(stopCh <-chan struct{}) {
 	// 定时操作搜索的Service2Endpoint，如果有Pod更新变化，则更新Service的Endpoints
 	for {
-		time.Sleep(10 * time.Second)
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(10 * time.Second):
+		}
 
 		// 获取所有的Service2Endpoint
 		res, err := etcdclient.EtcdStore.PrefixGet(config.EtcdService2EndpointPrefix)
@@ -239,7 +328,8 @@ func ScanServiceStatus() {
 			if len(newServiceEvent.Endpoints) == len(oldServiceEvent.Endpoints) {
 				isSame := true
 				for i := 0; i < len(newServiceEvent.Endpoints); i++ {
-					if newServiceEvent.Endpoints[i].IP != oldServiceEvent.Endpoints[i].IP {
+					if newServiceEvent.Endpoints[i].IP != oldServiceEvent.Endpoints[i].IP ||
+						newServiceEvent.Endpoints[i].Ready != oldServiceEvent.Endpoints[i].Ready {
 						newServiceEvent.Action = entity.UpdateEvent
 						isSame = false
 						break
@@ -270,6 +360,16 @@ func ScanServiceStatus() {
 					log.ErrorLog("ScanServiceStatus: " + err.Error())
 				}
 			}
+			// 记录最新的Endpoints，避免下次扫描时重复通知kubeproxy
+			service2Endpoint, err := json.Marshal(newServiceEvent)
+			if err != nil {
+				log.WarnLog("ScanServiceStatus: " + err.Error())
+				continue
+			}
+			key := config.EtcdService2EndpointPrefix + "/" + newServiceEvent.Service.Metadata.Namespace + "/" + newServiceEvent.Service.Metadata.Name
+			if err = etcdclient.EtcdStore.Put(key, string(service2Endpoint)); err != nil {
+				log.WarnLog("ScanServiceStatus: " + err.Error())
+			}
 
 		}
 	}
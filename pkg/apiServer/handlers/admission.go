@@ -0,0 +1,507 @@
+// 描述：apiServer在创建对象时执行的准入控制，包括Pod优先级的填写、LimitRange的默认值注入与范围校验，以及ResourceQuota的配额检查
+// 参考：https://kubernetes.io/zh-cn/docs/concepts/policy/limit-range/
+//	https://kubernetes.io/zh-cn/docs/concepts/policy/resource-quotas/
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/config"
+	"minik8s/tools/conversion"
+	"minik8s/tools/log"
+
+	etcdclient "minik8s/pkg/apiServer/etcdClient"
+)
+
+// ErrAdmissionDenied 对象被准入控制拒绝
+var ErrAdmissionDenied = errors.New("forbidden")
+
+// AdmissionStatusCode 根据准入控制返回的错误得到对应的HTTP状态码
+func AdmissionStatusCode(err error) int {
+	if errors.Is(err, ErrAdmissionDenied) {
+		return 403
+	}
+	return 500
+}
+
+const (
+	// quotaLockTTL 配额锁的有效时间，持有锁的apiServer副本异常退出后，其他副本在锁过期之后可以重新获取
+	quotaLockTTL = 10 * time.Second
+	// quotaLockRetryInterval 配额锁被其他请求持有时重新尝试获取的间隔
+	quotaLockRetryInterval = 10 * time.Millisecond
+)
+
+// quotaLock 保存在etcd中的命名空间配额锁
+type quotaLock struct {
+	Holder   string    `json:"holder"`
+	ExpireAt time.Time `json:"expireAt"`
+}
+
+// LockNamespaceQuota 锁定命名空间的配额，返回解锁函数
+//
+//	ResourceQuota的用量根据etcd中已经存在的对象计算，并发创建的对象可能同时通过检查而超出配额，
+//	因此创建对象时需要在执行准入控制之前锁定，并在对象写入etcd之后解锁
+//	多个apiServer副本共享同一份配额，锁保存在etcd中，通过CompareAndSwap获取与释放
+func LockNamespaceQuota(namespace string) (func(), error) {
+	key := config.EtcdQuotaLockPrefix + "/" + namespace
+	holder := uuid.New().String()
+	for {
+		res, revision, err := etcdclient.EtcdStore.GetWithRevision(key)
+		if err != nil {
+			return nil, err
+		}
+		if res != "" {
+			var lock quotaLock
+			if err = json.Unmarshal([]byte(res), &lock); err == nil && time.Now().Before(lock.ExpireAt) {
+				time.Sleep(quotaLockRetryInterval)
+				continue
+			}
+		}
+		lockJSON, err := json.Marshal(quotaLock{Holder: holder, ExpireAt: time.Now().Add(quotaLockTTL)})
+		if err != nil {
+			return nil, err
+		}
+		ok, err := etcdclient.EtcdStore.CompareAndSwap(key, string(lockJSON), revision)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return func() { unlockNamespaceQuota(key, holder) }, nil
+		}
+	}
+}
+
+// unlockNamespaceQuota 释放配额锁，锁已经过期并被其他请求获取时保持不变
+func unlockNamespaceQuota(key string, holder string) {
+	res, revision, err := etcdclient.EtcdStore.GetWithRevision(key)
+	if err != nil {
+		log.WarnLog("unlockNamespaceQuota: " + err.Error())
+		return
+	}
+	var lock quotaLock
+	if res == "" || json.Unmarshal([]byte(res), &lock) != nil || lock.Holder != holder {
+		return
+	}
+	if _, err = etcdclient.EtcdStore.CompareAndSwap(key, "", revision); err != nil {
+		log.WarnLog("unlockNamespaceQuota: " + err.Error())
+	}
+}
+
+// AdmitPod 对即将创建的Pod执行准入控制
+//
+//	1. 根据PriorityClass填写Pod的优先级与抢占策略
+//	2. 根据命名空间内的LimitRange为容器注入默认的资源请求与限制，并校验最小值与最大值
+//	3. 校验容器的资源请求不超过资源限制
+//	4. 检查命名空间内的ResourceQuota是否允许创建该Pod
+func AdmitPod(pod *apiObject.Pod) error {
+	if err := resolvePodPriority(pod); err != nil {
+		return err
+	}
+	if err := applyLimitRanges(pod); err != nil {
+		return err
+	}
+	if err := validateContainerResources(pod); err != nil {
+		return err
+	}
+	delta, err := podResources(pod)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrAdmissionDenied, err.Error())
+	}
+	delta[apiObject.ResourcePods] = 1
+	return checkResourceQuota(pod.Metadata.Namespace, delta, pod)
+}
+
+// AdmitObjectCount 检查命名空间内的ResourceQuota是否允许再创建一个resource类型的对象
+func AdmitObjectCount(namespace string, resource string) error {
+	return checkResourceQuota(namespace, map[string]int64{resource: 1}, nil)
+}
+
+// resolvePodPriority 根据Pod的priorityClassName填写优先级与抢占策略
+//
+//	没有指定priorityClassName时使用globalDefault的优先级类，集群中没有默认优先级类时优先级为0
+func resolvePodPriority(pod *apiObject.Pod) error {
+	var priorityClass *apiObject.PriorityClass
+	if pod.Spec.PriorityClassName != "" {
+		var err error
+		priorityClass, err = getPriorityClass(pod.Spec.PriorityClassName)
+		if err != nil {
+			return err
+		}
+		if priorityClass == nil {
+			return fmt.Errorf("%w: no PriorityClass with name %s was found", ErrAdmissionDenied, pod.Spec.PriorityClassName)
+		}
+	} else {
+		priorityClasses, err := listPriorityClasses()
+		if err != nil {
+			return err
+		}
+		for i := range priorityClasses {
+			if priorityClasses[i].GlobalDefault {
+				priorityClass = &priorityClasses[i]
+				pod.Spec.PriorityClassName = priorityClass.Metadata.Name
+				break
+			}
+		}
+	}
+
+	var priority int32
+	preemptionPolicy := apiObject.PreemptLowerPriority
+	if priorityClass != nil {
+		priority = priorityClass.Value
+		if priorityClass.PreemptionPolicy != "" {
+			preemptionPolicy = priorityClass.PreemptionPolicy
+		}
+	}
+	// 优先级只能由优先级类决定
+	if pod.Spec.Priority != nil && *pod.Spec.Priority != priority {
+		return fmt.Errorf("%w: the integer value of priority (%d) must not be provided in pod spec; priority admission controller computed %d from the given PriorityClass name",
+			ErrAdmissionDenied, *pod.Spec.Priority, priority)
+	}
+	pod.Spec.Priority = &priority
+	if pod.Spec.PreemptionPolicy == "" {
+		pod.Spec.PreemptionPolicy = preemptionPolicy
+	}
+	return nil
+}
+
+// applyLimitRanges 为Pod中的容器注入LimitRange中的默认值，并校验最小值与最大值
+func applyLimitRanges(pod *apiObject.Pod) error {
+	limitRanges, err := getLimitRanges(pod.Metadata.Namespace)
+	if err != nil {
+		return err
+	}
+	for _, limitRange := range limitRanges {
+		for _, item := range limitRange.Spec.Limits {
+			if item.Type != "" && item.Type != apiObject.LimitTypeContainer {
+				continue
+			}
+			for i := range pod.Spec.Containers {
+				container := &pod.Spec.Containers[i]
+				if container.Resources.Limits == nil {
+					container.Resources.Limits = apiObject.ResourceList{}
+				}
+				if container.Resources.Requests == nil {
+					container.Resources.Requests = apiObject.ResourceList{}
+				}
+				for name, quantity := range item.Default {
+					if _, ok := container.Resources.Limits[name]; !ok {
+						container.Resources.Limits[name] = quantity
+					}
+				}
+				for name, quantity := range item.DefaultRequest {
+					if _, ok := container.Resources.Requests[name]; !ok {
+						container.Resources.Requests[name] = quantity
+					}
+				}
+				// 未指定资源请求时，资源请求默认等于资源限制
+				for name, quantity := range container.Resources.Limits {
+					if _, ok := container.Resources.Requests[name]; !ok {
+						container.Resources.Requests[name] = quantity
+					}
+				}
+				if err = checkLimitRangeItem(limitRange.Metadata.Name, item, container); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkLimitRangeItem 校验容器的资源请求与限制是否在LimitRange规定的范围之内
+func checkLimitRangeItem(limitRangeName string, item apiObject.LimitRangeItem, container *apiObject.Container) error {
+	for name, min := range item.Min {
+		minValue, err := conversion.ParseQuantity(name, min)
+		if err != nil {
+			return err
+		}
+		request, ok := container.Resources.Requests[name]
+		if !ok {
+			return fmt.Errorf("%w: limitRange %s: minimum %s usage per Container is %s, but request is not specified for container %s",
+				ErrAdmissionDenied, limitRangeName, name, min, container.Name)
+		}
+		requestValue, err := conversion.ParseQuantity(name, request)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrAdmissionDenied, err.Error())
+		}
+		if requestValue < minValue {
+			return fmt.Errorf("%w: limitRange %s: minimum %s usage per Container is %s, but request is %s for container %s",
+				ErrAdmissionDenied, limitRangeName, name, min, request, container.Name)
+		}
+	}
+	for name, max := range item.Max {
+		maxValue, err := conversion.ParseQuantity(name, max)
+		if err != nil {
+			return err
+		}
+		limit, ok := container.Resources.Limits[name]
+		if !ok {
+			return fmt.Errorf("%w: limitRange %s: maximum %s usage per Container is %s, but limit is not specified for container %s",
+				ErrAdmissionDenied, limitRangeName, name, max, container.Name)
+		}
+		limitValue, err := conversion.ParseQuantity(name, limit)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrAdmissionDenied, err.Error())
+		}
+		if limitValue > maxValue {
+			return fmt.Errorf("%w: limitRange %s: maximum %s usage per Container is %s, but limit is %s for container %s",
+				ErrAdmissionDenied, limitRangeName, name, max, limit, container.Name)
+		}
+	}
+	return nil
+}
+
+// validateContainerResources 校验容器的资源数量是否合法，并且资源请求不超过资源限制
+func validateContainerResources(pod *apiObject.Pod) error {
+	for _, container := range pod.Spec.Containers {
+		for name, request := range container.Resources.Requests {
+			requestValue, err := conversion.ParseQuantity(name, request)
+			if err != nil {
+				return fmt.Errorf("%w: container %s: %s", ErrAdmissionDenied, container.Name, err.Error())
+			}
+			limit, ok := container.Resources.Limits[name]
+			if !ok {
+				continue
+			}
+			limitValue, err := conversion.ParseQuantity(name, limit)
+			if err != nil {
+				return fmt.Errorf("%w: container %s: %s", ErrAdmissionDenied, container.Name, err.Error())
+			}
+			if requestValue > limitValue {
+				return fmt.Errorf("%w: container %s: %s request %s must be less than or equal to limit %s",
+					ErrAdmissionDenied, container.Name, name, request, limit)
+			}
+		}
+	}
+	return nil
+}
+
+// checkResourceQuota 检查命名空间在增加delta的用量后是否超出ResourceQuota的限制
+//
+//	当pod不为空且ResourceQuota限制了requests或limits时，要求pod的每个容器都显式给出对应的资源
+func checkResourceQuota(namespace string, delta map[string]int64, pod *apiObject.Pod) error {
+	quotas, err := getResourceQuotas(namespace)
+	if err != nil {
+		return err
+	}
+	if len(quotas) == 0 {
+		return nil
+	}
+	usage, err := GetNamespaceUsage(namespace)
+	if err != nil {
+		return err
+	}
+	for _, quota := range quotas {
+		for name, hard := range quota.Spec.Hard {
+			resource := normalizeQuotaResource(name)
+			if pod != nil {
+				if err = checkPodSpecifiesResource(quota.Metadata.Name, resource, pod); err != nil {
+					return err
+				}
+			}
+			change, ok := delta[resource]
+			if !ok || change == 0 {
+				continue
+			}
+			hardValue, err := conversion.ParseQuantity(resource, hard)
+			if err != nil {
+				return err
+			}
+			if usage[resource]+change > hardValue {
+				return fmt.Errorf("%w: exceeded quota: %s, requested: %s=%s, used: %s=%s, limited: %s=%s",
+					ErrAdmissionDenied, quota.Metadata.Name,
+					resource, conversion.FormatQuantity(resource, change),
+					resource, conversion.FormatQuantity(resource, usage[resource]),
+					resource, hard)
+			}
+		}
+	}
+	return nil
+}
+
+// checkPodSpecifiesResource 当配额限制了某种计算资源时，Pod的每个容器都必须给出该资源的请求或限制
+func checkPodSpecifiesResource(quotaName string, resource string, pod *apiObject.Pod) error {
+	parts := strings.SplitN(resource, ".", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	for _, container := range pod.Spec.Containers {
+		var list apiObject.ResourceList
+		switch parts[0] {
+		case "requests":
+			list = container.Resources.Requests
+		case "limits":
+			list = container.Resources.Limits
+		default:
+			return nil
+		}
+		if _, ok := list[parts[1]]; !ok {
+			return fmt.Errorf("%w: failed quota: %s: must specify %s for container %s",
+				ErrAdmissionDenied, quotaName, resource, container.Name)
+		}
+	}
+	return nil
+}
+
+// GetNamespaceUsage 从etcd中统计命名空间内的对象数量与资源用量
+func GetNamespaceUsage(namespace string) (map[string]int64, error) {
+	var pods []apiObject.Pod
+	if err := listNamespaceObjects(config.EtcdPodPrefix, namespace, func(v string) error {
+		pod := apiObject.Pod{}
+		err := json.Unmarshal([]byte(v), &pod)
+		pods = append(pods, pod)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	var services []apiObject.Service
+	if err := listNamespaceObjects(config.EtcdServicePrefix, namespace, func(v string) error {
+		service := apiObject.Service{}
+		err := json.Unmarshal([]byte(v), &service)
+		services = append(services, service)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	var replicaSets []apiObject.ReplicaSet
+	if err := listNamespaceObjects(config.EtcdReplicaSetPrefix, namespace, func(v string) error {
+		rs := apiObject.ReplicaSet{}
+		err := json.Unmarshal([]byte(v), &rs)
+		replicaSets = append(replicaSets, rs)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	var pvcs []apiObject.PersistentVolumeClaim
+	if err := listNamespaceObjects(config.EtcdPvcPrefix, namespace, func(v string) error {
+		pvc := apiObject.PersistentVolumeClaim{}
+		err := json.Unmarshal([]byte(v), &pvc)
+		pvcs = append(pvcs, pvc)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return QuotaUsage(pods, services, replicaSets, pvcs), nil
+}
+
+// podResources 计算Pod中所有容器的资源请求与限制之和，cpu 单位为毫核，memory 单位为 KB
+//
+//	返回值的键为 requests.cpu、requests.memory、limits.cpu、limits.memory
+func podResources(pod *apiObject.Pod) (map[string]int64, error) {
+	res := map[string]int64{}
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			value, err := conversion.ParseQuantity(name, quantity)
+			if err != nil {
+				return nil, err
+			}
+			res["requests."+name] += value
+		}
+		for name, quantity := range container.Resources.Limits {
+			value, err := conversion.ParseQuantity(name, quantity)
+			if err != nil {
+				return nil, err
+			}
+			res["limits."+name] += value
+		}
+	}
+	return res, nil
+}
+
+// QuotaUsage 统计传入对象的数量以及Pod的资源用量，调用者需要保证传入的对象属于同一个命名空间
+func QuotaUsage(pods []apiObject.Pod, services []apiObject.Service, replicaSets []apiObject.ReplicaSet, pvcs []apiObject.PersistentVolumeClaim) map[string]int64 {
+	usage := map[string]int64{
+		apiObject.ResourcePods:                   0,
+		apiObject.ResourceServices:               int64(len(services)),
+		apiObject.ResourceReplicaSets:            int64(len(replicaSets)),
+		apiObject.ResourcePersistentVolumeClaims: int64(len(pvcs)),
+		apiObject.ResourceRequestsCPU:            0,
+		apiObject.ResourceRequestsMemory:         0,
+		apiObject.ResourceLimitsCPU:              0,
+		apiObject.ResourceLimitsMemory:           0,
+	}
+	for i := range pods {
+		// 已经终止的Pod不再占用配额
+		if pods[i].Status.Phase == apiObject.PodFailed || pods[i].Status.Phase == apiObject.PodSucceeded {
+			continue
+		}
+		usage[apiObject.ResourcePods]++
+		resources, err := podResources(&pods[i])
+		if err != nil {
+			continue
+		}
+		for name, value := range resources {
+			usage[name] += value
+		}
+	}
+	return usage
+}
+
+// normalizeQuotaResource 将ResourceQuota中的 cpu、memory 转化为等价的 requests.cpu、requests.memory
+func normalizeQuotaResource(name string) string {
+	switch name {
+	case apiObject.ResourceCPU:
+		return apiObject.ResourceRequestsCPU
+	case apiObject.ResourceMemory:
+		return apiObject.ResourceRequestsMemory
+	default:
+		return name
+	}
+}
+
+// QuotaStatus 根据硬性上限与用量生成ResourceQuota的状态，只记录上限中出现的资源
+func QuotaStatus(hard apiObject.ResourceList, usage map[string]int64) apiObject.ResourceQuotaStatus {
+	status := apiObject.ResourceQuotaStatus{
+		Hard: hard,
+		Used: apiObject.ResourceList{},
+	}
+	for name := range hard {
+		resource := normalizeQuotaResource(name)
+		status.Used[name] = conversion.FormatQuantity(resource, usage[resource])
+	}
+	return status
+}
+
+func getResourceQuotas(namespace string) ([]apiObject.ResourceQuota, error) {
+	var quotas []apiObject.ResourceQuota
+	err := listNamespaceObjects(config.EtcdResourceQuotaPrefix, namespace, func(v string) error {
+		quota := apiObject.ResourceQuota{}
+		err := json.Unmarshal([]byte(v), &quota)
+		quotas = append(quotas, quota)
+		return err
+	})
+	return quotas, err
+}
+
+func getLimitRanges(namespace string) ([]apiObject.LimitRange, error) {
+	var limitRanges []apiObject.LimitRange
+	err := listNamespaceObjects(config.EtcdLimitRangePrefix, namespace, func(v string) error {
+		limitRange := apiObject.LimitRange{}
+		err := json.Unmarshal([]byte(v), &limitRange)
+		limitRanges = append(limitRanges, limitRange)
+		return err
+	})
+	return limitRanges, err
+}
+
+// listNamespaceObjects 遍历etcd中某一命名空间下的所有对象
+func listNamespaceObjects(prefix string, namespace string, handle func(string) error) error {
+	res, err := etcdclient.EtcdStore.PrefixGet(prefix + "/" + namespace + "/")
+	if err != nil {
+		return err
+	}
+	for _, v := range res {
+		if err = handle(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
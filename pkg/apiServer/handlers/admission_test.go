@@ -0,0 +1,35 @@
+// 测试配额用量的统计
+
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+)
+
+func TestQuotaUsage(t *testing.T) {
+	container := apiObject.Container{
+		Resources: apiObject.ResourceRequirements{
+			Requests: apiObject.ResourceList{apiObject.ResourceCPU: "250m", apiObject.ResourceMemory: "64Mi"},
+			Limits:   apiObject.ResourceList{apiObject.ResourceCPU: "500m"},
+		},
+	}
+	running := apiObject.Pod{Spec: apiObject.PodSpec{Containers: []apiObject.Container{container, container}}}
+	failed := running
+	failed.Status.Phase = apiObject.PodFailed
+
+	usage := QuotaUsage([]apiObject.Pod{running, failed}, []apiObject.Service{{}}, nil, nil)
+	assert.Equal(t, int64(1), usage[apiObject.ResourcePods])
+	assert.Equal(t, int64(1), usage[apiObject.ResourceServices])
+	assert.Equal(t, int64(0), usage[apiObject.ResourceReplicaSets])
+	assert.Equal(t, int64(500), usage[apiObject.ResourceRequestsCPU])
+	assert.Equal(t, int64(128*1024), usage[apiObject.ResourceRequestsMemory])
+	assert.Equal(t, int64(1000), usage[apiObject.ResourceLimitsCPU])
+
+	status := QuotaStatus(apiObject.ResourceList{apiObject.ResourceCPU: "1", apiObject.ResourcePods: "2"}, usage)
+	assert.Equal(t, "500m", status.Used[apiObject.ResourceCPU])
+	assert.Equal(t, "1", status.Used[apiObject.ResourcePods])
+}
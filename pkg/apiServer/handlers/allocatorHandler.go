@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/apiServer/allocator"
+	"minik8s/pkg/config"
+	"minik8s/tools/log"
+
+	etcdclient "minik8s/pkg/apiServer/etcdClient"
+)
+
+var (
+	serviceIPAllocator *allocator.IPAllocator
+	nodePortAllocator  *allocator.PortAllocator
+	allocatorInitErr   error
+	allocatorInitOnce  sync.Once
+)
+
+// getServiceAllocators 获取ClusterIP与NodePort分配器，首次调用时根据配置创建
+func getServiceAllocators() (*allocator.IPAllocator, *allocator.PortAllocator, error) {
+	allocatorInitOnce.Do(func() {
+		if etcdclient.EtcdStore == nil {
+			allocatorInitErr = errors.New("etcd client is not initialized")
+			return
+		}
+		serviceIPAllocator, allocatorInitErr = allocator.NewIPAllocator(
+			config.ServiceClusterIPRange, config.EtcdServiceIPRangeKey, etcdclient.EtcdStore, existingClusterIPs)
+		if allocatorInitErr != nil {
+			return
+		}
+		nodePortAllocator, allocatorInitErr = allocator.NewPortAllocator(
+			config.ServiceNodePortRange, config.EtcdNodePortRangeKey, etcdclient.EtcdStore, existingNodePorts)
+	})
+	return serviceIPAllocator, nodePortAllocator, allocatorInitErr
+}
+
+// listServices 获取etcd中的所有Service
+func listServices() ([]apiObject.Service, error) {
+	res, err := etcdclient.EtcdStore.PrefixGet(config.EtcdServicePrefix)
+	if err != nil {
+		return nil, err
+	}
+	services := make([]apiObject.Service, 0, len(res))
+	for _, v := range res {
+		var service apiObject.Service
+		if err = json.Unmarshal([]byte(v), &service); err != nil {
+			return nil, err
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+// existingClusterIPs 返回已有Service使用的ClusterIP，用于在网段变化后重建分配器
+func existingClusterIPs() ([]string, error) {
+	services, err := listServices()
+	if err != nil {
+		return nil, err
+	}
+	var ips []string
+	for _, service := range services {
+		if service.Spec.ClusterIP != "" {
+			ips = append(ips, service.Spec.ClusterIP)
+		}
+	}
+	return ips, nil
+}
+
+// existingNodePorts 返回已有Service使用的NodePort，用于在端口范围变化后重建分配器
+func existingNodePorts() ([]int, error) {
+	services, err := listServices()
+	if err != nil {
+		return nil, err
+	}
+	var ports []int
+	for _, service := range services {
+		for _, port := range service.Spec.Ports {
+			if port.NodePort != 0 {
+				ports = append(ports, int(port.NodePort))
+			}
+		}
+	}
+	return ports, nil
+}
+
+// GetAllocatorUsage 获取ClusterIP与NodePort分配器的使用情况
+func GetAllocatorUsage(c *gin.Context) {
+	ipAlloc, portAlloc, err := getServiceAllocators()
+	if err != nil {
+		log.ErrorLog("GetAllocatorUsage: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	ipUsage, err := ipAlloc.Usage()
+	if err != nil {
+		log.ErrorLog("GetAllocatorUsage: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	portUsage, err := portAlloc.Usage()
+	if err != nil {
+		log.ErrorLog("GetAllocatorUsage: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": gin.H{
+		"serviceIPs":       ipUsage,
+		"serviceNodePorts": portUsage,
+	}})
+}
+
+// allocateServiceResources 为Service分配ClusterIP与NodePort
+//
+//	old不为空时表示更新，沿用旧Service已经分配的ClusterIP与NodePort
+//	返回的release函数用于在后续步骤失败时归还本次新分配的资源
+func allocateServiceResources(service *apiObject.Service, old *apiObject.Service) (release func(), err error) {
+	ipAlloc, portAlloc, err := getServiceAllocators()
+	if err != nil {
+		return nil, err
+	}
+	var allocatedIP string
+	var allocatedPorts []int
+	release = func() {
+		if allocatedIP != "" {
+			_ = ipAlloc.Release(allocatedIP)
+		}
+		for _, port := range allocatedPorts {
+			_ = portAlloc.Release(port)
+		}
+	}
+
+	// 1. 分配ClusterIP，更新时ClusterIP不可修改
+	if old != nil {
+		if service.Spec.ClusterIP != "" && service.Spec.ClusterIP != old.Spec.ClusterIP {
+			return nil, errors.New("spec.clusterIP: field is immutable")
+		}
+		service.Spec.ClusterIP = old.Spec.ClusterIP
+	} else if service.Spec.ClusterIP != "" {
+		if err = ipAlloc.Allocate(service.Spec.ClusterIP); err != nil {
+			return nil, errors.New("failed to allocate clusterIP " + service.Spec.ClusterIP + ": " + err.Error())
+		}
+		allocatedIP = service.Spec.ClusterIP
+	} else {
+		if service.Spec.ClusterIP, err = ipAlloc.AllocateNext(); err != nil {
+			return nil, errors.New("failed to allocate clusterIP: " + err.Error())
+		}
+		allocatedIP = service.Spec.ClusterIP
+	}
+
+	// 2. 为NodePort类型的Service分配NodePort
+	if service.Spec.Type != apiObject.ServiceTypeNodePort {
+		for i := range service.Spec.Ports {
+			service.Spec.Ports[i].NodePort = 0
+		}
+		return release, nil
+	}
+	// 更新时，未指定NodePort的端口沿用旧Service中相同端口的NodePort
+	oldPorts := make(map[int32]int32)
+	if old != nil {
+		for _, port := range old.Spec.Ports {
+			if port.NodePort != 0 {
+				oldPorts[port.Port] = port.NodePort
+			}
+		}
+	}
+	for i := range service.Spec.Ports {
+		port := &service.Spec.Ports[i]
+		if port.NodePort == 0 && oldPorts[port.Port] != 0 {
+			port.NodePort = oldPorts[port.Port]
+		}
+		if port.NodePort != 0 {
+			if old != nil && hasNodePort(old, port.NodePort) {
+				continue
+			}
+			if err = portAlloc.Allocate(int(port.NodePort)); err != nil {
+				release()
+				return nil, fmt.Errorf("failed to allocate nodePort %d: %v", port.NodePort, err)
+			}
+			allocatedPorts = append(allocatedPorts, int(port.NodePort))
+			continue
+		}
+		next, err := portAlloc.AllocateNext()
+		if err != nil {
+			release()
+			return nil, errors.New("failed to allocate nodePort: " + err.Error())
+		}
+		port.NodePort = int32(next)
+		allocatedPorts = append(allocatedPorts, next)
+	}
+	return release, nil
+}
+
+// releaseServiceResources 归还Service占用的ClusterIP与NodePort
+//
+//	keep不为空时表示更新，只归还keep中不再使用的NodePort
+func releaseServiceResources(service *apiObject.Service, keep *apiObject.Service) error {
+	ipAlloc, portAlloc, err := getServiceAllocators()
+	if err != nil {
+		return err
+	}
+	// 不在分配范围内的地址与端口（如旧版本随机生成的ClusterIP）没有记录在分配器中，直接跳过
+	if keep == nil && service.Spec.ClusterIP != "" {
+		has, err := ipAlloc.Has(service.Spec.ClusterIP)
+		if err != nil {
+			return err
+		}
+		if has {
+			if err = ipAlloc.Release(service.Spec.ClusterIP); err != nil {
+				return err
+			}
+		}
+	}
+	for _, port := range service.Spec.Ports {
+		if port.NodePort == 0 || (keep != nil && hasNodePort(keep, port.NodePort)) {
+			continue
+		}
+		has, err := portAlloc.Has(int(port.NodePort))
+		if err != nil {
+			return err
+		}
+		if has {
+			if err = portAlloc.Release(int(port.NodePort)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func hasNodePort(service *apiObject.Service, nodePort int32) bool {
+	for _, port := range service.Spec.Ports {
+		if port.NodePort == nodePort {
+			return true
+		}
+	}
+	return false
+}
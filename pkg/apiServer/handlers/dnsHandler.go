@@ -83,17 +83,21 @@ func GetDNSs(c *gin.Context) {
 }
 
 func AddDNS(c *gin.Context) {
-	// 在真正的服务之前，要确保是否已经创建出了Nginx的Pod
-	nginxIP, err := GetNginxPod()
-	if err != nil {
-		log.ErrorLog("AddDNS: " + err.Error())
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
+	// 在真正的服务之前，要确保是否已经创建出了Nginx的Pod，dryRun请求不创建Nginx的Pod
+	nginxIP := ""
+	if !IsDryRun(c) {
+		ip, err := GetNginxPod()
+		if err != nil {
+			log.ErrorLog("AddDNS: " + err.Error())
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		nginxIP = ip
 	}
 
 	// 读取请求中的DNS对象
 	var dns apiObject.Dns
-	err = c.BindJSON(&dns)
+	err := c.BindJSON(&dns)
 	if err != nil {
 		log.ErrorLog("AddDNS: " + err.Error())
 		c.JSON(400, gin.H{"error": err.Error()})
@@ -154,6 +158,11 @@ func AddDNS(c *gin.Context) {
 		dns.Spec.Paths[it].SvcIp = service.Spec.ClusterIP
 	}
 	dns.Metadata.UUID = uuid.New().String()
+	// dryRun请求不更新各节点的hosts文件和Nginx配置，也不写入etcd
+	if IsDryRun(c) {
+		DryRunResult(c, 200, dns)
+		return
+	}
 
 	// 更新每个节点的hosts文件
 	Nodes := GetALLNodes()
@@ -237,6 +246,10 @@ func DeleteDNS(c *gin.Context) {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	if IsDryRun(c) {
+		DryRunResult(c, 200, dns)
+		return
+	}
 
 	// 删除每个节点的hosts文件
 	Nodes := GetALLNodes()
@@ -400,7 +413,7 @@ func reloadNginx() error {
 		return err
 	}
 
-	url := config.HttpSchema + config.APIServerLocalAddress + ":" + fmt.Sprint(config.APIServerLocalPort) + config.PodExecURI
+	url := config.APIServerURL() + config.PodExecURI
 	url = strings.Replace(url, config.NameSpaceReplace, nginxPod.Namespace, -1)
 	url = strings.Replace(url, config.NameReplace, nginxPod.Name, -1)
 	url = strings.Replace(url, config.ContainerReplace, nginxPod.ContainerName, -1)
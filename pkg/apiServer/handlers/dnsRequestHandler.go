@@ -46,6 +46,10 @@ func DeleteDnsRequest(c *gin.Context) {
 		c.JSON(404, gin.H{"error": "not found"})
 		return
 	}
+	if IsDryRun(c) {
+		DryRunResult(c, 200, resJson)
+		return
+	}
 
 	err = etcdclient.EtcdStore.Delete(key)
 	if err != nil {
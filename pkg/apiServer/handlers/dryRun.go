@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"minik8s/pkg/config"
+	"minik8s/tools/log"
+)
+
+// ValidateDryRun 校验请求中的dryRun参数，目前只支持 dryRun=All
+func ValidateDryRun(c *gin.Context) {
+	value, ok := c.GetQuery(config.DryRunQuery)
+	if ok && value != config.DryRunAll {
+		log.ErrorLog("ValidateDryRun: unsupported dryRun value " + value)
+		c.AbortWithStatusJSON(400, gin.H{"error": "unsupported dryRun value: " + value})
+		return
+	}
+	c.Next()
+}
+
+// IsDryRun 判断请求是否为dryRun请求
+//
+//	dryRun请求会完成默认值填充与校验，并返回将要写入的对象，但不会写入etcd，也不会调用scheduler、kubelet等下游组件
+func IsDryRun(c *gin.Context) bool {
+	return c.Query(config.DryRunQuery) == config.DryRunAll
+}
+
+// DryRunResult 返回dryRun请求的结果
+func DryRunResult(c *gin.Context, code int, obj interface{}) {
+	log.InfoLog("DryRun: " + c.Request.Method + " " + c.Request.URL.Path)
+	c.JSON(code, gin.H{"data": obj, config.DryRunQuery: config.DryRunAll})
+}
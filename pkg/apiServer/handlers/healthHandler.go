@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+
+	"minik8s/pkg/config"
+	"minik8s/tools/log"
+
+	etcdclient "minik8s/pkg/apiServer/etcdClient"
+)
+
+// isLeader 当前apiServer副本是否为leader
+var isLeader atomic.Bool
+
+// SetLeader 记录当前apiServer副本是否为leader
+func SetLeader(leader bool) {
+	isLeader.Store(leader)
+}
+
+// Healthz 健康检查，etcd可以正常读取时返回200，组件据此在多个apiServer副本之间切换
+func Healthz(c *gin.Context) {
+	if etcdclient.EtcdStore == nil {
+		c.JSON(500, gin.H{"error": "etcd client is not initialized"})
+		return
+	}
+	if err := etcdclient.EtcdStore.Healthy(config.APIServerHealthCheckTimeout); err != nil {
+		log.WarnLog("Healthz: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": "ok", "leader": isLeader.Load()})
+}
@@ -109,6 +109,10 @@ func AddHPA(c *gin.Context) {
 		return
 	}
 	hpa.Metadata.UUID = uuid.New().String()
+	if IsDryRun(c) {
+		DryRunResult(c, 200, hpa)
+		return
+	}
 	resJson, err := json.Marshal(hpa)
 	if err != nil {
 		log.ErrorLog("AddHPA: " + err.Error())
@@ -139,6 +143,28 @@ func DeleteHPA(c *gin.Context) {
 	}
 	log.InfoLog("DeleteHPA: " + namespace + "/" + name)
 
+	if IsDryRun(c) {
+		res, err := etcdclient.EtcdStore.Get(config.EtcdHpaPrefix + "/" + namespace + "/" + name)
+		if err != nil {
+			log.ErrorLog("DeleteHPA: " + err.Error())
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		if res == "" {
+			log.ErrorLog("DeleteHPA: not found")
+			c.JSON(404, gin.H{"error": "not found"})
+			return
+		}
+		hpa := apiObject.HPA{}
+		err = json.Unmarshal([]byte(res), &hpa)
+		if err != nil {
+			log.ErrorLog("DeleteHPA: " + err.Error())
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		DryRunResult(c, 200, hpa)
+		return
+	}
 	err := etcdclient.EtcdStore.Delete(config.EtcdHpaPrefix + "/" + namespace + "/" + name)
 	if err != nil {
 		log.ErrorLog("DeleteHPA: " + err.Error())
@@ -205,6 +231,10 @@ func UpdateHPAStatus(c *gin.Context) {
 		return
 	}
 	hpa.Status = status
+	if IsDryRun(c) {
+		DryRunResult(c, 200, hpa)
+		return
+	}
 	resJson, err := json.Marshal(hpa)
 	if err != nil {
 		log.ErrorLog("UpdateHPAStatus: " + err.Error())
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/config"
+	"minik8s/tools/log"
+
+	etcdclient "minik8s/pkg/apiServer/etcdClient"
+)
+
+// GetLease 获取指定Lease
+func GetLease(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	if namespace == "" || name == "" {
+		log.ErrorLog("GetLease: namespace or name is empty")
+		c.JSON(400, gin.H{"error": "namespace or name is empty"})
+		return
+	}
+
+	res, err := etcdclient.EtcdStore.Get(config.EtcdLeasePrefix + "/" + namespace + "/" + name)
+	if err != nil {
+		log.ErrorLog("GetLease: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	lease := apiObject.Lease{}
+	err = json.Unmarshal([]byte(res), &lease)
+	if err != nil {
+		log.ErrorLog("GetLease: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": lease})
+}
+
+// GetLeases 获取命名空间内的所有Lease
+func GetLeases(c *gin.Context) {
+	namespace := c.Param("namespace")
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	var leases []apiObject.Lease
+	err := listNamespaceObjects(config.EtcdLeasePrefix, namespace, func(v string) error {
+		lease := apiObject.Lease{}
+		err := json.Unmarshal([]byte(v), &lease)
+		leases = append(leases, lease)
+		return err
+	})
+	if err != nil {
+		log.ErrorLog("GetLeases: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, leases)
+}
+
+// PutLease 创建或续约Lease，节点心跳的请求来自于kubelet
+func PutLease(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	if namespace == "" || name == "" {
+		log.ErrorLog("PutLease: namespace or name is empty")
+		c.JSON(400, gin.H{"error": "namespace or name is empty"})
+		return
+	}
+	var lease apiObject.Lease
+	err := c.ShouldBindJSON(&lease)
+	if err != nil {
+		log.ErrorLog("PutLease: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := config.EtcdLeasePrefix + "/" + namespace + "/" + name
+	res, err := etcdclient.EtcdStore.Get(key)
+	if err != nil {
+		log.ErrorLog("PutLease: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	code := 200
+	if res == "" {
+		lease.Metadata.UUID = uuid.New().String()
+		code = 201
+	} else {
+		oldLease := apiObject.Lease{}
+		err = json.Unmarshal([]byte(res), &oldLease)
+		if err != nil {
+			log.ErrorLog("PutLease: " + err.Error())
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		lease.Metadata.UUID = oldLease.Metadata.UUID
+		// 持有者不变时保留首次获得Lease的时间
+		if lease.Spec.HolderIdentity == oldLease.Spec.HolderIdentity {
+			lease.Spec.AcquireTime = oldLease.Spec.AcquireTime
+		}
+	}
+	lease.Kind = apiObject.LeaseType
+	lease.Metadata.Name = name
+	lease.Metadata.Namespace = namespace
+	if IsDryRun(c) {
+		DryRunResult(c, code, lease)
+		return
+	}
+
+	resJson, err := json.Marshal(lease)
+	if err != nil {
+		log.ErrorLog("PutLease: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	err = etcdclient.EtcdStore.Put(key, string(resJson))
+	if err != nil {
+		log.ErrorLog("PutLease: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(code, gin.H{"data": lease})
+}
+
+// DeleteLease 删除指定Lease
+func DeleteLease(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	if namespace == "" || name == "" {
+		log.ErrorLog("DeleteLease: namespace or name is empty")
+		c.JSON(400, gin.H{"error": "namespace or name is empty"})
+		return
+	}
+	log.InfoLog("DeleteLease: " + namespace + "/" + name)
+
+	key := config.EtcdLeasePrefix + "/" + namespace + "/" + name
+	res, err := etcdclient.EtcdStore.Get(key)
+	if err != nil {
+		log.ErrorLog("DeleteLease: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	if IsDryRun(c) {
+		lease := apiObject.Lease{}
+		_ = json.Unmarshal([]byte(res), &lease)
+		DryRunResult(c, 200, lease)
+		return
+	}
+	err = etcdclient.EtcdStore.Delete(key)
+	if err != nil {
+		log.ErrorLog("DeleteLease: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": "success"})
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/config"
+	"minik8s/tools/conversion"
+	"minik8s/tools/log"
+
+	etcdclient "minik8s/pkg/apiServer/etcdClient"
+)
+
+// GetLimitRange 获取指定LimitRange
+func GetLimitRange(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	if namespace == "" {
+		namespace = "default"
+	}
+	if name == "" {
+		log.ErrorLog("GetLimitRange: name is empty")
+		c.JSON(400, gin.H{"error": "name is empty"})
+		return
+	}
+	log.InfoLog("GetLimitRange: " + namespace + "/" + name)
+
+	res, err := etcdclient.EtcdStore.Get(config.EtcdLimitRangePrefix + "/" + namespace + "/" + name)
+	if err != nil {
+		log.ErrorLog("GetLimitRange: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	limitRange := apiObject.LimitRange{}
+	err = json.Unmarshal([]byte(res), &limitRange)
+	if err != nil {
+		log.ErrorLog("GetLimitRange: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": limitRange})
+}
+
+// GetLimitRanges 获取命名空间内的所有LimitRange
+func GetLimitRanges(c *gin.Context) {
+	namespace := c.Param("namespace")
+	if namespace == "" {
+		namespace = "default"
+	}
+	log.InfoLog("GetLimitRanges: " + namespace)
+
+	limitRanges, err := getLimitRanges(namespace)
+	if err != nil {
+		log.ErrorLog("GetLimitRanges: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, limitRanges)
+}
+
+// AddLimitRange 创建LimitRange
+func AddLimitRange(c *gin.Context) {
+	var limitRange apiObject.LimitRange
+	err := c.ShouldBindJSON(&limitRange)
+	if err != nil {
+		log.ErrorLog("AddLimitRange: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if limitRange.Metadata.Name == "" {
+		log.ErrorLog("AddLimitRange: name is empty")
+		c.JSON(400, gin.H{"error": "name is empty"})
+		return
+	}
+	if limitRange.Metadata.Namespace == "" {
+		limitRange.Metadata.Namespace = c.Param("namespace")
+	}
+	if limitRange.Metadata.Namespace == "" {
+		limitRange.Metadata.Namespace = "default"
+	}
+	if err = validateLimitRange(&limitRange); err != nil {
+		log.ErrorLog("AddLimitRange: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	log.InfoLog("AddLimitRange: " + limitRange.Metadata.Namespace + "/" + limitRange.Metadata.Name)
+
+	key := config.EtcdLimitRangePrefix + "/" + limitRange.Metadata.Namespace + "/" + limitRange.Metadata.Name
+	res, err := etcdclient.EtcdStore.Get(key)
+	if err != nil {
+		log.ErrorLog("AddLimitRange: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res != "" {
+		log.ErrorLog("AddLimitRange: already exists")
+		c.JSON(409, gin.H{"error": "already exists"})
+		return
+	}
+
+	limitRange.Metadata.UUID = uuid.New().String()
+	if IsDryRun(c) {
+		DryRunResult(c, 201, limitRange)
+		return
+	}
+	resJson, err := json.Marshal(limitRange)
+	if err != nil {
+		log.ErrorLog("AddLimitRange: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	err = etcdclient.EtcdStore.Put(key, string(resJson))
+	if err != nil {
+		log.ErrorLog("AddLimitRange: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(201, gin.H{"data": limitRange})
+}
+
+// UpdateLimitRange 更新LimitRange，只对之后创建的Pod生效
+func UpdateLimitRange(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	if namespace == "" || name == "" {
+		log.ErrorLog("UpdateLimitRange: namespace or name is empty")
+		c.JSON(400, gin.H{"error": "namespace or name is empty"})
+		return
+	}
+	log.InfoLog("UpdateLimitRange: " + namespace + "/" + name)
+
+	key := config.EtcdLimitRangePrefix + "/" + namespace + "/" + name
+	res, err := etcdclient.EtcdStore.Get(key)
+	if err != nil {
+		log.ErrorLog("UpdateLimitRange: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		log.ErrorLog("UpdateLimitRange: not found")
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	oldLimitRange := apiObject.LimitRange{}
+	err = json.Unmarshal([]byte(res), &oldLimitRange)
+	if err != nil {
+		log.ErrorLog("UpdateLimitRange: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	var limitRange apiObject.LimitRange
+	err = c.ShouldBindJSON(&limitRange)
+	if err != nil {
+		log.ErrorLog("UpdateLimitRange: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err = validateLimitRange(&limitRange); err != nil {
+		log.ErrorLog("UpdateLimitRange: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	limitRange.Metadata.Name = name
+	limitRange.Metadata.Namespace = namespace
+	limitRange.Metadata.UUID = oldLimitRange.Metadata.UUID
+	if IsDryRun(c) {
+		DryRunResult(c, 200, limitRange)
+		return
+	}
+
+	resJson, err := json.Marshal(limitRange)
+	if err != nil {
+		log.ErrorLog("UpdateLimitRange: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	err = etcdclient.EtcdStore.Put(key, string(resJson))
+	if err != nil {
+		log.ErrorLog("UpdateLimitRange: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": limitRange})
+}
+
+// DeleteLimitRange 删除LimitRange
+func DeleteLimitRange(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	if namespace == "" || name == "" {
+		log.ErrorLog("DeleteLimitRange: namespace or name is empty")
+		c.JSON(400, gin.H{"error": "namespace or name is empty"})
+		return
+	}
+	log.InfoLog("DeleteLimitRange: " + namespace + "/" + name)
+
+	key := config.EtcdLimitRangePrefix + "/" + namespace + "/" + name
+	res, err := etcdclient.EtcdStore.Get(key)
+	if err != nil {
+		log.ErrorLog("DeleteLimitRange: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		log.ErrorLog("DeleteLimitRange: not found")
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	limitRange := apiObject.LimitRange{}
+	err = json.Unmarshal([]byte(res), &limitRange)
+	if err != nil {
+		log.ErrorLog("DeleteLimitRange: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if IsDryRun(c) {
+		DryRunResult(c, 200, limitRange)
+		return
+	}
+
+	err = etcdclient.EtcdStore.Delete(key)
+	if err != nil {
+		log.ErrorLog("DeleteLimitRange: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": "success"})
+}
+
+// validateLimitRange 校验LimitRange中的资源数量，并保证 min <= defaultRequest <= default <= max
+func validateLimitRange(limitRange *apiObject.LimitRange) error {
+	for _, item := range limitRange.Spec.Limits {
+		if item.Type != "" && item.Type != apiObject.LimitTypeContainer {
+			return errors.New("unsupported limit type: " + item.Type)
+		}
+		lists := []apiObject.ResourceList{item.Min, item.DefaultRequest, item.Default, item.Max}
+		for _, list := range lists {
+			for name, quantity := range list {
+				if name != apiObject.ResourceCPU && name != apiObject.ResourceMemory {
+					return errors.New("unsupported resource in limitRange: " + name)
+				}
+				if _, err := conversion.ParseQuantity(name, quantity); err != nil {
+					return err
+				}
+			}
+		}
+		// 按照 min、defaultRequest、default、max 的顺序，后者不能小于前者
+		for _, name := range []string{apiObject.ResourceCPU, apiObject.ResourceMemory} {
+			var last int64 = -1
+			lastField := ""
+			for i, field := range []string{"min", "defaultRequest", "default", "max"} {
+				quantity, ok := lists[i][name]
+				if !ok {
+					continue
+				}
+				value, _ := conversion.ParseQuantity(name, quantity)
+				if value < last {
+					return errors.New(name + " " + field + " must be greater than or equal to " + lastField)
+				}
+				last = value
+				lastField = field
+			}
+		}
+	}
+	return nil
+}
@@ -100,6 +100,12 @@ func RegisterNodeMonitor(c *gin.Context) {
 		return
 	}
 
+	// dryRun请求不写入prometheus配置文件，也不热加载prometheus
+	if IsDryRun(c) {
+		DryRunResult(c, 200, config.ScrapeConfigs)
+		return
+	}
+
 	// 4. 保存并写入配置文件
 	err := PutPrometheusConfig(config)
 	if err != nil {
@@ -158,6 +164,12 @@ func DeleteNodeMonitor(c *gin.Context) {
 		return
 	}
 
+	// dryRun请求不写入prometheus配置文件，也不热加载prometheus
+	if IsDryRun(c) {
+		DryRunResult(c, 200, config.ScrapeConfigs)
+		return
+	}
+
 	// 4. 保存并写入配置文件
 	err := PutPrometheusConfig(config)
 	if err != nil {
@@ -223,6 +235,12 @@ func RegisterPodMonitor(c *gin.Context) {
 		podScrapeConfig.StaticConfigs = append(podScrapeConfig.StaticConfigs, newStaticConfig)
 	}
 
+	// dryRun请求不写入prometheus配置文件，也不热加载prometheus
+	if IsDryRun(c) {
+		DryRunResult(c, 200, config.ScrapeConfigs)
+		return
+	}
+
 	// 3. 保存并写入配置文件
 	err := PutPrometheusConfig(config)
 	if err != nil {
@@ -275,6 +293,12 @@ func DeletePodMonitor(c *gin.Context) {
 		}
 	}
 
+	// dryRun请求不写入prometheus配置文件，也不热加载prometheus
+	if IsDryRun(c) {
+		DryRunResult(c, 200, config.ScrapeConfigs)
+		return
+	}
+
 	// 3. 保存并写入配置文件
 	err := PutPrometheusConfig(config)
 	if err != nil {
@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
+	"io"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -56,9 +59,25 @@ func CreateNode(c *gin.Context) {
 		return
 	}
 
+	// dryRun请求只校验节点信息，不注册monitor，也不同步pod
+	if IsDryRun(c) {
+		if len(res) == 0 && node.Kind != apiObject.NodeType {
+			log.WarnLog("CreateNode: node kind is not correct")
+			c.JSON(config.HttpErrorCode, gin.H{"error": "node kind is not correct"})
+			return
+		}
+		DryRunResult(c, config.HttpSuccessCode, node)
+		return
+	}
+
 	if len(res) > 0 {
-		// 节点已经存在，则无需重新注册
+		// 节点已经存在，则无需重新注册，只合并kubelet配置中的标签与污点
 		log.InfoLog("CreateNode: node already exists")
+		if err = mergeNodeRegistration(&node); err != nil {
+			log.WarnLog("CreateNode: " + err.Error())
+			c.JSON(config.HttpErrorCode, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(config.HttpSuccessCode, "message: node already exists")
 	} else {
 		// 新节点，需要注册
@@ -176,116 +195,274 @@ func DeleteNodes(c *gin.Context) {
 func GetNode(c *gin.Context) {
 	name := c.Param("name")
 	log.InfoLog("GetNode: " + name)
-	// for k, v := range nodes {
-	// 	if k == name {
-	// 		c.JSON(config.HttpSuccessCode, v)
-	// 		return
-	// 	}
-	// }
-	c.JSON(config.HttpNotFoundCode, "")
+	node, revision, err := getNodeWithRevision(name)
+	if err != nil {
+		log.WarnLog("GetNode: " + err.Error())
+		c.JSON(config.HttpErrorCode, gin.H{"error": err.Error()})
+		return
+	}
+	if node == nil {
+		c.JSON(config.HttpNotFoundCode, gin.H{"error": "not found"})
+		return
+	}
+	// 节点的修改版本号，部分更新时可以通过If-Match要求节点在读取之后没有被修改
+	c.Header("ETag", strconv.FormatInt(revision, 10))
+	c.JSON(config.HttpSuccessCode, gin.H{"data": *node})
 }
 
-// UpdateNode 更新指定节点
+// UpdateNode 更新指定节点，用于修改节点的污点、标签、是否可调度等信息
+//
+//	只替换节点的元数据与spec，节点的状态由kubelet与nodeLifecycleController维护，保持不变
 func UpdateNode(c *gin.Context) {
-	var node apiObject.Node
-	err := c.ShouldBindJSON(&node)
+	modifyNode(c, "UpdateNode", func(node *apiObject.Node, body []byte) error {
+		var newNode apiObject.Node
+		if err := json.Unmarshal(body, &newNode); err != nil {
+			return err
+		}
+		if newNode.Metadata.Name != node.Metadata.Name {
+			return errors.New("node name does not match")
+		}
+		stampTaintTime(&newNode, node)
+		node.Metadata = newNode.Metadata
+		node.Spec = newNode.Spec
+		return nil
+	})
+}
+
+// PatchNode 部分更新指定节点，只覆盖请求体中出现的字段，例如请求体只包含spec.taints时只更新节点的污点
+func PatchNode(c *gin.Context) {
+	modifyNode(c, "PatchNode", func(node *apiObject.Node, patch []byte) error {
+		name := node.Metadata.Name
+		oldNode := &apiObject.Node{Spec: apiObject.NodeSpec{Taints: append([]apiObject.Taint(nil), node.Spec.Taints...)}}
+		if err := json.Unmarshal(patch, node); err != nil {
+			return err
+		}
+		if node.Metadata.Name != name {
+			return errors.New("node name does not match")
+		}
+		stampTaintTime(node, oldNode)
+		return nil
+	})
+}
+
+// PatchNodeStatus 部分更新指定节点的状态，nodeLifecycleController通过该请求只更新节点的条件
+func PatchNodeStatus(c *gin.Context) {
+	modifyNode(c, "PatchNodeStatus", func(node *apiObject.Node, patch []byte) error {
+		return json.Unmarshal(patch, &node.Status)
+	})
+}
+
+// modifyNode 将请求体合并到etcd中的节点上，并以读取时的修改版本号写回
+//
+//	请求头中的If-Match与节点当前的修改版本号不一致，或者节点在此期间被其他请求修改时返回409，由调用者重新读取节点后重试
+func modifyNode(c *gin.Context, handler string, merge func(node *apiObject.Node, body []byte) error) {
+	name := c.Param("name")
+	patch, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		log.ErrorLog("UpdateNode error: " + err.Error())
+		log.ErrorLog(handler + " error: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	node, revision, err := getNodeWithRevision(name)
+	if err != nil {
+		log.WarnLog(handler + ": " + err.Error())
+		c.JSON(config.HttpErrorCode, gin.H{"error": err.Error()})
+		return
+	}
+	if node == nil {
+		c.JSON(config.HttpNotFoundCode, gin.H{"error": "not found"})
+		return
+	}
+	if match := c.GetHeader("If-Match"); match != "" && match != strconv.FormatInt(revision, 10) {
+		log.WarnLog(handler + ": node " + name + " has been modified")
+		c.JSON(409, gin.H{"error": "node has been modified, please retry"})
+		return
+	}
+	if err = merge(node, patch); err != nil {
+		log.ErrorLog(handler + " error: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if IsDryRun(c) {
+		DryRunResult(c, config.HttpSuccessCode, *node)
+		return
+	}
+	nodeJSON, err := json.Marshal(node)
+	if err != nil {
+		log.WarnLog(handler + ": " + err.Error())
+		c.JSON(config.HttpErrorCode, gin.H{"error": err.Error()})
+		return
+	}
+	ok, err := etcdclient.EtcdStore.CompareAndSwap(config.EtcdNodePrefix+"/"+name, string(nodeJSON), revision)
+	if err != nil {
+		log.WarnLog(handler + ": " + err.Error())
+		c.JSON(config.HttpErrorCode, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		log.WarnLog(handler + ": node " + name + " has been modified")
+		c.JSON(409, gin.H{"error": "node has been modified, please retry"})
+		return
 	}
-	name := c.Param("name")
-	// nodes[name] = node
 
-	log.InfoLog("UpdateNode: " + name)
-	c.JSON(config.HttpSuccessCode, "")
+	log.DebugLog(handler + ": " + name)
+	c.JSON(config.HttpSuccessCode, gin.H{"data": *node})
 }
 
-// DeleteNode 删除指定节点
+// DeleteNode 删除指定节点，同时删除节点的心跳Lease与监控配置
 func DeleteNode(c *gin.Context) {
 	name := c.Param("name")
-	// delete(nodes, name)
+	node, err := getNode(name)
+	if err != nil {
+		log.WarnLog("DeleteNode: " + err.Error())
+		c.JSON(config.HttpErrorCode, gin.H{"error": err.Error()})
+		return
+	}
+	if node == nil {
+		c.JSON(config.HttpNotFoundCode, gin.H{"error": "not found"})
+		return
+	}
+	if IsDryRun(c) {
+		DryRunResult(c, config.HttpSuccessCode, *node)
+		return
+	}
+
+	url := config.APIServerURL() + config.MonitorNodeURL
+	resp, err := httprequest.DelMsg(url, *node)
+	if err != nil || resp.StatusCode != config.HttpSuccessCode {
+		log.WarnLog("DeleteNode: delete monitor config failed")
+	}
+	err = etcdclient.EtcdStore.Delete(config.EtcdLeasePrefix + "/" + apiObject.NodeLeaseNamespace + "/" + name)
+	if err != nil {
+		log.WarnLog("DeleteNode: " + err.Error())
+	}
+	err = etcdclient.EtcdStore.Delete(config.EtcdNodePrefix + "/" + name)
+	if err != nil {
+		log.WarnLog("DeleteNode: " + err.Error())
+		c.JSON(config.HttpErrorCode, gin.H{"error": err.Error()})
+		return
+	}
 
 	log.InfoLog("DeleteNode: " + name)
-	c.JSON(config.HttpSuccessCode, "")
+	c.JSON(config.HttpSuccessCode, gin.H{"data": "success"})
 }
 
 // GetNodeStatus 获取指定节点的状态
 func GetNodeStatus(c *gin.Context) {
 	name := c.Param("name")
 	log.InfoLog("GetNodeStatus: " + name)
-}
-
-// PingNodeStatus 更新指定节点的状态，其实就是试一试能不能联通
-func PingNodeStatus(c *gin.Context) {
-	var node apiObject.Node
-	err := c.ShouldBindJSON(&node)
+	node, err := getNode(name)
 	if err != nil {
-		log.ErrorLog("PingNodeStatus error: " + err.Error())
+		log.WarnLog("GetNodeStatus: " + err.Error())
+		c.JSON(config.HttpErrorCode, gin.H{"error": err.Error()})
 		return
 	}
+	if node == nil {
+		c.JSON(config.HttpNotFoundCode, gin.H{"error": "not found"})
+		return
+	}
+	c.JSON(config.HttpSuccessCode, gin.H{"data": node.Status})
+}
 
-	log.DebugLog("start ping NodeIP: " + node.Status.Addresses[0].Address)
-
-	// 尝试三次，失败则认为节点不可用
-	times := 0
-	success := false
-	for times < 3 {
-		url := config.HttpSchema + node.Status.Addresses[0].Address + ":" + fmt.Sprint(config.KubeletAPIPort) + config.NodeStatusURI
-		url = strings.Replace(url, config.NameReplace, node.Metadata.Name, -1)
-		resp, err := httprequest.GetMsg(url)
-		if err != nil || resp.StatusCode != config.HttpSuccessCode {
-			// 无法联通，说明节点不可用
-			log.WarnLog("PingNodeStatus: Node can't be connected")
-			c.JSON(config.HttpErrorCode, gin.H{"error": "Node can't be connected"})
-		} else {
-			success = true
-			newNodeStatus := apiObject.NodeStatus{}
-			err = json.NewDecoder(resp.Body).Decode(&newNodeStatus)
-			if err != nil {
-				log.ErrorLog("PingNodeStatus: " + err.Error())
-				c.JSON(config.HttpErrorCode, gin.H{"error": err.Error()})
-				return
-			}
-			node.Status = newNodeStatus
-			nodeJSON, err := json.Marshal(node)
-			if err != nil {
-				log.ErrorLog("PingNodeStatus: " + err.Error())
-				c.JSON(config.HttpErrorCode, gin.H{"error": err.Error()})
-				return
-			}
-			err = etcdclient.EtcdStore.Put(config.EtcdNodePrefix+"/"+node.Metadata.Name, string(nodeJSON))
-			if err != nil {
-				log.ErrorLog("PingNodeStatus: " + err.Error())
-				c.JSON(config.HttpErrorCode, gin.H{"error": err.Error()})
-				return
+// UpdateNodeStatus 更新指定节点的状态，该请求来自于kubelet
+//
+//	请求中没有出现的条件保持不变，nodeLifecycleController写入的其他条件不会被kubelet的上报覆盖
+func UpdateNodeStatus(c *gin.Context) {
+	modifyNode(c, "UpdateNodeStatus", func(node *apiObject.Node, body []byte) error {
+		var status apiObject.NodeStatus
+		if err := json.Unmarshal(body, &status); err != nil {
+			return err
+		}
+		for _, condition := range node.Status.Conditions {
+			if !hasNodeCondition(status.Conditions, condition.Type) {
+				status.Conditions = append(status.Conditions, condition)
 			}
-			break
 		}
-		times++
+		node.Status = status
+		return nil
+	})
+}
+
+// hasNodeCondition 判断条件列表中是否包含指定类型的条件
+func hasNodeCondition(conditions []apiObject.NodeCondition, conditionType string) bool {
+	for _, condition := range conditions {
+		if condition.Type == conditionType {
+			return true
+		}
 	}
+	return false
+}
 
-	if success {
-		log.DebugLog("Ping Node success, NodeIp : " + node.Status.Addresses[0].Address)
-		c.JSON(config.HttpSuccessCode, "")
-	} else {
-		log.WarnLog("Ping Node failed : " + node.Status.Addresses[0].Address + " is not available")
-		// 无法联通，说明节点不可用
-		// 删除monitor配置
-		url := config.APIServerURL() + config.MonitorNodeURL
-		resp, err := httprequest.DelMsg(url, node)
-		if err != nil || resp.StatusCode != config.HttpSuccessCode {
-			log.ErrorLog("PingNodeStatus failed")
-			c.JSON(config.HttpErrorCode, gin.H{"error": "PingNodeStatus failed"})
-			return
+// getNode 从etcd中获取指定节点，节点不存在时返回nil
+func getNode(name string) (*apiObject.Node, error) {
+	node, _, err := getNodeWithRevision(name)
+	return node, err
+}
+
+// getNodeWithRevision 从etcd中获取指定节点以及其修改版本号，节点不存在时返回nil
+func getNodeWithRevision(name string) (*apiObject.Node, int64, error) {
+	res, revision, err := etcdclient.EtcdStore.GetWithRevision(config.EtcdNodePrefix + "/" + name)
+	if err != nil {
+		return nil, 0, err
+	}
+	if res == "" {
+		return nil, 0, nil
+	}
+	node := &apiObject.Node{}
+	if err = json.Unmarshal([]byte(res), node); err != nil {
+		return nil, 0, err
+	}
+	return node, revision, nil
+}
+
+// mergeNodeRegistration 将kubelet重新注册时携带的标签与污点合并到已有节点上，其余标签与污点保持不变
+//
+//	节点在此期间被其他请求修改时重新读取节点后重试
+func mergeNodeRegistration(registration *apiObject.Node) error {
+	if len(registration.Metadata.Labels) == 0 && len(registration.Spec.Taints) == 0 {
+		return nil
+	}
+	for {
+		node, revision, err := getNodeWithRevision(registration.Metadata.Name)
+		if err != nil || node == nil {
+			return err
+		}
+		if node.Metadata.Labels == nil {
+			node.Metadata.Labels = make(map[string]string)
 		}
-		//删除该节点信息
-		err = etcdclient.EtcdStore.Delete(config.EtcdNodePrefix + "/" + node.Metadata.Name)
+		for key, value := range registration.Metadata.Labels {
+			node.Metadata.Labels[key] = value
+		}
+		for _, taint := range registration.Spec.Taints {
+			if !node.HasTaint(taint.Key, taint.Effect) {
+				node.Spec.Taints = append(node.Spec.Taints, taint)
+			}
+		}
+		nodeJSON, err := json.Marshal(node)
 		if err != nil {
-			log.ErrorLog("PingNodeStatus failed")
-			c.JSON(config.HttpSuccessCode, "")
-			return
+			return err
+		}
+		ok, err := etcdclient.EtcdStore.CompareAndSwap(config.EtcdNodePrefix+"/"+node.Metadata.Name, string(nodeJSON), revision)
+		if err != nil || ok {
+			return err
+		}
+	}
+}
+
+// stampTaintTime 为新添加的NoExecute污点记录添加时间，已有污点保留原来的添加时间，用于计算tolerationSeconds
+func stampTaintTime(node *apiObject.Node, oldNode *apiObject.Node) {
+	now := time.Now()
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect != apiObject.TaintEffectNoExecute || !taint.TimeAdded.IsZero() {
+			continue
+		}
+		taint.TimeAdded = now
+		for _, oldTaint := range oldNode.Spec.Taints {
+			if oldTaint.Key == taint.Key && oldTaint.Effect == taint.Effect && !oldTaint.TimeAdded.IsZero() {
+				taint.TimeAdded = oldTaint.TimeAdded
+			}
 		}
-		log.WarnLog("PingNodeStatus: Node can't be connected, delete node success")
-		c.JSON(config.HttpSuccessCode, "")
 	}
 }
 
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/config"
+	"minik8s/tools/log"
+
+	etcdclient "minik8s/pkg/apiServer/etcdClient"
+)
+
+// GetPodGroup 获取指定PodGroup
+func GetPodGroup(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	if namespace == "" {
+		namespace = "default"
+	}
+	if name == "" {
+		log.ErrorLog("GetPodGroup: name is empty")
+		c.JSON(400, gin.H{"error": "name is empty"})
+		return
+	}
+	log.InfoLog("GetPodGroup: " + namespace + "/" + name)
+
+	res, err := etcdclient.EtcdStore.Get(config.EtcdPodGroupPrefix + "/" + namespace + "/" + name)
+	if err != nil {
+		log.ErrorLog("GetPodGroup: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	podGroup := apiObject.PodGroup{}
+	err = json.Unmarshal([]byte(res), &podGroup)
+	if err != nil {
+		log.ErrorLog("GetPodGroup: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": podGroup})
+}
+
+// GetPodGroups 获取命名空间内的所有PodGroup
+func GetPodGroups(c *gin.Context) {
+	namespace := c.Param("namespace")
+	if namespace == "" {
+		namespace = "default"
+	}
+	log.InfoLog("GetPodGroups: " + namespace)
+
+	var podGroups []apiObject.PodGroup
+	err := listNamespaceObjects(config.EtcdPodGroupPrefix, namespace, func(v string) error {
+		podGroup := apiObject.PodGroup{}
+		err := json.Unmarshal([]byte(v), &podGroup)
+		podGroups = append(podGroups, podGroup)
+		return err
+	})
+	if err != nil {
+		log.ErrorLog("GetPodGroups: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, podGroups)
+}
+
+// GetGlobalPodGroups 获取全局所有PodGroup
+func GetGlobalPodGroups(c *gin.Context) {
+	log.DebugLog("GetGlobalPodGroups")
+	res, err := etcdclient.EtcdStore.PrefixGet(config.EtcdPodGroupPrefix)
+	if err != nil {
+		log.ErrorLog("GetGlobalPodGroups: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	var podGroups []apiObject.PodGroup
+	for _, v := range res {
+		podGroup := apiObject.PodGroup{}
+		err = json.Unmarshal([]byte(v), &podGroup)
+		if err != nil {
+			log.ErrorLog("GetGlobalPodGroups: " + err.Error())
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		podGroups = append(podGroups, podGroup)
+	}
+	c.JSON(200, podGroups)
+}
+
+// AddPodGroup 创建PodGroup
+func AddPodGroup(c *gin.Context) {
+	var podGroup apiObject.PodGroup
+	err := c.ShouldBindJSON(&podGroup)
+	if err != nil {
+		log.ErrorLog("AddPodGroup: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if podGroup.Metadata.Name == "" {
+		log.ErrorLog("AddPodGroup: name is empty")
+		c.JSON(400, gin.H{"error": "name is empty"})
+		return
+	}
+	if podGroup.Metadata.Namespace == "" {
+		podGroup.Metadata.Namespace = c.Param("namespace")
+	}
+	if podGroup.Metadata.Namespace == "" {
+		podGroup.Metadata.Namespace = "default"
+	}
+	if err = validatePodGroup(&podGroup); err != nil {
+		log.ErrorLog("AddPodGroup: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	log.InfoLog("AddPodGroup: " + podGroup.Metadata.Namespace + "/" + podGroup.Metadata.Name)
+
+	key := config.EtcdPodGroupPrefix + "/" + podGroup.Metadata.Namespace + "/" + podGroup.Metadata.Name
+	res, err := etcdclient.EtcdStore.Get(key)
+	if err != nil {
+		log.ErrorLog("AddPodGroup: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res != "" {
+		log.ErrorLog("AddPodGroup: already exists")
+		c.JSON(409, gin.H{"error": "already exists"})
+		return
+	}
+
+	podGroup.Metadata.UUID = uuid.New().String()
+	if IsDryRun(c) {
+		DryRunResult(c, 201, podGroup)
+		return
+	}
+	resJson, err := json.Marshal(podGroup)
+	if err != nil {
+		log.ErrorLog("AddPodGroup: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	err = etcdclient.EtcdStore.Put(key, string(resJson))
+	if err != nil {
+		log.ErrorLog("AddPodGroup: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(201, gin.H{"data": podGroup})
+}
+
+// UpdatePodGroup 更新PodGroup，只对之后的调度生效
+func UpdatePodGroup(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	if namespace == "" || name == "" {
+		log.ErrorLog("UpdatePodGroup: namespace or name is empty")
+		c.JSON(400, gin.H{"error": "namespace or name is empty"})
+		return
+	}
+	log.InfoLog("UpdatePodGroup: " + namespace + "/" + name)
+
+	key := config.EtcdPodGroupPrefix + "/" + namespace + "/" + name
+	res, err := etcdclient.EtcdStore.Get(key)
+	if err != nil {
+		log.ErrorLog("UpdatePodGroup: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		log.ErrorLog("UpdatePodGroup: not found")
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	oldPodGroup := apiObject.PodGroup{}
+	err = json.Unmarshal([]byte(res), &oldPodGroup)
+	if err != nil {
+		log.ErrorLog("UpdatePodGroup: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	var podGroup apiObject.PodGroup
+	err = c.ShouldBindJSON(&podGroup)
+	if err != nil {
+		log.ErrorLog("UpdatePodGroup: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err = validatePodGroup(&podGroup); err != nil {
+		log.ErrorLog("UpdatePodGroup: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	podGroup.Metadata.Name = name
+	podGroup.Metadata.Namespace = namespace
+	podGroup.Metadata.UUID = oldPodGroup.Metadata.UUID
+	if IsDryRun(c) {
+		DryRunResult(c, 200, podGroup)
+		return
+	}
+
+	resJson, err := json.Marshal(podGroup)
+	if err != nil {
+		log.ErrorLog("UpdatePodGroup: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	err = etcdclient.EtcdStore.Put(key, string(resJson))
+	if err != nil {
+		log.ErrorLog("UpdatePodGroup: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": podGroup})
+}
+
+// DeletePodGroup 删除PodGroup，组内尚未调度的Pod将无法被调度
+func DeletePodGroup(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	if namespace == "" || name == "" {
+		log.ErrorLog("DeletePodGroup: namespace or name is empty")
+		c.JSON(400, gin.H{"error": "namespace or name is empty"})
+		return
+	}
+	log.InfoLog("DeletePodGroup: " + namespace + "/" + name)
+
+	key := config.EtcdPodGroupPrefix + "/" + namespace + "/" + name
+	res, err := etcdclient.EtcdStore.Get(key)
+	if err != nil {
+		log.ErrorLog("DeletePodGroup: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		log.ErrorLog("DeletePodGroup: not found")
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	podGroup := apiObject.PodGroup{}
+	err = json.Unmarshal([]byte(res), &podGroup)
+	if err != nil {
+		log.ErrorLog("DeletePodGroup: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if IsDryRun(c) {
+		DryRunResult(c, 200, podGroup)
+		return
+	}
+
+	err = etcdclient.EtcdStore.Delete(key)
+	if err != nil {
+		log.ErrorLog("DeletePodGroup: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": "success"})
+}
+
+// validatePodGroup 校验PodGroup的最小成员数量与等待超时时间
+func validatePodGroup(podGroup *apiObject.PodGroup) error {
+	if podGroup.Spec.MinMember < 1 {
+		return errors.New("spec.minMember must be at least 1")
+	}
+	if timeout := podGroup.Spec.ScheduleTimeoutSeconds; timeout != nil && *timeout <= 0 {
+		return errors.New("spec.scheduleTimeoutSeconds must be positive")
+	}
+	return nil
+}
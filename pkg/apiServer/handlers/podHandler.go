@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,10 +15,12 @@ import (
 
 	"minik8s/pkg/apiObject"
 	"minik8s/pkg/config"
+	"minik8s/tools/conversion"
 	"minik8s/tools/log"
 
 	etcdclient "minik8s/pkg/apiServer/etcdClient"
 	httprequest "minik8s/tools/httpRequest"
+	"minik8s/tools/remotecommand"
 )
 
 // GetPod 获取指定Pod
@@ -82,6 +86,16 @@ func UpdatePod(c *gin.Context) {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	if pod.Metadata.Namespace == "" || pod.Metadata.Name == "" {
+		log.ErrorLog("UpdatePod: namespace or name is empty")
+		c.JSON(400, gin.H{"error": "namespace or name is empty"})
+		return
+	}
+	// dryRun请求不通知kubelet，也不写入etcd
+	if IsDryRun(c) {
+		DryRunResult(c, 200, pod)
+		return
+	}
 	// 更新pod
 	UpdatePodProps(pod)
 	// 解析更新后的pod
@@ -91,11 +105,6 @@ func UpdatePod(c *gin.Context) {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
-	if pod.Metadata.Namespace == "" || pod.Metadata.Name == "" {
-		log.ErrorLog("UpdatePod: namespace or name is empty")
-		c.JSON(400, gin.H{"error": "namespace or name is empty"})
-		return
-	}
 	// 将更新后的pod写入etcd
 	key = config.EtcdPodPrefix + "/" + pod.Metadata.Namespace + "/" + pod.Metadata.Name
 	err = etcdclient.EtcdStore.Put(key, string(resJson))
@@ -136,6 +145,21 @@ func DeletePod(c *gin.Context) {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	// 携带 ?gracePeriodSeconds= 时覆盖Pod的优雅退出时间，kubelet按照该时间等待容器退出
+	if value, ok := c.GetQuery(config.GracePeriodQuery); ok {
+		gracePeriod, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || gracePeriod < 0 {
+			log.ErrorLog("DeletePods: invalid grace period " + value)
+			c.JSON(400, gin.H{"error": "gracePeriodSeconds must be a non-negative integer"})
+			return
+		}
+		pod.Spec.TerminationGracePeriodSeconds = &gracePeriod
+	}
+	// dryRun请求不解绑pvc、不通知kubelet，也不删除etcd中的记录
+	if IsDryRun(c) {
+		DryRunResult(c, 200, pod)
+		return
+	}
 	nodeName := pod.Spec.NodeName
 
 	// 若pod使用了pvc，则对其进行处理
@@ -173,62 +197,70 @@ func DeletePod(c *gin.Context) {
 			}
 		}
 	}
-	// 获取pod所在node的IP
-	res, err = etcdclient.EtcdStore.Get(config.EtcdNodePrefix + "/" + nodeName)
-	if err != nil {
-		log.ErrorLog("DeletePods: " + err.Error())
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
-	}
-	node := &apiObject.Node{}
-	err = json.Unmarshal([]byte(res), node)
-	if err != nil {
-		log.ErrorLog("DeletePods: " + err.Error())
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
-	}
-	addresses := node.Status.Addresses
-	address := addresses[0].Address
-
-	// 如果是一个自定义Metrics的pod，则需要对该pod的监控配置进行删除
-	needMonitor := false
-	var monitorPod apiObject.MonitorPod
-	monitorPod.PodName = name
-	for _, container := range pod.Spec.Containers {
-		for _, port := range container.Ports {
-			if port.Metrics != "" {
-				needMonitor = true
-				url := address + ":" + fmt.Sprint(port.HostPort)
-				monitorPod.MonitorUris = append(monitorPod.MonitorUris, url)
-			}
-		}
-	}
-	if needMonitor {
-		// 删除监控
-		monitorUri := config.HttpSchema + config.APIServerLocalAddress + ":" + fmt.Sprint(config.APIServerLocalPort) + config.MonitorPodURL
-		resp, err := httprequest.DelMsg(monitorUri, monitorPod)
+	// 尚未调度的Pod没有运行在任何节点上，直接删除etcd中的记录即可
+	if nodeName != "" {
+		// 获取pod所在node的IP
+		res, err = etcdclient.EtcdStore.Get(config.EtcdNodePrefix + "/" + nodeName)
 		if err != nil {
 			log.ErrorLog("DeletePods: " + err.Error())
 			c.JSON(500, gin.H{"error": err.Error()})
 			return
 		}
-		if resp.StatusCode != http.StatusOK {
-			log.ErrorLog("DeletePods: " + resp.Status)
-			c.JSON(500, gin.H{"error": resp.Status})
+		node := &apiObject.Node{}
+		err = json.Unmarshal([]byte(res), node)
+		if err != nil {
+			log.ErrorLog("DeletePods: " + err.Error())
+			c.JSON(500, gin.H{"error": err.Error()})
 			return
 		}
-	}
+		addresses := node.Status.Addresses
+		address := addresses[0].Address
 
-	// 发送删除请求到kubelet
-	url := config.HttpSchema + address + ":" + fmt.Sprint(config.KubeletAPIPort)
-	delUri := url + config.PodURI
-	delUri = strings.Replace(delUri, config.NameSpaceReplace, namespace, -1)
-	delUri = strings.Replace(delUri, config.NameReplace, name, -1)
-	_, err = httprequest.DelMsg(delUri, *pod)
-	if err != nil {
-		log.ErrorLog("DeletePods: " + err.Error())
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
+		// 如果是一个自定义Metrics的pod，则需要对该pod的监控配置进行删除
+		needMonitor := false
+		var monitorPod apiObject.MonitorPod
+		monitorPod.PodName = name
+		for _, container := range pod.Spec.Containers {
+			for _, port := range container.Ports {
+				if port.Metrics != "" {
+					needMonitor = true
+					url := address + ":" + fmt.Sprint(port.HostPort)
+					monitorPod.MonitorUris = append(monitorPod.MonitorUris, url)
+				}
+			}
+		}
+		if needMonitor {
+			// 删除监控
+			monitorUri := config.APIServerURL() + config.MonitorPodURL
+			resp, err := httprequest.DelMsg(monitorUri, monitorPod)
+			if err != nil {
+				log.ErrorLog("DeletePods: " + err.Error())
+				c.JSON(500, gin.H{"error": err.Error()})
+				return
+			}
+			if resp.StatusCode != http.StatusOK {
+				log.ErrorLog("DeletePods: " + resp.Status)
+				c.JSON(500, gin.H{"error": resp.Status})
+				return
+			}
+		}
+
+		// 发送删除请求到kubelet
+		//	节点失联时无法通知kubelet，直接删除etcd中的记录以便驱逐Pod，kubelet恢复后重新注册时会同步Pod信息
+		if node.IsReady() {
+			url := config.HttpSchema + address + ":" + fmt.Sprint(config.KubeletAPIPort)
+			delUri := url + config.PodURI
+			delUri = strings.Replace(delUri, config.NameSpaceReplace, namespace, -1)
+			delUri = strings.Replace(delUri, config.NameReplace, name, -1)
+			_, err = httprequest.DelMsg(delUri, *pod)
+			if err != nil {
+				log.ErrorLog("DeletePods: " + err.Error())
+				c.JSON(500, gin.H{"error": err.Error()})
+				return
+			}
+		} else {
+			log.WarnLog("DeletePods: node " + nodeName + " is not ready, skip notifying kubelet")
+		}
 	}
 
 	// 删除etcd中的Pod
@@ -305,6 +337,11 @@ func UpdatePodStatus(c *gin.Context) {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	if res == "" {
+		log.WarnLog("UpdatePodStatus: pod " + namespace + "/" + name + " not found")
+		c.JSON(404, gin.H{"error": "pod not found"})
+		return
+	}
 	podStatus := &apiObject.PodStatus{}
 	err = c.ShouldBindJSON(podStatus)
 	if err != nil {
@@ -320,7 +357,17 @@ func UpdatePodStatus(c *gin.Context) {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	// kubelet上报的状态中不包含调度器维护的状况时，保留原有的状况
+	if podStatus.Conditions == nil {
+		podStatus.Conditions = pod.Status.Conditions
+	}
+	// Pod首次获得IP时才能确定监控地址
+	firstRunning := pod.Status.PodIP == "" && podStatus.PodIP != ""
 	pod.Status = *podStatus
+	if IsDryRun(c) {
+		DryRunResult(c, 200, pod)
+		return
+	}
 	// 将更新后的pod写入etcd
 	resJson, err := json.Marshal(pod)
 	if err != nil {
@@ -357,10 +404,47 @@ func UpdatePodStatus(c *gin.Context) {
 		}
 	}
 
+	// 如果是一个自定义Metrics的pod，则需要对该pod进行监控
+	if firstRunning {
+		if err = registerPodMonitor(pod); err != nil {
+			log.ErrorLog("UpdatePodStatus: " + err.Error())
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	log.DebugLog("UpdatePodStatus: " + namespace + "/" + name)
 	c.JSON(200, gin.H{"data": resJson})
 }
 
+// registerPodMonitor 为暴露了自定义Metrics端口的Pod注册监控
+func registerPodMonitor(pod *apiObject.Pod) error {
+	needMonitor := false
+	var monitorPod apiObject.MonitorPod
+	monitorPod.PodName = pod.Metadata.Name
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Metrics != "" {
+				needMonitor = true
+				url := pod.Status.PodIP + ":" + fmt.Sprint(port.HostPort)
+				monitorPod.MonitorUris = append(monitorPod.MonitorUris, url)
+			}
+		}
+	}
+	if !needMonitor {
+		return nil
+	}
+	url := config.APIServerURL() + config.MonitorPodURL
+	resp, err := httprequest.PutObjMsg(url, monitorPod)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("register pod monitor failed: " + resp.Status)
+	}
+	return nil
+}
+
 // GetPods 获取所有Pod
 func GetPods(c *gin.Context) {
 	namespace := c.Param("namespace")
@@ -409,6 +493,28 @@ func CreatePod(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "name or namespace is empty"})
 		return
 	}
+	if err = validatePodProbes(pod); err != nil {
+		log.ErrorLog("CreatePod: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err = validatePodEnv(pod); err != nil {
+		log.ErrorLog("CreatePod: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err = validatePodPorts(pod); err != nil {
+		log.ErrorLog("CreatePod: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	switch pod.Spec.RestartPolicy {
+	case "", apiObject.RestartPolicyAlways, apiObject.RestartPolicyOnFailure, apiObject.RestartPolicyNever:
+	default:
+		log.ErrorLog("CreatePod: unsupported restartPolicy " + pod.Spec.RestartPolicy)
+		c.JSON(400, gin.H{"error": "restartPolicy must be one of Always, OnFailure and Never"})
+		return
+	}
 	// 判断pod是否已经存在
 	key := config.EtcdPodPrefix + "/" + pod.Metadata.Namespace + "/" + pod.Metadata.Name
 	response, _ := etcdclient.EtcdStore.Get(key)
@@ -417,101 +523,292 @@ func CreatePod(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Pod already exists"})
 		return
 	}
+	// 准入控制：注入LimitRange中的默认值并检查ResourceQuota，Pod写入etcd之前不释放配额锁
+	unlock, err := LockNamespaceQuota(pod.Metadata.Namespace)
+	if err != nil {
+		log.ErrorLog("CreatePod: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	defer unlock()
+	if err = AdmitPod(pod); err != nil {
+		log.ErrorLog("CreatePod: " + err.Error())
+		c.JSON(AdmissionStatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
 	// 完成检查，生成 UUID
 	pod.Metadata.UUID = uuid.New().String()
 	log.InfoLog("CreatePod: " + newPodNamespace + "/" + newPodName)
-	// 发送的时候筛选 node
-	ScheduledUri := config.SchedulerURL() + config.SchedulerConfigPath
-	resp, err := http.Get(ScheduledUri)
+	// 已经指定nodeName的Pod不经过调度器，但节点必须存在
+	if pod.Spec.NodeName != "" {
+		node, err := getNode(pod.Spec.NodeName)
+		if err != nil {
+			log.ErrorLog("CreatePod: " + err.Error())
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		if node == nil {
+			log.ErrorLog("CreatePod: node " + pod.Spec.NodeName + " not found")
+			c.JSON(400, gin.H{"error": "node " + pod.Spec.NodeName + " not found"})
+			return
+		}
+	}
+	// Pod以Pending状态存入etcd，由调度器异步调度，kubelet拉取绑定到本节点的Pod后创建
+	pod.Status = apiObject.PodStatus{Phase: apiObject.PodPending}
+	if pod.Spec.NodeName != "" {
+		pod.Status.SetCondition(apiObject.PodCondition{Type: apiObject.PodScheduled, Status: apiObject.ConditionTrue})
+	}
+	// dryRun请求不写入etcd
+	if IsDryRun(c) {
+		DryRunResult(c, 201, pod)
+		return
+	}
+	reaJson, err := json.Marshal(pod)
 	if err != nil {
 		log.ErrorLog("CreatePod: " + err.Error())
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
-	var node apiObject.Node
-	err = json.NewDecoder(resp.Body).Decode(&node)
+	log.DebugLog("CreatePod: " + string(reaJson))
+	// 将pod信息存入etcd
+	err = etcdclient.EtcdStore.Put(key, string(reaJson))
 	if err != nil {
 		log.ErrorLog("CreatePod: " + err.Error())
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
-	pod.Spec.NodeName = node.Metadata.Name
-	// 得到node的IP
-	addresses := node.Status.Addresses
-	address := addresses[0].Address
-	log.InfoLog("CreatePod: " + address)
-	// 打印创建的pod信息
-	podBytes, _ := json.Marshal(pod)
-	log.DebugLog("CreatePod: " + string(podBytes))
-	// 发送创建请求到kubelet
-	url := config.HttpSchema + address + ":" + fmt.Sprint(config.KubeletAPIPort)
-	createUri := url + config.PodsURI
-	createUri = strings.Replace(createUri, config.NameSpaceReplace, newPodNamespace, -1)
-	createUri = strings.Replace(createUri, config.NameReplace, newPodName, -1)
-	log.DebugLog("createUri: " + createUri)
 
-	// 发送创建请求并解析返回的pod信息
-	resp, err = httprequest.PostObjMsg(createUri, pod)
+	c.JSON(201, reaJson)
+}
+
+// validatePodPorts 检查容器端口的范围与协议，同一个Pod中不能重复使用相同的主机端口
+func validatePodPorts(pod *apiObject.Pod) error {
+	hostPorts := map[string]bool{}
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			prefix := "container " + container.Name + " port"
+			if port.ContainerPort < 0 || port.ContainerPort > 65535 || port.HostPort < 0 || port.HostPort > 65535 {
+				return errors.New(prefix + " must be between 0 and 65535")
+			}
+			protocol := port.Protocol
+			switch protocol {
+			case "":
+				protocol = apiObject.ProtocolTCP
+			case apiObject.ProtocolTCP, apiObject.ProtocolUDP, apiObject.ProtocolSCTP:
+			default:
+				return errors.New(prefix + " protocol must be one of TCP, UDP and SCTP")
+			}
+			if port.HostPort == 0 {
+				continue
+			}
+			key := fmt.Sprintf("%s/%s:%d", protocol, port.HostIP, port.HostPort)
+			if hostPorts[key] {
+				return fmt.Errorf("%s hostPort %d/%s is duplicated", prefix, port.HostPort, protocol)
+			}
+			hostPorts[key] = true
+		}
+	}
+	return nil
+}
+
+// validatePodEnv 检查容器的环境变量，valueFrom只能引用支持的Pod字段以及Pod中容器的cpu与memory
+func validatePodEnv(pod *apiObject.Pod) error {
+	containers := map[string]bool{}
+	for _, container := range pod.Spec.Containers {
+		containers[container.Name] = true
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, env := range container.Env {
+			prefix := "container " + container.Name + " env " + env.Name
+			if env.Name == "" {
+				return errors.New("container " + container.Name + " env name is empty")
+			}
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.Value != "" {
+				return errors.New(prefix + " must not specify both value and valueFrom")
+			}
+			fieldRef, resourceFieldRef := env.ValueFrom.FieldRef, env.ValueFrom.ResourceFieldRef
+			if (fieldRef == nil) == (resourceFieldRef == nil) {
+				return errors.New(prefix + " must specify exactly one of fieldRef and resourceFieldRef")
+			}
+			if fieldRef != nil {
+				switch fieldRef.FieldPath {
+				case apiObject.FieldPathPodName, apiObject.FieldPathNamespace, apiObject.FieldPathPodUID,
+					apiObject.FieldPathNodeName, apiObject.FieldPathPodIP:
+				default:
+					return errors.New(prefix + " unsupported fieldPath " + fieldRef.FieldPath)
+				}
+				continue
+			}
+			if resourceFieldRef.ContainerName != "" && !containers[resourceFieldRef.ContainerName] {
+				return errors.New(prefix + " container " + resourceFieldRef.ContainerName + " not found")
+			}
+			var divisor int64
+			var err error
+			switch resourceFieldRef.Resource {
+			case "limits.cpu", "requests.cpu":
+				divisor = 1000
+				if resourceFieldRef.Divisor != "" {
+					divisor, err = conversion.ParseCPU(resourceFieldRef.Divisor)
+				}
+			case "limits.memory", "requests.memory":
+				divisor = 1
+				if resourceFieldRef.Divisor != "" {
+					divisor, err = conversion.ParseMemoryBytes(resourceFieldRef.Divisor)
+				}
+			default:
+				return errors.New(prefix + " unsupported resource " + resourceFieldRef.Resource)
+			}
+			if err != nil || divisor <= 0 {
+				return errors.New(prefix + " invalid divisor " + resourceFieldRef.Divisor)
+			}
+		}
+	}
+	return nil
+}
+
+// validatePodProbes 检查容器的探针，每个探针只能指定一种探测方式，存活探针与启动探针的successThreshold只能为1
+func validatePodProbes(pod *apiObject.Pod) error {
+	for _, container := range pod.Spec.Containers {
+		probes := []struct {
+			name  string
+			probe *apiObject.Probe
+		}{
+			{"livenessProbe", container.LivenessProbe},
+			{"readinessProbe", container.ReadinessProbe},
+			{"startupProbe", container.StartupProbe},
+		}
+		for _, p := range probes {
+			if p.probe == nil {
+				continue
+			}
+			prefix := "container " + container.Name + " " + p.name
+			handlers := 0
+			if p.probe.Exec != nil {
+				handlers++
+			}
+			if p.probe.HTTPGet != nil {
+				handlers++
+			}
+			if p.probe.TCPSocket != nil {
+				handlers++
+			}
+			if handlers != 1 {
+				return errors.New(prefix + " must specify exactly one of exec, httpGet and tcpSocket")
+			}
+			if p.probe.InitialDelaySeconds < 0 || p.probe.TimeoutSeconds < 0 || p.probe.PeriodSeconds < 0 ||
+				p.probe.SuccessThreshold < 0 || p.probe.FailureThreshold < 0 {
+				return errors.New(prefix + " must not have negative fields")
+			}
+			if p.name != "readinessProbe" && p.probe.SuccessThreshold > 1 {
+				return errors.New(prefix + " successThreshold must be 1")
+			}
+		}
+	}
+	return nil
+}
+
+// CreatePodBinding 将Pod绑定到节点上，该请求来自于调度器
+//
+//	已经绑定到节点上的Pod不能再次绑定
+func CreatePodBinding(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	if namespace == "" || name == "" {
+		log.ErrorLog("CreatePodBinding: name or namespace is empty")
+		c.JSON(400, gin.H{"error": "name or namespace is empty"})
+		return
+	}
+	binding := &apiObject.Binding{}
+	err := c.ShouldBindJSON(binding)
 	if err != nil {
-		log.ErrorLog("Could not post the object message.\n" + err.Error())
+		log.ErrorLog("CreatePodBinding: " + err.Error())
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
-	if resp.StatusCode != http.StatusOK {
-		log.ErrorLog("CreatePod: " + resp.Status)
-		c.JSON(500, gin.H{"error": resp.Status})
+	if binding.Target.Kind != "" && binding.Target.Kind != apiObject.NodeType {
+		log.ErrorLog("CreatePodBinding: unsupported target kind " + binding.Target.Kind)
+		c.JSON(400, gin.H{"error": "unsupported target kind " + binding.Target.Kind})
+		return
+	}
+	nodeName := binding.Target.Name
+	if nodeName == "" {
+		log.ErrorLog("CreatePodBinding: target node is empty")
+		c.JSON(400, gin.H{"error": "target node is empty"})
 		return
 	}
-	err = json.NewDecoder(resp.Body).Decode(&pod)
+
+	key := config.EtcdPodPrefix + "/" + namespace + "/" + name
+	// 以读取时的修改版本号写回，避免覆盖绑定期间其他写入者对Pod的修改，也避免同一个Pod被重复绑定
+	res, revision, err := etcdclient.EtcdStore.GetWithRevision(key)
 	if err != nil {
-		log.ErrorLog("CreatePod: " + err.Error())
+		log.ErrorLog("CreatePodBinding: " + err.Error())
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
-	reaJson, err := json.Marshal(pod)
+	if res == "" {
+		log.ErrorLog("CreatePodBinding: pod " + namespace + "/" + name + " not found")
+		c.JSON(404, gin.H{"error": "pod not found"})
+		return
+	}
+	pod := &apiObject.Pod{}
+	err = json.Unmarshal([]byte(res), pod)
 	if err != nil {
-		log.ErrorLog("CreatePod: " + err.Error())
+		log.ErrorLog("CreatePodBinding: " + err.Error())
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
-	// 将pod信息存入etcd
-	err = etcdclient.EtcdStore.Put(key, string(reaJson))
+	if binding.Metadata.UUID != "" && binding.Metadata.UUID != pod.Metadata.UUID {
+		log.ErrorLog("CreatePodBinding: pod " + namespace + "/" + name + " has been recreated")
+		c.JSON(409, gin.H{"error": "pod uid mismatch"})
+		return
+	}
+	if pod.Spec.NodeName != "" {
+		log.ErrorLog("CreatePodBinding: pod " + namespace + "/" + name + " is already assigned to node " + pod.Spec.NodeName)
+		c.JSON(409, gin.H{"error": "pod is already assigned to node " + pod.Spec.NodeName})
+		return
+	}
+	node, err := getNode(nodeName)
 	if err != nil {
-		log.ErrorLog("CreatePod: " + err.Error())
+		log.ErrorLog("CreatePodBinding: " + err.Error())
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	if node == nil {
+		log.ErrorLog("CreatePodBinding: node " + nodeName + " not found")
+		c.JSON(400, gin.H{"error": "node " + nodeName + " not found"})
+		return
+	}
 
-	// 如果是一个自定义Metrics的pod，则需要对该pod进行监控
-	needMonitor := false
-	var monitorPod apiObject.MonitorPod
-	monitorPod.PodName = newPodName
-	for _, container := range pod.Spec.Containers {
-		for _, port := range container.Ports {
-			if port.Metrics != "" {
-				needMonitor = true
-				url := pod.Status.PodIP + ":" + fmt.Sprint(port.HostPort)
-				monitorPod.MonitorUris = append(monitorPod.MonitorUris, url)
-			}
-		}
+	pod.Spec.NodeName = nodeName
+	pod.Status.SetCondition(apiObject.PodCondition{Type: apiObject.PodScheduled, Status: apiObject.ConditionTrue})
+	pod.Status.NominatedNodeName = ""
+	if IsDryRun(c) {
+		DryRunResult(c, 201, pod)
+		return
 	}
-	if needMonitor {
-		// 注册监控
-		url := config.HttpSchema + config.APIServerLocalAddress + ":" + fmt.Sprint(config.APIServerLocalPort) + config.MonitorPodURL
-		resp, err := httprequest.PutObjMsg(url, monitorPod)
-		if err != nil {
-			log.ErrorLog("CreatePod: " + err.Error())
-			c.JSON(500, gin.H{"error": err.Error()})
-			return
-		}
-		if resp.StatusCode != http.StatusOK {
-			log.ErrorLog("CreatePod: " + resp.Status)
-			c.JSON(500, gin.H{"error": resp.Status})
-			return
-		}
+	resJson, err := json.Marshal(pod)
+	if err != nil {
+		log.ErrorLog("CreatePodBinding: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	ok, err := etcdclient.EtcdStore.CompareAndSwap(key, string(resJson), revision)
+	if err != nil {
+		log.ErrorLog("CreatePodBinding: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if !ok {
+		log.ErrorLog("CreatePodBinding: pod " + namespace + "/" + name + " has been modified")
+		c.JSON(409, gin.H{"error": "pod has been modified, please retry"})
+		return
 	}
 
-	c.JSON(201, reaJson)
+	log.InfoLog("CreatePodBinding: " + namespace + "/" + name + " -> " + nodeName)
+	c.JSON(201, gin.H{"data": binding})
 }
 
 // DeletePods 删除所有Pod
@@ -546,7 +843,10 @@ func DeletePods(c *gin.Context) {
 }
 
 // GetGlobalPods 获取全局所有Pod
+//
+//	携带 ?nodeName= 查询参数时只返回绑定到该节点上的Pod，参数为空时返回尚未调度的Pod
 func GetGlobalPods(c *gin.Context) {
+	nodeName, filterByNode := c.GetQuery(config.NodeNameQuery)
 	key := config.EtcdPodPrefix
 	res, err := etcdclient.EtcdStore.PrefixGet(key)
 	if err != nil {
@@ -564,6 +864,9 @@ func GetGlobalPods(c *gin.Context) {
 			c.JSON(500, gin.H{"error": err.Error()})
 			return
 		}
+		if filterByNode && pod.Spec.NodeName != nodeName {
+			continue
+		}
 		pods = append(pods, pod)
 	}
 
@@ -698,3 +1001,180 @@ func ExecPod(c *gin.Context) {
 	log.DebugLog("ExecPod: " + namespace + "/" + name + "/" + containerID + "/" + param + " success: " + result)
 	c.JSON(200, result)
 }
+
+// GetPodLog 获取Pod中容器的日志，请求被代理到Pod所在节点的kubelet
+//
+//	Pod只有一个容器时可以省略 ?container=，follow 时日志会持续输出直到容器停止或者客户端断开连接
+func GetPodLog(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	log.InfoLog("GetPodLog: " + namespace + "/" + name)
+
+	res, err := etcdclient.EtcdStore.Get(config.EtcdPodPrefix + "/" + namespace + "/" + name)
+	if err != nil {
+		log.ErrorLog("GetPodLog: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		c.JSON(404, gin.H{"error": "pod " + namespace + "/" + name + " not found"})
+		return
+	}
+	pod := &apiObject.Pod{}
+	if err = json.Unmarshal([]byte(res), pod); err != nil {
+		log.ErrorLog("GetPodLog: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	container, err := podContainerName(pod, c.Query(config.LogContainerQuery))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if pod.Spec.NodeName == "" {
+		c.JSON(400, gin.H{"error": "pod " + namespace + "/" + name + " has not been scheduled to a node"})
+		return
+	}
+	node, err := getNode(pod.Spec.NodeName)
+	if err != nil || node == nil || len(node.Status.Addresses) == 0 {
+		log.ErrorLog("GetPodLog: node " + pod.Spec.NodeName + " not found")
+		c.JSON(500, gin.H{"error": "node " + pod.Spec.NodeName + " not found"})
+		return
+	}
+
+	// 除container外的查询参数原样转发给kubelet
+	query := c.Request.URL.Query()
+	query.Del(config.LogContainerQuery)
+	url := config.HttpSchema + node.Status.Addresses[0].Address + ":" + fmt.Sprint(config.KubeletAPIPort) + config.ContainerLogsURI
+	url = strings.Replace(url, config.NameSpaceReplace, namespace, -1)
+	url = strings.Replace(url, config.PodReplace, name, -1)
+	url = strings.Replace(url, config.ContainerReplace, container, -1)
+	if encoded := query.Encode(); encoded != "" {
+		url += "?" + encoded
+	}
+	request, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		log.ErrorLog("GetPodLog: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.ErrorLog("GetPodLog: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	defer resp.Body.Close()
+
+	c.Header("Content-Type", resp.Header.Get("Content-Type"))
+	c.Status(resp.StatusCode)
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err = c.Writer.Write(buf[:n]); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+		if readErr != nil {
+			return
+		}
+	}
+}
+
+// podContainerName 确定请求的目标容器，没有指定容器时Pod只能有一个容器
+func podContainerName(pod *apiObject.Pod, container string) (string, error) {
+	var names []string
+	for _, c := range pod.Spec.Containers {
+		if c.Name == container {
+			return container, nil
+		}
+		names = append(names, c.Name)
+	}
+	if container != "" {
+		return "", errors.New("container " + container + " is not valid for pod " + pod.Metadata.Name)
+	}
+	if len(names) == 1 {
+		return names[0], nil
+	}
+	return "", errors.New("a container name must be specified for pod " + pod.Metadata.Name +
+		", choose one of: [" + strings.Join(names, " ") + "]")
+}
+
+// ExecPodStream 在Pod的容器中交互式地执行命令，返回命令的退出码
+//
+//	客户端通过WebSocket连接，请求被代理到Pod所在节点的kubelet，由kubelet转发给容器运行时的流服务器
+func ExecPodStream(c *gin.Context) {
+	if len(c.QueryArray(config.ExecCommandQuery)) == 0 {
+		c.JSON(400, gin.H{"error": "you must specify at least one command for the container"})
+		return
+	}
+	proxyPodStream(c, config.ContainerExecURI)
+}
+
+// AttachPod 连接到Pod中容器的主进程，请求被代理到Pod所在节点的kubelet
+func AttachPod(c *gin.Context) {
+	proxyPodStream(c, config.ContainerAttachURI)
+}
+
+// proxyPodStream 连接Pod所在节点kubelet的流式接口，升级客户端的连接后在两者之间转发各个通道的数据
+func proxyPodStream(c *gin.Context, kubeletURI string) {
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	log.InfoLog("proxyPodStream: " + namespace + "/" + name)
+
+	res, err := etcdclient.EtcdStore.Get(config.EtcdPodPrefix + "/" + namespace + "/" + name)
+	if err != nil {
+		log.ErrorLog("proxyPodStream: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		c.JSON(404, gin.H{"error": "pod " + namespace + "/" + name + " not found"})
+		return
+	}
+	pod := &apiObject.Pod{}
+	if err = json.Unmarshal([]byte(res), pod); err != nil {
+		log.ErrorLog("proxyPodStream: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	container, err := podContainerName(pod, c.Query(config.ExecContainerQuery))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if pod.Spec.NodeName == "" {
+		c.JSON(400, gin.H{"error": "pod " + namespace + "/" + name + " has not been scheduled to a node"})
+		return
+	}
+	node, err := getNode(pod.Spec.NodeName)
+	if err != nil || node == nil || len(node.Status.Addresses) == 0 {
+		log.ErrorLog("proxyPodStream: node " + pod.Spec.NodeName + " not found")
+		c.JSON(500, gin.H{"error": "node " + pod.Spec.NodeName + " not found"})
+		return
+	}
+
+	query := c.Request.URL.Query()
+	query.Del(config.ExecContainerQuery)
+	url := config.HttpSchema + node.Status.Addresses[0].Address + ":" + fmt.Sprint(config.KubeletAPIPort) + kubeletURI
+	url = strings.Replace(url, config.NameSpaceReplace, namespace, -1)
+	url = strings.Replace(url, config.PodReplace, name, -1)
+	url = strings.Replace(url, config.ContainerReplace, container, -1)
+	url += "?" + query.Encode()
+	// 先连接kubelet，失败时仍然可以向客户端返回普通的错误响应
+	backend, err := remotecommand.Dial(url)
+	if err != nil {
+		log.ErrorLog("proxyPodStream: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	client, err := remotecommand.Upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.ErrorLog("proxyPodStream: " + err.Error())
+		_ = backend.Close()
+		return
+	}
+	remotecommand.Proxy(client, backend)
+}
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/config"
+	"minik8s/tools/log"
+
+	etcdclient "minik8s/pkg/apiServer/etcdClient"
+)
+
+// GetPriorityClass 获取指定PriorityClass
+func GetPriorityClass(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		log.ErrorLog("GetPriorityClass: name is empty")
+		c.JSON(400, gin.H{"error": "name is empty"})
+		return
+	}
+	log.InfoLog("GetPriorityClass: " + name)
+
+	priorityClass, err := getPriorityClass(name)
+	if err != nil {
+		log.ErrorLog("GetPriorityClass: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if priorityClass == nil {
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	c.JSON(200, gin.H{"data": priorityClass})
+}
+
+// GetPriorityClasses 获取所有PriorityClass，包括内置的优先级类
+func GetPriorityClasses(c *gin.Context) {
+	log.InfoLog("GetPriorityClasses")
+
+	priorityClasses, err := listPriorityClasses()
+	if err != nil {
+		log.ErrorLog("GetPriorityClasses: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, append(apiObject.SystemPriorityClasses(), priorityClasses...))
+}
+
+// AddPriorityClass 创建PriorityClass
+func AddPriorityClass(c *gin.Context) {
+	var priorityClass apiObject.PriorityClass
+	err := c.ShouldBindJSON(&priorityClass)
+	if err != nil {
+		log.ErrorLog("AddPriorityClass: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err = validatePriorityClass(&priorityClass); err != nil {
+		log.ErrorLog("AddPriorityClass: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	name := priorityClass.Metadata.Name
+	log.InfoLog("AddPriorityClass: " + name)
+
+	existing, err := getPriorityClass(name)
+	if err != nil {
+		log.ErrorLog("AddPriorityClass: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if existing != nil {
+		log.ErrorLog("AddPriorityClass: already exists")
+		c.JSON(409, gin.H{"error": "already exists"})
+		return
+	}
+	if err = checkGlobalDefault(&priorityClass); err != nil {
+		log.ErrorLog("AddPriorityClass: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	priorityClass.Metadata.Namespace = ""
+	priorityClass.Metadata.UUID = uuid.New().String()
+	if IsDryRun(c) {
+		DryRunResult(c, 201, priorityClass)
+		return
+	}
+	resJson, err := json.Marshal(priorityClass)
+	if err != nil {
+		log.ErrorLog("AddPriorityClass: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	err = etcdclient.EtcdStore.Put(config.EtcdPriorityClassPrefix+"/"+name, string(resJson))
+	if err != nil {
+		log.ErrorLog("AddPriorityClass: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(201, gin.H{"data": priorityClass})
+}
+
+// UpdatePriorityClass 更新PriorityClass，优先级的数值不能修改，已经创建的Pod的优先级保持不变
+func UpdatePriorityClass(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		log.ErrorLog("UpdatePriorityClass: name is empty")
+		c.JSON(400, gin.H{"error": "name is empty"})
+		return
+	}
+	log.InfoLog("UpdatePriorityClass: " + name)
+
+	key := config.EtcdPriorityClassPrefix + "/" + name
+	res, err := etcdclient.EtcdStore.Get(key)
+	if err != nil {
+		log.ErrorLog("UpdatePriorityClass: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		log.ErrorLog("UpdatePriorityClass: not found")
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	oldPriorityClass := apiObject.PriorityClass{}
+	err = json.Unmarshal([]byte(res), &oldPriorityClass)
+	if err != nil {
+		log.ErrorLog("UpdatePriorityClass: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	var priorityClass apiObject.PriorityClass
+	err = c.ShouldBindJSON(&priorityClass)
+	if err != nil {
+		log.ErrorLog("UpdatePriorityClass: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	priorityClass.Metadata.Name = name
+	priorityClass.Metadata.Namespace = ""
+	priorityClass.Metadata.UUID = oldPriorityClass.Metadata.UUID
+	if err = validatePriorityClass(&priorityClass); err != nil {
+		log.ErrorLog("UpdatePriorityClass: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if priorityClass.Value != oldPriorityClass.Value {
+		log.ErrorLog("UpdatePriorityClass: value is immutable")
+		c.JSON(400, gin.H{"error": "value of priorityClass is immutable"})
+		return
+	}
+	if err = checkGlobalDefault(&priorityClass); err != nil {
+		log.ErrorLog("UpdatePriorityClass: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if IsDryRun(c) {
+		DryRunResult(c, 200, priorityClass)
+		return
+	}
+
+	resJson, err := json.Marshal(priorityClass)
+	if err != nil {
+		log.ErrorLog("UpdatePriorityClass: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	err = etcdclient.EtcdStore.Put(key, string(resJson))
+	if err != nil {
+		log.ErrorLog("UpdatePriorityClass: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": priorityClass})
+}
+
+// DeletePriorityClass 删除PriorityClass，已经创建的Pod的优先级保持不变
+func DeletePriorityClass(c *gin.Context) {
+	name := c.Param("name")
+	if name == "" {
+		log.ErrorLog("DeletePriorityClass: name is empty")
+		c.JSON(400, gin.H{"error": "name is empty"})
+		return
+	}
+	log.InfoLog("DeletePriorityClass: " + name)
+	if strings.HasPrefix(name, apiObject.SystemPriorityClassPrefix) {
+		log.ErrorLog("DeletePriorityClass: system priorityClass can't be deleted")
+		c.JSON(403, gin.H{"error": "system priorityClass can't be deleted"})
+		return
+	}
+
+	key := config.EtcdPriorityClassPrefix + "/" + name
+	res, err := etcdclient.EtcdStore.Get(key)
+	if err != nil {
+		log.ErrorLog("DeletePriorityClass: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		log.ErrorLog("DeletePriorityClass: not found")
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	priorityClass := apiObject.PriorityClass{}
+	err = json.Unmarshal([]byte(res), &priorityClass)
+	if err != nil {
+		log.ErrorLog("DeletePriorityClass: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if IsDryRun(c) {
+		DryRunResult(c, 200, priorityClass)
+		return
+	}
+
+	err = etcdclient.EtcdStore.Delete(key)
+	if err != nil {
+		log.ErrorLog("DeletePriorityClass: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": "success"})
+}
+
+// validatePriorityClass 校验PriorityClass的名称、数值与抢占策略
+func validatePriorityClass(priorityClass *apiObject.PriorityClass) error {
+	name := priorityClass.Metadata.Name
+	if name == "" {
+		return errors.New("name is empty")
+	}
+	if strings.HasPrefix(name, apiObject.SystemPriorityClassPrefix) {
+		return errors.New("priorityClass names with '" + apiObject.SystemPriorityClassPrefix + "' prefix are reserved for system use")
+	}
+	if priorityClass.Value > apiObject.HighestUserDefinablePriority {
+		return fmt.Errorf("maximum allowed value of a user defined priority is %d", apiObject.HighestUserDefinablePriority)
+	}
+	switch priorityClass.PreemptionPolicy {
+	case "":
+		priorityClass.PreemptionPolicy = apiObject.PreemptLowerPriority
+	case apiObject.PreemptLowerPriority, apiObject.PreemptNever:
+	default:
+		return errors.New("unsupported preemptionPolicy: " + priorityClass.PreemptionPolicy)
+	}
+	return nil
+}
+
+// checkGlobalDefault 集群中最多只能有一个globalDefault的优先级类
+func checkGlobalDefault(priorityClass *apiObject.PriorityClass) error {
+	if !priorityClass.GlobalDefault {
+		return nil
+	}
+	priorityClasses, err := listPriorityClasses()
+	if err != nil {
+		return err
+	}
+	for _, existing := range priorityClasses {
+		if existing.GlobalDefault && existing.Metadata.Name != priorityClass.Metadata.Name {
+			return errors.New("priorityClass " + existing.Metadata.Name + " is already marked as default")
+		}
+	}
+	return nil
+}
+
+// getPriorityClass 获取指定名称的优先级类，包括内置的优先级类，不存在时返回nil
+func getPriorityClass(name string) (*apiObject.PriorityClass, error) {
+	for _, priorityClass := range apiObject.SystemPriorityClasses() {
+		if priorityClass.Metadata.Name == name {
+			return &priorityClass, nil
+		}
+	}
+	res, err := etcdclient.EtcdStore.Get(config.EtcdPriorityClassPrefix + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if res == "" {
+		return nil, nil
+	}
+	priorityClass := &apiObject.PriorityClass{}
+	if err = json.Unmarshal([]byte(res), priorityClass); err != nil {
+		return nil, err
+	}
+	return priorityClass, nil
+}
+
+// listPriorityClasses 获取用户创建的所有优先级类
+func listPriorityClasses() ([]apiObject.PriorityClass, error) {
+	res, err := etcdclient.EtcdStore.PrefixGet(config.EtcdPriorityClassPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var priorityClasses []apiObject.PriorityClass
+	for _, v := range res {
+		priorityClass := apiObject.PriorityClass{}
+		if err = json.Unmarshal([]byte(v), &priorityClass); err != nil {
+			return nil, err
+		}
+		priorityClasses = append(priorityClasses, priorityClass)
+	}
+	return priorityClasses, nil
+}
@@ -31,6 +31,11 @@ func CreatePV(c *gin.Context) {
 		return
 	}
 
+	if IsDryRun(c) {
+		DryRunResult(c, 200, pv)
+		return
+	}
+
 	// 创建pv
 	log.DebugLog("CreatePv: " + pvNamespace + "/" + pvName)
 	url := config.PVServerURL() + config.PersistentVolumesURI
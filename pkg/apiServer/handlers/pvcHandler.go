@@ -32,6 +32,24 @@ func CreatePVC(c *gin.Context) {
 		return
 	}
 
+	// pvController写入etcd之前不释放配额锁
+	unlock, err := LockNamespaceQuota(pvcNamespace)
+	if err != nil {
+		log.ErrorLog("Create PersistentVolumeClaim: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	defer unlock()
+	if err = AdmitObjectCount(pvcNamespace, apiObject.ResourcePersistentVolumeClaims); err != nil {
+		log.ErrorLog("Create PersistentVolumeClaim: " + err.Error())
+		c.JSON(AdmissionStatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
+	if IsDryRun(c) {
+		DryRunResult(c, 200, pvc)
+		return
+	}
+
 	// 转发给pvController
 	log.DebugLog("CreatePvc: " + pvcNamespace + "/" + pvcName)
 	url := config.PVServerURL() + config.PersistentVolumeClaimsURI
@@ -67,6 +85,11 @@ func BindPVC(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "name or namespace is empty"})
 		return
 	}
+	// dryRun请求不进行pv绑定
+	if IsDryRun(c) {
+		DryRunResult(c, 200, pvc)
+		return
+	}
 
 	// 转发给pvController
 	url := config.PVServerURL() + config.PersistentVolumeClaimURI
@@ -94,8 +94,24 @@ func AddReplicaSet(c *gin.Context) {
 		c.JSON(500, gin.H{"error": "replicaSet already exists"})
 		return
 	}
+	unlock, err := LockNamespaceQuota(rs.Metadata.Namespace)
+	if err != nil {
+		log.ErrorLog("AddReplicaSet: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	defer unlock()
+	if err = AdmitObjectCount(rs.Metadata.Namespace, apiObject.ResourceReplicaSets); err != nil {
+		log.ErrorLog("AddReplicaSet: " + err.Error())
+		c.JSON(AdmissionStatusCode(err), gin.H{"error": err.Error()})
+		return
+	}
 
 	rs.Metadata.UUID = uuid.New().String()
+	if IsDryRun(c) {
+		DryRunResult(c, 200, rs)
+		return
+	}
 	resJson, err := json.Marshal(rs)
 	if err != nil {
 		log.ErrorLog("AddReplicaSet: " + err.Error())
@@ -123,6 +139,28 @@ func DeleteReplicaSet(c *gin.Context) {
 	log.InfoLog("DeleteReplicaSet: " + namespace + "/" + name)
 
 	key := config.EtcdReplicaSetPrefix + "/" + namespace + "/" + name
+	if IsDryRun(c) {
+		res, err := etcdclient.EtcdStore.Get(key)
+		if err != nil {
+			log.ErrorLog("DeleteReplicaSet: " + err.Error())
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		if res == "" {
+			log.ErrorLog("DeleteReplicaSet: replicaSet not found")
+			c.JSON(404, gin.H{"error": "replicaSet not found"})
+			return
+		}
+		rs := &apiObject.ReplicaSet{}
+		err = json.Unmarshal([]byte(res), rs)
+		if err != nil {
+			log.ErrorLog("DeleteReplicaSet: " + err.Error())
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		DryRunResult(c, 200, rs)
+		return
+	}
 	err := etcdclient.EtcdStore.Delete(key)
 	if err != nil {
 		log.ErrorLog("DeleteReplicaSet: " + err.Error())
@@ -171,6 +209,10 @@ func UpdateReplicaSet(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "namespace or name is empty"})
 		return
 	}
+	if IsDryRun(c) {
+		DryRunResult(c, 200, rs)
+		return
+	}
 	key = config.EtcdReplicaSetPrefix + "/" + rs.Metadata.Namespace + "/" + rs.Metadata.Name
 	err = etcdclient.EtcdStore.Put(key, string(resJson))
 	if err != nil {
@@ -272,6 +314,10 @@ func UpdateReplicaSetStatus(c *gin.Context) {
 		return
 	}
 	rs.Status = status
+	if IsDryRun(c) {
+		DryRunResult(c, 200, rs)
+		return
+	}
 	resJson, err := json.Marshal(rs)
 	if err != nil {
 		log.ErrorLog("UpdateReplicaSetStatus: " + err.Error())
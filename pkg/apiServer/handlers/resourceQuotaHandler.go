@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/config"
+	"minik8s/tools/conversion"
+	"minik8s/tools/log"
+
+	etcdclient "minik8s/pkg/apiServer/etcdClient"
+)
+
+// GetResourceQuota 获取指定ResourceQuota
+func GetResourceQuota(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	if namespace == "" {
+		namespace = "default"
+	}
+	if name == "" {
+		log.ErrorLog("GetResourceQuota: name is empty")
+		c.JSON(400, gin.H{"error": "name is empty"})
+		return
+	}
+	log.InfoLog("GetResourceQuota: " + namespace + "/" + name)
+
+	res, err := etcdclient.EtcdStore.Get(config.EtcdResourceQuotaPrefix + "/" + namespace + "/" + name)
+	if err != nil {
+		log.ErrorLog("GetResourceQuota: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	quota := apiObject.ResourceQuota{}
+	err = json.Unmarshal([]byte(res), &quota)
+	if err != nil {
+		log.ErrorLog("GetResourceQuota: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": quota})
+}
+
+// GetResourceQuotas 获取命名空间内的所有ResourceQuota
+func GetResourceQuotas(c *gin.Context) {
+	namespace := c.Param("namespace")
+	if namespace == "" {
+		namespace = "default"
+	}
+	log.InfoLog("GetResourceQuotas: " + namespace)
+
+	quotas, err := getResourceQuotas(namespace)
+	if err != nil {
+		log.ErrorLog("GetResourceQuotas: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, quotas)
+}
+
+// GetGlobalResourceQuotas 获取全局所有ResourceQuota
+func GetGlobalResourceQuotas(c *gin.Context) {
+	log.DebugLog("GetGlobalResourceQuotas")
+	res, err := etcdclient.EtcdStore.PrefixGet(config.EtcdResourceQuotaPrefix)
+	if err != nil {
+		log.ErrorLog("GetGlobalResourceQuotas: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	var quotas []apiObject.ResourceQuota
+	for _, v := range res {
+		quota := apiObject.ResourceQuota{}
+		err = json.Unmarshal([]byte(v), &quota)
+		if err != nil {
+			log.ErrorLog("GetGlobalResourceQuotas: " + err.Error())
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		quotas = append(quotas, quota)
+	}
+	c.JSON(200, quotas)
+}
+
+// AddResourceQuota 创建ResourceQuota
+func AddResourceQuota(c *gin.Context) {
+	var quota apiObject.ResourceQuota
+	err := c.ShouldBindJSON(&quota)
+	if err != nil {
+		log.ErrorLog("AddResourceQuota: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if quota.Metadata.Name == "" {
+		log.ErrorLog("AddResourceQuota: name is empty")
+		c.JSON(400, gin.H{"error": "name is empty"})
+		return
+	}
+	if quota.Metadata.Namespace == "" {
+		quota.Metadata.Namespace = c.Param("namespace")
+	}
+	if quota.Metadata.Namespace == "" {
+		quota.Metadata.Namespace = "default"
+	}
+	if err = validateResourceQuota(&quota); err != nil {
+		log.ErrorLog("AddResourceQuota: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	log.InfoLog("AddResourceQuota: " + quota.Metadata.Namespace + "/" + quota.Metadata.Name)
+
+	key := config.EtcdResourceQuotaPrefix + "/" + quota.Metadata.Namespace + "/" + quota.Metadata.Name
+	res, err := etcdclient.EtcdStore.Get(key)
+	if err != nil {
+		log.ErrorLog("AddResourceQuota: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res != "" {
+		log.ErrorLog("AddResourceQuota: already exists")
+		c.JSON(409, gin.H{"error": "already exists"})
+		return
+	}
+
+	quota.Metadata.UUID = uuid.New().String()
+	// 创建时即统计一次当前用量，之后由controller定期更新
+	usage, err := GetNamespaceUsage(quota.Metadata.Namespace)
+	if err != nil {
+		log.ErrorLog("AddResourceQuota: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	quota.Status = QuotaStatus(quota.Spec.Hard, usage)
+	if IsDryRun(c) {
+		DryRunResult(c, 201, quota)
+		return
+	}
+
+	resJson, err := json.Marshal(quota)
+	if err != nil {
+		log.ErrorLog("AddResourceQuota: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	err = etcdclient.EtcdStore.Put(key, string(resJson))
+	if err != nil {
+		log.ErrorLog("AddResourceQuota: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(201, gin.H{"data": quota})
+}
+
+// UpdateResourceQuota 更新ResourceQuota的规格
+func UpdateResourceQuota(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	if namespace == "" || name == "" {
+		log.ErrorLog("UpdateResourceQuota: namespace or name is empty")
+		c.JSON(400, gin.H{"error": "namespace or name is empty"})
+		return
+	}
+	log.InfoLog("UpdateResourceQuota: " + namespace + "/" + name)
+
+	key := config.EtcdResourceQuotaPrefix + "/" + namespace + "/" + name
+	res, err := etcdclient.EtcdStore.Get(key)
+	if err != nil {
+		log.ErrorLog("UpdateResourceQuota: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		log.ErrorLog("UpdateResourceQuota: not found")
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	oldQuota := apiObject.ResourceQuota{}
+	err = json.Unmarshal([]byte(res), &oldQuota)
+	if err != nil {
+		log.ErrorLog("UpdateResourceQuota: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	var quota apiObject.ResourceQuota
+	err = c.ShouldBindJSON(&quota)
+	if err != nil {
+		log.ErrorLog("UpdateResourceQuota: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err = validateResourceQuota(&quota); err != nil {
+		log.ErrorLog("UpdateResourceQuota: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	// 名称、命名空间与UUID不允许修改
+	quota.Metadata.Name = name
+	quota.Metadata.Namespace = namespace
+	quota.Metadata.UUID = oldQuota.Metadata.UUID
+	quota.Status = oldQuota.Status
+	quota.Status.Hard = quota.Spec.Hard
+	if IsDryRun(c) {
+		DryRunResult(c, 200, quota)
+		return
+	}
+
+	resJson, err := json.Marshal(quota)
+	if err != nil {
+		log.ErrorLog("UpdateResourceQuota: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	err = etcdclient.EtcdStore.Put(key, string(resJson))
+	if err != nil {
+		log.ErrorLog("UpdateResourceQuota: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": quota})
+}
+
+// DeleteResourceQuota 删除ResourceQuota
+func DeleteResourceQuota(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	if namespace == "" || name == "" {
+		log.ErrorLog("DeleteResourceQuota: namespace or name is empty")
+		c.JSON(400, gin.H{"error": "namespace or name is empty"})
+		return
+	}
+	log.InfoLog("DeleteResourceQuota: " + namespace + "/" + name)
+
+	key := config.EtcdResourceQuotaPrefix + "/" + namespace + "/" + name
+	res, err := etcdclient.EtcdStore.Get(key)
+	if err != nil {
+		log.ErrorLog("DeleteResourceQuota: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		log.ErrorLog("DeleteResourceQuota: not found")
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	quota := apiObject.ResourceQuota{}
+	err = json.Unmarshal([]byte(res), &quota)
+	if err != nil {
+		log.ErrorLog("DeleteResourceQuota: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if IsDryRun(c) {
+		DryRunResult(c, 200, quota)
+		return
+	}
+
+	err = etcdclient.EtcdStore.Delete(key)
+	if err != nil {
+		log.ErrorLog("DeleteResourceQuota: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": "success"})
+}
+
+// UpdateResourceQuotaStatus 更新ResourceQuota的用量，该请求来自于resourceQuotaController
+func UpdateResourceQuotaStatus(c *gin.Context) {
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	if namespace == "" || name == "" {
+		log.ErrorLog("UpdateResourceQuotaStatus: namespace or name is empty")
+		c.JSON(400, gin.H{"error": "namespace or name is empty"})
+		return
+	}
+	log.DebugLog("UpdateResourceQuotaStatus: " + namespace + "/" + name)
+
+	var status apiObject.ResourceQuotaStatus
+	err := c.ShouldBindJSON(&status)
+	if err != nil {
+		log.ErrorLog("UpdateResourceQuotaStatus: " + err.Error())
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := config.EtcdResourceQuotaPrefix + "/" + namespace + "/" + name
+	res, err := etcdclient.EtcdStore.Get(key)
+	if err != nil {
+		log.ErrorLog("UpdateResourceQuotaStatus: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if res == "" {
+		log.ErrorLog("UpdateResourceQuotaStatus: not found")
+		c.JSON(404, gin.H{"error": "not found"})
+		return
+	}
+	quota := apiObject.ResourceQuota{}
+	err = json.Unmarshal([]byte(res), &quota)
+	if err != nil {
+		log.ErrorLog("UpdateResourceQuotaStatus: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	quota.Status = status
+	if IsDryRun(c) {
+		DryRunResult(c, 200, quota)
+		return
+	}
+
+	resJson, err := json.Marshal(quota)
+	if err != nil {
+		log.ErrorLog("UpdateResourceQuotaStatus: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	err = etcdclient.EtcdStore.Put(key, string(resJson))
+	if err != nil {
+		log.ErrorLog("UpdateResourceQuotaStatus: " + err.Error())
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"data": string(resJson)})
+}
+
+// validateResourceQuota 校验ResourceQuota中的资源名称与数量
+func validateResourceQuota(quota *apiObject.ResourceQuota) error {
+	if len(quota.Spec.Hard) == 0 {
+		return errors.New("spec.hard is empty")
+	}
+	for name, quantity := range quota.Spec.Hard {
+		resource := normalizeQuotaResource(name)
+		switch resource {
+		case apiObject.ResourcePods, apiObject.ResourceServices, apiObject.ResourceReplicaSets,
+			apiObject.ResourcePersistentVolumeClaims, apiObject.ResourceRequestsCPU, apiObject.ResourceRequestsMemory,
+			apiObject.ResourceLimitsCPU, apiObject.ResourceLimitsMemory:
+		default:
+			return errors.New("unsupported resource in spec.hard: " + name)
+		}
+		if _, err := conversion.ParseQuantity(resource, quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,7 +3,6 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -142,6 +141,11 @@ func DeleteService(c *gin.Context) {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	// dryRun请求不通知kubeproxy，也不删除etcd中的记录
+	if IsDryRun(c) {
+		DryRunResult(c, config.HttpSuccessCode, service)
+		return
+	}
 
 	// 把deleteEvent发送给所有的Node
 	var serviceEvent entity.ServiceEvent
@@ -175,6 +179,9 @@ func DeleteService(c *gin.Context) {
 		}
 	}
 	etcdclient.EtcdStore.Delete(key)
+	if err = releaseServiceResources(&service, nil); err != nil {
+		log.WarnLog("DeleteService: " + err.Error())
+	}
 
 	// 删除service2endpoint
 	key = config.EtcdService2EndpointPrefix + "/" + namespace + "/" + name
@@ -201,31 +208,70 @@ func PutService(c *gin.Context) {
 	}
 	key := config.EtcdServicePrefix + "/" + newServiceNamespace + "/" + newServiceName
 	response, _ := etcdclient.EtcdStore.Get(key)
+	var oldService *apiObject.Service
 	if response != "" {
 		serviceEvent.Action = entity.UpdateEvent
+		oldService = &apiObject.Service{}
+		if err = json.Unmarshal([]byte(response), oldService); err != nil {
+			log.ErrorLog("PutService error: " + err.Error())
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		service.Metadata.UUID = oldService.Metadata.UUID
 	} else {
 		serviceEvent.Action = entity.CreateEvent
+		// 新建的service需要检查ResourceQuota，service写入etcd之前不释放配额锁
+		unlock, err := LockNamespaceQuota(newServiceNamespace)
+		if err != nil {
+			log.ErrorLog("PutService error: " + err.Error())
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		defer unlock()
+		if err = AdmitObjectCount(newServiceNamespace, apiObject.ResourceServices); err != nil {
+			log.ErrorLog("PutService error: " + err.Error())
+			c.JSON(AdmissionStatusCode(err), gin.H{"error": err.Error()})
+			return
+		}
+		service.Metadata.UUID = uuid.New().String()
 	}
 
-	service.Metadata.UUID = uuid.New().String()
-	service.Spec.ClusterIP = AllocClusterIP()
-	log.InfoLog("AllocClusterIP: " + service.Spec.ClusterIP)
+	// dryRun请求不分配ClusterIP与NodePort，不写入etcd，也不通知kubeproxy
+	if IsDryRun(c) {
+		DryRunResult(c, config.HttpSuccessCode, *service)
+		return
+	}
+	release, err := allocateServiceResources(service, oldService)
+	if err != nil {
+		log.ErrorLog("PutService error: " + err.Error())
+		c.JSON(422, gin.H{"error": err.Error()})
+		return
+	}
+	log.InfoLog("PutService: clusterIP " + service.Spec.ClusterIP)
 	serviceEvent.Service = *service
 	serviceEvent.Endpoints = *Selector(service)
 
 	resJson, err := json.Marshal(serviceEvent.Service)
 	if err != nil {
+		release()
 		log.WarnLog("GetNodes: " + err.Error())
 		c.JSON(config.HttpErrorCode, gin.H{"error": err.Error()})
 		return
 	}
 	err = etcdclient.EtcdStore.Put(key, string(resJson))
 	if err != nil {
+		release()
 		log.WarnLog("GetNodes: " + err.Error())
 		c.JSON(config.HttpErrorCode, gin.H{"error": err.Error()})
 		return
 	}
 	log.InfoLog("PutService: " + newServiceNamespace + "/" + newServiceName)
+	// 更新成功后归还旧Service中不再使用的NodePort
+	if oldService != nil {
+		if err = releaseServiceResources(oldService, service); err != nil {
+			log.WarnLog("PutService: " + err.Error())
+		}
+	}
 
 	// 将service存入etcd
 	service2Endpoint, err := json.Marshal(serviceEvent)
@@ -302,6 +348,7 @@ func Selector(service *apiObject.Service) *[]apiObject.Endpoint {
 			endpoint := apiObject.Endpoint{
 				PodUUID: pod.APIVersion,
 				IP:      pod.Status.PodIP,
+				Ready:   pod.Status.PodIP != "" && pod.Status.IsReady(),
 			}
 			endpoints = append(endpoints, endpoint)
 		}
@@ -309,40 +356,3 @@ func Selector(service *apiObject.Service) *[]apiObject.Endpoint {
 
 	return &endpoints
 }
-
-func AllocClusterIP() string {
-	IP := ""
-	var service apiObject.Service
-	isused := false
-
-	for {
-		i := 0
-		for i = 0; i < 4; i++ {
-			IP += fmt.Sprint(rand.Intn(255))
-			if i != 3 {
-				IP += "."
-			}
-		}
-
-		// 比对所有的service的clusterIP，如果有重复则重新生成
-		res, err := etcdclient.EtcdStore.PrefixGet(config.EtcdServicePrefix)
-		if err != nil {
-			log.ErrorLog("AllocClusterIP: " + err.Error())
-			return ""
-		}
-
-		for _, v := range res {
-			json.Unmarshal([]byte(v), &service)
-			if service.Spec.ClusterIP == IP {
-				isused = true
-				break
-			}
-		}
-
-		if !isused {
-			break
-		}
-	}
-
-	return IP
-}
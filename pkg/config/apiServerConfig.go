@@ -1,6 +1,13 @@
 package config
 
-import "strconv"
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
 
 const (
 	// APIServerLocalAddress api server的本地服务器地址
@@ -9,17 +16,47 @@ const (
 	APIServerLocalPort = 7000
 )
 
+const (
+	// APIServerHealthCheckInterval 组件重新检查当前apiServer是否健康的间隔
+	APIServerHealthCheckInterval = 5 * time.Second
+	// APIServerHealthCheckTimeout 检查apiServer健康状态的超时时间
+	APIServerHealthCheckTimeout = 1 * time.Second
+)
+
+// APIServerEndpoints 所有apiServer副本的地址，可通过环境变量 MINIK8S_APISERVER_ENDPOINTS 指定以逗号分隔的多个 ip:port
+var APIServerEndpoints = splitEndpoints(getEnvOrDefault("MINIK8S_APISERVER_ENDPOINTS",
+	APIServerLocalAddress+":"+strconv.Itoa(APIServerLocalPort)))
+
 type APIServerConfig struct {
 	APIServerIP   string
 	APIServerPort int
 }
 
+// APIServerURL 返回当前可用的apiServer地址，配置了多个apiServer副本时会根据健康检查自动切换
 func (c *APIServerConfig) APIServerURL() string {
+	if c.APIServerIP == APIServerLocalAddress && c.APIServerPort == APIServerLocalPort {
+		return APIServerURL()
+	}
 	return HttpSchema + c.APIServerIP + ":" + strconv.Itoa(c.APIServerPort)
 }
 
+// APIServerURL 返回当前可用的apiServer地址，配置了多个apiServer副本时会根据健康检查自动切换
 func APIServerURL() string {
-	return HttpSchema + APIServerLocalAddress + ":" + strconv.Itoa(APIServerLocalPort)
+	return HttpSchema + apiServerSelector.endpoint()
+}
+
+// APIServerBindAddress apiServer监听的地址，可通过环境变量 MINIK8S_APISERVER_ADDRESS 修改，用于在多台机器上运行apiServer副本
+func APIServerBindAddress() (string, int) {
+	address := getEnvOrDefault("MINIK8S_APISERVER_ADDRESS", APIServerLocalAddress+":"+strconv.Itoa(APIServerLocalPort))
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address, APIServerLocalPort
+	}
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		return host, APIServerLocalPort
+	}
+	return host, portNum
 }
 
 func NewAPIServerConfig() *APIServerConfig {
@@ -28,3 +65,76 @@ func NewAPIServerConfig() *APIServerConfig {
 		APIServerPort: APIServerLocalPort,
 	}
 }
+
+// endpointSelector 在多个apiServer副本之间选择一个健康的副本，当前副本不可用时切换到下一个
+//
+//	健康检查在后台协程中周期性执行，获取地址时只读取最近一次检查的结果
+type endpointSelector struct {
+	endpoints []string
+	client    *http.Client
+	probeOnce sync.Once
+
+	lock    sync.Mutex
+	current int
+}
+
+var apiServerSelector = &endpointSelector{
+	endpoints: APIServerEndpoints,
+	client:    &http.Client{Timeout: APIServerHealthCheckTimeout},
+}
+
+func (s *endpointSelector) endpoint() string {
+	if len(s.endpoints) == 0 {
+		return APIServerLocalAddress + ":" + strconv.Itoa(APIServerLocalPort)
+	}
+	if len(s.endpoints) > 1 {
+		s.probeOnce.Do(func() { go s.probeRoutine() })
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.endpoints[s.current]
+}
+
+// probeRoutine 每隔APIServerHealthCheckInterval检查一次各副本的健康状态
+func (s *endpointSelector) probeRoutine() {
+	for {
+		s.probe()
+		time.Sleep(APIServerHealthCheckInterval)
+	}
+}
+
+// probe 从当前副本开始依次检查，选择第一个健康的副本，全部不可用时保持不变
+func (s *endpointSelector) probe() {
+	s.lock.Lock()
+	current := s.current
+	s.lock.Unlock()
+	for i := 0; i < len(s.endpoints); i++ {
+		idx := (current + i) % len(s.endpoints)
+		if s.healthy(s.endpoints[idx]) {
+			s.lock.Lock()
+			s.current = idx
+			s.lock.Unlock()
+			return
+		}
+	}
+}
+
+func (s *endpointSelector) healthy(endpoint string) bool {
+	resp, err := s.client.Get(HttpSchema + endpoint + HealthzURI)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == HttpSuccessCode
+}
+
+// splitEndpoints 解析以逗号分隔的endpoint列表
+func splitEndpoints(value string) []string {
+	var endpoints []string
+	for _, endpoint := range strings.Split(value, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
@@ -20,11 +20,27 @@ const (
 	EtcdDnsRequestPrefix       = "/registry/dnsrequest"
 	EtcdNginxPrefix            = "/registry/nginx"
 	EtcdService2EndpointPrefix = "/registry/service2endpoint"
+	EtcdResourceQuotaPrefix    = "/registry/resourcequotas"
+	EtcdLimitRangePrefix       = "/registry/limitranges"
+	EtcdLeasePrefix            = "/registry/leases"
+	EtcdPriorityClassPrefix    = "/registry/priorityclasses"
+	EtcdPodGroupPrefix         = "/registry/podgroups"
+	EtcdServiceIPRangeKey      = "/registry/ranges/serviceips"
+	EtcdNodePortRangeKey       = "/registry/ranges/servicenodeports"
+	EtcdQuotaLockPrefix        = "/registry/quotalocks"
 )
 
+const (
+	// EtcdApiServerElectionPrefix 多个apiServer副本选举leader时使用的前缀，只有leader执行后台扫描任务
+	EtcdApiServerElectionPrefix = "/registry/election/apiserver"
+	// ApiServerElectionTTL leader失联超过该时长（秒）后，其他副本可以当选
+	ApiServerElectionTTL = 15
+)
+
+// NewEtcdConfig 创建etcd的配置，可通过环境变量 MINIK8S_ETCD_ENDPOINTS 指定以逗号分隔的多个endpoint
 func NewEtcdConfig() *EtcdConfig {
 	return &EtcdConfig{
-		Endpoints: []string{"localhost:2379"},
+		Endpoints: splitEndpoints(getEnvOrDefault("MINIK8S_ETCD_ENDPOINTS", "localhost:2379")),
 		Timeout:   3 * time.Second,
 	}
 }
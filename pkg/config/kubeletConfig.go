@@ -1,5 +1,12 @@
 package config
 
+import (
+	"strings"
+	"time"
+
+	"minik8s/pkg/apiObject"
+)
+
 const (
 	// KubeletAPIPort kubelet server 与 apiServer 通信的端口
 	KubeletAPIPort = 10250
@@ -11,6 +18,84 @@ const (
 	ReadOnlyPort = 10255
 )
 
+const (
+	// NodeLeaseDurationSeconds kubelet续约节点Lease时声明的有效时长
+	NodeLeaseDurationSeconds = 40
+	// NodeLeaseRenewInterval kubelet续约节点Lease的间隔
+	NodeLeaseRenewInterval = 10 * time.Second
+	// NodeStatusReportInterval kubelet向apiServer上报节点状态的间隔
+	NodeStatusReportInterval = 1 * time.Minute
+	// PodSyncInterval kubelet从apiServer拉取绑定到本节点的Pod的间隔
+	PodSyncInterval = 3 * time.Second
+)
+
+const (
+	// KubeletMaxPods 每个节点最多可以运行的Pod数量
+	KubeletMaxPods = 110
+	// SystemReservedCPU 为系统进程与kubelet预留的CPU，不参与调度
+	SystemReservedCPU = "100m"
+	// SystemReservedMemory 为系统进程与kubelet预留的内存，不参与调度
+	SystemReservedMemory = "256Mi"
+)
+
+const (
+	// CgroupDriverCgroupfs kubelet直接在cgroup文件系统中创建cgroup
+	CgroupDriverCgroupfs = "cgroupfs"
+	// CgroupDriverSystemd cgroup由systemd以slice的形式管理
+	CgroupDriverSystemd = "systemd"
+	// CgroupRoot cgroup文件系统的挂载点
+	CgroupRoot = "/sys/fs/cgroup"
+	// KubepodsCgroupName 所有Pod的cgroup都位于该cgroup之下
+	KubepodsCgroupName = "kubepods"
+)
+
+// CgroupDriver kubelet使用的cgroup驱动，需要与containerd中runc的SystemdCgroup配置保持一致
+//
+//	可通过环境变量 MINIK8S_CGROUP_DRIVER 指定为 cgroupfs 或 systemd，默认为 cgroupfs
+var CgroupDriver = getEnvOrDefault("MINIK8S_CGROUP_DRIVER", CgroupDriverCgroupfs)
+
+// PodLogsRootDirectory 容器日志的根目录，每个Pod的日志位于 <根目录>/<namespace>_<name>_<uid>/<container>/<restartCount>.log
+const PodLogsRootDirectory = "/var/log/pods"
+
+// NodeLabels kubelet注册节点时附带的标签，可通过环境变量 MINIK8S_NODE_LABELS 指定，格式为 key1=value1,key2=value2
+var NodeLabels = parseNodeLabels(getEnvOrDefault("MINIK8S_NODE_LABELS", ""))
+
+// NodeTaints kubelet注册节点时附带的污点，可通过环境变量 MINIK8S_NODE_TAINTS 指定，格式为 key1=value1:Effect,key2:Effect
+//
+//	例如为运行apiServer的master节点设置 node-role.kubernetes.io/master:NoSchedule，只允许容忍该污点的系统Pod调度到该节点上
+var NodeTaints = parseNodeTaints(getEnvOrDefault("MINIK8S_NODE_TAINTS", ""))
+
+// LabelHostname kubelet为节点自动添加的主机名标签
+const LabelHostname = "kubernetes.io/hostname"
+
+func parseNodeLabels(value string) map[string]string {
+	labels := make(map[string]string)
+	for _, pair := range splitEndpoints(value) {
+		key, val, _ := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); key != "" {
+			labels[key] = strings.TrimSpace(val)
+		}
+	}
+	return labels
+}
+
+func parseNodeTaints(value string) []apiObject.Taint {
+	var taints []apiObject.Taint
+	for _, item := range splitEndpoints(value) {
+		pair, effect, ok := strings.Cut(item, ":")
+		if !ok {
+			continue
+		}
+		key, val, _ := strings.Cut(pair, "=")
+		taints = append(taints, apiObject.Taint{
+			Key:    strings.TrimSpace(key),
+			Value:  strings.TrimSpace(val),
+			Effect: strings.TrimSpace(effect),
+		})
+	}
+	return taints
+}
+
 const (
 	ContainerRuntimeEndpoint = "unix:///run/containerd/containerd.sock"
 	ImageRuntimeEndpoint     = "unix:///run/containerd/containerd.sock"
@@ -1,5 +1,8 @@
 package config
-import "strconv"
+import (
+	"os"
+	"strconv"
+)
 
 const (
 	SchedulerConfigPath = "/scheduler"
@@ -31,4 +34,7 @@ type SchedulerConfig struct {
 
 func (c *SchedulerConfig) SchedulerURL() string {
 	return "http://" + c.SchedulerIP + ":" + strconv.Itoa(c.SchedulerPort)
-}
\ No newline at end of file
+}
+
+// SchedulerProfilePath 调度器配置文件的路径，可通过环境变量 MINIK8S_SCHEDULER_CONFIG 指定，为空时使用默认配置
+var SchedulerProfilePath = os.Getenv("MINIK8S_SCHEDULER_CONFIG")
@@ -0,0 +1,16 @@
+package config
+
+import "os"
+
+// ServiceClusterIPRange Service的ClusterIP分配网段，可通过环境变量 MINIK8S_SERVICE_CLUSTER_IP_RANGE 修改
+var ServiceClusterIPRange = getEnvOrDefault("MINIK8S_SERVICE_CLUSTER_IP_RANGE", "10.96.0.0/16")
+
+// ServiceNodePortRange Service的NodePort分配范围，可通过环境变量 MINIK8S_SERVICE_NODE_PORT_RANGE 修改
+var ServiceNodePortRange = getEnvOrDefault("MINIK8S_SERVICE_NODE_PORT_RANGE", "30000-32767")
+
+func getEnvOrDefault(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -3,16 +3,22 @@ package config
 import "minik8s/pkg/apiObject"
 
 const (
+	HealthzURI = "/healthz"
+
 	NodesURI      = "/api/v1/nodes"
 	NodeURI       = "/api/v1/nodes/:name"
 	NodeStatusURI = "/api/v1/nodes/:name/status"
 
-	PodURI        = "/api/v1/namespaces/:namespace/pods/:name"
-	PodStatusURI  = "/api/v1/namespaces/:namespace/pods/:name/status"
-	PodExecURI    = "/api/v1/namespaces/:namespace/pods/:name/exec/:container/param"
-	PodsURI       = "/api/v1/namespaces/:namespace/pods"
-	PodsGlobalURI = "/api/v1/pods"
-	PodsSyncURI   = "/api/v1/pods/sync"
+	PodURI           = "/api/v1/namespaces/:namespace/pods/:name"
+	PodStatusURI     = "/api/v1/namespaces/:namespace/pods/:name/status"
+	PodBindingURI    = "/api/v1/namespaces/:namespace/pods/:name/binding"
+	PodExecURI       = "/api/v1/namespaces/:namespace/pods/:name/exec/:container/param"
+	PodLogURI        = "/api/v1/namespaces/:namespace/pods/:name/log"
+	PodExecStreamURI = "/api/v1/namespaces/:namespace/pods/:name/exec"
+	PodAttachURI     = "/api/v1/namespaces/:namespace/pods/:name/attach"
+	PodsURI          = "/api/v1/namespaces/:namespace/pods"
+	PodsGlobalURI    = "/api/v1/pods"
+	PodsSyncURI      = "/api/v1/pods/sync"
 
 	ProxyStatusURI   = "/api/v1/proxy"
 	ProxiesStatusURI = "/api/v1/proxy/:name"
@@ -49,6 +55,26 @@ const (
 	PersistentVolumeClaimsURI = "/api/v1/pvc"
 	PersistentVolumeClaimURI  = "/api/v1/pvc/:namespace/:name"
 
+	ResourceQuotasURI       = "/api/v1/namespaces/:namespace/resourcequotas"
+	ResourceQuotaURI        = "/api/v1/namespaces/:namespace/resourcequotas/:name"
+	ResourceQuotaStatusURI  = "/api/v1/namespaces/:namespace/resourcequotas/:name/status"
+	GlobalResourceQuotasURI = "/api/v1/resourcequotas"
+
+	LimitRangesURI = "/api/v1/namespaces/:namespace/limitranges"
+	LimitRangeURI  = "/api/v1/namespaces/:namespace/limitranges/:name"
+
+	PriorityClassesURI = "/api/v1/priorityclasses"
+	PriorityClassURI   = "/api/v1/priorityclasses/:name"
+
+	PodGroupsURI       = "/api/v1/namespaces/:namespace/podgroups"
+	PodGroupURI        = "/api/v1/namespaces/:namespace/podgroups/:name"
+	GlobalPodGroupsURI = "/api/v1/podgroups"
+
+	AllocatorsURI = "/api/v1/allocators"
+
+	LeasesURI = "/api/v1/namespaces/:namespace/leases"
+	LeaseURI  = "/api/v1/namespaces/:namespace/leases/:name"
+
 	MonitorNodeURL = "/api/v1/monitor/node"
 	MonitorPodURL  = "/api/v1/monitor/pod"
 )
@@ -58,8 +84,53 @@ const (
 	NameReplace      = ":name"
 	ParamReplace     = ":param"
 	ContainerReplace = ":container"
+	PodReplace       = ":pod"
+)
+
+// ContainerLogsURI kubelet提供的读取容器日志的接口，apiServer通过Pod的log子资源代理该接口
+const ContainerLogsURI = "/containerLogs/:namespace/:pod/:container"
+
+// kubelet提供的在容器中交互式执行命令与连接到容器主进程的WebSocket接口，apiServer通过Pod的exec与attach子资源代理这两个接口
+const (
+	ContainerExecURI   = "/exec/:namespace/:pod/:container"
+	ContainerAttachURI = "/attach/:namespace/:pod/:container"
 )
 
+// 交互式exec与attach的查询参数，Pod的exec与attach子资源通过 ?container= 指定容器，其余参数原样转发给kubelet
+//
+//	command 可以重复出现，依次为命令与参数；stdin=true 将客户端的输入发送给容器；tty=true 为命令分配终端
+const (
+	ExecContainerQuery = "container"
+	ExecCommandQuery   = "command"
+	ExecStdinQuery     = "stdin"
+	ExecTTYQuery       = "tty"
+)
+
+// 读取容器日志的查询参数，Pod的log子资源通过 ?container= 指定容器，其余参数原样转发给kubelet
+//
+//	follow=true 持续输出新的日志，tailLines 只输出最后若干行，sinceSeconds 只输出最近若干秒的日志
+//	timestamps=true 在每行日志前输出时间戳，previous=true 读取容器上一次运行的日志
+const (
+	LogContainerQuery    = "container"
+	LogFollowQuery       = "follow"
+	LogTailLinesQuery    = "tailLines"
+	LogSinceSecondsQuery = "sinceSeconds"
+	LogTimestampsQuery   = "timestamps"
+	LogPreviousQuery     = "previous"
+)
+
+// dryRun 查询参数，携带 ?dryRun=All 的修改类请求只做默认值填充与校验，不写入etcd，也不调用下游组件
+const (
+	DryRunQuery = "dryRun"
+	DryRunAll   = "All"
+)
+
+// NodeNameQuery 获取全局Pod时按照所在节点过滤的查询参数，?nodeName= 为空时只返回尚未调度的Pod
+const NodeNameQuery = "nodeName"
+
+// GracePeriodQuery 删除Pod时覆盖Pod优雅退出时间的查询参数，单位为秒，0表示立即停止容器
+const GracePeriodQuery = "gracePeriodSeconds"
+
 var UriMapping = map[string]string{
 	apiObject.NodeType: NodesURI,
 	apiObject.PodType:  PodsURI,
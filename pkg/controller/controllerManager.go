@@ -13,6 +13,8 @@ type ControllerManagerImpl struct {
 	replicaSetController specctlrs.ReplicaSetController
 	hpaController        specctlrs.HpaController
 	pvController         specctlrs.PvController
+	quotaController      specctlrs.ResourceQuotaController
+	nodeController       specctlrs.NodeLifecycleController
 }
 
 func NewControllerManager() ControllerManager {
@@ -28,7 +30,15 @@ func NewControllerManager() ControllerManager {
 	if err != nil {
 		panic(err)
 	}
-	return &ControllerManagerImpl{replicaSetController: newrc, hpaController: newhc, pvController: newpc}
+	newqc, err := specctlrs.NewResourceQuotaController()
+	if err != nil {
+		panic(err)
+	}
+	newnc, err := specctlrs.NewNodeLifecycleController()
+	if err != nil {
+		panic(err)
+	}
+	return &ControllerManagerImpl{replicaSetController: newrc, hpaController: newhc, pvController: newpc, quotaController: newqc, nodeController: newnc}
 }
 
 func (cm *ControllerManagerImpl) Run(stopCh <-chan struct{}) {
@@ -36,5 +46,7 @@ func (cm *ControllerManagerImpl) Run(stopCh <-chan struct{}) {
 	go cm.replicaSetController.Run()
 	go cm.hpaController.Run()
 	go cm.pvController.Run()
+	go cm.quotaController.Run()
+	go cm.nodeController.Run()
 	<-stopCh
 }
@@ -0,0 +1,362 @@
+package specctlrs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/config"
+	"minik8s/tools/executor"
+	"minik8s/tools/log"
+
+	netRequest "minik8s/tools/netRequest"
+)
+
+// NodeLifecycleController 根据kubelet续约的Lease判断Node是否存活
+//
+//	Lease超过NodeMonitorGracePeriod未续约时，将Node的Ready条件置为Unknown并添加NotReady污点
+//	Lease恢复续约后，将Node的Ready条件置为True并移除NotReady污点
+//	Node带有NoExecute污点时，驱逐没有容忍该污点或容忍时长已过的Pod，由ReplicaSet在其他Node上重新创建
+//	没有显式容忍NotReady污点的Pod默认容忍PodEvictionTimeout
+type NodeLifecycleController interface {
+	Run()
+}
+
+type NodeLifecycleControllerImpl struct {
+	// 记录首次发现Node没有Lease的时间，用于兼容尚未开始续约的Node
+	firstSeen map[string]time.Time
+}
+
+var (
+	NodeLifecycleControllerDelay   = 3 * time.Second
+	NodeLifecycleControllerTimeGap = []time.Duration{5 * time.Second}
+
+	// NodeMonitorGracePeriod Lease超过该时长未续约时认为Node失联
+	NodeMonitorGracePeriod = 40 * time.Second
+	// PodEvictionTimeout Node失联超过该时长后驱逐其上没有显式容忍NotReady污点的Pod
+	PodEvictionTimeout = 1 * time.Minute
+)
+
+// nodeUpdateRetries 更新Node与其他写入者冲突时的最大重试次数
+const nodeUpdateRetries = 5
+
+// errNodeConflict Node在读取之后被kubelet或用户修改，需要重新读取后再更新
+var errNodeConflict = errors.New("node has been modified")
+
+func NewNodeLifecycleController() (NodeLifecycleController, error) {
+	return &NodeLifecycleControllerImpl{firstSeen: make(map[string]time.Time)}, nil
+}
+
+func (nc *NodeLifecycleControllerImpl) Run() {
+	// 定期执行
+	executor.ExecuteInPeriod(NodeLifecycleControllerDelay, NodeLifecycleControllerTimeGap, nc.monitorNodeHealth)
+}
+
+func GetAllNodesFromAPIServer() (nodes []apiObject.Node, err error) {
+	url := config.APIServerURL() + config.NodesURI
+	res, err := http.Get(url)
+	if err != nil {
+		log.ErrorLog("GetAllNodesFromAPIServer: " + err.Error())
+		return nodes, err
+	}
+	err = json.NewDecoder(res.Body).Decode(&nodes)
+	if err != nil {
+		log.ErrorLog("GetAllNodesFromAPIServer: " + err.Error())
+		return nodes, err
+	}
+	return nodes, nil
+}
+
+// GetNodeFromAPIServer 获取指定的Node以及其修改版本号
+func GetNodeFromAPIServer(name string) (*apiObject.Node, string, error) {
+	url := config.APIServerURL() + config.NodeURI
+	url = strings.Replace(url, config.NameReplace, name, -1)
+	res, err := http.Get(url)
+	if err != nil {
+		log.ErrorLog("GetNodeFromAPIServer: " + err.Error())
+		return nil, "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		log.ErrorLog("GetNodeFromAPIServer: " + res.Status)
+		return nil, "", errors.New("get node " + name + ": " + res.Status)
+	}
+	var body struct {
+		Data apiObject.Node `json:"data"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		log.ErrorLog("GetNodeFromAPIServer: " + err.Error())
+		return nil, "", err
+	}
+	return &body.Data, res.Header.Get("ETag"), nil
+}
+
+func GetNodeLeasesFromAPIServer() (leases []apiObject.Lease, err error) {
+	url := config.APIServerURL() + config.LeasesURI
+	url = strings.Replace(url, config.NameSpaceReplace, apiObject.NodeLeaseNamespace, -1)
+	res, err := http.Get(url)
+	if err != nil {
+		log.ErrorLog("GetNodeLeasesFromAPIServer: " + err.Error())
+		return leases, err
+	}
+	err = json.NewDecoder(res.Body).Decode(&leases)
+	if err != nil {
+		log.ErrorLog("GetNodeLeasesFromAPIServer: " + err.Error())
+		return leases, err
+	}
+	return leases, nil
+}
+
+func (nc *NodeLifecycleControllerImpl) monitorNodeHealth() {
+	// 1. 获取所有的Node与节点心跳Lease
+	nodes, err := GetAllNodesFromAPIServer()
+	if err != nil {
+		log.ErrorLog("monitorNodeHealth: " + err.Error())
+		return
+	}
+	leases, err := GetNodeLeasesFromAPIServer()
+	if err != nil {
+		log.ErrorLog("monitorNodeHealth: " + err.Error())
+		return
+	}
+	leaseMapping := make(map[string]apiObject.Lease)
+	for _, lease := range leases {
+		leaseMapping[lease.Metadata.Name] = lease
+	}
+
+	// 2. 根据Lease的续约时间更新每个Node的状态
+	now := time.Now()
+	for i := range nodes {
+		node := &nodes[i]
+		name := node.Metadata.Name
+		lease, ok := leaseMapping[name]
+		var lastRenew time.Time
+		if ok {
+			delete(nc.firstSeen, name)
+			lastRenew = lease.Spec.RenewTime
+		} else {
+			if _, seen := nc.firstSeen[name]; !seen {
+				nc.firstSeen[name] = now
+			}
+			lastRenew = nc.firstSeen[name]
+		}
+
+		if err = nc.syncNodeHealth(node, now.Sub(lastRenew) <= NodeMonitorGracePeriod, lastRenew, now); err != nil {
+			log.ErrorLog("monitorNodeHealth: " + err.Error())
+		}
+	}
+
+	// 3. 驱逐无法容忍Node上NoExecute污点的Pod
+	nc.evictPods(nodes, now)
+}
+
+// syncNodeHealth 根据Node是否按时续约更新其Ready条件与NotReady污点
+//
+//	需要更新时重新读取Node，并要求污点在读取之后没有被修改，否则再次读取后重试，避免覆盖kubelet或用户的修改
+func (nc *NodeLifecycleControllerImpl) syncNodeHealth(node *apiObject.Node, healthy bool, lastRenew time.Time, now time.Time) error {
+	revision := ""
+	for i := 0; ; i++ {
+		var conditionChanged, taintsChanged bool
+		if healthy {
+			conditionChanged, taintsChanged = markNodeReady(node, lastRenew, now)
+		} else {
+			conditionChanged, taintsChanged = markNodeUnknown(node, now)
+		}
+		if !conditionChanged && !taintsChanged {
+			return nil
+		}
+		if revision != "" {
+			err := nc.updateNode(node, revision, conditionChanged, taintsChanged)
+			if !errors.Is(err, errNodeConflict) || i > nodeUpdateRetries {
+				return err
+			}
+		} else if healthy {
+			log.InfoLog("nodeLifecycleController: node " + node.Metadata.Name + " is ready again")
+		} else {
+			log.WarnLog("nodeLifecycleController: node " + node.Metadata.Name + " stopped posting lease, mark it as unknown")
+		}
+		latest, latestRevision, err := GetNodeFromAPIServer(node.Metadata.Name)
+		if err != nil {
+			return err
+		}
+		*node, revision = *latest, latestRevision
+	}
+}
+
+// markNodeReady Node恢复续约后，将Ready条件置为True并移除NotReady污点，返回条件与污点是否发生了变化
+func markNodeReady(node *apiObject.Node, lastRenew time.Time, now time.Time) (bool, bool) {
+	ready := node.IsReady()
+	tainted := node.HasTaint(apiObject.TaintNodeNotReady, apiObject.TaintEffectNoExecute)
+	if !ready {
+		setReadyCondition(node, apiObject.ConditionTrue, "KubeletReady", lastRenew, now)
+	}
+	if tainted {
+		var taints []apiObject.Taint
+		for _, taint := range node.Spec.Taints {
+			if taint.Key != apiObject.TaintNodeNotReady {
+				taints = append(taints, taint)
+			}
+		}
+		node.Spec.Taints = taints
+	}
+	return !ready, tainted
+}
+
+// markNodeUnknown Node失联后，将Ready条件置为Unknown并添加NotReady污点，返回条件与污点是否发生了变化
+func markNodeUnknown(node *apiObject.Node, now time.Time) (bool, bool) {
+	condition := node.GetCondition(apiObject.NodeReady)
+	unknown := condition != nil && condition.Status == apiObject.ConditionUnknown
+	tainted := node.HasTaint(apiObject.TaintNodeNotReady, apiObject.TaintEffectNoExecute)
+	if !unknown {
+		var lastHeartbeat time.Time
+		if condition != nil {
+			lastHeartbeat = condition.LastHeartbeatTime
+		}
+		setReadyCondition(node, apiObject.ConditionUnknown, "NodeStatusUnknown", lastHeartbeat, now)
+	}
+	if !tainted {
+		node.Spec.Taints = append(node.Spec.Taints, apiObject.Taint{
+			Key:       apiObject.TaintNodeNotReady,
+			Effect:    apiObject.TaintEffectNoExecute,
+			TimeAdded: now,
+		})
+	}
+	return !unknown, !tainted
+}
+
+func setReadyCondition(node *apiObject.Node, status string, reason string, heartbeat time.Time, now time.Time) {
+	condition := node.GetCondition(apiObject.NodeReady)
+	if condition == nil {
+		node.Status.Conditions = append(node.Status.Conditions, apiObject.NodeCondition{Type: apiObject.NodeReady})
+		condition = &node.Status.Conditions[len(node.Status.Conditions)-1]
+	}
+	if condition.Status != status {
+		condition.LastTransitionTime = now
+	}
+	condition.Status = status
+	condition.Reason = reason
+	condition.LastHeartbeatTime = heartbeat
+}
+
+// evictPods 删除无法容忍所在Node的NoExecute污点的Pod，ReplicaSet会在其他Node上重新创建
+func (nc *NodeLifecycleControllerImpl) evictPods(nodes []apiObject.Node, now time.Time) {
+	taints := make(map[string][]apiObject.Taint)
+	for _, node := range nodes {
+		for _, taint := range node.Spec.Taints {
+			if taint.Effect == apiObject.TaintEffectNoExecute {
+				taints[node.Metadata.Name] = append(taints[node.Metadata.Name], taint)
+			}
+		}
+	}
+	if len(taints) == 0 {
+		return
+	}
+
+	pods, err := GetAllPodsFromAPIServer()
+	if err != nil {
+		log.ErrorLog("evictPods: " + err.Error())
+		return
+	}
+	for _, pod := range pods {
+		nodeTaints, ok := taints[pod.Spec.NodeName]
+		if !ok {
+			continue
+		}
+		deadline, evict := podEvictionTime(&pod, nodeTaints)
+		if !evict || now.Before(deadline) {
+			continue
+		}
+		log.WarnLog("nodeLifecycleController: evict pod " + pod.Metadata.Namespace + "/" + pod.Metadata.Name + " from node " + pod.Spec.NodeName)
+		url := config.APIServerURL() + config.PodURI
+		url = strings.Replace(url, config.NameSpaceReplace, pod.Metadata.Namespace, -1)
+		url = strings.Replace(url, config.NameReplace, pod.Metadata.Name, -1)
+		code, err := netRequest.DelRequest(url)
+		if err != nil {
+			log.ErrorLog("evictPods: " + err.Error())
+		} else if code != http.StatusOK {
+			log.ErrorLog("evictPods: delete pod " + pod.Metadata.Name + " code is not 200")
+		}
+	}
+}
+
+// podEvictionTime 计算Pod因NoExecute污点需要被驱逐的时间，第二个返回值为false时Pod可以一直留在Node上
+//
+//	没有容忍的污点从添加时起立即驱逐，容忍中设置了tolerationSeconds的污点在容忍时长过后驱逐
+func podEvictionTime(pod *apiObject.Pod, taints []apiObject.Taint) (time.Time, bool) {
+	var deadline time.Time
+	evict := false
+	for i := range taints {
+		taint := &taints[i]
+		var taintDeadline time.Time
+		toleration := apiObject.FindMatchingToleration(pod.Spec.Tolerations, taint)
+		switch {
+		case toleration == nil && taint.Key == apiObject.TaintNodeNotReady:
+			taintDeadline = taint.TimeAdded.Add(PodEvictionTimeout)
+		case toleration == nil:
+			taintDeadline = taint.TimeAdded
+		case toleration.TolerationSeconds == nil:
+			continue
+		default:
+			taintDeadline = taint.TimeAdded.Add(time.Duration(max(*toleration.TolerationSeconds, 0)) * time.Second)
+		}
+		if !evict || taintDeadline.Before(deadline) {
+			deadline = taintDeadline
+		}
+		evict = true
+	}
+	return deadline, evict
+}
+
+// updateNode 先更新Node的污点再更新条件，污点在revision之后被修改时返回errNodeConflict
+func (nc *NodeLifecycleControllerImpl) updateNode(node *apiObject.Node, revision string, conditionChanged bool, taintsChanged bool) error {
+	if taintsChanged {
+		if err := nc.UpdateNodeTaints(node, revision); err != nil {
+			return err
+		}
+	}
+	if conditionChanged {
+		return nc.UpdateNodeConditions(node)
+	}
+	return nil
+}
+
+// UpdateNodeConditions 只更新Node的条件，不会覆盖kubelet上报的资源容量等状态
+func (nc *NodeLifecycleControllerImpl) UpdateNodeConditions(node *apiObject.Node) error {
+	return patchNode(config.NodeStatusURI, node.Metadata.Name, "", map[string]interface{}{
+		"conditions": node.Status.Conditions,
+	})
+}
+
+// UpdateNodeTaints 只更新Node的污点，不会覆盖Node的标签与是否可调度等信息，Node的修改版本号不为revision时返回errNodeConflict
+func (nc *NodeLifecycleControllerImpl) UpdateNodeTaints(node *apiObject.Node, revision string) error {
+	return patchNode(config.NodeURI, node.Metadata.Name, revision, map[string]interface{}{
+		"spec": map[string]interface{}{"taints": node.Spec.Taints},
+	})
+}
+
+// patchNode 向apiServer发送只包含部分字段的更新请求，revision不为空时要求Node的修改版本号与其一致，否则返回errNodeConflict
+func patchNode(uri string, name string, revision string, patch interface{}) error {
+	url := config.APIServerURL() + uri
+	url = strings.Replace(url, config.NameReplace, name, -1)
+	header := http.Header{}
+	if revision != "" {
+		header.Set("If-Match", revision)
+	}
+	code, _, err := netRequest.PatchRequestByTarget(url, patch, header)
+	if err != nil {
+		log.ErrorLog("nodeLifecycleController: " + "patchNode error: " + err.Error())
+		return err
+	}
+	if code == http.StatusConflict {
+		return errNodeConflict
+	}
+	if code != http.StatusOK {
+		log.ErrorLog("nodeLifecycleController: " + "patchNode code is not 200")
+		return errors.New("patchNode code is not 200")
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package specctlrs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/apiServer/handlers"
+	"minik8s/pkg/config"
+	"minik8s/tools/executor"
+	"minik8s/tools/log"
+
+	etcdclient "minik8s/pkg/apiServer/etcdClient"
+	netRequest "minik8s/tools/netRequest"
+)
+
+type ResourceQuotaController interface {
+	Run()
+}
+
+type ResourceQuotaControllerImpl struct {
+}
+
+var (
+	ResourceQuotaControllerDelay   = 3 * time.Second
+	ResourceQuotaControllerTimeGap = []time.Duration{10 * time.Second}
+)
+
+func NewResourceQuotaController() (ResourceQuotaController, error) {
+	return &ResourceQuotaControllerImpl{}, nil
+}
+
+func (qc *ResourceQuotaControllerImpl) Run() {
+	// 定期执行
+	executor.ExecuteInPeriod(ResourceQuotaControllerDelay, ResourceQuotaControllerTimeGap, qc.syncResourceQuota)
+}
+
+func GetAllResourceQuotasFromAPIServer() (quotas []apiObject.ResourceQuota, err error) {
+	url := config.APIServerURL() + config.GlobalResourceQuotasURI
+	res, err := http.Get(url)
+	if err != nil {
+		log.ErrorLog("GetAllResourceQuotasFromAPIServer: " + err.Error())
+		return quotas, err
+	}
+	err = json.NewDecoder(res.Body).Decode(&quotas)
+	if err != nil {
+		log.ErrorLog("GetAllResourceQuotasFromAPIServer: " + err.Error())
+		return quotas, err
+	}
+	return quotas, nil
+}
+
+func GetAllServicesFromAPIServer() (services []apiObject.Service, err error) {
+	url := config.APIServerURL() + config.ServicesURI
+	url = strings.Replace(url, config.NameSpaceReplace, "default", -1)
+	res, err := http.Get(url)
+	if err != nil {
+		log.ErrorLog("GetAllServicesFromAPIServer: " + err.Error())
+		return services, err
+	}
+	err = json.NewDecoder(res.Body).Decode(&services)
+	if err != nil {
+		log.ErrorLog("GetAllServicesFromAPIServer: " + err.Error())
+		return services, err
+	}
+	return services, nil
+}
+
+// getAllPvcs PVC由pvController直接保存在etcd中，因此从etcd中读取
+func getAllPvcs() (pvcs []apiObject.PersistentVolumeClaim, err error) {
+	res, err := etcdclient.EtcdStore.PrefixGet(config.EtcdPvcPrefix)
+	if err != nil {
+		log.ErrorLog("getAllPvcs: " + err.Error())
+		return pvcs, err
+	}
+	for _, v := range res {
+		pvc := apiObject.PersistentVolumeClaim{}
+		err = json.Unmarshal([]byte(v), &pvc)
+		if err != nil {
+			log.ErrorLog("getAllPvcs: " + err.Error())
+			return pvcs, err
+		}
+		pvcs = append(pvcs, pvc)
+	}
+	return pvcs, nil
+}
+
+func (qc *ResourceQuotaControllerImpl) syncResourceQuota() {
+	// 1. 获取所有的ResourceQuota
+	quotas, err := GetAllResourceQuotasFromAPIServer()
+	if err != nil {
+		log.ErrorLog("syncResourceQuota: " + err.Error())
+		return
+	}
+	if len(quotas) == 0 {
+		return
+	}
+	// 2. 获取所有需要计入配额的对象
+	pods, err := GetAllPodsFromAPIServer()
+	if err != nil {
+		log.ErrorLog("syncResourceQuota: " + err.Error())
+		return
+	}
+	services, err := GetAllServicesFromAPIServer()
+	if err != nil {
+		log.ErrorLog("syncResourceQuota: " + err.Error())
+		return
+	}
+	replicaSets, err := GetAllReplicaSetsFromAPIServer()
+	if err != nil {
+		log.ErrorLog("syncResourceQuota: " + err.Error())
+		return
+	}
+	pvcs, err := getAllPvcs()
+	if err != nil {
+		log.ErrorLog("syncResourceQuota: " + err.Error())
+		return
+	}
+
+	// 3. 按命名空间统计用量，并更新每个ResourceQuota的状态
+	usageCache := make(map[string]map[string]int64)
+	for _, quota := range quotas {
+		namespace := quota.Metadata.Namespace
+		usage, ok := usageCache[namespace]
+		if !ok {
+			usage = handlers.QuotaUsage(
+				filterByNamespace(pods, namespace, func(p apiObject.Pod) string { return p.Metadata.Namespace }),
+				filterByNamespace(services, namespace, func(s apiObject.Service) string { return s.Metadata.Namespace }),
+				filterByNamespace(replicaSets, namespace, func(rs apiObject.ReplicaSet) string { return rs.Metadata.Namespace }),
+				filterByNamespace(pvcs, namespace, func(pvc apiObject.PersistentVolumeClaim) string { return pvc.Metadata.Namespace }),
+			)
+			usageCache[namespace] = usage
+		}
+		status := handlers.QuotaStatus(quota.Spec.Hard, usage)
+		if reflect.DeepEqual(status, quota.Status) {
+			continue
+		}
+		if err = qc.UpdateStatus(&quota, status); err != nil {
+			log.ErrorLog("syncResourceQuota: " + err.Error())
+		}
+	}
+}
+
+func (qc *ResourceQuotaControllerImpl) UpdateStatus(quota *apiObject.ResourceQuota, status apiObject.ResourceQuotaStatus) error {
+	url := config.APIServerURL() + config.ResourceQuotaStatusURI
+	url = strings.Replace(url, config.NameSpaceReplace, quota.Metadata.Namespace, -1)
+	url = strings.Replace(url, config.NameReplace, quota.Metadata.Name, -1)
+	code, _, err := netRequest.PutRequestByTarget(url, &status)
+	if err != nil {
+		log.ErrorLog("resourceQuotaController: " + "UpdateStatus error: " + err.Error())
+		return err
+	}
+	if code != http.StatusOK {
+		log.ErrorLog("resourceQuotaController: " + "UpdateStatus code is not 200")
+		return errors.New("UpdateStatus code is not 200")
+	}
+	return nil
+}
+
+func filterByNamespace[T any](objects []T, namespace string, namespaceOf func(T) string) []T {
+	var res []T
+	for _, obj := range objects {
+		if namespaceOf(obj) == namespace {
+			res = append(res, obj)
+		}
+	}
+	return res
+}
@@ -0,0 +1,41 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// Leadership 通过etcd选举获得的领导权，session过期后领导权随之丢失
+type Leadership struct {
+	session  *concurrency.Session
+	election *concurrency.Election
+}
+
+// Campaign 在prefix下参与选举，阻塞直到当选或ctx被取消
+//
+//	ttl为session的租约时长（秒），持有者失联超过该时长后其他参与者可以当选
+func (c *EtcdClientWrapper) Campaign(ctx context.Context, prefix string, identity string, ttl int) (*Leadership, error) {
+	session, err := concurrency.NewSession(c.etcdClient, concurrency.WithTTL(ttl))
+	if err != nil {
+		return nil, fmt.Errorf("concurrency.NewSession err:%v", err)
+	}
+	election := concurrency.NewElection(session, prefix)
+	if err = election.Campaign(ctx, identity); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("election.Campaign err:%v", err)
+	}
+	return &Leadership{session: session, election: election}, nil
+}
+
+// Done 领导权丢失时关闭
+func (l *Leadership) Done() <-chan struct{} {
+	return l.session.Done()
+}
+
+// Resign 主动放弃领导权
+func (l *Leadership) Resign() error {
+	defer l.session.Close()
+	return l.election.Resign(context.Background())
+}
@@ -18,13 +18,27 @@ func NewEtcdClient(endpoints []string,timeout time.Duration) (*EtcdClientWrapper
 	if err != nil {
 		return nil,fmt.Errorf("etcd.New err:%v",err)
 	}
-	timeoutCtx ,cancel := context.WithTimeout(context.Background(),timeout)
+	// 只要集群中有一个endpoint可用即可，客户端会在endpoint之间自动切换
+	for _,endpoint := range endpoints {
+		timeoutCtx ,cancel := context.WithTimeout(context.Background(),timeout)
+		_,err = cli.Status(timeoutCtx,endpoint)
+		cancel()
+		if err == nil {
+			return &EtcdClientWrapper{etcdClient:cli},nil
+		}
+	}
+	return nil,fmt.Errorf("cli.Status err:%v",err)
+}
+
+// Healthy 检查etcd集群是否可以正常读取
+func (c *EtcdClientWrapper) Healthy(timeout time.Duration) error {
+	ctx,cancel := context.WithTimeout(context.Background(),timeout)
 	defer cancel()
-	_,err = cli.Status(timeoutCtx,endpoints[0])
+	_,err := c.etcdClient.Get(ctx,"health")
 	if err != nil {
-		return nil,fmt.Errorf("cli.Status err:%v",err)
+		return fmt.Errorf("cli.Get err:%v",err)
 	}
-	return &EtcdClientWrapper{etcdClient:cli},nil
+	return nil
 }
 
 func (c *EtcdClientWrapper) Put(key,value string) error {
@@ -72,4 +86,30 @@ func (c *EtcdClientWrapper) PrefixGet(key string) ([]string,error) {
 		values = append(values,string(kv.Value))
 	}
 	return values,nil
-}
\ No newline at end of file
+}
+// GetWithRevision 获取key对应的值以及其修改版本号，key不存在时返回空字符串与版本号0
+func (c *EtcdClientWrapper) GetWithRevision(key string) (string,int64,error) {
+	ctx := context.Background()
+	resp,err := c.etcdClient.Get(ctx,key)
+	if err != nil {
+		return "",0,fmt.Errorf("cli.Get err:%v",err)
+	}
+	if len(resp.Kvs) == 0 {
+		return "",0,nil
+	}
+	return string(resp.Kvs[0].Value),resp.Kvs[0].ModRevision,nil
+}
+
+// CompareAndSwap 仅当key的修改版本号仍为revision时写入value，返回是否写入成功
+//	revision为0表示要求key尚不存在
+func (c *EtcdClientWrapper) CompareAndSwap(key,value string,revision int64) (bool,error) {
+	ctx := context.Background()
+	resp,err := c.etcdClient.Txn(ctx).
+		If(etcd.Compare(etcd.ModRevision(key),"=",revision)).
+		Then(etcd.OpPut(key,value)).
+		Commit()
+	if err != nil {
+		return false,fmt.Errorf("cli.Txn err:%v",err)
+	}
+	return resp.Succeeded,nil
+}
@@ -32,9 +32,14 @@ const (
 	PersistentVolumeClaim ApplyObject = "PersistentVolumeClaim"
 	Hpa                   ApplyObject = "Hpa"
 	Dns                   ApplyObject = "Dns"
+	ResourceQuota         ApplyObject = "ResourceQuota"
+	LimitRange            ApplyObject = "LimitRange"
+	PriorityClass         ApplyObject = "PriorityClass"
+	PodGroup              ApplyObject = "PodGroup"
 )
 
 func applyHandler(cmd *cobra.Command, args []string) {
+	parseDryRunFlag(cmd)
 	if len(args) == 0 {
 		log.ErrorLog("You must specify the type of resource to apply.")
 		os.Exit(1)
@@ -81,6 +86,14 @@ func applyHandler(cmd *cobra.Command, args []string) {
 			ReplicaSetHandler(content)
 		case "Dns":
 			DnsHandler(content)
+		case "ResourceQuota":
+			ResourceQuotaHandler(content)
+		case "LimitRange":
+			LimitRangeHandler(content)
+		case "PriorityClass":
+			PriorityClassHandler(content)
+		case "PodGroup":
+			PodGroupHandler(content)
 		default:
 			log.ErrorLog("The kind specified is not supported.")
 			os.Exit(1)
@@ -106,7 +119,11 @@ func PodHandler(content []byte) {
 	url = strings.Replace(url, config.NameSpaceReplace, pod.Metadata.Namespace, -1)
 	log.DebugLog("Post " + url)
 
-	resp, err := httprequest.PostObjMsg(url, pod)
+	if dryRunStrategy == DryRunClient {
+		DryRunClientDisplay(Pod, pod)
+		return
+	}
+	resp, err := httprequest.PostObjMsg(DryRunURL(url), pod)
 	if err != nil {
 		log.ErrorLog("Could not post the object message." + err.Error())
 		os.Exit(1)
@@ -132,7 +149,11 @@ func ServiceHandler(content []byte) {
 	url = strings.Replace(url, config.NameSpaceReplace, service.Metadata.Namespace, -1)
 	url = strings.Replace(url, config.NameReplace, service.Metadata.Name, -1)
 	log.DebugLog("PUT " + url)
-	resp, err := httprequest.PutObjMsg(url, service)
+	if dryRunStrategy == DryRunClient {
+		DryRunClientDisplay(Service, service)
+		return
+	}
+	resp, err := httprequest.PutObjMsg(DryRunURL(url), service)
 	if err != nil {
 		log.ErrorLog("Could not post the object message." + err.Error())
 		os.Exit(1)
@@ -155,7 +176,11 @@ func PersistentVolumeHandler(content []byte) {
 	url := config.APIServerURL() + config.PersistentVolumeURI
 	url = strings.Replace(url, config.NameReplace, persistentVolume.Metadata.Name, -1)
 	url = strings.Replace(url, config.NameSpaceReplace, persistentVolume.Metadata.Namespace, -1)
-	resp, err := httprequest.PostObjMsg(url, persistentVolume)
+	if dryRunStrategy == DryRunClient {
+		DryRunClientDisplay(PersistentVolume, persistentVolume)
+		return
+	}
+	resp, err := httprequest.PostObjMsg(DryRunURL(url), persistentVolume)
 	if err != nil {
 		log.ErrorLog("Could not post the object message." + err.Error())
 		os.Exit(1)
@@ -174,7 +199,11 @@ func PersistentVolumeClaimHandler(content []byte) {
 	url := config.APIServerURL() + config.PersistentVolumeClaimURI
 	url = strings.Replace(url, config.NameSpaceReplace, pvc.Metadata.Namespace, -1)
 	url = strings.Replace(url, config.NameReplace, pvc.Metadata.Name, -1)
-	resp, err := httprequest.PostObjMsg(url, pvc)
+	if dryRunStrategy == DryRunClient {
+		DryRunClientDisplay(PersistentVolumeClaim, pvc)
+		return
+	}
+	resp, err := httprequest.PostObjMsg(DryRunURL(url), pvc)
 	if err != nil {
 		log.ErrorLog("Could not post the object message." + err.Error())
 		os.Exit(1)
@@ -199,7 +228,11 @@ func HpaHandler(content []byte) {
 	url := config.APIServerURL() + config.HpasURI
 	url = strings.Replace(url, config.NameSpaceReplace, hpa.Metadata.Namespace, -1)
 	log.DebugLog("PUT " + url)
-	resp, err := httprequest.PostObjMsg(url, hpa)
+	if dryRunStrategy == DryRunClient {
+		DryRunClientDisplay(Hpa, hpa)
+		return
+	}
+	resp, err := httprequest.PostObjMsg(DryRunURL(url), hpa)
 	if err != nil {
 		log.ErrorLog("Could not post the object message." + err.Error())
 		os.Exit(1)
@@ -224,7 +257,11 @@ func ReplicaSetHandler(content []byte) {
 	url := config.APIServerURL() + config.ReplicaSetsURI
 	url = strings.Replace(url, config.NameSpaceReplace, replicaSet.Metadata.Namespace, -1)
 	log.DebugLog("PUT " + url)
-	resp, err := httprequest.PostObjMsg(url, replicaSet)
+	if dryRunStrategy == DryRunClient {
+		DryRunClientDisplay(ReplicaSet, replicaSet)
+		return
+	}
+	resp, err := httprequest.PostObjMsg(DryRunURL(url), replicaSet)
 	if err != nil {
 		log.ErrorLog("Could not post the object message." + err.Error())
 		os.Exit(1)
@@ -249,7 +286,11 @@ func DnsHandler(content []byte) {
 	url := config.APIServerURL() + config.DNSsURI
 	url = strings.Replace(url, config.NameSpaceReplace, dns.Metadata.Namespace, -1)
 	log.DebugLog("PUT " + url)
-	resp, err := httprequest.PostObjMsg(url, dns)
+	if dryRunStrategy == DryRunClient {
+		DryRunClientDisplay(Dns, dns)
+		return
+	}
+	resp, err := httprequest.PostObjMsg(DryRunURL(url), dns)
 	if err != nil {
 		log.ErrorLog("Could not post the object message." + err.Error())
 		os.Exit(1)
@@ -257,12 +298,132 @@ func DnsHandler(content []byte) {
 	ApplyResultDisplay(Dns, resp)
 }
 
+func ResourceQuotaHandler(content []byte) {
+	var quota apiObject.ResourceQuota
+	err := translator.ParseApiObjFromYaml(content, &quota)
+	if err != nil {
+		log.ErrorLog("Could not unmarshal the yaml file.")
+		os.Exit(1)
+	}
+	if quota.Metadata.Namespace == "" {
+		quota.Metadata.Namespace = "default"
+	}
+	if quota.Metadata.Name == "" {
+		log.ErrorLog("The name of the resourceQuota is required.")
+		os.Exit(1)
+	}
+	url := config.APIServerURL() + config.ResourceQuotasURI
+	url = strings.Replace(url, config.NameSpaceReplace, quota.Metadata.Namespace, -1)
+	log.DebugLog("POST " + url)
+	if dryRunStrategy == DryRunClient {
+		DryRunClientDisplay(ResourceQuota, quota)
+		return
+	}
+	resp, err := httprequest.PostObjMsg(DryRunURL(url), quota)
+	if err != nil {
+		log.ErrorLog("Could not post the object message." + err.Error())
+		os.Exit(1)
+	}
+	ApplyResultDisplay(ResourceQuota, resp)
+}
+
+func LimitRangeHandler(content []byte) {
+	var limitRange apiObject.LimitRange
+	err := translator.ParseApiObjFromYaml(content, &limitRange)
+	if err != nil {
+		log.ErrorLog("Could not unmarshal the yaml file.")
+		os.Exit(1)
+	}
+	if limitRange.Metadata.Namespace == "" {
+		limitRange.Metadata.Namespace = "default"
+	}
+	if limitRange.Metadata.Name == "" {
+		log.ErrorLog("The name of the limitRange is required.")
+		os.Exit(1)
+	}
+	url := config.APIServerURL() + config.LimitRangesURI
+	url = strings.Replace(url, config.NameSpaceReplace, limitRange.Metadata.Namespace, -1)
+	log.DebugLog("POST " + url)
+	if dryRunStrategy == DryRunClient {
+		DryRunClientDisplay(LimitRange, limitRange)
+		return
+	}
+	resp, err := httprequest.PostObjMsg(DryRunURL(url), limitRange)
+	if err != nil {
+		log.ErrorLog("Could not post the object message." + err.Error())
+		os.Exit(1)
+	}
+	ApplyResultDisplay(LimitRange, resp)
+}
+
+func PriorityClassHandler(content []byte) {
+	var priorityClass apiObject.PriorityClass
+	err := translator.ParseApiObjFromYaml(content, &priorityClass)
+	if err != nil {
+		log.ErrorLog("Could not unmarshal the yaml file.")
+		os.Exit(1)
+	}
+	if priorityClass.Metadata.Name == "" {
+		log.ErrorLog("The name of the priorityClass is required.")
+		os.Exit(1)
+	}
+	// PriorityClass不属于任何命名空间
+	url := config.APIServerURL() + config.PriorityClassesURI
+	log.DebugLog("POST " + url)
+	if dryRunStrategy == DryRunClient {
+		DryRunClientDisplay(PriorityClass, priorityClass)
+		return
+	}
+	resp, err := httprequest.PostObjMsg(DryRunURL(url), priorityClass)
+	if err != nil {
+		log.ErrorLog("Could not post the object message." + err.Error())
+		os.Exit(1)
+	}
+	ApplyResultDisplay(PriorityClass, resp)
+}
+
+func PodGroupHandler(content []byte) {
+	var podGroup apiObject.PodGroup
+	err := translator.ParseApiObjFromYaml(content, &podGroup)
+	if err != nil {
+		log.ErrorLog("Could not unmarshal the yaml file.")
+		os.Exit(1)
+	}
+	if podGroup.Metadata.Namespace == "" {
+		podGroup.Metadata.Namespace = "default"
+	}
+	if podGroup.Metadata.Name == "" {
+		log.ErrorLog("The name of the podGroup is required.")
+		os.Exit(1)
+	}
+	url := config.APIServerURL() + config.PodGroupsURI
+	url = strings.Replace(url, config.NameSpaceReplace, podGroup.Metadata.Namespace, -1)
+	log.DebugLog("POST " + url)
+	if dryRunStrategy == DryRunClient {
+		DryRunClientDisplay(PodGroup, podGroup)
+		return
+	}
+	resp, err := httprequest.PostObjMsg(DryRunURL(url), podGroup)
+	if err != nil {
+		log.ErrorLog("Could not post the object message." + err.Error())
+		os.Exit(1)
+	}
+	ApplyResultDisplay(PodGroup, resp)
+}
+
 func ApplyResultDisplay(kind ApplyObject, resp *http.Response) {
 	if resp.StatusCode == http.StatusCreated {
-		fmt.Printf("%s created\n", kind)
+		fmt.Printf("%s created%s\n", kind, dryRunSuffix())
 	} else if resp.StatusCode == http.StatusOK {
-		fmt.Printf("%s updated\n", kind)
+		fmt.Printf("%s updated%s\n", kind, dryRunSuffix())
 	} else {
-		fmt.Printf("%s failed\n", kind)
+		fmt.Printf("%s failed%s\n", kind, dryRunSuffix())
+	}
+	if dryRunStrategy == DryRunServer {
+		DryRunServerDisplay(resp)
 	}
 }
+
+func init() {
+	addDryRunFlag(applyCmd)
+}
@@ -41,8 +41,13 @@ func CreatePod(name string, image string, namespace string) {
 		},
 	}
 
+	if dryRunStrategy == DryRunClient {
+		DryRunClientDisplay(Pod, pod)
+		return
+	}
 	url := config.APIServerURL() + config.PodsURI
 	url = strings.Replace(url, config.NameSpaceReplace, namespace, -1)
+	url = DryRunURL(url)
 	resp, err := httprequest.PostObjMsg(url, pod)
 	fmt.Println("Post", url)
 	if err != nil {
@@ -54,6 +59,7 @@ func CreatePod(name string, image string, namespace string) {
 
 // createHandler interprets the command line arguments and invokes the CreatePod function.
 func createHandler(cmd *cobra.Command, args []string) {
+	parseDryRunFlag(cmd)
 	if len(args) != 2 {
 		fmt.Println("Error: You must specify a name for the pod.")
 		os.Exit(1)
@@ -86,4 +92,5 @@ func init() {
 	rootCmd.AddCommand(createCmd)
 	createCmd.Flags().String("image", "", "Specify the image of the pod")
 	createCmd.Flags().String("namespace", "", "Specify the namespace of the pod")
+	addDryRunFlag(createCmd)
 }
@@ -25,6 +25,7 @@ func deleteHandler(cmd *cobra.Command, args []string) {
 		fmt.Println("Usage: delete <namespace> <resource type> <resource name>")
 		os.Exit(1)
 	}
+	parseDryRunFlag(cmd)
 	nameSpace := args[0]
 	resourceType := args[1]
 	resourceName := args[2]
@@ -39,13 +40,26 @@ func deleteHandler(cmd *cobra.Command, args []string) {
 		url = config.APIServerURL() + config.ReplicaSetURI
 	case "Dns":
 		url = config.APIServerURL() + config.DNSURI
+	case "ResourceQuota":
+		url = config.APIServerURL() + config.ResourceQuotaURI
+	case "LimitRange":
+		url = config.APIServerURL() + config.LimitRangeURI
+	case "PriorityClass":
+		// PriorityClass不属于任何命名空间，忽略namespace参数
+		url = config.APIServerURL() + config.PriorityClassURI
+	case "PodGroup":
+		url = config.APIServerURL() + config.PodGroupURI
 	default:
-		fmt.Println("Supported resource types: Pod, Service, ReplicaSet, Dns")
+		fmt.Println("Supported resource types: Pod, Service, ReplicaSet, Dns, ResourceQuota, LimitRange, PriorityClass, PodGroup")
 	}
 
 	url = strings.Replace(url, config.NameSpaceReplace, nameSpace, -1)
 	url = strings.Replace(url, config.NameReplace, resourceName, -1)
-	resp, err := httprequest.DelMsg(url, nil)
+	if dryRunStrategy == DryRunClient {
+		fmt.Println(resourceName + " deleted" + dryRunSuffix())
+		return
+	}
+	resp, err := httprequest.DelMsg(DryRunURL(url), nil)
 	if err != nil {
 		fmt.Println("Error: Could not delete the object.")
 		os.Exit(1)
@@ -56,8 +70,15 @@ func deleteHandler(cmd *cobra.Command, args []string) {
 
 func DeleteResultDisplay(name string, resp *http.Response) {
 	if resp.StatusCode == 200 {
-		fmt.Println(name + " deleted successfully.")
+		fmt.Println(name + " deleted successfully" + dryRunSuffix() + ".")
+		if dryRunStrategy == DryRunServer {
+			DryRunServerDisplay(resp)
+		}
 	} else {
 		fmt.Println("Error: Could not delete the " + name + ".")
 	}
 }
+
+func init() {
+	addDryRunFlag(deletedCmd)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"minik8s/pkg/config"
+)
+
+// DryRunStrategy 表示 --dry-run 参数的取值
+//
+//	none: 正常发送请求
+//	client: 只在本地打印将要发送的对象，不发送请求
+//	server: 携带 ?dryRun=All 发送请求，由apiServer完成校验并返回将要写入的对象，但不产生副作用
+type DryRunStrategy string
+
+const (
+	DryRunNone   DryRunStrategy = "none"
+	DryRunClient DryRunStrategy = "client"
+	DryRunServer DryRunStrategy = "server"
+)
+
+// dryRunStrategy 当前命令使用的dryRun策略
+var dryRunStrategy = DryRunNone
+
+// addDryRunFlag 为命令添加 --dry-run 参数
+func addDryRunFlag(cmd *cobra.Command) {
+	cmd.Flags().String("dry-run", string(DryRunNone), `Must be "none", "server", or "client". If client strategy, only print the object that would be sent. If server strategy, submit server-side request without persisting the resource.`)
+}
+
+// parseDryRunFlag 解析 --dry-run 参数并设置当前命令的dryRun策略
+func parseDryRunFlag(cmd *cobra.Command) {
+	value, _ := cmd.Flags().GetString("dry-run")
+	switch DryRunStrategy(value) {
+	case "", DryRunNone:
+		dryRunStrategy = DryRunNone
+	case DryRunClient:
+		dryRunStrategy = DryRunClient
+	case DryRunServer:
+		dryRunStrategy = DryRunServer
+	default:
+		fmt.Println(`Error: Invalid dry-run value (` + value + `). Must be "none", "server", or "client".`)
+		os.Exit(1)
+	}
+}
+
+// DryRunURL 在server模式下为请求的url追加dryRun参数
+func DryRunURL(url string) string {
+	if dryRunStrategy != DryRunServer {
+		return url
+	}
+	return url + "?" + config.DryRunQuery + "=" + config.DryRunAll
+}
+
+// dryRunSuffix 返回结果展示时追加的dryRun说明
+func dryRunSuffix() string {
+	switch dryRunStrategy {
+	case DryRunClient:
+		return " (dry run)"
+	case DryRunServer:
+		return " (server dry run)"
+	default:
+		return ""
+	}
+}
+
+// DryRunClientDisplay 在client模式下打印将要发送给apiServer的对象
+func DryRunClientDisplay(kind ApplyObject, obj interface{}) {
+	fmt.Printf("%s created%s\n", kind, dryRunSuffix())
+	printYaml(obj)
+}
+
+// DryRunServerDisplay 在server模式下打印apiServer返回的将要写入的对象
+func DryRunServerDisplay(resp *http.Response) {
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return
+	}
+	if data, ok := body["data"]; ok {
+		printYaml(data)
+	} else if errMsg, ok := body["error"]; ok {
+		fmt.Println("Error:", errMsg)
+	}
+}
+
+func printYaml(obj interface{}) {
+	// 先转换为json再转换为yaml，从而保留json标签中的字段名
+	objJson, err := json.Marshal(obj)
+	if err != nil {
+		fmt.Println("Error: Could not marshal the object.")
+		return
+	}
+	var generic interface{}
+	if err = json.Unmarshal(objJson, &generic); err != nil {
+		fmt.Println("Error: Could not marshal the object.")
+		return
+	}
+	out, err := yaml.Marshal(generic)
+	if err != nil {
+		fmt.Println("Error: Could not marshal the object.")
+		return
+	}
+	fmt.Print(string(out))
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"minik8s/pkg/config"
+	"minik8s/tools/remotecommand"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <pod> [-c container] [-i] [-t] -- <command> [args...]",
+	Short: "Execute a command in a container",
+	Long: `Execute a command in a container. Use -i to pass stdin to the command and -t to allocate a terminal,
+for example "kubectl exec -it web -- sh". kubectl exits with the exit code of the remote command.`,
+	Args: cobra.MinimumNArgs(2),
+	Run:  execHandler,
+}
+
+var attachCmd = &cobra.Command{
+	Use:   "attach <pod> [-c container] [-i] [-t]",
+	Short: "Attach to a running container",
+	Long:  "Attach to the main process of a running container to view its output or interact with it.",
+	Args:  cobra.ExactArgs(1),
+	Run:   attachHandler,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{execCmd, attachCmd} {
+		c.Flags().StringP("namespace", "n", "", "Namespace")
+		c.Flags().StringP("container", "c", "", "Container name. If omitted, the pod must have only one container")
+		c.Flags().BoolP("stdin", "i", false, "Pass stdin to the container")
+		c.Flags().BoolP("tty", "t", false, "Stdin is a TTY")
+		rootCmd.AddCommand(c)
+	}
+}
+
+func execHandler(cmd *cobra.Command, args []string) {
+	// 命令需要写在 -- 之后，避免命令的参数被当作kubectl的参数解析
+	if cmd.ArgsLenAtDash() != 1 {
+		fmt.Println("Error: expected 'exec <pod> -- <command> [args...]'")
+		os.Exit(1)
+	}
+	query := streamQuery(cmd)
+	for _, arg := range args[1:] {
+		query.Add(config.ExecCommandQuery, arg)
+	}
+	streamPod(cmd, args[0], config.PodExecStreamURI, query)
+}
+
+func attachHandler(cmd *cobra.Command, args []string) {
+	streamPod(cmd, args[0], config.PodAttachURI, streamQuery(cmd))
+}
+
+// streamQuery 根据命令行参数生成exec与attach共用的查询参数
+func streamQuery(cmd *cobra.Command) url.Values {
+	container, _ := cmd.Flags().GetString("container")
+	stdin, _ := cmd.Flags().GetBool("stdin")
+	tty, _ := cmd.Flags().GetBool("tty")
+	query := url.Values{}
+	if container != "" {
+		query.Set(config.ExecContainerQuery, container)
+	}
+	if stdin {
+		query.Set(config.ExecStdinQuery, "true")
+	}
+	if tty {
+		query.Set(config.ExecTTYQuery, "true")
+	}
+	return query
+}
+
+// streamPod 连接Pod的exec或attach子资源，并以远程命令的退出码退出
+func streamPod(cmd *cobra.Command, name string, uri string, query url.Values) {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	if namespace == "" {
+		namespace = "default"
+	}
+	err := streamPodCommand(namespace, name, uri, query)
+	var exitErr *remotecommand.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.Code)
+	}
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+}
+
+// streamPodCommand 通过WebSocket连接apiServer，在命令结束前转发本地的标准输入输出与终端大小
+func streamPodCommand(namespace string, name string, uri string, query url.Values) error {
+	stdin := query.Get(config.ExecStdinQuery) == "true"
+	tty := query.Get(config.ExecTTYQuery) == "true"
+	// 只有标准输入是终端时才能分配终端
+	fd := int(os.Stdin.Fd())
+	if tty && (!stdin || !term.IsTerminal(fd)) {
+		fmt.Fprintln(os.Stderr, "Unable to use a TTY - input is not a terminal or the right kind of file")
+		tty = false
+		query.Del(config.ExecTTYQuery)
+	}
+
+	streamURL := config.APIServerURL() + uri
+	streamURL = strings.Replace(streamURL, config.NameSpaceReplace, namespace, -1)
+	streamURL = strings.Replace(streamURL, config.NameReplace, name, -1)
+	conn, err := remotecommand.Dial(streamURL + "?" + query.Encode())
+	if err != nil {
+		return err
+	}
+
+	opts := remotecommand.StreamOptions{Stdout: os.Stdout}
+	if stdin {
+		opts.Stdin = os.Stdin
+	}
+	if tty {
+		// 终端进入原始模式，按键由远程的终端处理；使用终端时stderr合并到stdout
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			_ = conn.Close()
+			return err
+		}
+		defer func() { _ = term.Restore(fd, state) }()
+		opts.Resize = monitorTerminalSize(int(os.Stdout.Fd()))
+	} else {
+		opts.Stderr = os.Stderr
+	}
+	return remotecommand.Stream(conn, opts)
+}
+
+// monitorTerminalSize 发送当前的终端大小，并在收到SIGWINCH后发送新的大小
+func monitorTerminalSize(fd int) <-chan remotecommand.TerminalSize {
+	sizes := make(chan remotecommand.TerminalSize, 1)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH)
+	go func() {
+		for {
+			if width, height, err := term.GetSize(fd); err == nil {
+				sizes <- remotecommand.TerminalSize{Width: uint16(width), Height: uint16(height)}
+			}
+			<-signals
+		}
+	}()
+	return sizes
+}
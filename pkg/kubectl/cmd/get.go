@@ -58,6 +58,12 @@ func getHandler(cmd *cobra.Command, args []string) {
 			getReplicaSetHandler(namespace)
 		case apiObject.HpaType:
 			getHpaHandler(namespace)
+		case apiObject.ResourceQuotaType:
+			getResourceQuotaHandler(namespace)
+		case apiObject.PriorityClassType:
+			getPriorityClassHandler()
+		case apiObject.PodGroupType:
+			getPodGroupHandler(namespace)
 		}
 	}
 }
@@ -92,15 +98,23 @@ func printNodesResult(nodes []apiObject.Node) {
 func printNodeResult(node apiObject.Node, writer table.Writer) {
 	// 根据状态为Status单元格选择颜色
 	var statusColor text.Colors
-	switch node.Status.Conditions[0].Type {
-	case "Ready":
+	status := "Ready"
+	if node.IsReady() {
 		statusColor = text.Colors{text.FgGreen}
-	default:
+	} else {
+		status = "NotReady"
 		statusColor = text.Colors{text.FgRed}
 	}
+	// 被cordon的节点不再调度新的Pod
+	if node.Spec.Unschedulable {
+		status += ",SchedulingDisabled"
+		if node.IsReady() {
+			statusColor = text.Colors{text.FgYellow}
+		}
+	}
 
 	// 应用颜色到Status
-	coloredStatus := statusColor.Sprint(node.Status.Conditions[0].Type)
+	coloredStatus := statusColor.Sprint(status)
 
 	var roleColor text.Colors
 	switch node.Metadata.Labels["kubernetes.io/role"] {
@@ -213,7 +227,7 @@ func getPodHandler(namespace string) {
 func printPodsResult(pods []apiObject.Pod) {
 	writer := table.NewWriter()
 	writer.SetOutputMirror(os.Stdout)
-	writer.AppendHeader(table.Row{"Kind", "Namespace", "Name", "Status", "Node", "IP"})
+	writer.AppendHeader(table.Row{"Kind", "Namespace", "Name", "Status", "Ready", "Restarts", "Node", "IP"})
 	for _, pod := range pods {
 		printPodResult(pod, writer)
 	}
@@ -221,27 +235,42 @@ func printPodsResult(pods []apiObject.Pod) {
 }
 
 func printPodResult(pod apiObject.Pod, writer table.Writer) {
+	// 有容器处于CrashLoopBackOff或因内存不足被终止时显示容器的原因，否则显示Pod所处的阶段
+	status := string(pod.Status.Phase)
+	var restarts int32
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		restarts += containerStatus.RestartCount
+		if waiting := containerStatus.State.Waiting; waiting != nil && waiting.Reason == apiObject.ContainerReasonCrashLoopBackOff {
+			status = waiting.Reason
+		} else if terminated := containerStatus.State.Terminated; terminated != nil && terminated.Reason == apiObject.ContainerReasonOOMKilled &&
+			status != apiObject.ContainerReasonCrashLoopBackOff {
+			status = terminated.Reason
+		}
+	}
+
 	// 根据状态为Status单元格选择颜色
 	var statusColor text.Colors
-	switch pod.Status.Phase {
+	switch status {
 	case "Running":
 		statusColor = text.Colors{text.FgGreen}
 	case "Pending":
 		statusColor = text.Colors{text.FgYellow}
-	case "Failed":
+	case "Failed", apiObject.ContainerReasonCrashLoopBackOff, apiObject.ContainerReasonOOMKilled:
 		statusColor = text.Colors{text.FgRed}
 	default:
 		statusColor = text.Colors{text.FgWhite}
 	}
 
 	// 应用颜色到Status
-	coloredStatus := statusColor.Sprint(pod.Status.Phase)
+	coloredStatus := statusColor.Sprint(status)
 
 	writer.AppendRow(table.Row{
 		"Pod",
 		pod.Metadata.Namespace,
 		pod.Metadata.Name,
 		coloredStatus, // 使用包装了颜色的status
+		pod.Status.IsReady(),
+		restarts,
 		pod.Spec.NodeName,
 		pod.Status.PodIP,
 	})
@@ -369,3 +398,127 @@ func printHpaResult(hpa apiObject.HPA, writer table.Writer) {
 		hpa.Status.CurrentReplicas,
 	})
 }
+
+func getResourceQuotaHandler(namespace string) {
+	url := config.APIServerURL() + config.ResourceQuotasURI
+	url = strings.Replace(url, config.NameSpaceReplace, namespace, -1)
+	var quotas []apiObject.ResourceQuota
+	resp, err := http.Get(url)
+	if err != nil {
+		log.ErrorLog("GetResourceQuota: " + err.Error())
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		log.ErrorLog("GetResourceQuota: " + resp.Status)
+		os.Exit(1)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&quotas)
+	if err != nil {
+		log.ErrorLog("GetResourceQuota: " + err.Error())
+		os.Exit(1)
+	}
+	printResourceQuotasResult(quotas)
+}
+
+func printResourceQuotasResult(quotas []apiObject.ResourceQuota) {
+	writer := table.NewWriter()
+	writer.SetOutputMirror(os.Stdout)
+	writer.AppendHeader(table.Row{"Kind", "Namespace", "Name", "Resource", "Used", "Hard"})
+	for _, quota := range quotas {
+		printResourceQuotaResult(quota, writer)
+	}
+	writer.Render()
+}
+
+func printResourceQuotaResult(quota apiObject.ResourceQuota, writer table.Writer) {
+	for resource, hard := range quota.Spec.Hard {
+		writer.AppendRow(table.Row{
+			"ResourceQuota",
+			quota.Metadata.Namespace,
+			quota.Metadata.Name,
+			resource,
+			quota.Status.Used[resource],
+			hard,
+		})
+	}
+}
+
+func getPriorityClassHandler() {
+	url := config.APIServerURL() + config.PriorityClassesURI
+	var priorityClasses []apiObject.PriorityClass
+	resp, err := http.Get(url)
+	if err != nil {
+		log.ErrorLog("GetPriorityClass: " + err.Error())
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		log.ErrorLog("GetPriorityClass: " + resp.Status)
+		os.Exit(1)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&priorityClasses)
+	if err != nil {
+		log.ErrorLog("GetPriorityClass: " + err.Error())
+		os.Exit(1)
+	}
+	printPriorityClassesResult(priorityClasses)
+}
+
+func printPriorityClassesResult(priorityClasses []apiObject.PriorityClass) {
+	writer := table.NewWriter()
+	writer.SetOutputMirror(os.Stdout)
+	writer.AppendHeader(table.Row{"Kind", "Name", "Value", "Global-Default", "Preemption-Policy"})
+	for _, priorityClass := range priorityClasses {
+		writer.AppendRow(table.Row{
+			"PriorityClass",
+			priorityClass.Metadata.Name,
+			priorityClass.Value,
+			priorityClass.GlobalDefault,
+			priorityClass.PreemptionPolicy,
+		})
+	}
+	writer.Render()
+}
+
+func getPodGroupHandler(namespace string) {
+	url := config.APIServerURL() + config.PodGroupsURI
+	url = strings.Replace(url, config.NameSpaceReplace, namespace, -1)
+	var podGroups []apiObject.PodGroup
+	resp, err := http.Get(url)
+	if err != nil {
+		log.ErrorLog("GetPodGroup: " + err.Error())
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		log.ErrorLog("GetPodGroup: " + resp.Status)
+		os.Exit(1)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&podGroups)
+	if err != nil {
+		log.ErrorLog("GetPodGroup: " + err.Error())
+		os.Exit(1)
+	}
+	printPodGroupsResult(podGroups)
+}
+
+func printPodGroupsResult(podGroups []apiObject.PodGroup) {
+	writer := table.NewWriter()
+	writer.SetOutputMirror(os.Stdout)
+	writer.AppendHeader(table.Row{"Kind", "Namespace", "Name", "Min-Member", "Schedule-Timeout"})
+	for _, podGroup := range podGroups {
+		timeout := "<default>"
+		if podGroup.Spec.ScheduleTimeoutSeconds != nil {
+			timeout = fmt.Sprintf("%ds", *podGroup.Spec.ScheduleTimeoutSeconds)
+		}
+		writer.AppendRow(table.Row{
+			"PodGroup",
+			podGroup.Metadata.Namespace,
+			podGroup.Metadata.Name,
+			podGroup.Spec.MinMember,
+			timeout,
+		})
+	}
+	writer.Render()
+}
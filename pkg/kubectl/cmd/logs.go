@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"minik8s/pkg/config"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs <pod>",
+	Short: "Print the logs for a container in a pod",
+	Long: `Print the logs for a container in a pod. If the pod has only one container, the container name is optional.
+Use -f to keep streaming new logs until the container stops, and -p to print the logs of the previous
+instance of a restarted container.`,
+	Args: cobra.ExactArgs(1),
+	Run:  logsHandler,
+}
+
+func init() {
+	logsCmd.Flags().StringP("namespace", "n", "", "Namespace")
+	logsCmd.Flags().StringP("container", "c", "", "Print the logs of this container")
+	logsCmd.Flags().BoolP("follow", "f", false, "Specify if the logs should be streamed")
+	logsCmd.Flags().BoolP("previous", "p", false, "Print the logs for the previous instance of the container in a pod if it exists")
+	logsCmd.Flags().Int64("tail", -1, "Lines of recent log file to display. Defaults to -1, showing all log lines")
+	logsCmd.Flags().Duration("since", 0, "Only return logs newer than a relative duration like 5s, 2m, or 3h. Defaults to all logs")
+	logsCmd.Flags().Bool("timestamps", false, "Include timestamps on each line in the log output")
+	rootCmd.AddCommand(logsCmd)
+}
+
+func logsHandler(cmd *cobra.Command, args []string) {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	if namespace == "" {
+		namespace = "default"
+	}
+	container, _ := cmd.Flags().GetString("container")
+	follow, _ := cmd.Flags().GetBool("follow")
+	previous, _ := cmd.Flags().GetBool("previous")
+	tail, _ := cmd.Flags().GetInt64("tail")
+	since, _ := cmd.Flags().GetDuration("since")
+	timestamps, _ := cmd.Flags().GetBool("timestamps")
+
+	query := url.Values{}
+	if container != "" {
+		query.Set(config.LogContainerQuery, container)
+	}
+	if follow {
+		query.Set(config.LogFollowQuery, "true")
+	}
+	if previous {
+		query.Set(config.LogPreviousQuery, "true")
+	}
+	if tail >= 0 {
+		query.Set(config.LogTailLinesQuery, strconv.FormatInt(tail, 10))
+	}
+	if since > 0 {
+		// 不足一秒的部分向上取整
+		query.Set(config.LogSinceSecondsQuery, strconv.FormatInt(int64((since+time.Second-1)/time.Second), 10))
+	}
+	if timestamps {
+		query.Set(config.LogTimestampsQuery, "true")
+	}
+
+	if err := streamPodLogs(namespace, args[0], query, os.Stdout); err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+}
+
+// streamPodLogs 请求Pod的log子资源，并将日志原样写入out，follow时直到apiServer结束响应才返回
+func streamPodLogs(namespace string, name string, query url.Values, out io.Writer) error {
+	logURL := config.APIServerURL() + config.PodLogURI
+	logURL = strings.Replace(logURL, config.NameSpaceReplace, namespace, -1)
+	logURL = strings.Replace(logURL, config.NameReplace, name, -1)
+	if encoded := query.Encode(); encoded != "" {
+		logURL += "?" + encoded
+	}
+	resp, err := http.Get(logURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&body) == nil && body.Error != "" {
+			return errors.New(body.Error)
+		}
+		return errors.New(resp.Status)
+	}
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
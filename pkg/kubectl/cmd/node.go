@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/config"
+
+	httprequest "minik8s/tools/httpRequest"
+	"minik8s/tools/netRequest"
+)
+
+// 更新节点时与其他请求冲突的最大重试次数
+const maxNodeUpdateRetries = 5
+
+var cordonCmd = &cobra.Command{
+	Use:   "cordon <node>",
+	Short: "Mark a node as unschedulable",
+	Long:  "Mark a node as unschedulable. The pods already running on the node are not affected.",
+	Args:  cobra.ExactArgs(1),
+	Run:   cordonHandler,
+}
+
+var uncordonCmd = &cobra.Command{
+	Use:   "uncordon <node>",
+	Short: "Mark a node as schedulable",
+	Long:  "Mark a node as schedulable again.",
+	Args:  cobra.ExactArgs(1),
+	Run:   uncordonHandler,
+}
+
+var drainCmd = &cobra.Command{
+	Use:   "drain <node>",
+	Short: "Drain a node in preparation for maintenance",
+	Long: `Mark the node as unschedulable, then evict all the pods on it and wait until they are gone.
+Pods managed by a ReplicaSet are recreated on other nodes. Standalone pods and serverless function
+instances are not recreated, so drain refuses to evict them unless --force is given.
+Serverless function instances can be left on the node with --ignore-serverless.`,
+	Args: cobra.ExactArgs(1),
+	Run:  drainHandler,
+}
+
+func init() {
+	addDryRunFlag(cordonCmd)
+	addDryRunFlag(uncordonCmd)
+	addDryRunFlag(drainCmd)
+	drainCmd.Flags().Bool("force", false, "Evict pods that are not managed by a ReplicaSet, including serverless function instances")
+	drainCmd.Flags().Bool("ignore-serverless", false, "Leave serverless function instances on the node")
+	drainCmd.Flags().Int64("grace-period", -1, "Seconds given to each pod to terminate gracefully. If negative, the pod's terminationGracePeriodSeconds is used")
+	drainCmd.Flags().Duration("timeout", 0, "The length of time to wait for the evicted pods to be gone, zero means infinite")
+	rootCmd.AddCommand(cordonCmd)
+	rootCmd.AddCommand(uncordonCmd)
+	rootCmd.AddCommand(drainCmd)
+}
+
+func cordonHandler(cmd *cobra.Command, args []string) {
+	parseDryRunFlag(cmd)
+	if err := setNodeUnschedulable(args[0], true); err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+	fmt.Println("node/" + args[0] + " cordoned" + dryRunSuffix())
+}
+
+func uncordonHandler(cmd *cobra.Command, args []string) {
+	parseDryRunFlag(cmd)
+	if err := setNodeUnschedulable(args[0], false); err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+	fmt.Println("node/" + args[0] + " uncordoned" + dryRunSuffix())
+}
+
+func drainHandler(cmd *cobra.Command, args []string) {
+	parseDryRunFlag(cmd)
+	nodeName := args[0]
+	force, _ := cmd.Flags().GetBool("force")
+	ignoreServerless, _ := cmd.Flags().GetBool("ignore-serverless")
+	gracePeriod, _ := cmd.Flags().GetInt64("grace-period")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	if err := setNodeUnschedulable(nodeName, true); err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+	fmt.Println("node/" + nodeName + " cordoned" + dryRunSuffix())
+
+	pods, err := getNodePods(nodeName)
+	if err != nil {
+		fmt.Println("Error: Could not get the pods on the node: " + err.Error())
+		os.Exit(1)
+	}
+	evict, skipped, err := filterDrainPods(pods, force, ignoreServerless)
+	if err != nil {
+		// 与kubernetes相同，节点保持cordon状态，处理完这些Pod后可以再次执行drain
+		fmt.Println("Error: unable to drain node " + nodeName + ": " + err.Error())
+		os.Exit(1)
+	}
+	for _, pod := range skipped {
+		fmt.Println("Warning: ignoring serverless function instance " + pod.Metadata.Namespace + "/" + pod.Metadata.Name)
+	}
+
+	// 并发驱逐，每个Pod的删除请求都会等待容器优雅退出
+	var wg sync.WaitGroup
+	errs := make([]error, len(evict))
+	for i := range evict {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = evictPod(&evict[i], gracePeriod)
+		}(i)
+	}
+	wg.Wait()
+	failed := false
+	for i, pod := range evict {
+		if errs[i] != nil {
+			fmt.Println("Error: Could not evict pod " + pod.Metadata.Namespace + "/" + pod.Metadata.Name + ": " + errs[i].Error())
+			failed = true
+			continue
+		}
+		fmt.Println("pod/" + pod.Metadata.Name + " evicted" + dryRunSuffix())
+	}
+	if failed {
+		os.Exit(1)
+	}
+	if dryRunStrategy != DryRunNone {
+		fmt.Println("node/" + nodeName + " drained" + dryRunSuffix())
+		return
+	}
+
+	if err = waitForPodsGone(nodeName, evict, timeout); err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+	fmt.Println("node/" + nodeName + " drained")
+}
+
+// setNodeUnschedulable 设置节点的spec.unschedulable，节点已经处于目标状态时不发送更新请求
+//
+//	只发送包含spec.unschedulable的部分更新，并通过If-Match要求节点在读取之后没有被修改，冲突时重新读取节点后重试
+func setNodeUnschedulable(nodeName string, unschedulable bool) error {
+	nodeURL := strings.Replace(config.APIServerURL()+config.NodeURI, config.NameReplace, nodeName, -1)
+	for i := 0; ; i++ {
+		node, revision, err := getNodeWithRevision(nodeURL, nodeName)
+		if err != nil {
+			return err
+		}
+		if node.Spec.Unschedulable == unschedulable || dryRunStrategy == DryRunClient {
+			return nil
+		}
+
+		header := http.Header{}
+		header.Set("If-Match", revision)
+		patch := map[string]interface{}{
+			"spec": map[string]interface{}{"unschedulable": unschedulable},
+		}
+		code, _, err := netRequest.PatchRequestByTarget(DryRunURL(nodeURL), patch, header)
+		if err != nil {
+			return err
+		}
+		if code == http.StatusConflict && i < maxNodeUpdateRetries {
+			continue
+		}
+		if code != http.StatusOK {
+			return errors.New("could not update node " + nodeName + ": " + http.StatusText(code))
+		}
+		return nil
+	}
+}
+
+// getNodeWithRevision 获取节点及其修改版本号
+func getNodeWithRevision(nodeURL string, nodeName string) (*apiObject.Node, string, error) {
+	resp, err := http.Get(nodeURL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", errors.New("node " + nodeName + " not found")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", errors.New("could not get node " + nodeName + ": " + resp.Status)
+	}
+	var body struct {
+		Data apiObject.Node `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, "", err
+	}
+	return &body.Data, resp.Header.Get("ETag"), nil
+}
+
+// getNodePods 获取绑定到指定节点上的所有Pod
+func getNodePods(nodeName string) ([]apiObject.Pod, error) {
+	var pods []apiObject.Pod
+	err := getJSON(config.APIServerURL()+config.PodsGlobalURI+"?"+config.NodeNameQuery+"="+url.QueryEscape(nodeName), &pods)
+	return pods, err
+}
+
+// filterDrainPods 将节点上的Pod分为需要驱逐的Pod与保留在节点上的Serverless实例
+//
+//	由ReplicaSet管理的Pod总是被驱逐，被删除后会在其他节点上重建
+//	独立的Pod与Serverless实例被删除后不会重建，只有指定force时才驱逐，指定ignoreServerless时Serverless实例保留在节点上
+//	存在不能驱逐的Pod时返回错误，不驱逐任何Pod
+func filterDrainPods(pods []apiObject.Pod, force bool, ignoreServerless bool) ([]apiObject.Pod, []apiObject.Pod, error) {
+	var evict, skipped []apiObject.Pod
+	var standalone, serverless []string
+	for _, pod := range pods {
+		name := pod.Metadata.Namespace + "/" + pod.Metadata.Name
+		switch {
+		case pod.Metadata.Labels[apiObject.ServerlessFunctionLabel] != "":
+			if ignoreServerless {
+				skipped = append(skipped, pod)
+				continue
+			}
+			if !force {
+				serverless = append(serverless, name)
+				continue
+			}
+		case pod.Metadata.Labels[apiObject.PodReplicaUUID] == "":
+			if !force {
+				standalone = append(standalone, name)
+				continue
+			}
+		}
+		evict = append(evict, pod)
+	}
+
+	var messages []string
+	if len(standalone) > 0 {
+		messages = append(messages, "cannot evict pods not managed by a ReplicaSet (use --force to override): "+strings.Join(standalone, ", "))
+	}
+	if len(serverless) > 0 {
+		messages = append(messages, "cannot evict serverless function instances (use --ignore-serverless to skip them or --force to evict them): "+strings.Join(serverless, ", "))
+	}
+	if len(messages) > 0 {
+		return nil, nil, errors.New(strings.Join(messages, "; "))
+	}
+	return evict, skipped, nil
+}
+
+// evictPod 为Pod添加DisruptionTarget状况说明删除的原因，然后删除Pod
+//
+//	gracePeriod为负数时使用Pod自身的terminationGracePeriodSeconds
+func evictPod(pod *apiObject.Pod, gracePeriod int64) error {
+	podURL := config.APIServerURL() + config.PodURI
+	podURL = strings.Replace(podURL, config.NameSpaceReplace, pod.Metadata.Namespace, -1)
+	podURL = strings.Replace(podURL, config.NameReplace, pod.Metadata.Name, -1)
+	switch dryRunStrategy {
+	case DryRunClient:
+		return nil
+	case DryRunServer:
+		return checkEvictResponse(httprequest.DelMsg(DryRunURL(podURL), nil))
+	}
+
+	pod.Status.SetCondition(apiObject.PodCondition{
+		Type:    apiObject.PodDisruptionTarget,
+		Status:  apiObject.ConditionTrue,
+		Reason:  apiObject.PodReasonEvictionByDrain,
+		Message: "evicted because node " + pod.Spec.NodeName + " is being drained",
+	})
+	statusURL := config.APIServerURL() + config.PodStatusURI
+	statusURL = strings.Replace(statusURL, config.NameSpaceReplace, pod.Metadata.Namespace, -1)
+	statusURL = strings.Replace(statusURL, config.NameReplace, pod.Metadata.Name, -1)
+	if err := checkEvictResponse(httprequest.PutObjMsg(statusURL, pod.Status)); err != nil {
+		return err
+	}
+
+	if gracePeriod >= 0 {
+		podURL += "?" + config.GracePeriodQuery + "=" + fmt.Sprint(gracePeriod)
+	}
+	return checkEvictResponse(httprequest.DelMsg(podURL, nil))
+}
+
+// checkEvictResponse Pod已经不存在时视为驱逐成功
+func checkEvictResponse(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return errors.New(resp.Status)
+	}
+	return nil
+}
+
+// waitForPodsGone 等待被驱逐的Pod从节点上消失，timeout为0时一直等待
+//
+//	按照UUID判断，被ReplicaSet以相同名称重建的Pod不影响等待的结果
+func waitForPodsGone(nodeName string, evicted []apiObject.Pod, timeout time.Duration) error {
+	uuids := make(map[string]bool)
+	for _, pod := range evicted {
+		uuids[pod.Metadata.UUID] = true
+	}
+	start := time.Now()
+	for {
+		pods, err := getNodePods(nodeName)
+		if err != nil {
+			return err
+		}
+		var remaining []string
+		for _, pod := range pods {
+			if uuids[pod.Metadata.UUID] {
+				remaining = append(remaining, pod.Metadata.Namespace+"/"+pod.Metadata.Name)
+			}
+		}
+		if len(remaining) == 0 {
+			return nil
+		}
+		if timeout > 0 && time.Since(start) >= timeout {
+			return fmt.Errorf("drain did not complete within %s, remaining pods: %s", timeout, strings.Join(remaining, ", "))
+		}
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+)
+
+func TestFilterDrainPods(t *testing.T) {
+	replica := apiObject.Pod{Metadata: apiObject.ObjectMeta{Namespace: "default", Name: "web-0", Labels: map[string]string{apiObject.PodReplicaUUID: "rs-uuid"}}}
+	standalone := apiObject.Pod{Metadata: apiObject.ObjectMeta{Namespace: "default", Name: "standalone"}}
+	function := apiObject.Pod{Metadata: apiObject.ObjectMeta{Namespace: "serverless", Name: "add-0", Labels: map[string]string{apiObject.ServerlessFunctionLabel: "add"}}}
+	pods := []apiObject.Pod{replica, standalone, function}
+
+	// 存在独立的Pod时需要force才能驱逐
+	_, _, err := filterDrainPods(pods, false, true)
+	assert.Equal(t, "cannot evict pods not managed by a ReplicaSet (use --force to override): default/standalone", err.Error())
+
+	// 忽略Serverless实例时跳过它们
+	evict, skipped, err := filterDrainPods(pods, true, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []apiObject.Pod{replica, standalone}, evict)
+	assert.Equal(t, []apiObject.Pod{function}, skipped)
+}
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/config"
+	"minik8s/pkg/kubectl/translator"
+	"minik8s/pkg/scheduler/framework"
+	"minik8s/pkg/scheduler/plugins"
+	"minik8s/tools/log"
+
+	scheduler "minik8s/pkg/scheduler/app"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Scheduling related commands",
+	Long:  "Scheduling related commands",
+}
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate -f [file]",
+	Short: "Simulate where the pods in a Pod or ReplicaSet file would be scheduled",
+	Long: `Snapshot the nodes and pods of the cluster and run the scheduler framework in memory.
+Print the node chosen for each pod, the filter reasons of the rejected nodes and the score of each plugin.
+The cluster is not modified.`,
+	Run: simulateHandler,
+}
+
+func init() {
+	simulateCmd.Flags().StringP("filename", "f", "", "The Pod or ReplicaSet file to simulate")
+	scheduleCmd.AddCommand(simulateCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
+
+func simulateHandler(cmd *cobra.Command, _ []string) {
+	filename, _ := cmd.Flags().GetString("filename")
+	if filename == "" {
+		log.ErrorLog("You must specify the file to simulate with -f.")
+		os.Exit(1)
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		log.ErrorLog("Could not read the file specified.")
+		os.Exit(1)
+	}
+	pods, err := podsFromFile(content)
+	if err != nil {
+		log.ErrorLog(err.Error())
+		os.Exit(1)
+	}
+	if err = resolvePriorities(pods); err != nil {
+		log.ErrorLog("Could not resolve the priority of the pods: " + err.Error())
+		os.Exit(1)
+	}
+
+	var nodes []apiObject.Node
+	if err = getJSON(config.APIServerURL()+config.NodesURI, &nodes); err != nil {
+		log.ErrorLog("Could not get the nodes: " + err.Error())
+		os.Exit(1)
+	}
+	var clusterPods []apiObject.Pod
+	if err = getJSON(config.APIServerURL()+config.PodsGlobalURI, &clusterPods); err != nil {
+		log.ErrorLog("Could not get the pods: " + err.Error())
+		os.Exit(1)
+	}
+	var podGroups podGroupLister
+	if err = getJSON(config.APIServerURL()+config.GlobalPodGroupsURI, &podGroups); err != nil {
+		log.ErrorLog("Could not get the pod groups: " + err.Error())
+		os.Exit(1)
+	}
+	cfg, err := framework.LoadConfiguration(config.SchedulerProfilePath)
+	if err != nil {
+		log.ErrorLog("Could not load the scheduler configuration: " + err.Error())
+		os.Exit(1)
+	}
+	simulator, err := scheduler.NewSimulator(cfg, plugins.NewInTreeRegistry(), nodes, clusterPods, framework.WithPodGroupLister(podGroups))
+	if err != nil {
+		log.ErrorLog("Could not create the simulator: " + err.Error())
+		os.Exit(1)
+	}
+	printSimulationResults(simulator.ScheduleAll(pods))
+}
+
+// podsFromFile 从Pod或者ReplicaSet文件中解析出需要调度的Pod，ReplicaSet按照副本数量生成Pod
+func podsFromFile(content []byte) ([]apiObject.Pod, error) {
+	kind, err := translator.FetchApiObjFromYaml(content)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case apiObject.PodType:
+		var pod apiObject.Pod
+		if err = translator.ParseApiObjFromYaml(content, &pod); err != nil {
+			return nil, err
+		}
+		return []apiObject.Pod{pod}, nil
+	case apiObject.ReplicaSetType:
+		var rs apiObject.ReplicaSet
+		if err = translator.ParseApiObjFromYaml(content, &rs); err != nil {
+			return nil, err
+		}
+		namespace := rs.Spec.Template.Metadata.Namespace
+		if namespace == "" {
+			namespace = rs.Metadata.Namespace
+		}
+		name := rs.Spec.Template.Metadata.Name
+		if name == "" {
+			name = rs.Metadata.Name
+		}
+		var pods []apiObject.Pod
+		for i := 0; i < int(rs.Spec.Replicas); i++ {
+			pod := apiObject.Pod{
+				TypeMeta: apiObject.TypeMeta{Kind: apiObject.PodType, APIVersion: "v1"},
+				Metadata: rs.Spec.Template.Metadata,
+				Spec:     rs.Spec.Template.Spec,
+			}
+			pod.Metadata.Name = fmt.Sprintf("%s-%d", name, i)
+			pod.Metadata.Namespace = namespace
+			pods = append(pods, pod)
+		}
+		return pods, nil
+	default:
+		return nil, fmt.Errorf("the kind %s is not supported, only Pod and ReplicaSet can be simulated", kind)
+	}
+}
+
+// resolvePriorities 与apiServer的准入控制相同，根据PriorityClass填写Pod的优先级与抢占策略
+func resolvePriorities(pods []apiObject.Pod) error {
+	var priorityClasses []apiObject.PriorityClass
+	if err := getJSON(config.APIServerURL()+config.PriorityClassesURI, &priorityClasses); err != nil {
+		return err
+	}
+	for i := range pods {
+		pod := &pods[i]
+		var priorityClass *apiObject.PriorityClass
+		for j := range priorityClasses {
+			class := &priorityClasses[j]
+			if (pod.Spec.PriorityClassName != "" && class.Metadata.Name == pod.Spec.PriorityClassName) ||
+				(pod.Spec.PriorityClassName == "" && class.GlobalDefault) {
+				priorityClass = class
+				break
+			}
+		}
+		if pod.Spec.PriorityClassName != "" && priorityClass == nil {
+			return fmt.Errorf("no PriorityClass with name %s was found", pod.Spec.PriorityClassName)
+		}
+		var priority int32
+		if priorityClass != nil {
+			priority = priorityClass.Value
+			if pod.Spec.PreemptionPolicy == "" {
+				pod.Spec.PreemptionPolicy = priorityClass.PreemptionPolicy
+			}
+		}
+		pod.Spec.Priority = &priority
+	}
+	return nil
+}
+
+// podGroupLister 模拟调度时从集群中获取的PodGroup
+type podGroupLister []apiObject.PodGroup
+
+func (l podGroupLister) GetPodGroup(namespace, name string) (*apiObject.PodGroup, error) {
+	for i := range l {
+		if l[i].Metadata.Namespace == namespace && l[i].Metadata.Name == name {
+			return &l[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func getJSON(url string, target interface{}) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+func printSimulationResults(results []scheduler.SimulationResult) {
+	writer := table.NewWriter()
+	writer.SetOutputMirror(os.Stdout)
+	writer.AppendHeader(table.Row{"Namespace", "Pod", "Node", "Result", "Feasible Nodes"})
+	for _, result := range results {
+		node, status := result.SuggestedHost, "Scheduled"
+		if result.Err != nil {
+			node, status = "<none>", "Unschedulable"
+			if result.NominatedNodeName != "" {
+				node, status = result.NominatedNodeName, "Nominated (preemption)"
+			}
+		}
+		writer.AppendRow(table.Row{result.Pod.Metadata.Namespace, result.Pod.Metadata.Name, node, status,
+			fmt.Sprintf("%d/%d", result.FeasibleNodes, result.EvaluatedNodes)})
+	}
+	writer.Render()
+
+	for _, result := range results {
+		fmt.Printf("\nPod %s/%s:\n", result.Pod.Metadata.Namespace, result.Pod.Metadata.Name)
+		if result.Err != nil {
+			fmt.Println("  " + result.Err.Error())
+		}
+		if len(result.Diagnosis) > 0 {
+			printFilterResults(result.Diagnosis)
+		}
+		if len(result.Scores) > 0 {
+			printScoreResults(result.Scores, result.SuggestedHost)
+		}
+	}
+}
+
+func printFilterResults(diagnosis map[string]*framework.Status) {
+	var nodeNames []string
+	for name := range diagnosis {
+		nodeNames = append(nodeNames, name)
+	}
+	sort.Strings(nodeNames)
+	writer := table.NewWriter()
+	writer.SetOutputMirror(os.Stdout)
+	writer.AppendHeader(table.Row{"Filtered Node", "Plugin", "Reason"})
+	for _, name := range nodeNames {
+		status := diagnosis[name]
+		writer.AppendRow(table.Row{name, status.Plugin(), status.Message()})
+	}
+	writer.Render()
+}
+
+func printScoreResults(scores []framework.NodeScore, selected string) {
+	writer := table.NewWriter()
+	writer.SetOutputMirror(os.Stdout)
+	writer.AppendHeader(table.Row{"Feasible Node", "Total", "Plugin Scores", "Selected"})
+	for _, score := range scores {
+		var pluginScores []string
+		for _, pluginScore := range score.Scores {
+			pluginScores = append(pluginScores, fmt.Sprintf("%s=%d", pluginScore.Name, pluginScore.Score))
+		}
+		mark := ""
+		if score.Name == selected {
+			mark = "*"
+		}
+		writer.AppendRow(table.Row{score.Name, score.Score, strings.Join(pluginScores, ", "), mark})
+	}
+	writer.Render()
+}
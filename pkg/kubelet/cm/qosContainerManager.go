@@ -0,0 +1,104 @@
+// 描述: 为不同服务质量等级的Pod创建cgroup层级，Guaranteed的Pod直接位于kubepods之下
+//
+//	cgroupfs驱动：/kubepods/pod<uid>、/kubepods/burstable/pod<uid>、/kubepods/besteffort/pod<uid>
+//	systemd驱动：kubepods-pod<uid>.slice、kubepods-burstable-pod<uid>.slice、kubepods-besteffort-pod<uid>.slice
+// 参考：https://github.com/kubernetes/kubernetes/blob/master/pkg/kubelet/cm/qos_container_manager_linux.go
+
+package cm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/config"
+	"minik8s/pkg/kubelet/qos"
+)
+
+// qosControllers QoS层级的cgroup需要开启的控制器
+var qosControllers = []string{"cpu", "memory", "pids"}
+
+// PodCgroupParent 返回Pod所在的cgroup，作为sandbox与容器的CgroupParent，容器运行时在创建sandbox时创建该cgroup
+func PodCgroupParent(pod *apiObject.Pod) string {
+	return podCgroupParent(config.CgroupDriver, qos.GetPodQOS(pod), pod.Metadata.UUID)
+}
+
+func podCgroupParent(driver string, qosClass apiObject.PodQOSClass, uid string) string {
+	names := qosCgroupNames(qosClass)
+	if driver == config.CgroupDriverSystemd {
+		// slice名称中的'-'表示层级，需要替换掉uid中的'-'
+		names = append(names, "pod"+strings.ReplaceAll(uid, "-", "_"))
+		return strings.Join(names, "-") + ".slice"
+	}
+	names = append(names, "pod"+uid)
+	return "/" + strings.Join(names, "/")
+}
+
+// qosCgroupNames 服务质量等级对应的cgroup相对于根cgroup的各级名称
+func qosCgroupNames(qosClass apiObject.PodQOSClass) []string {
+	if qosClass == apiObject.PodQOSGuaranteed {
+		return []string{config.KubepodsCgroupName}
+	}
+	return []string{config.KubepodsCgroupName, strings.ToLower(string(qosClass))}
+}
+
+// SetupQOSCgroups 创建kubepods以及burstable与besteffort的cgroup，BestEffort的Pod只能使用其他Pod空闲的cpu
+//
+//	使用systemd驱动时slice由systemd在创建sandbox时自动创建
+func SetupQOSCgroups() error {
+	if config.CgroupDriver == config.CgroupDriverSystemd {
+		return nil
+	}
+	return setupQOSCgroups(config.CgroupRoot)
+}
+
+func setupQOSCgroups(root string) error {
+	var dirs []string
+	for _, qosClass := range []apiObject.PodQOSClass{apiObject.PodQOSGuaranteed, apiObject.PodQOSBurstable, apiObject.PodQOSBestEffort} {
+		dirs = append(dirs, filepath.Join(qosCgroupNames(qosClass)...))
+	}
+	besteffort := filepath.Join(qosCgroupNames(apiObject.PodQOSBestEffort)...)
+
+	// cgroup v1 中每个控制器有独立的层级
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err != nil {
+		for _, controller := range qosControllers {
+			for _, dir := range dirs {
+				if err = os.MkdirAll(filepath.Join(root, controller, dir), 0755); err != nil {
+					return err
+				}
+			}
+		}
+		return os.WriteFile(filepath.Join(root, "cpu", besteffort, "cpu.shares"), []byte("2"), 0644)
+	}
+
+	// cgroup v2 中子cgroup只能使用父cgroup在subtree_control中开启的控制器
+	var errs []error
+	if err := enableControllers(root); err != nil {
+		errs = append(errs, err)
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			return err
+		}
+		if err := enableControllers(filepath.Join(root, dir)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, besteffort, "cpu.weight"), []byte("1"), 0644); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// enableControllers 为cgroup v2 中的cgroup的子cgroup开启控制器，每个控制器单独开启，避免一个控制器不可用时影响其他控制器
+func enableControllers(path string) error {
+	var errs []error
+	for _, controller := range qosControllers {
+		if err := os.WriteFile(filepath.Join(path, "cgroup.subtree_control"), []byte("+"+controller), 0644); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
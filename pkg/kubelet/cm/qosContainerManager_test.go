@@ -0,0 +1,49 @@
+package cm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/config"
+)
+
+func TestPodCgroupParent(t *testing.T) {
+	uid := "6a3f-11ef"
+	assert.Equal(t, "/kubepods/pod6a3f-11ef", podCgroupParent(config.CgroupDriverCgroupfs, apiObject.PodQOSGuaranteed, uid))
+	assert.Equal(t, "/kubepods/burstable/pod6a3f-11ef", podCgroupParent(config.CgroupDriverCgroupfs, apiObject.PodQOSBurstable, uid))
+	assert.Equal(t, "/kubepods/besteffort/pod6a3f-11ef", podCgroupParent(config.CgroupDriverCgroupfs, apiObject.PodQOSBestEffort, uid))
+	assert.Equal(t, "kubepods-pod6a3f_11ef.slice", podCgroupParent(config.CgroupDriverSystemd, apiObject.PodQOSGuaranteed, uid))
+	assert.Equal(t, "kubepods-besteffort-pod6a3f_11ef.slice", podCgroupParent(config.CgroupDriverSystemd, apiObject.PodQOSBestEffort, uid))
+}
+
+func readFile(t *testing.T, path string) string {
+	content, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	return string(content)
+}
+
+func TestSetupQOSCgroups(t *testing.T) {
+	// cgroup v1
+	root := t.TempDir()
+	assert.Nil(t, setupQOSCgroups(root))
+	for _, controller := range qosControllers {
+		for _, dir := range []string{"kubepods", "kubepods/burstable", "kubepods/besteffort"} {
+			assert.DirExists(t, filepath.Join(root, controller, dir))
+		}
+	}
+	assert.Equal(t, "2", readFile(t, filepath.Join(root, "cpu/kubepods/besteffort/cpu.shares")))
+
+	// cgroup v2
+	root = t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpu memory pids"), 0644))
+	assert.Nil(t, setupQOSCgroups(root))
+	for _, dir := range []string{"", "kubepods", "kubepods/burstable", "kubepods/besteffort"} {
+		assert.FileExists(t, filepath.Join(root, dir, "cgroup.subtree_control"))
+	}
+	assert.NoDirExists(t, filepath.Join(root, "cpu"))
+	assert.Equal(t, "1", readFile(t, filepath.Join(root, "kubepods/besteffort/cpu.weight")))
+}
@@ -2,14 +2,18 @@ package kubelet
 
 import (
 	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"minik8s/pkg/apiObject"
 	"minik8s/pkg/config"
+	"minik8s/pkg/kubelet/cm"
 	"minik8s/pkg/kubelet/pod"
+	"minik8s/tools/conversion"
 	"minik8s/tools/host"
 	"minik8s/tools/log"
 	"minik8s/tools/netRequest"
@@ -44,6 +48,18 @@ func (k *Kubelet) Run() {
 	// 注册node
 	k.registerNode()
 
+	// 定时续约node的Lease，并上报node的状态
+	go k.renewNodeLease()
+	go k.reportNodeStatus()
+
+	// 创建不同服务质量等级的Pod所在的cgroup
+	if err := cm.SetupQOSCgroups(); err != nil {
+		log.ErrorLog("setup qos cgroups failed: " + err.Error())
+	}
+
+	// 定时拉取调度到本节点的pod并创建
+	go pod.SyncBoundPods(k.node.Metadata.Name)
+
 	// 定时扫描pod的状态并进行相应的处理
 	pod.ScanPodStatus()
 
@@ -70,23 +86,65 @@ func (k *Kubelet) registerNode() bool {
 
 }
 
+// renewNodeLease 定时续约node的Lease，nodeLifecycleController据此判断node是否存活
+func (k *Kubelet) renewNodeLease() {
+	url := k.ApiServerConfig.APIServerURL() + config.LeaseURI
+	url = strings.Replace(url, config.NameSpaceReplace, apiObject.NodeLeaseNamespace, -1)
+	url = strings.Replace(url, config.NameReplace, k.node.Metadata.Name, -1)
+	lease := apiObject.Lease{
+		TypeMeta: apiObject.TypeMeta{
+			Kind:       apiObject.LeaseType,
+			APIVersion: "coordination.k8s.io/v1",
+		},
+		Metadata: apiObject.ObjectMeta{
+			Name:      k.node.Metadata.Name,
+			Namespace: apiObject.NodeLeaseNamespace,
+		},
+		Spec: apiObject.LeaseSpec{
+			HolderIdentity:       k.node.Metadata.Name,
+			LeaseDurationSeconds: config.NodeLeaseDurationSeconds,
+			AcquireTime:          time.Now(),
+		},
+	}
+	for {
+		lease.Spec.RenewTime = time.Now()
+		statusCode, _, err := netRequest.PutRequestByTarget(url, lease)
+		if err != nil {
+			log.WarnLog("renew node lease failed: " + err.Error())
+		} else if statusCode != config.HttpSuccessCode && statusCode != http.StatusCreated {
+			log.WarnLog("renew node lease failed, status code: " + fmt.Sprint(statusCode))
+		}
+		time.Sleep(config.NodeLeaseRenewInterval)
+	}
+}
+
+// reportNodeStatus 定时向apiServer上报node的状态
+func (k *Kubelet) reportNodeStatus() {
+	url := k.ApiServerConfig.APIServerURL() + config.NodeStatusURI
+	url = strings.Replace(url, config.NameReplace, k.node.Metadata.Name, -1)
+	for {
+		time.Sleep(config.NodeStatusReportInterval)
+		k.UpdateNodeStatusInternal()
+		statusCode, _, err := netRequest.PutRequestByTarget(url, k.node.Status)
+		if err != nil {
+			log.WarnLog("report node status failed: " + err.Error())
+		} else if statusCode != config.HttpSuccessCode {
+			log.WarnLog("report node status failed, status code: " + fmt.Sprint(statusCode))
+		}
+	}
+}
+
 // buildNode 构建node的信息
 func (k *Kubelet) buildNode() {
 	// 注册所需的参数
 	HostName, _ := host.GetHostname()
-	HostIP, _ := host.GetHostIP()
-
-	// 获取主机的内存大小
-	capacity := make(map[string]string)
-	totalMemory, _ := host.GetTotalMemory()
-	capacity["memory"] = strconv.FormatUint(totalMemory, 10)
 
-	// 获取主机的内存和CPU使用率
-	allocatable := make(map[string]string)
-	MemoryUsage, _ := host.GetMemoryUsageRate()
-	CPUUsage, _ := host.GetCPULoad()
-	allocatable["memory"] = strconv.FormatFloat(MemoryUsage, 'f', -1, 64)
-	allocatable["cpu"] = strconv.FormatFloat(CPUUsage[0], 'f', -1, 64)
+	// 节点的标签来自kubelet的配置，并自动添加主机名标签
+	labels := make(map[string]string)
+	for key, value := range config.NodeLabels {
+		labels[key] = value
+	}
+	labels[config.LabelHostname] = HostName
 
 	k.node = &apiObject.Node{
 		TypeMeta: apiObject.TypeMeta{
@@ -96,63 +154,83 @@ func (k *Kubelet) buildNode() {
 		Metadata: apiObject.ObjectMeta{
 			Name:        HostName,
 			Namespace:   "",
-			Labels:      make(map[string]string),
+			Labels:      labels,
 			Annotations: make(map[string]string),
 			UUID:        "",
 		},
 		Spec: apiObject.NodeSpec{
 			PodCIDR:       "",
 			ProviderID:    "",
-			Unschedulable: true,
-		},
-		Status: apiObject.NodeStatus{
-			Capacity:    capacity,
-			Allocatable: allocatable,
-			Phase:       "running",
-			Conditions: []apiObject.NodeCondition{
-				{
-					Type:   "Ready",
-					Status: "True",
-				},
-			},
-			Addresses: []apiObject.NodeAddress{
-				{
-					Type:    "InternalIP",
-					Address: HostIP,
-				},
-			},
+			Unschedulable: false,
+			Taints:        nodeTaints(),
 		},
+		Status: buildNodeStatus(),
 	}
 
 }
 
+// nodeTaints 返回kubelet配置中的污点，NoExecute污点的添加时间为注册的时间
+func nodeTaints() []apiObject.Taint {
+	taints := make([]apiObject.Taint, 0, len(config.NodeTaints))
+	for _, taint := range config.NodeTaints {
+		if taint.Effect == apiObject.TaintEffectNoExecute {
+			taint.TimeAdded = time.Now()
+		}
+		taints = append(taints, taint)
+	}
+	return taints
+}
+
 // UpdateNodeStatusInternal 更新node的状态
 func (k *Kubelet) UpdateNodeStatusInternal() {
 	log.DebugLog("UpdateNodeStatus")
+	k.node.Status = buildNodeStatus()
+}
 
+// buildNodeStatus 收集主机的资源容量、可分配资源与使用率，生成node的状态
+func buildNodeStatus() apiObject.NodeStatus {
 	// 注册所需的参数
 	HostIP, _ := host.GetHostIP()
 
-	// 获取主机的内存大小
-	capacity := make(map[string]string)
+	// 获取主机的CPU数量与内存大小，cpu 单位为毫核，memory 单位为 KB
+	cpuCount, _ := host.GetCPUCount()
 	totalMemory, _ := host.GetTotalMemory()
-	capacity["memory"] = strconv.FormatUint(totalMemory, 10)
+	cpuCapacity := int64(cpuCount) * 1000
+	memoryCapacity := int64(totalMemory / 1024)
+	capacity := apiObject.ResourceList{
+		apiObject.ResourceCPU:    conversion.FormatQuantity(apiObject.ResourceCPU, cpuCapacity),
+		apiObject.ResourceMemory: conversion.FormatQuantity(apiObject.ResourceMemory, memoryCapacity),
+		apiObject.ResourcePods:   strconv.Itoa(config.KubeletMaxPods),
+	}
+
+	// 可分配资源为资源容量减去系统预留的部分
+	reservedCPU, _ := conversion.ParseCPU(config.SystemReservedCPU)
+	reservedMemory, _ := conversion.ParseMemory(config.SystemReservedMemory)
+	allocatable := apiObject.ResourceList{
+		apiObject.ResourceCPU:    conversion.FormatQuantity(apiObject.ResourceCPU, max(cpuCapacity-reservedCPU, 0)),
+		apiObject.ResourceMemory: conversion.FormatQuantity(apiObject.ResourceMemory, max(memoryCapacity-reservedMemory, 0)),
+		apiObject.ResourcePods:   strconv.Itoa(config.KubeletMaxPods),
+	}
 
 	// 获取主机的内存和CPU使用率
-	allocatable := make(map[string]string)
 	MemoryUsage, _ := host.GetMemoryUsageRate()
 	CPUUsage, _ := host.GetCPULoad()
-	allocatable["memory"] = strconv.FormatFloat(MemoryUsage, 'f', -1, 64)
-	allocatable["cpu"] = strconv.FormatFloat(CPUUsage[0], 'f', -1, 64)
+	var cpuUsage float64
+	if len(CPUUsage) > 0 {
+		cpuUsage = CPUUsage[0]
+	}
 
-	nodeStatus := &apiObject.NodeStatus{
+	return apiObject.NodeStatus{
 		Capacity:    capacity,
 		Allocatable: allocatable,
+		CpuUsage:    cpuUsage,
+		MemUsage:    MemoryUsage,
 		Phase:       "running",
 		Conditions: []apiObject.NodeCondition{
 			{
-				Type:   "Ready", // Ready: kubelet准备好接受Pod
-				Status: "True",
+				Type:              apiObject.NodeReady, // Ready: kubelet准备好接受Pod
+				Status:            apiObject.ConditionTrue,
+				LastHeartbeatTime: time.Now(),
 			},
 		},
 		Addresses: []apiObject.NodeAddress{
@@ -162,9 +240,6 @@ func (k *Kubelet) UpdateNodeStatusInternal() {
 			},
 		},
 	}
-
-	k.node.Status = *nodeStatus
-
 }
 
 // registerKubeletAPI 注册kubelet的API
@@ -187,6 +262,12 @@ func (k *Kubelet) registerKubeletAPI() {
 
 	// 执行指定Pod和container的命令
 	k.KubeletAPIRouter.POST(config.PodExecURI, pod.ExecPodContainer)
+	// 读取指定Pod中容器的日志
+	k.KubeletAPIRouter.GET(config.ContainerLogsURI, pod.GetContainerLogs)
+	// 在指定Pod的容器中交互式地执行命令
+	k.KubeletAPIRouter.GET(config.ContainerExecURI, pod.ExecContainerStream)
+	// 连接到指定Pod中容器的主进程
+	k.KubeletAPIRouter.GET(config.ContainerAttachURI, pod.AttachContainerStream)
 
 	// 获取所有Pod
 	k.KubeletAPIRouter.GET(config.PodsURI, pod.GetPods)
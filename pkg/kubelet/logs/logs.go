@@ -0,0 +1,204 @@
+// 描述: 读取容器运行时以CRI格式写入的容器日志
+// 参考：https://github.com/kubernetes/kubernetes/blob/master/pkg/kubelet/kuberuntime/logs/logs.go
+
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"minik8s/tools/log"
+)
+
+const (
+	// tagPartial 一行日志过长时被拆分成多段，除最后一段外都带有该标签
+	tagPartial = "P"
+	// tagFull 完整的一行日志或者被拆分的日志的最后一段
+	tagFull = "F"
+	// pollInterval follow时检查日志文件中是否有新内容的间隔
+	pollInterval = 500 * time.Millisecond
+)
+
+// Options 读取容器日志的选项
+type Options struct {
+	// 输出已有的日志后继续输出新的日志，直到容器不再运行或者请求结束
+	Follow bool
+	// 只输出最后若干行日志，小于0时输出全部日志
+	TailLines int64
+	// 只输出该时间之后的日志，为零值时不过滤
+	Since time.Time
+	// 在每行日志前输出RFC3339Nano格式的时间戳
+	Timestamps bool
+}
+
+// logMessage 解析后的一行日志
+type logMessage struct {
+	timestamp time.Time
+	// stdout 或者 stderr
+	stream string
+	// 日志的内容，完整的一行日志以换行符结尾
+	log []byte
+}
+
+// parseCRILog 解析CRI格式的一行日志，格式为：<RFC3339Nano时间戳> <stdout|stderr> <P|F> <内容>
+func parseCRILog(line []byte, msg *logMessage) error {
+	fields := bytes.SplitN(line, []byte{' '}, 4)
+	if len(fields) < 3 {
+		return errors.New("invalid CRI log: " + string(line))
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, string(fields[0]))
+	if err != nil {
+		return errors.New("unexpected timestamp format in CRI log: " + string(fields[0]))
+	}
+	msg.timestamp = timestamp
+	msg.stream = string(fields[1])
+	if msg.stream != "stdout" && msg.stream != "stderr" {
+		return errors.New("unexpected stream type in CRI log: " + msg.stream)
+	}
+	tag := string(fields[2])
+	if tag != tagPartial && tag != tagFull {
+		return errors.New("unexpected tag in CRI log: " + tag)
+	}
+	msg.log = nil
+	if len(fields) == 4 {
+		msg.log = fields[3]
+	}
+	if tag == tagFull {
+		msg.log = append(msg.log, '\n')
+	}
+	return nil
+}
+
+// ReadLogs 读取容器的日志文件，按照选项将日志的内容写入w
+//
+//	follow时每隔一段时间检查日志文件，isRunning返回false或者ctx结束后不再等待新的日志
+func ReadLogs(ctx context.Context, path string, opts *Options, w io.Writer, isRunning func() bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := &logReader{reader: bufio.NewReader(f), opts: opts, w: w}
+	if opts.TailLines >= 0 {
+		r.tail = make([]logMessage, 0)
+	}
+	if err = r.readAvailable(); err != nil {
+		return err
+	}
+	if err = r.flushTail(); err != nil {
+		return err
+	}
+	for opts.Follow {
+		// 容器停止后读取最后写入的日志再退出
+		running := isRunning()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pollInterval):
+		}
+		if err = r.readAvailable(); err != nil {
+			return err
+		}
+		if !running {
+			return nil
+		}
+	}
+	return nil
+}
+
+// logReader 逐行读取日志文件，合并被拆分的日志
+type logReader struct {
+	reader *bufio.Reader
+	opts   *Options
+	w      io.Writer
+
+	// 尚未读到换行符的内容，容器运行时可能正在写入这一行
+	pending []byte
+	// 被拆分的日志中已经读到的部分
+	partial *logMessage
+	// 设置了tailLines时缓存最后若干行日志，输出后置为nil
+	tail []logMessage
+}
+
+// readAvailable 读取日志文件中当前已有的所有完整的行
+func (r *logReader) readAvailable() error {
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		r.pending = append(r.pending, line...)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = bytes.TrimSuffix(r.pending, []byte{'\n'})
+		r.pending = nil
+		var msg logMessage
+		if err = parseCRILog(line, &msg); err != nil {
+			log.WarnLog(err.Error())
+			continue
+		}
+		if err = r.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// handle 合并被拆分的日志，得到完整的一行后按照选项过滤并输出
+func (r *logReader) handle(msg logMessage) error {
+	full := len(msg.log) > 0 && msg.log[len(msg.log)-1] == '\n'
+	if r.partial != nil {
+		r.partial.log = append(r.partial.log, msg.log...)
+		if !full {
+			return nil
+		}
+		msg, r.partial = *r.partial, nil
+	} else if !full {
+		msg.log = append([]byte(nil), msg.log...)
+		r.partial = &msg
+		return nil
+	}
+
+	if !r.opts.Since.IsZero() && msg.timestamp.Before(r.opts.Since) {
+		return nil
+	}
+	if r.tail == nil {
+		return r.write(msg)
+	}
+	if r.opts.TailLines == 0 {
+		return nil
+	}
+	if int64(len(r.tail)) == r.opts.TailLines {
+		r.tail = r.tail[1:]
+	}
+	r.tail = append(r.tail, msg)
+	return nil
+}
+
+// flushTail 输出缓存的最后若干行日志，之后读到的日志直接输出
+func (r *logReader) flushTail() error {
+	tail := r.tail
+	r.tail = nil
+	for _, msg := range tail {
+		if err := r.write(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *logReader) write(msg logMessage) error {
+	if r.opts.Timestamps {
+		if _, err := io.WriteString(r.w, msg.timestamp.Format(time.RFC3339Nano)+" "); err != nil {
+			return err
+		}
+	}
+	_, err := r.w.Write(msg.log)
+	return err
+}
@@ -0,0 +1,118 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuffer 可以在follow的同时读取已经输出的内容
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func writeLogFile(t *testing.T, lines ...string) string {
+	path := filepath.Join(t.TempDir(), "0.log")
+	assert.Nil(t, os.WriteFile(path, []byte(strings.Join(lines, "")), 0644))
+	return path
+}
+
+func readLogs(t *testing.T, path string, opts *Options) string {
+	var buf bytes.Buffer
+	assert.Nil(t, ReadLogs(context.Background(), path, opts, &buf, func() bool { return false }))
+	return buf.String()
+}
+
+func TestParseCRILog(t *testing.T) {
+	var msg logMessage
+	assert.Nil(t, parseCRILog([]byte("2024-05-01T10:00:00.123456789Z stderr F hello world"), &msg))
+	assert.Equal(t, "stderr", msg.stream)
+	assert.Equal(t, "hello world\n", string(msg.log))
+	assert.Equal(t, 123456789, msg.timestamp.Nanosecond())
+
+	assert.Nil(t, parseCRILog([]byte("2024-05-01T10:00:00Z stdout P "), &msg))
+	assert.Equal(t, "", string(msg.log))
+
+	assert.NotNil(t, parseCRILog([]byte("2024-05-01T10:00:00Z stdin F x"), &msg))
+	assert.NotNil(t, parseCRILog([]byte("yesterday stdout F x"), &msg))
+	assert.NotNil(t, parseCRILog([]byte("2024-05-01T10:00:00Z stdout"), &msg))
+}
+
+func TestReadLogs(t *testing.T) {
+	path := writeLogFile(t,
+		"2024-05-01T10:00:00Z stdout F first\n",
+		"2024-05-01T10:00:01Z stdout P sec\n",
+		"2024-05-01T10:00:01.5Z stdout F ond\n",
+		"2024-05-01T10:00:02Z stderr F third\n",
+		"malformed\n",
+		"2024-05-01T10:00:03Z stdout F fourth\n",
+	)
+
+	assert.Equal(t, "first\nsecond\nthird\nfourth\n", readLogs(t, path, &Options{TailLines: -1}))
+	assert.Equal(t, "third\nfourth\n", readLogs(t, path, &Options{TailLines: 2}))
+	assert.Equal(t, "", readLogs(t, path, &Options{TailLines: 0}))
+
+	// 被拆分的日志使用第一段的时间戳
+	since, _ := time.Parse(time.RFC3339, "2024-05-01T10:00:01Z")
+	assert.Equal(t, "2024-05-01T10:00:01Z second\n2024-05-01T10:00:02Z third\n2024-05-01T10:00:03Z fourth\n",
+		readLogs(t, path, &Options{TailLines: -1, Since: since, Timestamps: true}))
+}
+
+func TestFollowLogs(t *testing.T) {
+	path := writeLogFile(t, "2024-05-01T10:00:00Z stdout F old\n")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	defer f.Close()
+
+	var running sync.Mutex
+	isRunning := true
+	var buf syncBuffer
+	done := make(chan error)
+	go func() {
+		done <- ReadLogs(context.Background(), path, &Options{Follow: true, TailLines: -1}, &buf, func() bool {
+			running.Lock()
+			defer running.Unlock()
+			return isRunning
+		})
+	}()
+
+	// 没有写完的一行不会被输出
+	_, _ = f.WriteString("2024-05-01T10:00:01Z stdout F ne")
+	time.Sleep(2 * pollInterval)
+	assert.Equal(t, "old\n", buf.String())
+	_, _ = f.WriteString("w\n")
+	assert.Eventually(t, func() bool { return buf.String() == "old\nnew\n" }, 5*time.Second, 50*time.Millisecond)
+
+	// 容器停止后输出最后写入的日志并结束
+	_, _ = f.WriteString("2024-05-01T10:00:02Z stdout F last\n")
+	running.Lock()
+	isRunning = false
+	running.Unlock()
+	select {
+	case err = <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("ReadLogs did not return after the container stopped")
+	}
+	assert.Equal(t, "old\nnew\nlast\n", buf.String())
+}
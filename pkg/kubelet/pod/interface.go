@@ -4,19 +4,24 @@ package pod
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"minik8s/pkg/apiObject"
 	"minik8s/pkg/config"
+	"minik8s/pkg/kubelet/logs"
+	"minik8s/pkg/kubelet/runtime"
 	"minik8s/tools/log"
 	"minik8s/tools/mount"
+	"minik8s/tools/remotecommand"
 
 	httprequest "minik8s/tools/httpRequest"
 )
@@ -54,6 +59,16 @@ func CreatePod(c *gin.Context) {
 		return
 	}
 
+	code, err := createPod(&pod)
+	if err != nil {
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(config.HttpSuccessCode, pod)
+}
+
+// createPod 处理 pod 使用的 volume 之后创建 pod，失败时返回对应的状态码
+func createPod(pod *apiObject.Pod) (int, error) {
 	// 若pod使用了volume，则对其进行处理
 	if pod.Spec.Volumes != nil {
 		for _, volume := range pod.Spec.Volumes {
@@ -66,30 +81,26 @@ func CreatePod(c *gin.Context) {
 				res, err := httprequest.GetMsg(url)
 				if err != nil {
 					log.ErrorLog("Could not post the object message." + err.Error())
-					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return http.StatusInternalServerError, err
 				}
 				if res == nil {
 					log.ErrorLog("CreatePod: res is nil")
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "res is nil"})
-					return
+					return http.StatusInternalServerError, errors.New("res is nil")
 				}
 				if res.StatusCode != http.StatusOK {
 					log.ErrorLog("CreatePod: " + res.Status)
-					c.JSON(res.StatusCode, gin.H{"error": res.Status})
-					return
+					return res.StatusCode, errors.New(res.Status)
 				}
 				// 解析pvc
 				var pvc apiObject.PersistentVolumeClaim
 				err = json.NewDecoder(res.Body).Decode(&pvc)
 				if err != nil {
 					log.ErrorLog("CreatePod: " + err.Error())
-					c.JSON(500, gin.H{"error": err.Error()})
-					return
+					return 500, err
 				}
 				if pvc.Status.Phase != apiObject.ClaimBound || pvc.Status.IsBound {
 					log.ErrorLog("CreatePod: PVC can't be used")
-					c.JSON(400, gin.H{"error": "PVC can't be used"})
-					return
+					return 400, errors.New("PVC can't be used")
 				}
 				// 将pod绑定到pvc
 				url = config.APIServerURL() + config.PersistentVolumeClaimURI
@@ -98,17 +109,15 @@ func CreatePod(c *gin.Context) {
 				res, err = httprequest.PutObjMsg(url, pvc)
 				if err != nil {
 					log.ErrorLog("Could not post the object message." + err.Error())
-					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return http.StatusInternalServerError, err
 				}
 				if res == nil {
 					log.ErrorLog("CreatePod: res is nil")
-					c.JSON(500, gin.H{"error": "res is nil"})
-					return
+					return 500, errors.New("res is nil")
 				}
 				if res.StatusCode != http.StatusOK {
 					log.ErrorLog("CreatePod: " + res.Status)
-					c.JSON(res.StatusCode, gin.H{"error": res.Status})
-					return
+					return res.StatusCode, errors.New(res.Status)
 				}
 				// 获取pvKey
 				url = config.APIServerURL() + config.PersistentVolumeURI
@@ -117,41 +126,36 @@ func CreatePod(c *gin.Context) {
 				res, err = httprequest.GetMsg(url)
 				if err != nil {
 					log.ErrorLog("Could not post the object message." + err.Error())
-					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return http.StatusInternalServerError, err
 				}
 				if res == nil {
 					log.ErrorLog("CreatePod: res is nil")
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "res is nil"})
-					return
+					return http.StatusInternalServerError, errors.New("res is nil")
 				}
 				if res.StatusCode != http.StatusOK {
 					log.ErrorLog("CreatePod: " + res.Status)
-					c.JSON(res.StatusCode, gin.H{"error": res.Status})
-					return
+					return res.StatusCode, errors.New(res.Status)
 				}
 				// 解析pvKey
 				var pvKey string
 				err = json.NewDecoder(res.Body).Decode(&pvKey)
 				if err != nil {
 					log.ErrorLog("CreatePod: " + err.Error())
-					c.JSON(500, gin.H{"error": err.Error()})
-					return
+					return 500, err
 				}
 				if pvKey == "" {
 					log.ErrorLog("CreatePod: pvName is empty")
-					c.JSON(400, gin.H{"error": "pvName is empty"})
-					return
+					return 400, errors.New("pvName is empty")
 				}
 				log.DebugLog("Parse pv name: " + pvKey)
 				// 将本地挂载目录挂载到服务器
 				err = mount.LocalToServer(pvKey)
 				if err != nil {
 					log.ErrorLog("CreatePod: " + err.Error())
-					c.JSON(500, gin.H{"error": err.Error()})
-					return
+					return 500, err
 				}
 				// 为pod中所有使用该持久化卷挂载的容器添加Mount
-				mount.AddMountsToContainer(&pod, volume, config.PVClientPath+"/"+pvKey)
+				mount.AddMountsToContainer(pod, volume, config.PVClientPath+"/"+pvKey)
 			}
 			// 处理使用了emptyDir的volume
 			if volume.EmptyDir.SizeLimit != "" {
@@ -159,41 +163,94 @@ func CreatePod(c *gin.Context) {
 				cleanCmd := "rm -rf " + config.DefaultVolumePath + "/" + pod.Metadata.Name + "/" + volume.Name
 				mkdirCmd := "mkdir -p " + config.DefaultVolumePath + "/" + pod.Metadata.Name + "/" + volume.Name
 				cmd := exec.Command("sh", "-c", cleanCmd)
-				err = cmd.Run()
+				err := cmd.Run()
 				if err != nil {
 					log.ErrorLog("CreatePod: " + err.Error())
-					c.JSON(500, gin.H{"error": err.Error()})
-					return
+					return 500, err
 				}
 				cmd = exec.Command("sh", "-c", mkdirCmd)
 				err = cmd.Run()
 				if err != nil {
 					log.ErrorLog("CreatePod: " + err.Error())
-					c.JSON(500, gin.H{"error": err.Error()})
-					return
+					return 500, err
 				}
-				mount.AddMountsToContainer(&pod, volume, config.DefaultVolumePath+"/"+pod.Metadata.Name+"/"+volume.Name)
+				mount.AddMountsToContainer(pod, volume, config.DefaultVolumePath+"/"+pod.Metadata.Name+"/"+volume.Name)
 			}
 			// 处理使用了hostPath的volume
 			if volume.HostPath.Path != "" {
 				// 如果hostPath不存在，则返回错误
 				if _, err := os.Stat(volume.HostPath.Path); os.IsNotExist(err) {
 					log.ErrorLog("CreatePod: hostPath not found")
-					c.JSON(400, gin.H{"error": "hostPath not found"})
-					return
+					return 400, errors.New("hostPath not found")
 				}
-				mount.AddMountsToContainer(&pod, volume, volume.HostPath.Path)
+				mount.AddMountsToContainer(pod, volume, volume.HostPath.Path)
 			}
 		}
 	}
 
-	err = podManager.AddPod(&pod)
+	err := podManager.AddPod(pod)
 	if err != nil {
 		log.ErrorLog("AddPod error: " + err.Error())
-		c.JSON(config.HttpErrorCode, err.Error())
+		return config.HttpErrorCode, err
+	}
+	return config.HttpSuccessCode, nil
+}
+
+// SyncBoundPods 定时从 apiServer 拉取调度到本节点的 pod 并创建
+func SyncBoundPods(nodeName string) {
+	log.InfoLog("start sync pods bound to node " + nodeName)
+	for {
+		SyncBoundPodsRoutine(nodeName)
+		time.Sleep(config.PodSyncInterval)
+	}
+}
+
+// SyncBoundPodsRoutine 创建已经绑定到本节点、但尚未在本节点上创建的 pod，并向 apiServer 上报创建结果
+func SyncBoundPodsRoutine(nodeName string) {
+	url := config.APIServerURL() + config.PodsGlobalURI + "?" + config.NodeNameQuery + "=" + nodeName
+	res, err := httprequest.GetMsg(url)
+	if err != nil {
+		log.WarnLog("SyncBoundPods: " + err.Error())
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		log.WarnLog("SyncBoundPods: " + res.Status)
+		return
+	}
+	var pods []apiObject.Pod
+	err = json.NewDecoder(res.Body).Decode(&pods)
+	if err != nil {
+		log.ErrorLog("SyncBoundPods: " + err.Error())
 		return
-	} else {
-		c.JSON(config.HttpSuccessCode, pod)
+	}
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase != apiObject.PodPending {
+			continue
+		}
+		if podManager.getPod(pod.GetPodUUID()) != nil {
+			continue
+		}
+		log.InfoLog("SyncBoundPods: create pod " + pod.Metadata.Namespace + "/" + pod.Metadata.Name)
+		if _, err = createPod(pod); err != nil {
+			// 拉取镜像失败等错误可能是暂时的，Pod保持Pending并记录原因，下一次同步时重试
+			log.ErrorLog("SyncBoundPods: " + err.Error())
+			pod.Status.Phase = apiObject.PodPending
+			pod.Status.SetCondition(apiObject.PodCondition{
+				Type:    apiObject.PodReadyToStartContainers,
+				Status:  apiObject.ConditionFalse,
+				Reason:  apiObject.PodReasonCreatePodFailed,
+				Message: err.Error(),
+			})
+			go UpdatePodStatus(pod)
+			continue
+		}
+		// 创建成功后pod会被其他协程访问，需要持有锁
+		podManager.lock.Lock()
+		pod.Status.SetCondition(apiObject.PodCondition{Type: apiObject.PodReadyToStartContainers, Status: apiObject.ConditionTrue})
+		go UpdatePodStatus(copyPod(pod))
+		podManager.lock.Unlock()
 	}
 }
 
@@ -225,12 +282,10 @@ func GetPodStatus(c *gin.Context) {
 		log.ErrorLog("GetPodStatus error: " + err.Error())
 	}
 
-	// 遍历 podManager 中的 PodMapByUUID，找到对应的 pod，返回 pod 的状态
-	for _, v := range podManager.PodMapByUUID {
-		if v.Metadata.UUID == pod.Metadata.UUID {
-			c.JSON(200, v.Status)
-			return
-		}
+	// 在 podManager 中找到对应的 pod，返回 pod 的状态
+	if v := podManager.getPod(pod.Metadata.UUID); v != nil {
+		c.JSON(200, v.Status)
+		return
 	}
 
 	// 如果没有找到对应的 pod，返回错误信息
@@ -272,19 +327,17 @@ func ScanPodStatusRoutine() {
 		log.ErrorLog("ScanPodStatus error: " + err.Error())
 	}
 	// 遍历所有的pod，根据pod的状态进行相应的操作
-	for _, pod := range podManager.PodMapByUUID {
-		// 防止协程中因为和主协程共享变量的变化
-		pod := pod
+	for _, pod := range podManager.listPods() {
 		// 根据每个pod当前所处的阶段进行相应的操作
 		phase := pod.Status.Phase
 		switch phase {
-		case apiObject.PodSucceeded:
-			// 如果 pod 处于 Succeeded 阶段，则运行 pod
+		case apiObject.PodCreated:
+			// 如果 pod 处于 Created 阶段，则运行 pod
 			go func() {
 				err := podManager.StartPod(pod)
 				if err != nil {
 					log.ErrorLog("StartPod error: " + err.Error())
-				} else {
+				} else if pod = podManager.getPod(pod.GetPodUUID()); pod != nil {
 					// 更新apiServer的pod状态
 					UpdatePodStatus(pod)
 				}
@@ -324,30 +377,263 @@ func ExecPodContainer(c *gin.Context) {
 	}
 }
 
+// GetContainerLogs 用于读取 pod 中容器的日志，follow 时持续输出新的日志直到容器停止
+func GetContainerLogs(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("pod")
+	containerName := c.Param("container")
+	opts, previous, err := parseLogOptions(c)
+	if err != nil {
+		log.ErrorLog("GetContainerLogs error: " + err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pod, index, code, err := findPodContainer(namespace, name, containerName)
+	if err != nil {
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 每次重启容器都会写入新的日志文件，previous 读取上一次运行的日志
+	var attempt int32
+	if status := pod.Status.GetContainerStatus(containerName); status != nil {
+		attempt = status.RestartCount
+	}
+	if previous {
+		if attempt == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "previous terminated container " + containerName + " in pod " + name + " not found"})
+			return
+		}
+		attempt--
+	}
+	path := runtime.ContainerLogPath(pod, containerName, attempt)
+	if _, err = os.Stat(path); err != nil {
+		log.ErrorLog("GetContainerLogs error: " + err.Error())
+		c.JSON(http.StatusNotFound, gin.H{"error": "logs of container " + containerName + " in pod " + name + " not found"})
+		return
+	}
+
+	// 容器被重启或者停止后不再等待新的日志
+	uuid, containerID := pod.GetPodUUID(), pod.Spec.Containers[index].ContainerID
+	isRunning := func() bool {
+		current := podManager.getPod(uuid)
+		if previous || current == nil {
+			return false
+		}
+		container := &current.Spec.Containers[index]
+		return container.ContainerID == containerID && container.ContainerStatus == apiObject.ContainerRunning
+	}
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	err = logs.ReadLogs(c.Request.Context(), path, opts, &flushWriter{writer: c.Writer}, isRunning)
+	if err != nil {
+		log.ErrorLog("GetContainerLogs error: " + err.Error())
+	}
+}
+
+// findPodContainer 在本节点的 pod 中查找指定的容器，返回 pod 的拷贝与容器的下标，找不到时返回 HTTP 状态码与错误
+func findPodContainer(namespace string, name string, containerName string) (*apiObject.Pod, int, int, error) {
+	for _, pod := range podManager.listPods() {
+		if pod.Metadata.Namespace != namespace || pod.Metadata.Name != name {
+			continue
+		}
+		for i := range pod.Spec.Containers {
+			if pod.Spec.Containers[i].Name == containerName {
+				return pod, i, http.StatusOK, nil
+			}
+		}
+		return nil, -1, http.StatusBadRequest, errors.New("container " + containerName + " is not valid for pod " + name)
+	}
+	return nil, -1, http.StatusNotFound, errors.New("pod " + namespace + "/" + name + " not found")
+}
+
+// ExecContainerStream 在 pod 的容器中交互式地执行命令
+//
+//	通过 CRI 的 Exec 接口获取容器运行时流服务器的 URL，升级客户端的连接后在两者之间转发各个通道的数据
+func ExecContainerStream(c *gin.Context) {
+	command := c.QueryArray(config.ExecCommandQuery)
+	if len(command) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "you must specify at least one command for the container"})
+		return
+	}
+	serveContainerStream(c, func(containerID string, stdin bool, tty bool) (string, error) {
+		return podManager.GetExecHandler(containerID, command, stdin, tty)
+	})
+}
+
+// AttachContainerStream 连接到 pod 中容器的主进程
+func AttachContainerStream(c *gin.Context) {
+	serveContainerStream(c, podManager.GetAttachHandler)
+}
+
+// serveContainerStream 获取运行中的容器的流式 URL，并将客户端的 WebSocket 连接转发给容器运行时的流服务器
+func serveContainerStream(c *gin.Context, getURL func(containerID string, stdin bool, tty bool) (string, error)) {
+	namespace := c.Param("namespace")
+	name := c.Param("pod")
+	containerName := c.Param("container")
+	stdin, tty := c.Query(config.ExecStdinQuery) == "true", c.Query(config.ExecTTYQuery) == "true"
+
+	pod, index, code, err := findPodContainer(namespace, name, containerName)
+	if err != nil {
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	container := &pod.Spec.Containers[index]
+	if container.ContainerStatus != apiObject.ContainerRunning {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "container " + containerName + " in pod " + name + " is not running"})
+		return
+	}
+	url, err := getURL(container.ContainerID, stdin, tty)
+	if err != nil {
+		log.ErrorLog("serveContainerStream error: " + err.Error())
+		c.JSON(config.HttpErrorCode, gin.H{"error": err.Error()})
+		return
+	}
+	backend, err := remotecommand.Dial(url)
+	if err != nil {
+		log.ErrorLog("serveContainerStream error: " + err.Error())
+		c.JSON(config.HttpErrorCode, gin.H{"error": err.Error()})
+		return
+	}
+	client, err := remotecommand.Upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.ErrorLog("serveContainerStream error: " + err.Error())
+		_ = backend.Close()
+		return
+	}
+	log.InfoLog("stream container " + namespace + "/" + name + "/" + containerName)
+	remotecommand.Proxy(client, backend)
+}
+
+// parseLogOptions 解析读取容器日志的查询参数，返回读取日志的选项以及是否读取容器上一次运行的日志
+func parseLogOptions(c *gin.Context) (*logs.Options, bool, error) {
+	opts := &logs.Options{TailLines: -1}
+	var previous bool
+	var err error
+	bools := map[string]*bool{
+		config.LogFollowQuery:     &opts.Follow,
+		config.LogTimestampsQuery: &opts.Timestamps,
+		config.LogPreviousQuery:   &previous,
+	}
+	for query, value := range bools {
+		if raw := c.Query(query); raw != "" {
+			if *value, err = strconv.ParseBool(raw); err != nil {
+				return nil, false, errors.New(query + " must be true or false")
+			}
+		}
+	}
+	if raw := c.Query(config.LogTailLinesQuery); raw != "" {
+		if opts.TailLines, err = strconv.ParseInt(raw, 10, 64); err != nil || opts.TailLines < 0 {
+			return nil, false, errors.New(config.LogTailLinesQuery + " must be a non-negative integer")
+		}
+	}
+	if raw := c.Query(config.LogSinceSecondsQuery); raw != "" {
+		seconds, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || seconds <= 0 {
+			return nil, false, errors.New(config.LogSinceSecondsQuery + " must be a positive integer")
+		}
+		opts.Since = time.Now().Add(-time.Duration(seconds) * time.Second)
+	}
+	return opts, previous, nil
+}
+
+// flushWriter 每次写入后立即发送给客户端，使 follow 的日志能够实时输出
+type flushWriter struct {
+	writer gin.ResponseWriter
+}
+
+func (w *flushWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.writer.Flush()
+	return n, err
+}
+
 // GetPods 用于获取所有的 pod
 func GetPods(c *gin.Context) {
 	log.DebugLog("GetPods")
 	pods := new([]apiObject.Pod)
-	for _, pod := range podManager.PodMapByUUID {
+	for _, pod := range podManager.listPods() {
 		*pods = append(*pods, *pod)
 	}
 	c.JSON(200, pods)
 }
 
+const (
+	// 上报Pod状态失败后第一次重试前等待的时间，之后每次失败等待的时间翻倍
+	statusReportInitialBackoff = 1 * time.Second
+	// 上报失败后等待的时间最多为30秒
+	statusReportMaxBackoff = 30 * time.Second
+)
+
+// statusReporters 记录正在上报状态的Pod以及等待上报的最新状态，每个Pod同时只有一个协程上报
+var statusReporters = struct {
+	lock    sync.Mutex
+	pending map[string]*apiObject.Pod
+}{pending: make(map[string]*apiObject.Pod)}
+
 // UpdatePodStatus 向 apiServer 更新 pod 的状态
+//
+//	已经有协程在上报该Pod的状态时只记录最新的状态，由该协程上报
+//	上报失败时按照指数退避重试，Pod已经被删除(404)或者与apiServer中的Pod冲突(409)时放弃上报
 func UpdatePodStatus(pod *apiObject.Pod) {
-	// 不断重试，直到更新成功
+	uuid := pod.GetPodUUID()
+	statusReporters.lock.Lock()
+	_, running := statusReporters.pending[uuid]
+	statusReporters.pending[uuid] = pod
+	statusReporters.lock.Unlock()
+	if running {
+		return
+	}
+
+	backoff := statusReportInitialBackoff
 	for {
-		url := config.HttpSchema + config.APIServerLocalAddress + ":" + fmt.Sprint(config.APIServerLocalPort) + config.PodStatusURI
-		url = strings.Replace(url, config.NameSpaceReplace, pod.Metadata.Namespace, -1)
-		url = strings.Replace(url, config.NameReplace, pod.Metadata.Name, -1)
-		res, err := httprequest.PutObjMsg(url, pod.Status)
-		if err != nil {
+		statusReporters.lock.Lock()
+		pod = statusReporters.pending[uuid]
+		statusReporters.lock.Unlock()
+
+		code, err := putPodStatus(pod)
+		switch {
+		case err != nil:
 			log.ErrorLog("UpdatePodStatus: " + err.Error())
-		} else if res.StatusCode != 200 {
-			log.ErrorLog("UpdatePodStatus error: " + res.Status)
-		} else {
-			break
+		case code == http.StatusNotFound || code == http.StatusConflict:
+			log.WarnLog("UpdatePodStatus: give up pod " + pod.Metadata.Namespace + "/" + pod.Metadata.Name + ": " + http.StatusText(code))
+		case code != http.StatusOK:
+			log.ErrorLog("UpdatePodStatus error: " + http.StatusText(code))
+		}
+
+		statusReporters.lock.Lock()
+		if err == nil && (code == http.StatusNotFound || code == http.StatusConflict) {
+			delete(statusReporters.pending, uuid)
+			statusReporters.lock.Unlock()
+			return
 		}
+		if err == nil && code == http.StatusOK {
+			// 上报期间没有新的状态时结束，否则立即上报最新的状态
+			if statusReporters.pending[uuid] == pod {
+				delete(statusReporters.pending, uuid)
+				statusReporters.lock.Unlock()
+				return
+			}
+			statusReporters.lock.Unlock()
+			backoff = statusReportInitialBackoff
+			continue
+		}
+		statusReporters.lock.Unlock()
+		time.Sleep(backoff)
+		backoff = min(backoff*2, statusReportMaxBackoff)
+	}
+}
+
+// putPodStatus 向 apiServer 发送 pod 的状态，返回响应的状态码
+func putPodStatus(pod *apiObject.Pod) (int, error) {
+	url := config.APIServerURL() + config.PodStatusURI
+	url = strings.Replace(url, config.NameSpaceReplace, pod.Metadata.Namespace, -1)
+	url = strings.Replace(url, config.NameReplace, pod.Metadata.Name, -1)
+	res, err := httprequest.PutObjMsg(url, pod.Status)
+	if err != nil {
+		return 0, err
 	}
+	res.Body.Close()
+	return res.StatusCode, nil
 }
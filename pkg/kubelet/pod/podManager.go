@@ -1,8 +1,13 @@
 package pod
 
 import (
+	"encoding/json"
 	"errors"
+	"sync"
+
 	"minik8s/pkg/apiObject"
+	"minik8s/pkg/kubelet/prober"
+	"minik8s/pkg/kubelet/restart"
 	"minik8s/pkg/kubelet/runtime"
 	"minik8s/tools/log"
 )
@@ -24,8 +29,14 @@ type PodManager interface {
 
 /*  */
 type podManagerImpl struct {
+	/* 保护PodMapByUUID以及其中pod的状态，pod会被探针、重启容器与读取日志等协程同时访问 */
+	lock sync.RWMutex
 	/* 实现从UUID到pod的映射 */
 	PodMapByUUID map[string]*apiObject.Pod
+	/* 正在创建的pod，避免同一个pod被重复创建 */
+	building map[string]bool
+	/* 正在重启的容器，键为pod的UUID与容器名，避免同一个容器被同时重启 */
+	restarting map[string]bool
 	/* 事件队列 */
 	EventQueue chan EventType
 	/* 不同事件的处理函数 */
@@ -36,7 +47,14 @@ type podManagerImpl struct {
 	DeletePodHandler         func(pod *apiObject.Pod) error
 	RecreateContainerHandler func(pod *apiObject.Pod) error
 	ExecPodHandler           func(req *apiObject.ExecReq) (string, error)
+	GetExecHandler           func(containerID string, cmd []string, stdin bool, tty bool) (string, error)
+	GetAttachHandler         func(containerID string, stdin bool, tty bool) (string, error)
 	UpdatePodStatusHandler   func(pod *apiObject.Pod) error
+	RestartContainerHandler  func(pod *apiObject.Pod, index int) error
+	/* 执行容器的探针，维护Pod的就绪状况 */
+	ProberManager *prober.Manager
+	/* 根据重启策略重启退出的容器 */
+	RestartManager *restart.Manager
 }
 
 /* Singleton pattern */
@@ -49,6 +67,8 @@ func GetPodManager() PodManager {
 		runtimeMgr := runtime.GetRuntimeManager()
 		podManager = &podManagerImpl{
 			PodMapByUUID:             newMapUUIDToPod,
+			building:                 make(map[string]bool),
+			restarting:               make(map[string]bool),
 			EventQueue:               eventChan,
 			AddPodHandler:            runtimeMgr.CreatePod,
 			StartPodHandler:          runtimeMgr.StartPod,
@@ -57,8 +77,17 @@ func GetPodManager() PodManager {
 			DeletePodHandler:         runtimeMgr.DeletePod,
 			RecreateContainerHandler: runtimeMgr.RecreatePodContainers,
 			ExecPodHandler:           runtimeMgr.ExecPodContainer,
+			GetExecHandler:           runtimeMgr.GetExec,
+			GetAttachHandler:         runtimeMgr.GetAttach,
 			UpdatePodStatusHandler:   runtimeMgr.UpdatePodStatus,
+			RestartContainerHandler:  runtimeMgr.RestartContainer,
 		}
+		containerRuntime := &unlockedRuntime{RuntimeManager: runtimeMgr, podManager: podManager}
+		podManager.RestartManager = restart.NewManager(containerRuntime)
+		// 就绪状况发生变化时立即上报，使Service及时更新后端
+		podManager.ProberManager = prober.NewManager(containerRuntime, &podManager.lock, func(pod *apiObject.Pod) {
+			go UpdatePodStatus(copyPod(pod))
+		})
 	}
 
 	return podManager
@@ -67,23 +96,33 @@ func GetPodManager() PodManager {
 func (p *podManagerImpl) AddPod(pod *apiObject.Pod) error {
 	log.InfoLog("Arrived into AddPod")
 	uuid := pod.GetPodUUID()
-	if _, ok := p.PodMapByUUID[uuid]; ok {
+	p.lock.Lock()
+	if _, ok := p.PodMapByUUID[uuid]; ok || p.building[uuid] {
+		p.lock.Unlock()
 		log.ErrorLog("Pod has been built already")
 		return errors.New("pod message has been handled")
 	}
+	p.building[uuid] = true
+	p.lock.Unlock()
 
+	// 创建pod需要拉取镜像，不持有锁，pod在创建完成后才会被其他协程访问
 	pod.Status.Phase = apiObject.PodBuilding
 
 	err := p.AddPodHandler(pod)
 	if err != nil {
 		log.ErrorLog("AddPodHandler error: " + err.Error())
 		pod.Status.Phase = apiObject.PodUnknown
-		return err
 	} else {
 		log.InfoLog("AddPodHandler success")
-		pod.Status.Phase = apiObject.PodSucceeded
+		pod.Status.Phase = apiObject.PodCreated
 	}
 
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.building, uuid)
+	if err != nil {
+		return err
+	}
 	p.PodMapByUUID[uuid] = pod
 	return nil
 }
@@ -91,12 +130,17 @@ func (p *podManagerImpl) AddPod(pod *apiObject.Pod) error {
 func (p *podManagerImpl) DeletePod(pod *apiObject.Pod) error {
 	log.DebugLog("[PodManager] Arrived into DeletePod")
 	uuid := pod.GetPodUUID()
+	p.lock.Lock()
 	if _, ok := p.PodMapByUUID[uuid]; !ok {
+		p.lock.Unlock()
 		log.ErrorLog("Pod has been deleted already")
 		return errors.New("pod message has been handled")
 	}
 
 	delete(p.PodMapByUUID, uuid)
+	p.lock.Unlock()
+	p.ProberManager.RemovePod(pod)
+	p.RestartManager.RemovePod(pod)
 
 	err := p.DeletePodHandler(pod)
 	if err != nil {
@@ -112,7 +156,10 @@ func (p *podManagerImpl) StartPod(pod *apiObject.Pod) error {
 	var msg string
 	log.DebugLog("[PodManager] Arrived into StartPod")
 	uuid := pod.GetPodUUID()
-	if _, ok := p.PodMapByUUID[uuid]; !ok {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	pod, ok := p.PodMapByUUID[uuid]
+	if !ok {
 		msg = "pod can't be found"
 		log.ErrorLog(msg)
 		return errors.New(msg)
@@ -120,7 +167,7 @@ func (p *podManagerImpl) StartPod(pod *apiObject.Pod) error {
 
 	// 需要对Pod的不同状况进行处理
 	switch pod.Status.Phase {
-	case apiObject.PodSucceeded:
+	case apiObject.PodCreated:
 		err := p.StartPodHandler(pod)
 		if err != nil {
 			log.ErrorLog("StartPodHandler error: " + err.Error())
@@ -129,6 +176,8 @@ func (p *podManagerImpl) StartPod(pod *apiObject.Pod) error {
 			log.InfoLog("StartPodHandler success")
 		}
 		pod.Status.Phase = apiObject.PodRunning
+		// 容器运行后开始执行探针
+		p.ProberManager.AddPod(pod)
 		return nil
 	case apiObject.PodRunning:
 		log.DebugLog("Pod has been running")
@@ -146,7 +195,10 @@ func (p *podManagerImpl) StartPod(pod *apiObject.Pod) error {
 func (p *podManagerImpl) StopPod(pod *apiObject.Pod) error {
 	log.DebugLog("[PodManager] Arrived into StopPod")
 	uuid := pod.GetPodUUID()
-	if _, ok := p.PodMapByUUID[uuid]; !ok {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	pod, ok := p.PodMapByUUID[uuid]
+	if !ok {
 		msg := "pod can't be found"
 		log.ErrorLog(msg)
 		return errors.New(msg)
@@ -159,7 +211,7 @@ func (p *podManagerImpl) StopPod(pod *apiObject.Pod) error {
 		} else {
 			log.InfoLog("StopPodHandler success")
 			// 回退到所有容器都被创建好的状态
-			pod.Status.Phase = apiObject.PodSucceeded
+			pod.Status.Phase = apiObject.PodCreated
 		}
 		return nil
 	} else {
@@ -173,12 +225,15 @@ func (p *podManagerImpl) StopPod(pod *apiObject.Pod) error {
 func (p *podManagerImpl) RestartPod(pod *apiObject.Pod) error {
 	log.DebugLog("[PodManager] Arrived into RestartPod")
 	uuid := pod.GetPodUUID()
-	if _, ok := p.PodMapByUUID[uuid]; !ok {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	pod, ok := p.PodMapByUUID[uuid]
+	if !ok {
 		msg := "pod can't be found"
 		log.ErrorLog(msg)
 		return errors.New(msg)
-	} else if pod.Status.Phase == apiObject.PodSucceeded || pod.Status.Phase == apiObject.PodFailed ||
-		pod.Status.Phase == apiObject.PodRunning {
+	} else if pod.Status.Phase == apiObject.PodCreated || pod.Status.Phase == apiObject.PodSucceeded ||
+		pod.Status.Phase == apiObject.PodFailed || pod.Status.Phase == apiObject.PodRunning {
 		err := p.StopPodHandler(pod)
 		if err != nil {
 			log.ErrorLog("RestartPodHandler error: " + err.Error())
@@ -189,7 +244,7 @@ func (p *podManagerImpl) RestartPod(pod *apiObject.Pod) error {
 			pod.Status.Phase = apiObject.PodRunning
 		}
 		// 回退到所有容器都被创建好的状态
-		pod.Status.Phase = apiObject.PodSucceeded
+		pod.Status.Phase = apiObject.PodCreated
 		return nil
 	} else {
 		msg := "status error"
@@ -212,6 +267,12 @@ func (p *podManagerImpl) ExecPodContainer(req *apiObject.ExecReq) (string, error
 }
 
 func (p *podManagerImpl) UpdatePodStatus() error {
+	type containerRestart struct {
+		pod   *apiObject.Pod
+		index int
+	}
+	var restarts []containerRestart
+	p.lock.Lock()
 	for _, pod := range p.PodMapByUUID {
 		if pod.Status.Phase == apiObject.PodPending || pod.Status.Phase == apiObject.PodBuilding {
 			continue
@@ -219,21 +280,136 @@ func (p *podManagerImpl) UpdatePodStatus() error {
 		err := p.UpdatePodStatusHandler(pod)
 		if err != nil {
 			log.ErrorLog("Get status failed in pod ID : " + pod.GetPodUUID())
+			continue
+		}
+		// 根据重启策略处理退出的容器，并根据最新的容器状态重新计算就绪状况，发生变化时再次上报
+		changed, indexes := p.RestartManager.SyncPod(pod)
+		for _, index := range indexes {
+			restarts = append(restarts, containerRestart{pod: copyPod(pod), index: index})
+		}
+		if p.ProberManager.UpdatePodStatus(pod) || changed {
+			go UpdatePodStatus(copyPod(pod))
+		}
+	}
+	p.lock.Unlock()
+
+	// 重启容器需要等待容器终止并重新创建，在释放锁之后进行
+	for _, r := range restarts {
+		err := p.RestartManager.RestartContainer(r.pod, r.index)
+		if err != nil && !errors.Is(err, errContainerRestarting) {
+			log.ErrorLog("restart container " + r.pod.Spec.Containers[r.index].Name + " failed: " + err.Error())
 		}
 	}
 	return nil
 }
 
+// restartContainer 在pod的副本上重启容器，重启期间不持有锁，完成后将容器的新状态写回pod
+func (p *podManagerImpl) restartContainer(uuid string, index int) error {
+	p.lock.Lock()
+	pod, ok := p.PodMapByUUID[uuid]
+	if !ok || index >= len(pod.Spec.Containers) {
+		p.lock.Unlock()
+		return errors.New("pod can't be found")
+	}
+	name := pod.Spec.Containers[index].Name
+	key := uuid + "/" + name
+	if p.restarting[key] {
+		p.lock.Unlock()
+		return errContainerRestarting
+	}
+	p.restarting[key] = true
+	podCopy := copyPod(pod)
+	p.lock.Unlock()
+
+	err := p.RestartContainerHandler(podCopy, index)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.restarting, key)
+	// 重启期间pod可能已经被删除
+	pod, ok = p.PodMapByUUID[uuid]
+	if !ok || index >= len(pod.Spec.Containers) || pod.Spec.Containers[index].Name != name {
+		return err
+	}
+	container := &pod.Spec.Containers[index]
+	container.ContainerID = podCopy.Spec.Containers[index].ContainerID
+	container.ContainerStatus = podCopy.Spec.Containers[index].ContainerStatus
+	if restarted := podCopy.Status.GetContainerStatus(name); restarted != nil {
+		if status := pod.Status.GetContainerStatus(name); status != nil {
+			*status = *restarted
+		} else {
+			pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, *restarted)
+		}
+	}
+	if err == nil {
+		p.ProberManager.UpdatePodStatus(pod)
+		go UpdatePodStatus(copyPod(pod))
+	}
+	return err
+}
+
+// errContainerRestarting 容器正在被其他协程重启
+var errContainerRestarting = errors.New("container is being restarted")
+
+// unlockedRuntime 供重启管理器与探针使用的容器运行时，重启容器时不需要调用者持有pod的锁
+type unlockedRuntime struct {
+	*runtime.RuntimeManager
+	podManager *podManagerImpl
+}
+
+func (r *unlockedRuntime) RestartContainer(pod *apiObject.Pod, index int) error {
+	return r.podManager.restartContainer(pod.GetPodUUID(), index)
+}
+
 func (p *podManagerImpl) SyncPods(pods *[]apiObject.Pod) error {
 	// 把apiServer的pods信息同步到本地
+	p.lock.Lock()
+	defer p.lock.Unlock()
 	if len(p.PodMapByUUID) != 0 {
 		log.DebugLog("PodMapByUUID is not empty")
 		return nil
 	}
 	for _, pod := range *pods {
 		p.PodMapByUUID[pod.GetPodUUID()] = &pod
+		if pod.Status.Phase == apiObject.PodRunning {
+			p.ProberManager.AddPod(&pod)
+		}
 	}
 
 	log.InfoLog("Sync pods success!")
 	return nil
 }
+
+// getPod 返回指定UUID的pod的拷贝，pod不存在时返回nil
+func (p *podManagerImpl) getPod(uuid string) *apiObject.Pod {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if pod, ok := p.PodMapByUUID[uuid]; ok {
+		return copyPod(pod)
+	}
+	return nil
+}
+
+// listPods 返回所有pod的拷贝
+func (p *podManagerImpl) listPods() []*apiObject.Pod {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	pods := make([]*apiObject.Pod, 0, len(p.PodMapByUUID))
+	for _, pod := range p.PodMapByUUID {
+		pods = append(pods, copyPod(pod))
+	}
+	return pods
+}
+
+// copyPod 深拷贝pod，调用者需要持有锁，拷贝可以在释放锁后交给其他协程使用
+func copyPod(pod *apiObject.Pod) *apiObject.Pod {
+	podCopy := &apiObject.Pod{}
+	data, err := json.Marshal(pod)
+	if err == nil {
+		err = json.Unmarshal(data, podCopy)
+	}
+	if err != nil {
+		log.ErrorLog("copy pod " + pod.GetPodUUID() + " failed: " + err.Error())
+	}
+	return podCopy
+}
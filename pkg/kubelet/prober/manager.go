@@ -0,0 +1,163 @@
+package prober
+
+import (
+	"strings"
+	"sync"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/tools/log"
+)
+
+// Manager 为容器的每个探针启动一个worker，记录探测结果，并据此维护Pod的ContainersReady与Ready状况
+//
+//	存活探针或启动探针失败时重启容器，就绪探针的结果决定Pod是否会被Service选为后端
+type Manager struct {
+	runtime ContainerRuntime
+	// 保护Pod的状态，worker读取容器的状态以及更新Pod的状况时需要持有，与kubelet其他修改Pod的协程共用
+	podLock sync.Locker
+	// 就绪状况发生变化时调用，用于立即向apiServer上报Pod的状态
+	statusUpdater func(pod *apiObject.Pod)
+
+	lock    sync.Mutex
+	workers map[probeKey]*worker
+	// 每个探针当前的探测结果
+	results map[probeKey]bool
+}
+
+type probeKey struct {
+	podUID    string
+	container string
+	probeType probeType
+}
+
+func NewManager(runtime ContainerRuntime, podLock sync.Locker, statusUpdater func(pod *apiObject.Pod)) *Manager {
+	return &Manager{
+		runtime:       runtime,
+		podLock:       podLock,
+		statusUpdater: statusUpdater,
+		workers:       make(map[probeKey]*worker),
+		results:       make(map[probeKey]bool),
+	}
+}
+
+// AddPod 为Pod中定义的每个探针启动worker，已经启动的探针不会重复启动，调用者需要持有Pod的锁
+func (m *Manager) AddPod(pod *apiObject.Pod) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		probes := map[probeType]*apiObject.Probe{
+			liveness:  container.LivenessProbe,
+			readiness: container.ReadinessProbe,
+			startup:   container.StartupProbe,
+		}
+		for t, probe := range probes {
+			if probe == nil {
+				continue
+			}
+			key := probeKey{podUID: pod.GetPodUUID(), container: container.Name, probeType: t}
+			if _, ok := m.workers[key]; ok {
+				continue
+			}
+			w := newWorker(m, t, pod, i, probe)
+			m.workers[key] = w
+			go w.run()
+		}
+	}
+}
+
+// RemovePod 停止Pod的所有worker并清除探测结果
+func (m *Manager) RemovePod(pod *apiObject.Pod) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key, w := range m.workers {
+		if key.podUID == pod.GetPodUUID() {
+			w.stop()
+			delete(m.workers, key)
+			delete(m.results, key)
+		}
+	}
+}
+
+// UpdatePodStatus 根据容器的运行状态与探测结果设置Pod的ContainersReady与Ready状况，返回状况是否发生了变化
+//
+//	容器处于运行状态、启动探针已经成功并且就绪探针成功时就绪，没有定义的探针视为成功
+//	调用者需要持有Pod的锁
+func (m *Manager) UpdatePodStatus(pod *apiObject.Pod) bool {
+	m.lock.Lock()
+	var notReady []string
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		if container.ContainerStatus != apiObject.ContainerRunning ||
+			!m.probePassedLocked(pod, i, startup) || !m.probePassedLocked(pod, i, readiness) {
+			notReady = append(notReady, container.Name)
+		}
+	}
+	m.lock.Unlock()
+
+	condition := apiObject.PodCondition{Status: apiObject.ConditionTrue}
+	if len(notReady) > 0 || len(pod.Spec.Containers) == 0 {
+		condition.Status = apiObject.ConditionFalse
+		condition.Reason = "ContainersNotReady"
+		condition.Message = "containers with unready status: [" + strings.Join(notReady, " ") + "]"
+	}
+	condition.Type = apiObject.ContainersReady
+	changed := pod.Status.SetCondition(condition)
+	condition.Type = apiObject.PodReady
+	return pod.Status.SetCondition(condition) || changed
+}
+
+// startupPassed 判断容器的启动探针是否已经成功，没有定义启动探针时视为成功
+func (m *Manager) startupPassed(pod *apiObject.Pod, index int) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.probePassedLocked(pod, index, startup)
+}
+
+func (m *Manager) probePassedLocked(pod *apiObject.Pod, index int, t probeType) bool {
+	container := &pod.Spec.Containers[index]
+	key := probeKey{podUID: pod.GetPodUUID(), container: container.Name, probeType: t}
+	if _, ok := m.workers[key]; !ok {
+		return (t == startup && container.StartupProbe == nil) || (t == readiness && container.ReadinessProbe == nil)
+	}
+	return m.results[key]
+}
+
+// setResult 记录worker的探测结果，就绪探针或启动探针的结果发生变化时重新计算Pod的就绪状况
+func (m *Manager) setResult(w *worker, result bool) {
+	key := probeKey{podUID: w.pod.GetPodUUID(), container: w.pod.Spec.Containers[w.index].Name, probeType: w.probeType}
+	m.lock.Lock()
+	// Pod已经被删除时忽略仍在进行的探测
+	if m.workers[key] != w {
+		m.lock.Unlock()
+		return
+	}
+	old, ok := m.results[key]
+	m.results[key] = result
+	m.lock.Unlock()
+	if (ok && old == result) || w.probeType == liveness {
+		return
+	}
+	m.podLock.Lock()
+	defer m.podLock.Unlock()
+	if m.UpdatePodStatus(w.pod) && m.statusUpdater != nil {
+		m.statusUpdater(w.pod)
+	}
+}
+
+// restartContainer 存活探针或启动探针失败时重启容器
+//
+//	重启需要等待容器终止并重新创建，不持有Pod的锁，完成后再根据新的容器重新计算就绪状况
+func (m *Manager) restartContainer(pod *apiObject.Pod, index int, reason string) {
+	name := pod.Spec.Containers[index].Name
+	log.InfoLog("restart container " + pod.Metadata.Namespace + "/" + pod.Metadata.Name + "/" + name + ": " + reason)
+	if err := m.runtime.RestartContainer(pod, index); err != nil {
+		log.ErrorLog("restart container " + name + " failed: " + err.Error())
+		return
+	}
+	m.podLock.Lock()
+	defer m.podLock.Unlock()
+	if m.UpdatePodStatus(pod) && m.statusUpdater != nil {
+		m.statusUpdater(pod)
+	}
+}
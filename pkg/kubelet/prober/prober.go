@@ -0,0 +1,106 @@
+package prober
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"minik8s/pkg/apiObject"
+)
+
+// ContainerRuntime 执行探针与重启容器所需的容器运行时接口
+type ContainerRuntime interface {
+	// ExecSync 在容器中同步执行命令，返回标准输出与退出码
+	ExecSync(containerID string, cmd []string, timeout time.Duration) ([]byte, int32, error)
+	// RestartContainer 重新创建并启动Pod中的指定容器，并在Pod的状态中增加容器的重启次数，调用时不持有Pod的锁
+	RestartContainer(pod *apiObject.Pod, index int) error
+}
+
+// probeType 探针的类型
+type probeType string
+
+const (
+	liveness  probeType = "Liveness"
+	readiness probeType = "Readiness"
+	startup   probeType = "Startup"
+)
+
+// runProbe 对容器执行一次探测，返回是否成功以及失败的原因
+func runProbe(runtime ContainerRuntime, probe *apiObject.Probe, podIP string, containerID string) (bool, string) {
+	timeout := time.Duration(probe.TimeoutSeconds) * time.Second
+	switch {
+	case probe.Exec != nil:
+		return probeExec(runtime, containerID, probe.Exec.Command, timeout)
+	case probe.HTTPGet != nil:
+		return probeHTTPGet(probe.HTTPGet, podIP, timeout)
+	case probe.TCPSocket != nil:
+		return probeTCPSocket(probe.TCPSocket, podIP, timeout)
+	default:
+		return false, "probe does not specify exec, httpGet or tcpSocket"
+	}
+}
+
+// probeExec 在容器中执行命令，退出码为0时探测成功
+func probeExec(runtime ContainerRuntime, containerID string, command []string, timeout time.Duration) (bool, string) {
+	output, exitCode, err := runtime.ExecSync(containerID, command, timeout)
+	if err != nil {
+		return false, "exec probe failed: " + err.Error()
+	}
+	if exitCode != 0 {
+		return false, fmt.Sprintf("command %v exited with code %d: %s", command, exitCode, strings.TrimSpace(string(output)))
+	}
+	return true, ""
+}
+
+// probeTransport 所有HTTP探针共用的Transport，探测完成后关闭连接，避免每次探测都留下空闲连接
+//
+//	与kubernetes相同，HTTPS探针不校验证书
+var probeTransport = &http.Transport{
+	TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+	DisableKeepAlives: true,
+}
+
+// probeHTTPGet 发送HTTP GET请求，状态码在200到399之间时探测成功
+func probeHTTPGet(action *apiObject.HTTPGetAction, podIP string, timeout time.Duration) (bool, string) {
+	host := action.Host
+	if host == "" {
+		host = podIP
+	}
+	scheme := strings.ToLower(action.Scheme)
+	if scheme == "" {
+		scheme = "http"
+	}
+	path := action.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	url := scheme + "://" + net.JoinHostPort(host, strconv.Itoa(action.Port)) + path
+	client := &http.Client{Timeout: timeout, Transport: probeTransport}
+	resp, err := client.Get(url)
+	if err != nil {
+		return false, "HTTP probe failed: " + err.Error()
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		return false, fmt.Sprintf("HTTP probe failed with statuscode: %d", resp.StatusCode)
+	}
+	return true, ""
+}
+
+// probeTCPSocket 与容器的端口建立TCP连接，连接成功时探测成功
+func probeTCPSocket(action *apiObject.TCPSocketAction, podIP string, timeout time.Duration) (bool, string) {
+	host := action.Host
+	if host == "" {
+		host = podIP
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(action.Port)), timeout)
+	if err != nil {
+		return false, "TCP probe failed: " + err.Error()
+	}
+	_ = conn.Close()
+	return true, ""
+}
@@ -0,0 +1,155 @@
+package prober
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+)
+
+// fakeRuntime 按照预设的退出码执行命令，重启容器时生成新的容器ID
+type fakeRuntime struct {
+	exitCodes map[string]int32
+	restarted []string
+}
+
+func (f *fakeRuntime) ExecSync(_ string, cmd []string, _ time.Duration) ([]byte, int32, error) {
+	return nil, f.exitCodes[cmd[0]], nil
+}
+
+func (f *fakeRuntime) RestartContainer(pod *apiObject.Pod, index int) error {
+	container := &pod.Spec.Containers[index]
+	f.restarted = append(f.restarted, container.Name)
+	container.ContainerID += "-restarted"
+	return nil
+}
+
+func newProbePod(container apiObject.Container) *apiObject.Pod {
+	container.ContainerID = container.Name + "-id"
+	container.ContainerStatus = apiObject.ContainerRunning
+	return &apiObject.Pod{
+		Metadata: apiObject.ObjectMeta{Name: "web", Namespace: "default", UUID: "web-uid"},
+		Spec:     apiObject.PodSpec{Containers: []apiObject.Container{container}},
+	}
+}
+
+func execProbe(command string, successThreshold int32, failureThreshold int32) *apiObject.Probe {
+	return &apiObject.Probe{
+		Exec:             &apiObject.ExecAction{Command: []string{command}},
+		SuccessThreshold: successThreshold,
+		FailureThreshold: failureThreshold,
+	}
+}
+
+// addWorker 注册worker但不启动探测协程，由测试逐次调用doProbe
+func addWorker(m *Manager, t probeType, pod *apiObject.Pod, probe *apiObject.Probe) *worker {
+	w := newWorker(m, t, pod, 0, probe)
+	m.workers[probeKey{podUID: pod.GetPodUUID(), container: pod.Spec.Containers[0].Name, probeType: t}] = w
+	return w
+}
+
+func TestProbeHTTPGetAndTCPSocket(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	host, portString, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portString)
+
+	success, _ := probeHTTPGet(&apiObject.HTTPGetAction{Path: "healthz", Port: port}, host, time.Second)
+	assert.True(t, success)
+	success, _ = probeHTTPGet(&apiObject.HTTPGetAction{Path: "/broken", Port: port}, host, time.Second)
+	assert.False(t, success)
+
+	success, _ = probeTCPSocket(&apiObject.TCPSocketAction{Port: port}, host, time.Second)
+	assert.True(t, success)
+	server.Close()
+	success, _ = probeTCPSocket(&apiObject.TCPSocketAction{Port: port}, host, time.Second)
+	assert.False(t, success)
+}
+
+func TestReadinessThresholds(t *testing.T) {
+	runtime := &fakeRuntime{exitCodes: map[string]int32{"fail": 1}}
+	m := NewManager(runtime, &sync.Mutex{}, nil)
+	pod := newProbePod(apiObject.Container{Name: "app", ReadinessProbe: execProbe("ok", 2, 2)})
+	w := addWorker(m, readiness, pod, pod.Spec.Containers[0].ReadinessProbe)
+
+	// 连续成功两次后才就绪
+	w.doProbe()
+	assert.False(t, pod.Status.IsReady())
+	w.doProbe()
+	assert.True(t, pod.Status.IsReady())
+
+	// 连续失败两次后不再就绪，就绪探针失败不会重启容器
+	w.probe.Exec.Command = []string{"fail"}
+	w.doProbe()
+	assert.True(t, pod.Status.IsReady())
+	w.doProbe()
+	assert.False(t, pod.Status.IsReady())
+	assert.Empty(t, runtime.restarted)
+}
+
+func TestStartupAndLivenessProbes(t *testing.T) {
+	runtime := &fakeRuntime{exitCodes: map[string]int32{"starting": 1, "dead": 1}}
+	m := NewManager(runtime, &sync.Mutex{}, nil)
+	pod := newProbePod(apiObject.Container{
+		Name:          "app",
+		StartupProbe:  execProbe("starting", 1, 3),
+		LivenessProbe: execProbe("dead", 1, 1),
+	})
+	startupWorker := addWorker(m, startup, pod, pod.Spec.Containers[0].StartupProbe)
+	livenessWorker := addWorker(m, liveness, pod, pod.Spec.Containers[0].LivenessProbe)
+
+	// 启动探针成功之前不执行存活探针，Pod也不会就绪
+	startupWorker.doProbe()
+	livenessWorker.doProbe()
+	assert.Empty(t, runtime.restarted)
+	assert.False(t, pod.Status.IsReady())
+
+	startupWorker.probe.Exec.Command = []string{"ok"}
+	startupWorker.doProbe()
+	assert.True(t, pod.Status.IsReady())
+
+	// 存活探针失败后重启容器，重启后的容器需要重新通过启动探针
+	livenessWorker.doProbe()
+	assert.Equal(t, []string{"app"}, runtime.restarted)
+	startupWorker.probe.Exec.Command = []string{"starting"}
+	startupWorker.doProbe()
+	assert.False(t, m.startupPassed(pod, 0))
+}
+
+func TestProbeWhileContainerRestarts(t *testing.T) {
+	podLock := &sync.Mutex{}
+	m := NewManager(&fakeRuntime{}, podLock, nil)
+	pod := newProbePod(apiObject.Container{Name: "app", ReadinessProbe: execProbe("ok", 1, 1)})
+	w := addWorker(m, readiness, pod, pod.Spec.Containers[0].ReadinessProbe)
+
+	// kubelet在持有Pod的锁时重启容器，worker同时进行探测
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			podLock.Lock()
+			pod.Spec.Containers[0].ContainerID = "app-id-" + strconv.Itoa(i)
+			podLock.Unlock()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		w.doProbe()
+	}
+	<-done
+	w.doProbe()
+	podLock.Lock()
+	defer podLock.Unlock()
+	assert.Equal(t, "app-id-99", w.containerID)
+	assert.True(t, pod.Status.IsReady())
+}
@@ -0,0 +1,133 @@
+package prober
+
+import (
+	"time"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/tools/log"
+)
+
+// worker 周期性地执行容器的一个探针
+type worker struct {
+	manager   *Manager
+	probeType probeType
+	pod       *apiObject.Pod
+	// 容器在Pod中的下标
+	index int
+	// 填写了默认值的探针
+	probe  apiObject.Probe
+	stopCh chan struct{}
+
+	// 正在探测的容器ID，容器被重启后ID会发生变化，此时重新开始探测
+	containerID string
+	// 开始探测当前容器的时间，用于计算initialDelaySeconds
+	startedAt time.Time
+	// 连续成功与连续失败的次数
+	successes int32
+	failures  int32
+	// 当前的探测结果
+	result bool
+}
+
+func newWorker(manager *Manager, probeType probeType, pod *apiObject.Pod, index int, probe *apiObject.Probe) *worker {
+	w := &worker{
+		manager:   manager,
+		probeType: probeType,
+		pod:       pod,
+		index:     index,
+		probe:     *probe,
+		stopCh:    make(chan struct{}),
+	}
+	w.probe.SetDefaults()
+	return w
+}
+
+// run 每隔periodSeconds执行一次探测，直到worker被停止
+func (w *worker) run() {
+	ticker := time.NewTicker(time.Duration(w.probe.PeriodSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		w.doProbe()
+		select {
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *worker) stop() {
+	close(w.stopCh)
+}
+
+func (w *worker) doProbe() {
+	// 容器可能同时被重启，复制容器的状态后在锁外进行探测
+	w.manager.podLock.Lock()
+	container := w.pod.Spec.Containers[w.index]
+	podIP := w.pod.Status.PodIP
+	w.manager.podLock.Unlock()
+	if container.ContainerID != w.containerID {
+		// 容器被创建或者重启，存活探针视为成功，就绪探针与启动探针视为失败，重新开始探测
+		w.containerID = container.ContainerID
+		w.startedAt = time.Now()
+		w.successes, w.failures = 0, 0
+		w.setResult(w.probeType == liveness)
+	}
+	if container.ContainerStatus != apiObject.ContainerRunning {
+		if w.probeType == readiness {
+			w.setResult(false)
+		}
+		return
+	}
+	// 启动探针成功后不再探测，直到容器被重启；启动探针成功之前不执行其他探针
+	if w.probeType == startup && w.result {
+		return
+	}
+	if w.probeType != startup && !w.manager.startupPassed(w.pod, w.index) {
+		return
+	}
+	if time.Since(w.startedAt) < time.Duration(w.probe.InitialDelaySeconds)*time.Second {
+		return
+	}
+
+	success, message := runProbe(w.manager.runtime, &w.probe, podIP, container.ContainerID)
+	if !success {
+		log.WarnLog(string(w.probeType) + " probe of container " + w.pod.Metadata.Namespace + "/" + w.pod.Metadata.Name +
+			"/" + container.Name + " failed: " + message)
+	}
+	if w.observe(success) {
+		w.manager.restartContainer(w.pod, w.index, string(w.probeType)+" probe failed: "+message)
+	}
+}
+
+// observe 根据连续成功或失败的次数更新探测结果，返回是否需要重启容器
+//
+//	连续成功successThreshold次后结果变为成功，连续失败failureThreshold次后结果变为失败
+//	存活探针与启动探针变为失败时需要重启容器，重启后重新计数
+func (w *worker) observe(success bool) bool {
+	if success {
+		w.successes++
+		w.failures = 0
+		if w.successes >= w.probe.SuccessThreshold {
+			w.setResult(true)
+		}
+		return false
+	}
+	w.failures++
+	w.successes = 0
+	if w.failures < w.probe.FailureThreshold {
+		return false
+	}
+	w.setResult(false)
+	if w.probeType == readiness {
+		return false
+	}
+	w.failures = 0
+	return true
+}
+
+// setResult 记录探测结果，结果发生变化时通知manager
+func (w *worker) setResult(result bool) {
+	w.result = result
+	w.manager.setResult(w, result)
+}
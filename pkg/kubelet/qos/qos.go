@@ -0,0 +1,112 @@
+// 描述: 根据容器的资源请求与限制计算Pod的服务质量等级，以及容器的oom_score_adj
+// 参考：https://github.com/kubernetes/kubernetes/blob/master/pkg/kubelet/qos/policy.go
+
+package qos
+
+import (
+	"minik8s/pkg/apiObject"
+	"minik8s/tools/conversion"
+)
+
+const (
+	// guaranteedOOMScoreAdj Guaranteed的容器最后被OOM killer终止
+	guaranteedOOMScoreAdj = -997
+	// besteffortOOMScoreAdj BestEffort的容器最先被OOM killer终止
+	besteffortOOMScoreAdj = 1000
+)
+
+// supportedResources 参与计算服务质量等级的资源
+var supportedResources = []string{apiObject.ResourceCPU, apiObject.ResourceMemory}
+
+// GetPodQOS 计算Pod的服务质量等级
+//
+//	容器只设置了限制时，请求等于限制
+func GetPodQOS(pod *apiObject.Pod) apiObject.PodQOSClass {
+	requests := map[string]int64{}
+	limits := map[string]int64{}
+	isGuaranteed := true
+	for i := range pod.Spec.Containers {
+		containerRequests, containerLimits := containerResources(&pod.Spec.Containers[i])
+		for name, value := range containerRequests {
+			requests[name] += value
+		}
+		for name, value := range containerLimits {
+			limits[name] += value
+		}
+		// 每个容器都需要同时设置cpu与memory的限制
+		if len(containerLimits) != len(supportedResources) {
+			isGuaranteed = false
+		}
+	}
+	if len(requests) == 0 && len(limits) == 0 {
+		return apiObject.PodQOSBestEffort
+	}
+	if isGuaranteed && len(requests) == len(limits) {
+		for name, request := range requests {
+			if limit, ok := limits[name]; !ok || limit != request {
+				isGuaranteed = false
+				break
+			}
+		}
+		if isGuaranteed {
+			return apiObject.PodQOSGuaranteed
+		}
+	}
+	return apiObject.PodQOSBurstable
+}
+
+// containerResources 返回容器大于0的cpu（毫核）与memory（KB）请求和限制，无法解析的数量视为未设置
+func containerResources(container *apiObject.Container) (map[string]int64, map[string]int64) {
+	requests := map[string]int64{}
+	limits := map[string]int64{}
+	for _, name := range supportedResources {
+		if quantity, ok := container.Resources.Limits[name]; ok {
+			if value, err := conversion.ParseQuantity(name, quantity); err == nil && value > 0 {
+				limits[name] = value
+			}
+		}
+		quantity, ok := container.Resources.Requests[name]
+		if !ok {
+			if limit, ok := limits[name]; ok {
+				requests[name] = limit
+			}
+			continue
+		}
+		if value, err := conversion.ParseQuantity(name, quantity); err == nil && value > 0 {
+			requests[name] = value
+		}
+	}
+	return requests, limits
+}
+
+// GetContainerOOMScoreAdjust 计算容器的oom_score_adj，节点内存不足时分数越高的进程越先被终止
+//
+//	Burstable的容器按照内存请求占节点内存容量memoryCapacity（字节）的比例计算，请求越多分数越低
+func GetContainerOOMScoreAdjust(pod *apiObject.Pod, container *apiObject.Container, memoryCapacity int64) int64 {
+	switch GetPodQOS(pod) {
+	case apiObject.PodQOSGuaranteed:
+		return guaranteedOOMScoreAdj
+	case apiObject.PodQOSBestEffort:
+		return besteffortOOMScoreAdj
+	}
+	var memoryRequest int64
+	quantity, ok := container.Resources.Requests[apiObject.ResourceMemory]
+	if !ok {
+		quantity = container.Resources.Limits[apiObject.ResourceMemory]
+	}
+	if value, err := conversion.ParseMemoryBytes(quantity); err == nil {
+		memoryRequest = value
+	}
+	if memoryCapacity <= 0 {
+		return besteffortOOMScoreAdj - 1
+	}
+	oomScoreAdjust := 1000 - (1000*memoryRequest)/memoryCapacity
+	// Burstable的分数需要高于Guaranteed，低于BestEffort
+	if oomScoreAdjust < 1000+guaranteedOOMScoreAdj {
+		return 1000 + guaranteedOOMScoreAdj
+	}
+	if oomScoreAdjust == besteffortOOMScoreAdj {
+		return oomScoreAdjust - 1
+	}
+	return oomScoreAdjust
+}
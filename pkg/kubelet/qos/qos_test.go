@@ -0,0 +1,58 @@
+package qos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+)
+
+func newContainer(requests apiObject.ResourceList, limits apiObject.ResourceList) apiObject.Container {
+	return apiObject.Container{Resources: apiObject.ResourceRequirements{Requests: requests, Limits: limits}}
+}
+
+func newPod(containers ...apiObject.Container) *apiObject.Pod {
+	return &apiObject.Pod{Spec: apiObject.PodSpec{Containers: containers}}
+}
+
+func TestGetPodQOS(t *testing.T) {
+	full := apiObject.ResourceList{apiObject.ResourceCPU: "500m", apiObject.ResourceMemory: "128Mi"}
+	cases := []struct {
+		name     string
+		pod      *apiObject.Pod
+		expected apiObject.PodQOSClass
+	}{
+		{"no resources", newPod(newContainer(nil, nil), newContainer(nil, nil)), apiObject.PodQOSBestEffort},
+		{"zero requests", newPod(newContainer(apiObject.ResourceList{apiObject.ResourceCPU: "0"}, nil)), apiObject.PodQOSBestEffort},
+		{"requests equal limits", newPod(newContainer(full, full)), apiObject.PodQOSGuaranteed},
+		// 只设置限制时请求等于限制
+		{"limits only", newPod(newContainer(nil, full), newContainer(nil, apiObject.ResourceList{apiObject.ResourceCPU: "1", apiObject.ResourceMemory: "1Gi"})), apiObject.PodQOSGuaranteed},
+		{"requests lower than limits", newPod(newContainer(apiObject.ResourceList{apiObject.ResourceCPU: "250m", apiObject.ResourceMemory: "128Mi"}, full)), apiObject.PodQOSBurstable},
+		{"memory limit missing", newPod(newContainer(nil, apiObject.ResourceList{apiObject.ResourceCPU: "1"})), apiObject.PodQOSBurstable},
+		{"one container without limits", newPod(newContainer(full, full), newContainer(nil, nil)), apiObject.PodQOSBurstable},
+		{"requests only", newPod(newContainer(apiObject.ResourceList{apiObject.ResourceMemory: "64Mi"}, nil)), apiObject.PodQOSBurstable},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, GetPodQOS(c.pod), c.name)
+	}
+}
+
+func TestGetContainerOOMScoreAdjust(t *testing.T) {
+	const capacity = 4 << 30
+	full := apiObject.ResourceList{apiObject.ResourceCPU: "1", apiObject.ResourceMemory: "1Gi"}
+
+	guaranteed := newPod(newContainer(full, full))
+	assert.Equal(t, int64(-997), GetContainerOOMScoreAdjust(guaranteed, &guaranteed.Spec.Containers[0], capacity))
+	bestEffort := newPod(newContainer(nil, nil))
+	assert.Equal(t, int64(1000), GetContainerOOMScoreAdjust(bestEffort, &bestEffort.Spec.Containers[0], capacity))
+
+	// 请求节点1/4的内存
+	burstable := newPod(newContainer(apiObject.ResourceList{apiObject.ResourceMemory: "1Gi"}, nil), newContainer(nil, nil))
+	assert.Equal(t, int64(750), GetContainerOOMScoreAdjust(burstable, &burstable.Spec.Containers[0], capacity))
+	// 没有内存请求的容器分数略低于BestEffort
+	assert.Equal(t, int64(999), GetContainerOOMScoreAdjust(burstable, &burstable.Spec.Containers[1], capacity))
+	// 请求几乎全部内存的容器分数仍然高于Guaranteed
+	burstable.Spec.Containers[0].Resources.Requests[apiObject.ResourceMemory] = "4Gi"
+	assert.Equal(t, int64(3), GetContainerOOMScoreAdjust(burstable, &burstable.Spec.Containers[0], capacity))
+}
@@ -0,0 +1,194 @@
+package restart
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/tools/log"
+)
+
+const (
+	// 容器第二次退出后等待10秒再重启，之后每次退出等待的时间翻倍
+	initialBackoff = 10 * time.Second
+	// 等待的时间最多为5分钟
+	maxBackoff = 5 * time.Minute
+	// 容器稳定运行超过该时间后重新从initialBackoff开始计算
+	backoffResetDuration = 2 * maxBackoff
+	// 无法获取退出状态的容器视为以该退出码退出，与kubernetes相同
+	unknownExitCode = 137
+)
+
+// ContainerRuntime 重启容器所需的容器运行时接口
+type ContainerRuntime interface {
+	// RestartContainer 重新创建并启动Pod中的指定容器，并在Pod的状态中增加容器的重启次数，调用时不持有Pod的锁
+	RestartContainer(pod *apiObject.Pod, index int) error
+}
+
+// Manager 根据Pod的重启策略重启已经退出的容器，并在所有容器都不再重启时设置Pod的最终阶段
+//
+//	同一个容器反复退出时按照指数退避等待，等待期间容器处于CrashLoopBackOff状态
+type Manager struct {
+	runtime ContainerRuntime
+	// 获取当前时间，便于测试
+	now func() time.Time
+
+	lock     sync.Mutex
+	backoffs map[backoffKey]*backoffEntry
+}
+
+type backoffKey struct {
+	podUID    string
+	container string
+}
+
+type backoffEntry struct {
+	// 下一次退出后需要等待的时间
+	backoff time.Duration
+	// 最近一次重启容器的时间
+	lastRestart time.Time
+}
+
+func NewManager(runtime ContainerRuntime) *Manager {
+	return &Manager{
+		runtime:  runtime,
+		now:      time.Now,
+		backoffs: make(map[backoffKey]*backoffEntry),
+	}
+}
+
+// RemovePod 清除Pod中所有容器的退避记录
+func (m *Manager) RemovePod(pod *apiObject.Pod) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key := range m.backoffs {
+		if key.podUID == pod.GetPodUUID() {
+			delete(m.backoffs, key)
+		}
+	}
+}
+
+// SyncPod 根据运行时上报的容器状态处理运行中的Pod，返回Pod的状态是否发生了变化以及需要重启的容器
+//
+//	需要重启的容器在退避时间结束后返回给调用者，否则将其状态设置为CrashLoopBackOff
+//	所有容器都已经退出并且不会再重启时，全部以0状态退出的Pod进入Succeeded阶段，否则进入Failed阶段
+//	调用者需要持有Pod的锁，并在释放锁之后调用RestartContainer重启返回的容器
+func (m *Manager) SyncPod(pod *apiObject.Pod) (bool, []int) {
+	if pod.Status.Phase != apiObject.PodRunning {
+		return false, nil
+	}
+	changed := false
+	var restarts []int
+	finished, failed := 0, false
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		if container.ContainerStatus != apiObject.ContainerExited {
+			continue
+		}
+		status := pod.Status.GetContainerStatus(container.Name)
+		var terminated *apiObject.ContainerStateTerminated
+		if status != nil {
+			terminated = status.State.Terminated
+			if terminated == nil {
+				// 容器已经处于CrashLoopBackOff，使用上一次终止时的状态
+				terminated = status.LastTerminationState.Terminated
+			}
+		}
+		if terminated == nil {
+			// 无法获取容器的退出状态时视为失败退出，不会因此一直停留在Running阶段
+			terminated = &apiObject.ContainerStateTerminated{
+				ExitCode: unknownExitCode,
+				Reason:   apiObject.ContainerReasonStatusUnknown,
+			}
+		}
+		if !shouldRestart(pod.GetRestartPolicy(), terminated.ExitCode) {
+			finished++
+			failed = failed || terminated.ExitCode != 0
+			continue
+		}
+
+		key := backoffKey{podUID: pod.GetPodUUID(), container: container.Name}
+		if backoff, waiting := m.inBackoff(key, terminated.FinishedAt); waiting && status != nil {
+			waitingState := apiObject.ContainerStateWaiting{
+				Reason: apiObject.ContainerReasonCrashLoopBackOff,
+				Message: fmt.Sprintf("back-off %s restarting failed container=%s pod=%s_%s(%s)",
+					backoff, container.Name, pod.Metadata.Name, pod.Metadata.Namespace, pod.GetPodUUID()),
+			}
+			if status.State.Waiting == nil || *status.State.Waiting != waitingState {
+				status.LastTerminationState = apiObject.ContainerState{Terminated: terminated}
+				status.State = apiObject.ContainerState{Waiting: &waitingState}
+				changed = true
+			}
+			continue
+		}
+
+		log.InfoLog(fmt.Sprintf("restart container %s/%s/%s exited with code %d, restartPolicy: %s",
+			pod.Metadata.Namespace, pod.Metadata.Name, container.Name, terminated.ExitCode, pod.GetRestartPolicy()))
+		restarts = append(restarts, i)
+	}
+
+	if len(pod.Spec.Containers) > 0 && finished == len(pod.Spec.Containers) {
+		if failed {
+			pod.Status.Phase = apiObject.PodFailed
+		} else {
+			pod.Status.Phase = apiObject.PodSucceeded
+		}
+		m.RemovePod(pod)
+		changed = true
+	}
+	return changed, restarts
+}
+
+// RestartContainer 重启SyncPod返回的容器，并将下一次的退避时间翻倍
+//
+//	重启需要等待容器终止并重新创建，调用者不能持有Pod的锁
+func (m *Manager) RestartContainer(pod *apiObject.Pod, index int) error {
+	if err := m.runtime.RestartContainer(pod, index); err != nil {
+		return err
+	}
+	m.next(backoffKey{podUID: pod.GetPodUUID(), container: pod.Spec.Containers[index].Name})
+	return nil
+}
+
+// shouldRestart 判断以指定退出码退出的容器是否需要按照重启策略重启
+func shouldRestart(restartPolicy string, exitCode int32) bool {
+	switch restartPolicy {
+	case apiObject.RestartPolicyNever:
+		return false
+	case apiObject.RestartPolicyOnFailure:
+		return exitCode != 0
+	default:
+		return true
+	}
+}
+
+// inBackoff 判断容器在finishedAt退出后是否仍处于退避时间内，返回当前的退避时间
+func (m *Manager) inBackoff(key backoffKey, finishedAt time.Time) (time.Duration, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	entry, ok := m.backoffs[key]
+	if !ok || m.expired(entry) {
+		return 0, false
+	}
+	return entry.backoff, m.now().Sub(finishedAt) < entry.backoff
+}
+
+// next 记录一次重启，并将下一次的退避时间翻倍
+func (m *Manager) next(key backoffKey) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	entry, ok := m.backoffs[key]
+	if !ok || m.expired(entry) {
+		entry = &backoffEntry{backoff: initialBackoff}
+		m.backoffs[key] = entry
+	} else {
+		entry.backoff = min(entry.backoff*2, maxBackoff)
+	}
+	entry.lastRestart = m.now()
+}
+
+// expired 容器在最近一次重启后稳定运行了足够长的时间，退避时间需要重新计算
+func (m *Manager) expired(entry *backoffEntry) bool {
+	return m.now().Sub(entry.lastRestart) > backoffResetDuration
+}
@@ -0,0 +1,129 @@
+package restart
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+)
+
+// fakeRuntime 重启容器时将容器设置为运行状态并增加重启次数
+type fakeRuntime struct {
+	restarted []string
+}
+
+func (f *fakeRuntime) RestartContainer(pod *apiObject.Pod, index int) error {
+	container := &pod.Spec.Containers[index]
+	f.restarted = append(f.restarted, container.Name)
+	container.ContainerStatus = apiObject.ContainerRunning
+	status := pod.Status.GetContainerStatus(container.Name)
+	status.LastTerminationState = status.State
+	status.State = apiObject.ContainerState{Running: &apiObject.ContainerStateRunning{}}
+	status.RestartCount++
+	return nil
+}
+
+func newRestartPod(restartPolicy string, names ...string) *apiObject.Pod {
+	pod := &apiObject.Pod{
+		Metadata: apiObject.ObjectMeta{Name: "job", Namespace: "default", UUID: "job-uid"},
+		Spec:     apiObject.PodSpec{RestartPolicy: restartPolicy},
+		Status:   apiObject.PodStatus{Phase: apiObject.PodRunning},
+	}
+	for _, name := range names {
+		pod.Spec.Containers = append(pod.Spec.Containers, apiObject.Container{Name: name, ContainerStatus: apiObject.ContainerRunning})
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, apiObject.PodContainerStatus{Name: name})
+	}
+	return pod
+}
+
+// exit 模拟运行时上报容器以指定退出码退出
+func exit(pod *apiObject.Pod, index int, exitCode int32, finishedAt time.Time) {
+	pod.Spec.Containers[index].ContainerStatus = apiObject.ContainerExited
+	pod.Status.ContainerStatuses[index].State = apiObject.ContainerState{
+		Terminated: &apiObject.ContainerStateTerminated{ExitCode: exitCode, FinishedAt: finishedAt},
+	}
+}
+
+// syncPod 处理Pod并重启需要重启的容器，返回Pod的状态是否发生了变化
+func syncPod(m *Manager, pod *apiObject.Pod) bool {
+	changed, restarts := m.SyncPod(pod)
+	for _, index := range restarts {
+		if m.RestartContainer(pod, index) == nil {
+			changed = true
+		}
+	}
+	return changed
+}
+
+func TestCrashLoopBackOff(t *testing.T) {
+	runtime := &fakeRuntime{}
+	m := NewManager(runtime)
+	now := time.Unix(1000, 0)
+	m.now = func() time.Time { return now }
+	pod := newRestartPod(apiObject.RestartPolicyAlways, "app")
+	status := &pod.Status.ContainerStatuses[0]
+
+	// 第一次退出后立即重启
+	exit(pod, 0, 0, now)
+	assert.True(t, syncPod(m, pod))
+	assert.Equal(t, int32(1), status.RestartCount)
+
+	// 之后每次退出都需要等待退避时间，退避时间翻倍
+	for _, backoff := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second} {
+		exit(pod, 0, 1, now)
+		restarts := status.RestartCount
+		assert.True(t, syncPod(m, pod))
+		assert.Equal(t, apiObject.ContainerReasonCrashLoopBackOff, status.State.Waiting.Reason)
+		assert.Equal(t, "back-off "+backoff.String()+" restarting failed container=app pod=job_default(job-uid)", status.State.Waiting.Message)
+		assert.Equal(t, int32(1), status.LastTerminationState.Terminated.ExitCode)
+		assert.Equal(t, restarts, status.RestartCount)
+
+		now = now.Add(backoff)
+		assert.True(t, syncPod(m, pod))
+		assert.Equal(t, restarts+1, status.RestartCount)
+	}
+
+	// 退避时间最多为5分钟
+	for i := 0; i < 10; i++ {
+		exit(pod, 0, 1, now)
+		now = now.Add(maxBackoff)
+		syncPod(m, pod)
+	}
+	assert.Equal(t, maxBackoff, m.backoffs[backoffKey{podUID: "job-uid", container: "app"}].backoff)
+}
+
+func TestRestartPolicyPhase(t *testing.T) {
+	runtime := &fakeRuntime{}
+	m := NewManager(runtime)
+
+	// Never：所有容器退出后根据退出码决定Pod的最终阶段
+	pod := newRestartPod(apiObject.RestartPolicyNever, "main", "sidecar")
+	exit(pod, 0, 0, time.Now())
+	assert.False(t, syncPod(m, pod))
+	assert.Equal(t, apiObject.PodPhase(apiObject.PodRunning), pod.Status.Phase)
+	exit(pod, 1, 2, time.Now())
+	assert.True(t, syncPod(m, pod))
+	assert.Equal(t, apiObject.PodPhase(apiObject.PodFailed), pod.Status.Phase)
+	assert.Empty(t, runtime.restarted)
+
+	// 无法获取退出状态的容器视为失败退出
+	pod = newRestartPod(apiObject.RestartPolicyNever, "main", "sidecar")
+	exit(pod, 0, 0, time.Now())
+	pod.Spec.Containers[1].ContainerStatus = apiObject.ContainerExited
+	pod.Status.ContainerStatuses = pod.Status.ContainerStatuses[:1]
+	assert.True(t, syncPod(m, pod))
+	assert.Equal(t, apiObject.PodPhase(apiObject.PodFailed), pod.Status.Phase)
+
+	// OnFailure：非0退出的容器被重启，全部以0退出后Pod成功
+	pod = newRestartPod(apiObject.RestartPolicyOnFailure, "main", "sidecar")
+	exit(pod, 0, 1, time.Now())
+	exit(pod, 1, 0, time.Now())
+	assert.True(t, syncPod(m, pod))
+	assert.Equal(t, []string{"main"}, runtime.restarted)
+	assert.Equal(t, apiObject.PodPhase(apiObject.PodRunning), pod.Status.Phase)
+	exit(pod, 0, 0, time.Now())
+	assert.True(t, syncPod(m, pod))
+	assert.Equal(t, apiObject.PodPhase(apiObject.PodSucceeded), pod.Status.Phase)
+}
@@ -1,8 +1,13 @@
 package runtime
 
 import (
+	"fmt"
 	"minik8s/pkg/apiObject"
+	"minik8s/pkg/config"
+	"minik8s/pkg/kubelet/cm"
+	"minik8s/pkg/kubelet/qos"
 	"minik8s/tools/conversion"
+	"minik8s/tools/host"
 	"minik8s/tools/log"
 
 	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
@@ -10,7 +15,7 @@ import (
 
 func (r *RuntimeManager) getPodSandBoxConfig(pod *apiObject.Pod) (*runtimeapi.PodSandboxConfig, error) {
 	// put basic infos from pod into config
-	logDirectory := "/var/log/pods" + pod.Metadata.Name
+	logDirectory := PodLogDirectory(pod)
 
 	podSandboxConfig := &runtimeapi.PodSandboxConfig{
 		Metadata: &runtimeapi.PodSandboxMetadata{
@@ -27,12 +32,11 @@ func (r *RuntimeManager) getPodSandBoxConfig(pod *apiObject.Pod) (*runtimeapi.Po
 	// TODO: 需要获取config中基本的DNS信息，暂时不需要
 
 	podSandboxConfig.Hostname = pod.Spec.NodeName
-	podSandboxConfig.LogDirectory = "/var/log/pods" //`/var/log/pods/<NAMESPACE>_<NAME>_<UID>/`
 
-	// TODO: 这里可能还需要实现端口映射
+	podSandboxConfig.PortMappings = getPortMappings(pod)
 
 	// TODO: 默认需要生成关于linux的配置
-	linuxConfig, err := r.getPodSandBoxLinuxConfig()
+	linuxConfig, err := r.getPodSandBoxLinuxConfig(pod)
 	if err != nil {
 		return nil, nil
 	}
@@ -41,9 +45,41 @@ func (r *RuntimeManager) getPodSandBoxConfig(pod *apiObject.Pod) (*runtimeapi.Po
 	return podSandboxConfig, nil
 }
 
-func (r *RuntimeManager) getPodSandBoxLinuxConfig() (*runtimeapi.LinuxPodSandboxConfig, error) {
+// getPortMappings 将容器中指定了hostPort的端口转化为sandbox的端口映射，由CNI的portmap插件在主机上建立转发规则
+func getPortMappings(pod *apiObject.Pod) []*runtimeapi.PortMapping {
+	var mappings []*runtimeapi.PortMapping
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.HostPort == 0 {
+				continue
+			}
+			protocol := runtimeapi.Protocol_TCP
+			switch port.Protocol {
+			case apiObject.ProtocolUDP:
+				protocol = runtimeapi.Protocol_UDP
+			case apiObject.ProtocolSCTP:
+				protocol = runtimeapi.Protocol_SCTP
+			}
+			// 只指定了hostPort时映射到容器的同一端口
+			containerPort := port.ContainerPort
+			if containerPort == 0 {
+				containerPort = port.HostPort
+			}
+			mappings = append(mappings, &runtimeapi.PortMapping{
+				Protocol:      protocol,
+				ContainerPort: containerPort,
+				HostPort:      port.HostPort,
+				HostIp:        port.HostIP,
+			})
+		}
+	}
+	return mappings
+}
+
+func (r *RuntimeManager) getPodSandBoxLinuxConfig(pod *apiObject.Pod) (*runtimeapi.LinuxPodSandboxConfig, error) {
 	linuxConfig := &runtimeapi.LinuxPodSandboxConfig{
-		CgroupParent: "",
+		// Pod位于其服务质量等级对应的cgroup之下，Pod中的容器位于Pod的cgroup之下
+		CgroupParent: cm.PodCgroupParent(pod),
 		SecurityContext: &runtimeapi.LinuxSandboxSecurityContext{
 			Privileged: false,
 			Seccomp: &runtimeapi.SecurityProfile{
@@ -57,15 +93,40 @@ func (r *RuntimeManager) getPodSandBoxLinuxConfig() (*runtimeapi.LinuxPodSandbox
 	return linuxConfig, nil
 }
 
-// 生成 ContainerConfig，可供runtimeClient直接使用发送
-func (r *RuntimeManager) getContainerConfig(container *apiObject.Container, sandboxConfig *runtimeapi.PodSandboxConfig) (*runtimeapi.ContainerConfig, error) {
+// PodLogDirectory Pod的日志目录，容器运行时将容器的日志写入该目录下
+func PodLogDirectory(pod *apiObject.Pod) string {
+	return config.PodLogsRootDirectory + "/" + pod.Metadata.Namespace + "_" + pod.Metadata.Name + "_" + pod.Metadata.UUID
+}
+
+// containerLogPath 容器第attempt次运行时的日志文件相对于Pod日志目录的路径，每次重启容器都会写入新的文件
+func containerLogPath(containerName string, attempt int32) string {
+	return fmt.Sprintf("%s/%d.log", containerName, attempt)
+}
+
+// ContainerLogPath 容器第attempt次运行时的日志文件，attempt即容器当时的重启次数
+func ContainerLogPath(pod *apiObject.Pod, containerName string, attempt int32) string {
+	return PodLogDirectory(pod) + "/" + containerLogPath(containerName, attempt)
+}
+
+// 生成 ContainerConfig，可供runtimeClient直接使用发送，attempt为容器的重启次数
+func (r *RuntimeManager) getContainerConfig(pod *apiObject.Pod, container *apiObject.Container, sandboxConfig *runtimeapi.PodSandboxConfig, attempt int32) (*runtimeapi.ContainerConfig, error) {
+	envs, err := makeEnvironmentVariables(pod, container)
+	if err != nil {
+		return nil, err
+	}
 	// 1. 将镜像拉取到本地
 	imageRef, err := r.imageManager.PullImage(container, sandboxConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	logPath := container.Name + ".log"
+	// 节点的内存容量用于计算Burstable容器的oom_score_adj
+	var memoryCapacity int64
+	if total, err := host.GetTotalMemory(); err == nil {
+		memoryCapacity = int64(total)
+	}
+
+	logPath := containerLogPath(container.Name, attempt)
 	// 2. 创建container
 	// 需要在dns中追加/etc/hosts文件，以便容器内部可以访问到集群内部署的DNS服务
 	container.Mounts = append(container.Mounts, &apiObject.Mount{
@@ -77,19 +138,71 @@ func (r *RuntimeManager) getContainerConfig(container *apiObject.Container, sand
 	config := &runtimeapi.ContainerConfig{
 		Metadata: &runtimeapi.ContainerMetadata{
 			Name:    container.Name,
-			Attempt: 1,
+			Attempt: uint32(attempt),
 		},
 		Image: &runtimeapi.ImageSpec{
 			Image:              imageRef,
 			UserSpecifiedImage: container.Image,
 		},
 		Command:    container.Command,
+		Args:       container.Args,
 		WorkingDir: container.WorkingDir,
+		Envs:       envs,
 		Mounts:     conversion.MountsToMounts(container.Mounts),
 		LogPath:    logPath,
+		Stdin:      container.Stdin,
+		StdinOnce:  container.StdinOnce,
+		Tty:        container.TTY,
+		Linux:      getContainerLinuxConfig(pod, container, memoryCapacity),
 	}
 	log.DebugLog("ContainerConfig: " + config.String())
 
 	return config, nil
 
 }
+
+// getContainerLinuxConfig 根据容器的资源请求与限制生成cgroup配置
+//
+//	cpu 请求决定 CpuShares，cpu 限制决定 CpuQuota，memory 限制决定 MemoryLimitInBytes，Pod的服务质量等级决定 OomScoreAdj
+func getContainerLinuxConfig(pod *apiObject.Pod, container *apiObject.Container, memoryCapacity int64) *runtimeapi.LinuxContainerConfig {
+	resources := &runtimeapi.LinuxContainerResources{
+		OomScoreAdj: qos.GetContainerOOMScoreAdjust(pod, container, memoryCapacity),
+	}
+	// 没有cpu请求时请求等于限制，两者都没有时使用最小的cpu权重
+	resources.CpuShares = minShares
+	quantity, ok := container.Resources.Requests[apiObject.ResourceCPU]
+	if !ok {
+		quantity, ok = container.Resources.Limits[apiObject.ResourceCPU]
+	}
+	if ok {
+		if milliCPU, err := conversion.ParseCPU(quantity); err == nil {
+			resources.CpuShares = milliCPUToShares(milliCPU)
+		}
+	}
+	if quantity, ok := container.Resources.Limits[apiObject.ResourceCPU]; ok {
+		if milliCPU, err := conversion.ParseCPU(quantity); err == nil && milliCPU > 0 {
+			resources.CpuPeriod = cpuPeriod
+			resources.CpuQuota = max(milliCPU*cpuPeriod/1000, minQuotaPeriod)
+		}
+	}
+	if quantity, ok := container.Resources.Limits[apiObject.ResourceMemory]; ok {
+		if memory, err := conversion.ParseMemoryBytes(quantity); err == nil {
+			resources.MemoryLimitInBytes = memory
+		}
+	}
+	return &runtimeapi.LinuxContainerConfig{Resources: resources}
+}
+
+const (
+	// cpuPeriod cfs调度周期，单位为微秒
+	cpuPeriod = 100000
+	// minQuotaPeriod cfs允许的最小配额，单位为微秒
+	minQuotaPeriod = 1000
+	// minShares cgroup允许的最小cpu权重
+	minShares = 2
+)
+
+// milliCPUToShares 将毫核转化为cgroup的cpu权重，1核对应1024
+func milliCPUToShares(milliCPU int64) int64 {
+	return max(milliCPU*1024/1000, minShares)
+}
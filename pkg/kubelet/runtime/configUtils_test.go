@@ -0,0 +1,61 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"minik8s/pkg/apiObject"
+)
+
+func TestGetPortMappings(t *testing.T) {
+	pod := &apiObject.Pod{Spec: apiObject.PodSpec{Containers: []apiObject.Container{
+		{Name: "web", Ports: []apiObject.ContainerPort{
+			{ContainerPort: 80, HostPort: 8080},
+			{ContainerPort: 9090},
+		}},
+		{Name: "dns", Ports: []apiObject.ContainerPort{
+			{ContainerPort: 53, HostPort: 5353, Protocol: apiObject.ProtocolUDP, HostIP: "127.0.0.1"},
+			{HostPort: 7080},
+		}},
+	}}}
+
+	assert.Equal(t, []*runtimeapi.PortMapping{
+		{Protocol: runtimeapi.Protocol_TCP, ContainerPort: 80, HostPort: 8080},
+		{Protocol: runtimeapi.Protocol_UDP, ContainerPort: 53, HostPort: 5353, HostIp: "127.0.0.1"},
+		{Protocol: runtimeapi.Protocol_TCP, ContainerPort: 7080, HostPort: 7080},
+	}, getPortMappings(pod))
+	assert.Nil(t, getPortMappings(&apiObject.Pod{}))
+}
+
+func TestGetContainerLinuxConfig(t *testing.T) {
+	const capacity = 4 << 30
+	// 只设置限制的容器请求等于限制，属于Guaranteed
+	pod := &apiObject.Pod{Spec: apiObject.PodSpec{Containers: []apiObject.Container{{
+		Name: "app",
+		Resources: apiObject.ResourceRequirements{
+			Limits: apiObject.ResourceList{apiObject.ResourceCPU: "500m", apiObject.ResourceMemory: "128Mi"},
+		},
+	}}}}
+	resources := getContainerLinuxConfig(pod, &pod.Spec.Containers[0], capacity).Resources
+	assert.Equal(t, int64(512), resources.CpuShares)
+	assert.Equal(t, int64(100000), resources.CpuPeriod)
+	assert.Equal(t, int64(50000), resources.CpuQuota)
+	assert.Equal(t, int64(128<<20), resources.MemoryLimitInBytes)
+	assert.Equal(t, int64(-997), resources.OomScoreAdj)
+
+	// 十进制单位、小数与不带单位的字节数
+	for quantity, expected := range map[string]int64{"512M": 512e6, "1G": 1e9, "1.5Gi": 3 << 29, "1048576": 1 << 20} {
+		pod.Spec.Containers[0].Resources.Limits[apiObject.ResourceMemory] = quantity
+		resources = getContainerLinuxConfig(pod, &pod.Spec.Containers[0], capacity).Resources
+		assert.Equal(t, expected, resources.MemoryLimitInBytes, quantity)
+	}
+
+	pod.Spec.Containers[0].Resources = apiObject.ResourceRequirements{}
+	resources = getContainerLinuxConfig(pod, &pod.Spec.Containers[0], capacity).Resources
+	assert.Equal(t, int64(2), resources.CpuShares)
+	assert.Equal(t, int64(0), resources.CpuQuota)
+	assert.Equal(t, int64(0), resources.MemoryLimitInBytes)
+	assert.Equal(t, int64(1000), resources.OomScoreAdj)
+}
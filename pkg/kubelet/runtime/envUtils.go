@@ -0,0 +1,121 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"minik8s/pkg/apiObject"
+	"minik8s/tools/conversion"
+	"minik8s/tools/host"
+	"strconv"
+	"strings"
+
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// makeEnvironmentVariables 生成容器的环境变量，valueFrom引用的Pod字段与资源数量在创建容器时确定
+func makeEnvironmentVariables(pod *apiObject.Pod, container *apiObject.Container) ([]*runtimeapi.KeyValue, error) {
+	envs := make([]*runtimeapi.KeyValue, 0, len(container.Env))
+	for _, env := range container.Env {
+		value := env.Value
+		if env.ValueFrom != nil {
+			var err error
+			switch {
+			case env.ValueFrom.FieldRef != nil:
+				value, err = podFieldValue(pod, env.ValueFrom.FieldRef.FieldPath)
+			case env.ValueFrom.ResourceFieldRef != nil:
+				value, err = containerResourceValue(pod, container, env.ValueFrom.ResourceFieldRef)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("env %s: %s", env.Name, err.Error())
+			}
+		}
+		envs = append(envs, &runtimeapi.KeyValue{Key: env.Name, Value: value})
+	}
+	return envs, nil
+}
+
+// podFieldValue 获取fieldRef引用的Pod字段，Pod的IP在创建sandbox之后才能确定
+func podFieldValue(pod *apiObject.Pod, fieldPath string) (string, error) {
+	switch fieldPath {
+	case apiObject.FieldPathPodName:
+		return pod.Metadata.Name, nil
+	case apiObject.FieldPathNamespace:
+		return pod.Metadata.Namespace, nil
+	case apiObject.FieldPathPodUID:
+		return pod.Metadata.UUID, nil
+	case apiObject.FieldPathNodeName:
+		return pod.Spec.NodeName, nil
+	case apiObject.FieldPathPodIP:
+		return pod.Status.PodIP, nil
+	}
+	return "", errors.New("unsupported fieldPath " + fieldPath)
+}
+
+// containerResourceValue 获取resourceFieldRef引用的资源数量除以divisor并向上取整的结果
+//
+//	容器没有设置资源限制时使用节点的容量，没有设置资源请求时为0
+func containerResourceValue(pod *apiObject.Pod, container *apiObject.Container, selector *apiObject.ResourceFieldSelector) (string, error) {
+	if selector.ContainerName != "" && selector.ContainerName != container.Name {
+		container = nil
+		for i := range pod.Spec.Containers {
+			if pod.Spec.Containers[i].Name == selector.ContainerName {
+				container = &pod.Spec.Containers[i]
+				break
+			}
+		}
+		if container == nil {
+			return "", errors.New("container " + selector.ContainerName + " not found")
+		}
+	}
+	kind, name, _ := strings.Cut(selector.Resource, ".")
+	var resources apiObject.ResourceList
+	switch kind {
+	case "limits":
+		resources = container.Resources.Limits
+	case "requests":
+		resources = container.Resources.Requests
+	default:
+		return "", errors.New("unsupported resource " + selector.Resource)
+	}
+	divisor := selector.Divisor
+	if divisor == "" {
+		divisor = "1"
+	}
+
+	var value, unit int64
+	var err error
+	quantity, ok := resources[name]
+	switch name {
+	case apiObject.ResourceCPU:
+		if unit, err = conversion.ParseCPU(divisor); err != nil {
+			return "", err
+		}
+		if ok {
+			value, err = conversion.ParseCPU(quantity)
+		} else if kind == "limits" {
+			var count int
+			count, err = host.GetCPUCount()
+			value = int64(count) * 1000
+		}
+	case apiObject.ResourceMemory:
+		if unit, err = conversion.ParseMemoryBytes(divisor); err != nil {
+			return "", err
+		}
+		if ok {
+			value, err = conversion.ParseMemoryBytes(quantity)
+		} else if kind == "limits" {
+			var total uint64
+			total, err = host.GetTotalMemory()
+			value = int64(total)
+		}
+	default:
+		return "", errors.New("unsupported resource " + selector.Resource)
+	}
+	if err != nil {
+		return "", err
+	}
+	if unit <= 0 {
+		return "", errors.New("divisor must be positive")
+	}
+	return strconv.FormatInt((value+unit-1)/unit, 10), nil
+}
@@ -0,0 +1,74 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+)
+
+func TestMakeEnvironmentVariables(t *testing.T) {
+	pod := &apiObject.Pod{
+		Metadata: apiObject.ObjectMeta{Name: "web", Namespace: "default", UUID: "uid-1"},
+		Spec: apiObject.PodSpec{
+			NodeName: "node-1",
+			Containers: []apiObject.Container{
+				{
+					Name: "app",
+					Resources: apiObject.ResourceRequirements{
+						Requests: apiObject.ResourceList{apiObject.ResourceCPU: "250m"},
+						Limits:   apiObject.ResourceList{apiObject.ResourceCPU: "1500m", apiObject.ResourceMemory: "64Mi"},
+					},
+				},
+				{Name: "sidecar"},
+			},
+		},
+		Status: apiObject.PodStatus{PodIP: "10.32.0.5"},
+	}
+	resourceEnv := func(name string, selector apiObject.ResourceFieldSelector) apiObject.EnvVar {
+		return apiObject.EnvVar{Name: name, ValueFrom: &apiObject.EnvVarSource{ResourceFieldRef: &selector}}
+	}
+	fieldEnv := func(name string, fieldPath string) apiObject.EnvVar {
+		return apiObject.EnvVar{Name: name, ValueFrom: &apiObject.EnvVarSource{FieldRef: &apiObject.ObjectFieldSelector{FieldPath: fieldPath}}}
+	}
+	sidecar := &pod.Spec.Containers[1]
+	sidecar.Env = []apiObject.EnvVar{
+		{Name: "MODE", Value: "debug"},
+		fieldEnv("POD_NAME", apiObject.FieldPathPodName),
+		fieldEnv("POD_NAMESPACE", apiObject.FieldPathNamespace),
+		fieldEnv("POD_IP", apiObject.FieldPathPodIP),
+		fieldEnv("NODE_NAME", apiObject.FieldPathNodeName),
+		// cpu 默认以核为单位并向上取整
+		resourceEnv("CPU_LIMIT", apiObject.ResourceFieldSelector{ContainerName: "app", Resource: "limits.cpu"}),
+		resourceEnv("CPU_REQUEST", apiObject.ResourceFieldSelector{ContainerName: "app", Resource: "requests.cpu", Divisor: "1m"}),
+		resourceEnv("MEMORY_LIMIT", apiObject.ResourceFieldSelector{ContainerName: "app", Resource: "limits.memory", Divisor: "1Mi"}),
+		resourceEnv("MEMORY_REQUEST", apiObject.ResourceFieldSelector{Resource: "requests.memory"}),
+	}
+
+	envs, err := makeEnvironmentVariables(pod, sidecar)
+	assert.Nil(t, err)
+	values := map[string]string{}
+	for _, env := range envs {
+		values[env.Key] = env.Value
+	}
+	assert.Equal(t, map[string]string{
+		"MODE":           "debug",
+		"POD_NAME":       "web",
+		"POD_NAMESPACE":  "default",
+		"POD_IP":         "10.32.0.5",
+		"NODE_NAME":      "node-1",
+		"CPU_LIMIT":      "2",
+		"CPU_REQUEST":    "250",
+		"MEMORY_LIMIT":   "64",
+		"MEMORY_REQUEST": "0",
+	}, values)
+	assert.Equal(t, "MODE", envs[0].Key)
+
+	sidecar.Env = []apiObject.EnvVar{resourceEnv("X", apiObject.ResourceFieldSelector{ContainerName: "db", Resource: "limits.cpu"})}
+	_, err = makeEnvironmentVariables(pod, sidecar)
+	assert.NotNil(t, err)
+	sidecar.Env = []apiObject.EnvVar{fieldEnv("X", "metadata.labels")}
+	_, err = makeEnvironmentVariables(pod, sidecar)
+	assert.NotNil(t, err)
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"minik8s/pkg/apiObject"
 	"minik8s/pkg/config"
+	"minik8s/pkg/kubelet/qos"
 	"minik8s/tools/host"
 	httprequest "minik8s/tools/httpRequest"
 	"minik8s/tools/log"
+	"os"
 	"strings"
 	"time"
 
@@ -28,16 +30,30 @@ type PodUtils interface {
 }
 
 // CreatePod 在这里，我们创建一个Pod相当于是创建一个Sandbox，并且会创建Pod内部的所有容器
-func (r *RuntimeManager) CreatePod(pod *apiObject.Pod) error {
+//
+//	创建失败时删除已经创建的Sandbox，以便下一次同步时重新创建
+func (r *RuntimeManager) CreatePod(pod *apiObject.Pod) (err error) {
 	log.InfoLog("[RPC] Start CreatePod")
+	defer func() {
+		if err != nil && pod.PodSandboxId != "" {
+			r.removePodSandbox(pod.PodSandboxId)
+			pod.PodSandboxId = ""
+		}
+	}()
 
 	pod.Status.Phase = apiObject.PodBuilding
+	pod.Status.QOSClass = qos.GetPodQOS(pod)
 	sandboxConfig, err := r.getPodSandBoxConfig(pod)
 	if err != nil {
 		log.ErrorLog("GetPodSandBoxConfig fail: " + err.Error())
 		return err
 	}
 
+	// 容器运行时将容器的日志写入Pod的日志目录中
+	if err = os.MkdirAll(sandboxConfig.LogDirectory, 0755); err != nil {
+		log.WarnLog("create pod log directory failed: " + err.Error())
+	}
+
 	request := &runtimeapi.RunPodSandboxRequest{
 		Config:         sandboxConfig,
 		RuntimeHandler: "",
@@ -72,7 +88,7 @@ func (r *RuntimeManager) CreatePod(pod *apiObject.Pod) error {
 	// 调用接口去创建Pod内部的所有容器
 	containers := &pod.Spec.Containers
 	for i := 0; i < len(*containers); i += 1 {
-		containerConfig, err := r.getContainerConfig(&(*containers)[i], sandboxConfig)
+		containerConfig, err := r.getContainerConfig(pod, &(*containers)[i], sandboxConfig, 0)
 		if err != nil {
 			log.ErrorLog("generate container config failed")
 			return err
@@ -90,11 +106,23 @@ func (r *RuntimeManager) CreatePod(pod *apiObject.Pod) error {
 		log.InfoLog(message)
 	}
 
-	pod.Status.Phase = apiObject.PodSucceeded
+	pod.Status.Phase = apiObject.PodCreated
 
 	return nil
 }
 
+// removePodSandbox 停止并删除Sandbox，容器运行时会一并删除Sandbox中的容器
+func (r *RuntimeManager) removePodSandbox(podSandboxId string) {
+	_, err := r.runtimeClient.StopPodSandbox(context.Background(), &runtimeapi.StopPodSandboxRequest{PodSandboxId: podSandboxId})
+	if err != nil {
+		log.WarnLog(fmt.Sprintf("[RPC] Stop pod sandbox failed, podSandboxId: %s", podSandboxId))
+	}
+	_, err = r.runtimeClient.RemovePodSandbox(context.Background(), &runtimeapi.RemovePodSandboxRequest{PodSandboxId: podSandboxId})
+	if err != nil {
+		log.ErrorLog(fmt.Sprintf("[RPC] Remove pod sandbox failed, podSandboxId: %s", podSandboxId))
+	}
+}
+
 // CreateContainers 创建指定配置文件的container
 func (r *RuntimeManager) CreateContainers(podSandBoxID string, containerConfig *runtimeapi.ContainerConfig,
 	sandboxConfig *runtimeapi.PodSandboxConfig) (string, error) {
@@ -202,6 +230,18 @@ func (r *RuntimeManager) GetPodSandboxStatus(podId string) (*runtimeapi.PodSandb
 
 func (r *RuntimeManager) DeletePod(pod *apiObject.Pod) error {
 	log.InfoLog("[RPC] Start DeletePod")
+	// 先停止容器，给容器优雅退出的时间，超过terminationGracePeriodSeconds后由容器运行时强制停止
+	gracePeriod := pod.GetTerminationGracePeriodSeconds()
+	for i := 0; i < len(pod.Spec.Containers); i += 1 {
+		_, err := r.runtimeClient.StopContainer(context.Background(), &runtimeapi.StopContainerRequest{
+			ContainerId: pod.Spec.Containers[i].ContainerID,
+			Timeout:     gracePeriod,
+		})
+		if err != nil {
+			errorMsg := fmt.Sprintf("[RPC] Stop container before removing failed, containerID: %s", pod.Spec.Containers[i].ContainerID)
+			log.WarnLog(errorMsg)
+		}
+	}
 	for i := 0; i < len(pod.Spec.Containers); i += 1 {
 		_, err := r.runtimeClient.RemoveContainer(context.Background(), &runtimeapi.RemoveContainerRequest{
 			ContainerId: pod.Spec.Containers[i].ContainerID,
@@ -222,9 +262,22 @@ func (r *RuntimeManager) DeletePod(pod *apiObject.Pod) error {
 		return err
 	}
 
+	// Pod被删除后不再需要保留容器的日志
+	if err = os.RemoveAll(PodLogDirectory(pod)); err != nil {
+		log.WarnLog("remove pod log directory failed: " + err.Error())
+	}
+
 	return nil
 }
 
+// restartCount 获取Pod状态中记录的容器重启次数
+func restartCount(pod *apiObject.Pod, containerName string) int32 {
+	if status := pod.Status.GetContainerStatus(containerName); status != nil {
+		return status.RestartCount
+	}
+	return 0
+}
+
 // RecreatePodContainers 此处保留所有podSandbox，创建pod内部所有的容器
 func (r *RuntimeManager) RecreatePodContainers(pod *apiObject.Pod) error {
 	log.InfoLog("[RPC] Start RecreatePodContainers")
@@ -235,7 +288,7 @@ func (r *RuntimeManager) RecreatePodContainers(pod *apiObject.Pod) error {
 	}
 	containers := &pod.Spec.Containers
 	for i := 0; i < len(*containers); i += 1 {
-		containerConfig, err := r.getContainerConfig(&(*containers)[i], sandboxConfig)
+		containerConfig, err := r.getContainerConfig(pod, &(*containers)[i], sandboxConfig, restartCount(pod, (*containers)[i].Name))
 		if err != nil {
 			log.ErrorLog("generate container config failed")
 			return err
@@ -301,6 +354,161 @@ func (r *RuntimeManager) ExecPodContainer(req *apiObject.ExecReq) (string, error
 	return strings.TrimSuffix(string(response.Stdout), "\n"), nil
 }
 
+// ExecSync 在容器中同步执行命令，返回标准输出与退出码，供探针使用
+func (r *RuntimeManager) ExecSync(containerID string, cmd []string, timeout time.Duration) ([]byte, int32, error) {
+	response, err := r.runtimeClient.ExecSync(context.Background(), &runtimeapi.ExecSyncRequest{
+		ContainerId: containerID,
+		Cmd:         cmd,
+		Timeout:     int64(timeout.Seconds()),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return response.Stdout, response.ExitCode, nil
+}
+
+// GetExec 调用CRI的Exec接口，获取在容器中交互式执行命令的流式URL
+//
+//	分配终端时标准错误与标准输出合并，只使用stdout通道
+func (r *RuntimeManager) GetExec(containerID string, cmd []string, stdin bool, tty bool) (string, error) {
+	response, err := r.runtimeClient.Exec(context.Background(), &runtimeapi.ExecRequest{
+		ContainerId: containerID,
+		Cmd:         cmd,
+		Tty:         tty,
+		Stdin:       stdin,
+		Stdout:      true,
+		Stderr:      !tty,
+	})
+	if err != nil {
+		return "", err
+	}
+	return response.Url, nil
+}
+
+// GetAttach 调用CRI的Attach接口，获取连接到容器主进程的流式URL
+func (r *RuntimeManager) GetAttach(containerID string, stdin bool, tty bool) (string, error) {
+	response, err := r.runtimeClient.Attach(context.Background(), &runtimeapi.AttachRequest{
+		ContainerId: containerID,
+		Tty:         tty,
+		Stdin:       stdin,
+		Stdout:      true,
+		Stderr:      !tty,
+	})
+	if err != nil {
+		return "", err
+	}
+	return response.Url, nil
+}
+
+// RestartContainer 停止并删除Pod中的指定容器，然后在原有的PodSandbox中重新创建并启动，容器的ID会发生变化
+//
+//	重启成功后在Pod的状态中记录容器上一次终止时的状态，并增加容器的重启次数
+//	旧容器删除之后的步骤失败时，容器的ID被清空，容器保持退出状态，等待下一次重启
+func (r *RuntimeManager) RestartContainer(pod *apiObject.Pod, index int) (err error) {
+	log.InfoLog("[RPC] Start RestartContainer")
+	container := &pod.Spec.Containers[index]
+	status := pod.Status.GetContainerStatus(container.Name)
+	if status == nil {
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, apiObject.PodContainerStatus{Name: container.Name})
+		status = &pod.Status.ContainerStatuses[len(pod.Status.ContainerStatuses)-1]
+	}
+	// 上一次重启失败时旧容器已经被删除，沿用记录的终止状态
+	lastState := status.State
+	if container.ContainerID != "" {
+		_, err = r.runtimeClient.StopContainer(context.Background(), &runtimeapi.StopContainerRequest{
+			ContainerId: container.ContainerID,
+			Timeout:     pod.GetTerminationGracePeriodSeconds(),
+		})
+		if err != nil {
+			log.WarnLog(fmt.Sprintf("[RPC] In restarting, stop container failed, containerID: %s", container.ContainerID))
+		}
+		// 删除容器之前记录其终止时的状态
+		statusResponse, err := r.runtimeClient.ContainerStatus(context.Background(), &runtimeapi.ContainerStatusRequest{
+			ContainerId: container.ContainerID,
+		})
+		if err == nil && statusResponse.Status != nil {
+			lastState = containerStateFromCRI(statusResponse.Status)
+		}
+		_, err = r.runtimeClient.RemoveContainer(context.Background(), &runtimeapi.RemoveContainerRequest{
+			ContainerId: container.ContainerID,
+		})
+		if err != nil {
+			log.ErrorLog(fmt.Sprintf("[RPC] In restarting, remove container failed, containerID: %s", container.ContainerID))
+			return err
+		}
+		container.ContainerID = ""
+		container.ContainerStatus = apiObject.ContainerExited
+		status.ContainerID = ""
+		status.State = lastState
+	}
+
+	sandboxConfig, err := r.getPodSandBoxConfig(pod)
+	if err != nil {
+		return err
+	}
+	// 使用容器的副本生成配置，避免重复追加/etc/hosts挂载
+	template := *container
+	containerConfig, err := r.getContainerConfig(pod, &template, sandboxConfig, restartCount(pod, container.Name)+1)
+	if err != nil {
+		log.ErrorLog("generate container config failed")
+		return err
+	}
+	containerID, err := r.CreateContainers(pod.PodSandboxId, containerConfig, sandboxConfig)
+	if err != nil {
+		log.ErrorLog("Create containers failed")
+		return err
+	}
+	_, err = r.runtimeClient.StartContainer(context.Background(), &runtimeapi.StartContainerRequest{
+		ContainerId: containerID,
+	})
+	if err != nil {
+		log.ErrorLog(fmt.Sprintf("[RPC] In restarting, start container failed, containerID: %s", containerID))
+		// 删除没有启动的容器，避免其CREATED状态使整个Pod被重新启动
+		if _, removeErr := r.runtimeClient.RemoveContainer(context.Background(), &runtimeapi.RemoveContainerRequest{
+			ContainerId: containerID,
+		}); removeErr != nil {
+			log.WarnLog(fmt.Sprintf("[RPC] In restarting, remove container failed, containerID: %s", containerID))
+		}
+		return err
+	}
+	container.ContainerID = containerID
+	container.ContainerStatus = apiObject.ContainerRunning
+
+	status.ContainerID = containerID
+	status.LastTerminationState = lastState
+	status.State = apiObject.ContainerState{Running: &apiObject.ContainerStateRunning{StartedAt: time.Now()}}
+	status.RestartCount++
+	return nil
+}
+
+// containerStateFromCRI 将CRI返回的容器状态转换为Pod状态中记录的容器状态
+func containerStateFromCRI(status *runtimeapi.ContainerStatus) apiObject.ContainerState {
+	switch status.State {
+	case runtimeapi.ContainerState_CONTAINER_CREATED:
+		return apiObject.ContainerState{Waiting: &apiObject.ContainerStateWaiting{Reason: apiObject.ContainerReasonContainerCreating}}
+	case runtimeapi.ContainerState_CONTAINER_RUNNING:
+		return apiObject.ContainerState{Running: &apiObject.ContainerStateRunning{StartedAt: time.Unix(0, status.StartedAt)}}
+	case runtimeapi.ContainerState_CONTAINER_EXITED:
+		// 容器因为内存超过限制被终止时，容器运行时给出的原因为OOMKilled
+		reason := status.Reason
+		if reason == "" && status.ExitCode == 0 {
+			reason = apiObject.ContainerReasonCompleted
+		} else if reason == "" {
+			reason = apiObject.ContainerReasonError
+		}
+		return apiObject.ContainerState{Terminated: &apiObject.ContainerStateTerminated{
+			ExitCode:    status.ExitCode,
+			Reason:      reason,
+			Message:     status.Message,
+			StartedAt:   time.Unix(0, status.StartedAt),
+			FinishedAt:  time.Unix(0, status.FinishedAt),
+			ContainerID: status.Id,
+		}}
+	default:
+		return apiObject.ContainerState{}
+	}
+}
+
 func (r *RuntimeManager) UpdatePodStatus(pod *apiObject.Pod) error {
 
 	log.DebugLog("Start UpdatePodStatus")
@@ -314,18 +522,34 @@ func (r *RuntimeManager) UpdatePodStatus(pod *apiObject.Pod) error {
 		return err
 	}
 
+	// 重启次数与上一次终止时的状态由kubelet维护，需要在刷新容器状态时保留
+	containerStatuses := make([]apiObject.PodContainerStatus, len(pod.Spec.Containers))
 	for id, container := range pod.Spec.Containers {
-		response1, _ := r.runtimeClient.ContainerStats(context.Background(), &runtimeapi.ContainerStatsRequest{
-			ContainerId: container.ContainerID,
-		})
+		if old := pod.Status.GetContainerStatus(container.Name); old != nil {
+			containerStatuses[id] = *old
+		}
+		containerStatuses[id].Name = container.Name
+		containerStatuses[id].ContainerID = container.ContainerID
+	}
+	pod.Status.ContainerStatuses = containerStatuses
 
-		response2, _ := r.runtimeClient.ContainerStatus(context.Background(), &runtimeapi.ContainerStatusRequest{
+	for id, container := range pod.Spec.Containers {
+		if container.ContainerID == "" {
+			// 容器重启失败后已经被删除，保留之前记录的状态
+			continue
+		}
+		response2, err := r.runtimeClient.ContainerStatus(context.Background(), &runtimeapi.ContainerStatusRequest{
 			ContainerId: container.ContainerID,
 		})
+		if err != nil || response2.Status == nil {
+			log.WarnLog(fmt.Sprintf("[RPC] get container status failed, containerID: %s", container.ContainerID))
+			continue
+		}
 
+		pod.Status.ContainerStatuses[id].State = containerStateFromCRI(response2.Status)
 		switch response2.Status.State {
 		case runtimeapi.ContainerState_CONTAINER_CREATED:
-			pod.Status.Phase = apiObject.PodSucceeded
+			pod.Status.Phase = apiObject.PodCreated
 			pod.Spec.Containers[id].ContainerStatus = apiObject.ContainerCreated
 		case runtimeapi.ContainerState_CONTAINER_RUNNING:
 			pod.Spec.Containers[id].ContainerStatus = apiObject.ContainerRunning
@@ -340,6 +564,13 @@ func (r *RuntimeManager) UpdatePodStatus(pod *apiObject.Pod) error {
 		if pod.Spec.Containers[id].ContainerStatus != apiObject.ContainerRunning {
 			continue
 		}
+		response1, err := r.runtimeClient.ContainerStats(context.Background(), &runtimeapi.ContainerStatsRequest{
+			ContainerId: container.ContainerID,
+		})
+		if err != nil || response1.Stats == nil {
+			log.WarnLog(fmt.Sprintf("[RPC] get container stats failed, containerID: %s", container.ContainerID))
+			continue
+		}
 
 		if (uint64(response1.Stats.Cpu.Timestamp) - uint64(response2.Status.StartedAt)) != 0 {
 			log.DebugLog(fmt.Sprintf("Cpu usage : %d , all usage: %d", response1.Stats.Cpu.UsageCoreNanoSeconds.Value, uint64(response1.Stats.Cpu.Timestamp)-uint64(response2.Status.StartedAt)))
@@ -362,7 +593,7 @@ func (r *RuntimeManager) UpdatePodStatus(pod *apiObject.Pod) error {
 	pod.Status.CpuUsage = cpuUsage
 	pod.Status.MemUsage = memoryUsage
 
-	url := "http://" + config.APIServerLocalAddress + ":" + fmt.Sprint(config.APIServerLocalPort) + config.PodStatusURI
+	url := config.APIServerURL() + config.PodStatusURI
 	url = strings.Replace(url, config.NameSpaceReplace, pod.Metadata.Namespace, -1)
 	url = strings.Replace(url, config.NameReplace, pod.Metadata.Name, -1)
 	res, err := httprequest.PutObjMsg(url, pod.Status)
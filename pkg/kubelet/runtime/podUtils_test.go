@@ -0,0 +1,31 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"minik8s/pkg/apiObject"
+)
+
+func TestContainerStateFromCRI(t *testing.T) {
+	exited := func(exitCode int32, reason string) *apiObject.ContainerStateTerminated {
+		return containerStateFromCRI(&runtimeapi.ContainerStatus{
+			Id:       "c1",
+			State:    runtimeapi.ContainerState_CONTAINER_EXITED,
+			ExitCode: exitCode,
+			Reason:   reason,
+		}).Terminated
+	}
+	assert.Equal(t, apiObject.ContainerReasonCompleted, exited(0, "").Reason)
+	assert.Equal(t, apiObject.ContainerReasonError, exited(1, "").Reason)
+	// 被OOM killer终止的容器保留容器运行时给出的原因
+	terminated := exited(137, apiObject.ContainerReasonOOMKilled)
+	assert.Equal(t, apiObject.ContainerReasonOOMKilled, terminated.Reason)
+	assert.Equal(t, int32(137), terminated.ExitCode)
+	assert.Equal(t, "c1", terminated.ContainerID)
+
+	state := containerStateFromCRI(&runtimeapi.ContainerStatus{State: runtimeapi.ContainerState_CONTAINER_CREATED})
+	assert.Equal(t, apiObject.ContainerReasonContainerCreating, state.Waiting.Reason)
+}
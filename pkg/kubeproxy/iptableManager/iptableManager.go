@@ -85,8 +85,11 @@ func (i *iptableManager) CreateService(createEvent *entity.ServiceEvent) error {
 	log.InfoLog("[KUBEPROXY]: Start CreateService")
 	serviceName := createEvent.Service.Metadata.Name
 	var podsIPList []string
+	// 只将请求转发给就绪的Pod
 	for _, endpoint := range createEvent.Endpoints {
-		podsIPList = append(podsIPList, endpoint.IP)
+		if endpoint.Ready {
+			podsIPList = append(podsIPList, endpoint.IP)
+		}
 	}
 
 	// 根据service制定开放的port端口，分别设置规则
@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/config"
+
+	httprequest "minik8s/tools/httpRequest"
+)
+
+// apiServerClient 调度框架通过apiServer写回调度结果、驱逐被抢占的Pod以及读取PodGroup
+type apiServerClient struct {
+	apiServerConfig *config.APIServerConfig
+}
+
+// Bind 通过创建Pod的binding子资源将Pod绑定到节点上
+func (b *apiServerClient) Bind(pod *apiObject.Pod, nodeName string) error {
+	binding := apiObject.Binding{
+		TypeMeta: apiObject.TypeMeta{Kind: apiObject.BindingType, APIVersion: "v1"},
+		Metadata: apiObject.ObjectMeta{
+			Name:      pod.Metadata.Name,
+			Namespace: pod.Metadata.Namespace,
+			UUID:      pod.Metadata.UUID,
+		},
+		Target: apiObject.ObjectReference{Kind: apiObject.NodeType, Name: nodeName},
+	}
+	url := b.apiServerConfig.APIServerURL() + config.PodBindingURI
+	url = strings.Replace(url, config.NameSpaceReplace, pod.Metadata.Namespace, -1)
+	url = strings.Replace(url, config.NameReplace, pod.Metadata.Name, -1)
+	resp, err := httprequest.PostObjMsg(url, binding)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		var body struct {
+			Error string `json:"error"`
+		}
+		bytes, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(bytes, &body) == nil && body.Error != "" {
+			return errors.New("bind pod failed: " + body.Error)
+		}
+		return errors.New("bind pod failed: " + resp.Status)
+	}
+	return nil
+}
+
+// EvictPod 为被抢占的Pod添加DisruptionTarget状况记录原因，随后删除该Pod
+func (b *apiServerClient) EvictPod(pod *apiObject.Pod, message string) error {
+	pod.Status.SetCondition(apiObject.PodCondition{
+		Type:    apiObject.PodDisruptionTarget,
+		Status:  apiObject.ConditionTrue,
+		Reason:  apiObject.PodReasonPreemptionByScheduler,
+		Message: message,
+	})
+	url := b.apiServerConfig.APIServerURL() + config.PodStatusURI
+	url = strings.Replace(url, config.NameSpaceReplace, pod.Metadata.Namespace, -1)
+	url = strings.Replace(url, config.NameReplace, pod.Metadata.Name, -1)
+	resp, err := httprequest.PutObjMsg(url, pod.Status)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("update status of preempted pod failed: " + resp.Status)
+	}
+
+	url = b.apiServerConfig.APIServerURL() + config.PodURI
+	url = strings.Replace(url, config.NameSpaceReplace, pod.Metadata.Namespace, -1)
+	url = strings.Replace(url, config.NameReplace, pod.Metadata.Name, -1)
+	resp, err = httprequest.DelMsg(url, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return errors.New("delete preempted pod failed: " + resp.Status)
+	}
+	return nil
+}
+
+// GetPodGroup 获取指定的PodGroup，不存在时返回nil
+func (b *apiServerClient) GetPodGroup(namespace, name string) (*apiObject.PodGroup, error) {
+	url := b.apiServerConfig.APIServerURL() + config.PodGroupURI
+	url = strings.Replace(url, config.NameSpaceReplace, namespace, -1)
+	url = strings.Replace(url, config.NameReplace, name, -1)
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("get pod group failed: " + resp.Status)
+	}
+	var body struct {
+		Data apiObject.PodGroup `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return &body.Data, nil
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+func TestCoschedulingBindsWholeGroup(t *testing.T) {
+	apiServer := &fakeAPIServer{
+		nodes: []apiObject.Node{newNodeWithAllocatable("node-a", "1", "1Gi"), newNodeWithAllocatable("node-b", "1", "1Gi")},
+		pods:  []apiObject.Pod{newGroupPod("job-0", "job", "500m"), newGroupPod("job-1", "job", "500m"), newGroupPod("job-2", "job", "500m")},
+	}
+	s := newQueuedScheduler(t, apiServer, framework.WithPodGroupLister(fakePodGroupLister{newPodGroup("job", 3, 10)}))
+
+	// 前两个成员预留节点后等待，不会被绑定
+	assert.Nil(t, s.scheduleOne())
+	assert.Nil(t, s.scheduleOne())
+	assert.Equal(t, "", apiServer.nodeNameOf("job-0"))
+	assert.Equal(t, "", apiServer.nodeNameOf("job-1"))
+
+	// 第三个成员到达后整组一起绑定
+	assert.Nil(t, s.scheduleOne())
+	assert.Eventually(t, func() bool {
+		return apiServer.nodeNameOf("job-0") != "" && apiServer.nodeNameOf("job-1") != "" && apiServer.nodeNameOf("job-2") != ""
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestCoschedulingRollsBackOnTimeout(t *testing.T) {
+	apiServer := &fakeAPIServer{
+		nodes: []apiObject.Node{newNodeWithAllocatable("node-a", "1", "1Gi"), newNodeWithAllocatable("node-b", "1", "1Gi")},
+		pods:  []apiObject.Pod{newGroupPod("job-0", "job", "500m"), newGroupPod("job-1", "job", "500m")},
+	}
+	s := newQueuedScheduler(t, apiServer, framework.WithPodGroupLister(fakePodGroupLister{newPodGroup("job", 3, 1)}))
+
+	// 组内只有两个成员，等待超时后整组撤销预留并回到调度队列
+	assert.Nil(t, s.scheduleOne())
+	assert.Nil(t, s.scheduleOne())
+	assert.Eventually(t, func() bool {
+		return len(s.queue.PendingPods()) == 2
+	}, 3*time.Second, 10*time.Millisecond)
+
+	apiServer.lock.Lock()
+	defer apiServer.lock.Unlock()
+	for _, name := range []string{"job-0", "job-1"} {
+		pod := apiServer.findPod("default", name)
+		assert.Equal(t, "", pod.Spec.NodeName)
+		condition := pod.Status.GetCondition(apiObject.PodScheduled)
+		assert.NotNil(t, condition)
+		assert.True(t, strings.HasPrefix(condition.Message, "rejected"), condition.Message)
+	}
+}
@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/extender"
+	"minik8s/pkg/scheduler/framework"
+)
+
+func TestExtenderFilterPrioritizeAndBind(t *testing.T) {
+	fake := &fakeExtender{unlicensed: map[string]bool{"node-a": true}, dataNode: "node-c"}
+	s := newExtenderScheduler(t, framework.Extender{
+		URLPrefix:      fake.start(t),
+		FilterVerb:     "filter",
+		PrioritizeVerb: "prioritize",
+		BindVerb:       "bind",
+		Weight:         5,
+	})
+	snapshot := framework.NewSnapshot([]apiObject.Node{newNode("node-a", true), newNode("node-b", true), newNode("node-c", true)}, nil)
+
+	// node-a没有许可证，数据在node-c上
+	pod := &apiObject.Pod{Metadata: apiObject.ObjectMeta{Name: "job", Namespace: "default", UUID: "job"}}
+	result, err := s.schedulePod(pod, snapshot)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, result.FeasibleNodes)
+	assert.Equal(t, "node-c", result.SuggestedHost)
+	assert.Equal(t, []extender.ExtenderBindingArgs{{PodName: "job", PodNamespace: "default", PodUID: "job", Node: "node-c"}}, fake.bindings)
+}
+
+func TestExtenderFailure(t *testing.T) {
+	url := (&fakeExtender{broken: true}).start(t)
+	slowURL := (&fakeExtender{delay: 200 * time.Millisecond}).start(t)
+	snapshot := framework.NewSnapshot([]apiObject.Node{newNode("node-a", true), newNode("node-b", true)}, nil)
+	pod := &apiObject.Pod{Metadata: apiObject.ObjectMeta{Name: "job", Namespace: "default"}}
+
+	// 出错或者超时的扩展程序阻止Pod调度
+	s := newExtenderScheduler(t, framework.Extender{URLPrefix: url, FilterVerb: "filter"})
+	_, err := s.schedulePod(pod, snapshot)
+	assert.NotNil(t, err)
+	s = newExtenderScheduler(t, framework.Extender{URLPrefix: slowURL, FilterVerb: "filter", HTTPTimeout: "50ms"})
+	_, err = s.schedulePod(pod, snapshot)
+	assert.NotNil(t, err)
+
+	// 可以忽略的扩展程序出错时跳过该扩展程序
+	s = newExtenderScheduler(t, framework.Extender{URLPrefix: url, FilterVerb: "filter", PrioritizeVerb: "prioritize", Ignorable: true})
+	result, err := s.schedulePod(pod, snapshot)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, result.FeasibleNodes)
+}
@@ -0,0 +1,325 @@
+package scheduler
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/config"
+	"minik8s/pkg/scheduler/extender"
+	"minik8s/pkg/scheduler/framework"
+	"minik8s/pkg/scheduler/plugins"
+	"minik8s/pkg/scheduler/queue"
+)
+
+// 调度器各个测试共用的节点、Pod与假的外部服务
+
+func newNode(name string, ready bool) apiObject.Node {
+	status := apiObject.ConditionTrue
+	if !ready {
+		status = apiObject.ConditionUnknown
+	}
+	return apiObject.Node{
+		Metadata: apiObject.ObjectMeta{Name: name},
+		Status: apiObject.NodeStatus{
+			Conditions: []apiObject.NodeCondition{{Type: apiObject.NodeReady, Status: status}},
+		},
+	}
+}
+
+func newLabeledNode(name string, labels map[string]string) apiObject.Node {
+	node := newNode(name, true)
+	node.Metadata.Labels = labels
+	return node
+}
+
+func newNodeWithAllocatable(name string, cpu string, memory string) apiObject.Node {
+	node := newNode(name, true)
+	node.Status.Capacity = apiObject.ResourceList{"cpu": cpu, "memory": memory, "pods": "110"}
+	node.Status.Allocatable = node.Status.Capacity
+	return node
+}
+
+func newPodWithRequests(name string, nodeName string, cpu string, memory string) apiObject.Pod {
+	return apiObject.Pod{
+		Metadata: apiObject.ObjectMeta{Name: name, UUID: name},
+		Spec: apiObject.PodSpec{
+			NodeName: nodeName,
+			Containers: []apiObject.Container{{
+				Name: name,
+				Resources: apiObject.ResourceRequirements{
+					Requests: apiObject.ResourceList{apiObject.ResourceCPU: cpu, apiObject.ResourceMemory: memory},
+				},
+			}},
+		},
+	}
+}
+
+func newPodWithHostPort(name string, nodeName string, port apiObject.ContainerPort) apiObject.Pod {
+	return apiObject.Pod{
+		Metadata: apiObject.ObjectMeta{Name: name, UUID: name},
+		Spec: apiObject.PodSpec{
+			NodeName:   nodeName,
+			Containers: []apiObject.Container{{Name: name, Ports: []apiObject.ContainerPort{port}}},
+		},
+	}
+}
+
+func newLabeledPod(name string, labels map[string]string) apiObject.Pod {
+	return apiObject.Pod{
+		Metadata: apiObject.ObjectMeta{Name: name, Namespace: "default", UUID: name, Labels: labels},
+	}
+}
+
+func newPriorityPod(name string, nodeName string, cpu string, priority int32) apiObject.Pod {
+	pod := newPodWithRequests(name, nodeName, cpu, "128Mi")
+	pod.Spec.Priority = &priority
+	return pod
+}
+
+func newSpreadPod(name string, topologyKey string, whenUnsatisfiable string) apiObject.Pod {
+	pod := newLabeledPod(name, map[string]string{"app": "web"})
+	pod.Spec.TopologySpreadConstraints = []apiObject.TopologySpreadConstraint{{
+		MaxSkew:           1,
+		TopologyKey:       topologyKey,
+		WhenUnsatisfiable: whenUnsatisfiable,
+		LabelSelector:     &apiObject.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+	}}
+	return pod
+}
+
+func newGroupPod(name string, group string, cpu string) apiObject.Pod {
+	pod := newPodWithRequests(name, "", cpu, "256Mi")
+	pod.Metadata.Namespace = "default"
+	pod.Metadata.Labels = map[string]string{apiObject.PodGroupLabel: group}
+	pod.Status.Phase = apiObject.PodPending
+	return pod
+}
+
+func newPodGroup(name string, minMember int32, timeoutSeconds int32) apiObject.PodGroup {
+	return apiObject.PodGroup{
+		Metadata: apiObject.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:     apiObject.PodGroupSpec{MinMember: minMember, ScheduleTimeoutSeconds: &timeoutSeconds},
+	}
+}
+
+func podAffinityTerm(labels map[string]string, topologyKey string) apiObject.PodAffinityTerm {
+	return apiObject.PodAffinityTerm{
+		LabelSelector: &apiObject.LabelSelector{MatchLabels: labels},
+		TopologyKey:   topologyKey,
+	}
+}
+
+func newTestScheduler(t *testing.T, opts ...framework.Option) *Scheduler {
+	s, err := NewScheduler(framework.DefaultConfiguration(), plugins.NewInTreeRegistry(), opts...)
+	assert.Nil(t, err)
+	return s
+}
+
+// fakeCluster 由假的节点与Pod列表构成的集群，用于在不依赖apiServer的情况下测试调度结果
+type fakeCluster struct {
+	nodes []apiObject.Node
+	pods  []apiObject.Pod
+}
+
+// addNode 添加一个就绪的节点，节点自动带有主机名标签
+func (f *fakeCluster) addNode(name string, labels map[string]string) *fakeCluster {
+	node := newNode(name, true)
+	node.Metadata.Labels = map[string]string{"kubernetes.io/hostname": name}
+	for key, value := range labels {
+		node.Metadata.Labels[key] = value
+	}
+	f.nodes = append(f.nodes, node)
+	return f
+}
+
+// addPod 添加一个已经绑定到节点上的Pod
+func (f *fakeCluster) addPod(pod apiObject.Pod, nodeName string) *fakeCluster {
+	pod.Spec.NodeName = nodeName
+	f.pods = append(f.pods, pod)
+	return f
+}
+
+func (f *fakeCluster) snapshot() *framework.Snapshot {
+	return framework.NewSnapshot(f.nodes, f.pods)
+}
+
+// schedule 调度Pod并将其绑定到选中的节点上，供后续调度使用
+func (f *fakeCluster) schedule(s *Scheduler, pod apiObject.Pod) (string, error) {
+	result, err := s.schedulePod(&pod, f.snapshot())
+	if err != nil {
+		return "", err
+	}
+	f.addPod(pod, result.SuggestedHost)
+	return result.SuggestedHost, nil
+}
+
+// fakeEvictor 记录被驱逐的Pod
+type fakeEvictor struct {
+	evicted []string
+}
+
+func (f *fakeEvictor) EvictPod(pod *apiObject.Pod, _ string) error {
+	f.evicted = append(f.evicted, pod.Metadata.Name)
+	return nil
+}
+
+// fakePodGroupLister 从内存中读取PodGroup
+type fakePodGroupLister []apiObject.PodGroup
+
+func (l fakePodGroupLister) GetPodGroup(namespace, name string) (*apiObject.PodGroup, error) {
+	for i := range l {
+		if l[i].Metadata.Namespace == namespace && l[i].Metadata.Name == name {
+			return &l[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// fakeAPIServer 只实现调度器用到的接口的apiServer
+type fakeAPIServer struct {
+	lock  sync.Mutex
+	nodes []apiObject.Node
+	pods  []apiObject.Pod
+}
+
+func (f *fakeAPIServer) findPod(namespace, name string) *apiObject.Pod {
+	for i := range f.pods {
+		if f.pods[i].Metadata.Namespace == namespace && f.pods[i].Metadata.Name == name {
+			return &f.pods[i]
+		}
+	}
+	return nil
+}
+
+func (f *fakeAPIServer) nodeNameOf(name string) string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.findPod("default", name).Spec.NodeName
+}
+
+func (f *fakeAPIServer) start(t *testing.T) *config.APIServerConfig {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET(config.NodesURI, func(c *gin.Context) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		c.JSON(200, f.nodes)
+	})
+	r.GET(config.PodsGlobalURI, func(c *gin.Context) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		c.JSON(200, f.pods)
+	})
+	r.POST(config.PodBindingURI, func(c *gin.Context) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		var binding apiObject.Binding
+		_ = c.ShouldBindJSON(&binding)
+		pod := f.findPod(c.Param("namespace"), c.Param("name"))
+		if pod == nil || pod.Spec.NodeName != "" {
+			c.JSON(409, gin.H{"error": "conflict"})
+			return
+		}
+		pod.Spec.NodeName = binding.Target.Name
+		c.JSON(201, gin.H{"data": binding})
+	})
+	r.PUT(config.PodStatusURI, func(c *gin.Context) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		pod := f.findPod(c.Param("namespace"), c.Param("name"))
+		_ = c.ShouldBindJSON(&pod.Status)
+		c.JSON(200, gin.H{"data": pod})
+	})
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return &config.APIServerConfig{APIServerIP: host, APIServerPort: portNumber}
+}
+
+// newQueuedScheduler 创建通过fakeAPIServer绑定Pod的调度器，并将待调度的Pod加入队列
+func newQueuedScheduler(t *testing.T, apiServer *fakeAPIServer, opts ...framework.Option) *Scheduler {
+	apiServerConfig := apiServer.start(t)
+	s := newTestScheduler(t, append(opts, framework.WithBinder(&apiServerClient{apiServerConfig: apiServerConfig}))...)
+	s.ApiServerConfig = apiServerConfig
+	// 不退避，使重新尝试调度的Pod直接进入activeQ
+	s.queue = queue.NewSchedulingQueue(0, 0, time.Minute)
+	s.syncPendingPods()
+	return s
+}
+
+// fakeExtender 拒绝没有许可证的节点，偏好数据所在的节点，并记录绑定请求
+type fakeExtender struct {
+	// 没有许可证的节点
+	unlicensed map[string]bool
+	// 数据所在的节点
+	dataNode string
+	// 收到的绑定请求
+	bindings []extender.ExtenderBindingArgs
+	// 为true时所有接口返回500
+	broken bool
+	// 处理请求前等待的时间
+	delay time.Duration
+}
+
+func (f *fakeExtender) start(t *testing.T) string {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		time.Sleep(f.delay)
+		if f.broken {
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}
+	})
+	router.POST("/scheduler/filter", func(c *gin.Context) {
+		var args extender.ExtenderArgs
+		_ = c.ShouldBindJSON(&args)
+		result := extender.ExtenderFilterResult{FailedNodes: map[string]string{}}
+		for _, name := range args.NodeNames {
+			if f.unlicensed[name] {
+				result.FailedNodes[name] = "node(s) didn't have a license for the pod"
+			} else {
+				result.NodeNames = append(result.NodeNames, name)
+			}
+		}
+		c.JSON(200, result)
+	})
+	router.POST("/scheduler/prioritize", func(c *gin.Context) {
+		var args extender.ExtenderArgs
+		_ = c.ShouldBindJSON(&args)
+		var result []extender.HostPriority
+		for _, name := range args.NodeNames {
+			if name == f.dataNode {
+				result = append(result, extender.HostPriority{Host: name, Score: extender.MaxExtenderPriority})
+			}
+		}
+		c.JSON(200, result)
+	})
+	router.POST("/scheduler/bind", func(c *gin.Context) {
+		var args extender.ExtenderBindingArgs
+		_ = c.ShouldBindJSON(&args)
+		f.bindings = append(f.bindings, args)
+		c.JSON(200, extender.ExtenderBindingResult{})
+	})
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server.URL + "/scheduler"
+}
+
+func newExtenderScheduler(t *testing.T, extenders ...framework.Extender) *Scheduler {
+	cfg := framework.DefaultConfiguration()
+	cfg.Extenders = extenders
+	s, err := NewScheduler(cfg, plugins.NewInTreeRegistry())
+	assert.Nil(t, err)
+	return s
+}
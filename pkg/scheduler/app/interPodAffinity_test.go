@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+)
+
+func TestInterPodAntiAffinitySpreadsReplicas(t *testing.T) {
+	s := newTestScheduler(t)
+	cluster := (&fakeCluster{}).addNode("node-a", nil).addNode("node-b", nil).addNode("node-c", nil)
+
+	newReplica := func(name string) apiObject.Pod {
+		pod := newLabeledPod(name, map[string]string{"app": "web"})
+		pod.Spec.Affinity = &apiObject.Affinity{PodAntiAffinity: &apiObject.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []apiObject.PodAffinityTerm{
+				podAffinityTerm(map[string]string{"app": "web"}, "kubernetes.io/hostname"),
+			},
+		}}
+		return pod
+	}
+
+	var hosts []string
+	for _, name := range []string{"web-1", "web-2", "web-3"} {
+		host, err := cluster.schedule(s, newReplica(name))
+		assert.Nil(t, err)
+		hosts = append(hosts, host)
+	}
+	assert.ElementsMatch(t, []string{"node-a", "node-b", "node-c"}, hosts)
+
+	// 每个节点上都已经有副本，第四个副本无法调度
+	_, err := cluster.schedule(s, newReplica("web-4"))
+	assert.Equal(t, "0/3 nodes are available: 3 node(s) didn't match pod anti-affinity rules.", err.Error())
+}
+
+func TestInterPodAffinityCoLocatesCache(t *testing.T) {
+	s := newTestScheduler(t)
+	cluster := (&fakeCluster{}).addNode("node-a", nil).addNode("node-b", nil).addNode("node-c", nil).
+		addPod(newLabeledPod("consumer", map[string]string{"app": "consumer"}), "node-b")
+
+	newCache := func(app string) apiObject.Pod {
+		cache := newLabeledPod("cache", map[string]string{"app": "cache"})
+		cache.Spec.Affinity = &apiObject.Affinity{PodAffinity: &apiObject.PodAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []apiObject.PodAffinityTerm{
+				podAffinityTerm(map[string]string{"app": app}, "kubernetes.io/hostname"),
+			},
+		}}
+		return cache
+	}
+
+	// 缓存必须与consumer运行在同一节点上
+	for i := 0; i < 3; i++ {
+		cache := newCache("consumer")
+		result, err := s.schedulePod(&cache, cluster.snapshot())
+		assert.Nil(t, err)
+		assert.Equal(t, "node-b", result.SuggestedHost)
+	}
+
+	// 集群中没有匹配的Pod时无法调度
+	cache := newCache("database")
+	_, err := s.schedulePod(&cache, cluster.snapshot())
+	assert.Equal(t, "0/3 nodes are available: 3 node(s) didn't match pod affinity rules.", err.Error())
+}
+
+func TestInterPodAffinityExistingAntiAffinity(t *testing.T) {
+	s := newTestScheduler(t)
+	exclusive := newLabeledPod("exclusive", map[string]string{"app": "exclusive"})
+	exclusive.Spec.Affinity = &apiObject.Affinity{PodAntiAffinity: &apiObject.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []apiObject.PodAffinityTerm{
+			podAffinityTerm(map[string]string{"tier": "batch"}, "kubernetes.io/hostname"),
+		},
+	}}
+	cluster := (&fakeCluster{}).addNode("node-a", nil).addNode("node-b", nil).addPod(exclusive, "node-a")
+
+	// 已有Pod的反亲和性条件拒绝batch Pod
+	batch := newLabeledPod("batch", map[string]string{"tier": "batch"})
+	result, err := s.schedulePod(&batch, cluster.snapshot())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.FeasibleNodes)
+	assert.Equal(t, "node-b", result.SuggestedHost)
+}
+
+func TestInterPodAffinityPreferredTerms(t *testing.T) {
+	s := newTestScheduler(t)
+	cluster := (&fakeCluster{}).addNode("node-a", nil).addNode("node-b", nil).addNode("node-c", nil).
+		addPod(newLabeledPod("consumer", map[string]string{"app": "consumer"}), "node-c").
+		addPod(newLabeledPod("noisy", map[string]string{"app": "noisy"}), "node-a")
+
+	// 偏好与consumer在一起，偏好远离noisy
+	for i := 0; i < 3; i++ {
+		pod := newLabeledPod("cache", map[string]string{"app": "cache"})
+		pod.Spec.Affinity = &apiObject.Affinity{
+			PodAffinity: &apiObject.PodAffinity{PreferredDuringSchedulingIgnoredDuringExecution: []apiObject.WeightedPodAffinityTerm{
+				{Weight: 100, PodAffinityTerm: podAffinityTerm(map[string]string{"app": "consumer"}, "kubernetes.io/hostname")},
+			}},
+			PodAntiAffinity: &apiObject.PodAntiAffinity{PreferredDuringSchedulingIgnoredDuringExecution: []apiObject.WeightedPodAffinityTerm{
+				{Weight: 50, PodAffinityTerm: podAffinityTerm(map[string]string{"app": "noisy"}, "kubernetes.io/hostname")},
+			}},
+		}
+		result, err := s.schedulePod(&pod, cluster.snapshot())
+		assert.Nil(t, err)
+		assert.Equal(t, 3, result.FeasibleNodes)
+		assert.Equal(t, "node-c", result.SuggestedHost)
+	}
+}
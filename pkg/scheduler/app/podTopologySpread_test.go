@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+)
+
+func TestPodTopologySpreadDoNotSchedule(t *testing.T) {
+	s := newTestScheduler(t)
+	cluster := (&fakeCluster{}).
+		addNode("node-a", map[string]string{"rack": "rack-1"}).
+		addNode("node-b", map[string]string{"rack": "rack-1"}).
+		addNode("node-c", map[string]string{"rack": "rack-2"}).
+		addNode("node-d", nil)
+
+	// 两个机架上的副本数量之差不超过1，没有rack标签的节点不参与调度
+	racks := make(map[string]int)
+	for _, name := range []string{"web-1", "web-2", "web-3", "web-4"} {
+		host, err := cluster.schedule(s, newSpreadPod(name, "rack", apiObject.DoNotSchedule))
+		assert.Nil(t, err)
+		assert.NotEqual(t, "node-d", host)
+		racks[cluster.snapshot().Get(host).Node.Metadata.Labels["rack"]]++
+	}
+	assert.Equal(t, map[string]int{"rack-1": 2, "rack-2": 2}, racks)
+}
+
+func TestPodTopologySpreadScheduleAnyway(t *testing.T) {
+	s := newTestScheduler(t)
+	cluster := (&fakeCluster{}).addNode("node-a", nil).addNode("node-b", nil).
+		addPod(newLabeledPod("web-0", map[string]string{"app": "web"}), "node-a")
+
+	// 超过maxSkew时DoNotSchedule拒绝node-a，ScheduleAnyway只是优先选择副本最少的节点
+	cluster.nodes[1].Spec.Unschedulable = true
+	_, err := cluster.schedule(s, newSpreadPod("web-1", "kubernetes.io/hostname", apiObject.DoNotSchedule))
+	assert.Equal(t, "0/2 nodes are available: 1 node(s) didn't match pod topology spread constraints, 1 node(s) were unschedulable.", err.Error())
+	host, err := cluster.schedule(s, newSpreadPod("web-1", "kubernetes.io/hostname", apiObject.ScheduleAnyway))
+	assert.Nil(t, err)
+	assert.Equal(t, "node-a", host)
+
+	cluster.nodes[1].Spec.Unschedulable = false
+	host, err = cluster.schedule(s, newSpreadPod("web-2", "kubernetes.io/hostname", apiObject.ScheduleAnyway))
+	assert.Nil(t, err)
+	assert.Equal(t, "node-b", host)
+}
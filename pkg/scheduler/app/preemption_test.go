@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+func TestPreemptionSelectsLowestPriorityVictims(t *testing.T) {
+	evictor := &fakeEvictor{}
+	s := newTestScheduler(t, framework.WithPodEvictor(evictor))
+	nodes := []apiObject.Node{newNodeWithAllocatable("node-a", "1", "1Gi"), newNodeWithAllocatable("node-b", "1", "1Gi")}
+	pods := []apiObject.Pod{
+		newPriorityPod("batch", "node-a", "600m", 10),
+		newPriorityPod("web", "node-b", "600m", 100),
+	}
+
+	// 驱逐优先级最低的batch
+	preemptor := newPriorityPod("critical", "", "600m", 1000)
+	result, err := s.schedulePod(&preemptor, framework.NewSnapshot(nodes, pods))
+	assert.IsType(t, &framework.FitError{}, err)
+	assert.Equal(t, "node-a", result.NominatedNodeName)
+	assert.Equal(t, []string{"batch"}, evictor.evicted)
+
+	// batch退出后，critical优先尝试提名的节点
+	preemptor.Status.NominatedNodeName = result.NominatedNodeName
+	result, err = s.schedulePod(&preemptor, framework.NewSnapshot(nodes, pods[1:]))
+	assert.Nil(t, err)
+	assert.Equal(t, "node-a", result.SuggestedHost)
+}
+
+func TestPreemptionNotPossible(t *testing.T) {
+	evictor := &fakeEvictor{}
+	s := newTestScheduler(t, framework.WithPodEvictor(evictor))
+	nodes := []apiObject.Node{newNodeWithAllocatable("node-a", "1", "1Gi")}
+	pods := []apiObject.Pod{newPriorityPod("web", "node-a", "600m", 1000)}
+
+	// node-a上的Pod优先级不低于待调度的Pod
+	preemptor := newPriorityPod("critical", "", "600m", 1000)
+	result, err := s.schedulePod(&preemptor, framework.NewSnapshot(nodes, pods))
+	assert.NotNil(t, err)
+	assert.Equal(t, "", result.NominatedNodeName)
+
+	// 抢占策略为Never的Pod不会抢占其他Pod
+	preemptor = newPriorityPod("critical", "", "600m", 2000)
+	preemptor.Spec.PreemptionPolicy = apiObject.PreemptNever
+	result, err = s.schedulePod(&preemptor, framework.NewSnapshot(nodes, pods))
+	assert.NotNil(t, err)
+	assert.Equal(t, "", result.NominatedNodeName)
+	assert.Empty(t, evictor.evicted)
+}
@@ -2,107 +2,592 @@ package scheduler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
 	"minik8s/pkg/apiObject"
 	"minik8s/pkg/config"
+	"minik8s/pkg/scheduler/extender"
+	"minik8s/pkg/scheduler/framework"
+	"minik8s/pkg/scheduler/plugins"
+	"minik8s/pkg/scheduler/queue"
+	"minik8s/tools/executor"
 	"minik8s/tools/log"
-	"net/http"
-	"sync"
 
-	"github.com/gin-gonic/gin"
+	httprequest "minik8s/tools/httpRequest"
+)
+
+const (
+	// podSyncInterval 从apiServer同步等待调度的Pod的间隔
+	podSyncInterval = 1 * time.Second
+	// queueFlushInterval 检查退避结束以及在unschedulableQ中停留超时的Pod的间隔
+	queueFlushInterval = 1 * time.Second
 )
 
 type Scheduler struct {
 	// ApiServerConfig 存储apiServer的配置信息，用于和apiServer进行通信
 	ApiServerConfig *config.APIServerConfig
-	//调度策略
-	Policy string
-}
+	// Profiles 调度配置名称到调度框架的映射
+	Profiles map[string]*framework.Framework
+	// Extenders 外部的调度扩展程序，在插件之后依次调用
+	Extenders []*extender.HTTPExtender
+	// lock 保证同一时间只有一个Pod在调度，避免插件的状态被并发修改
+	lock sync.Mutex
 
-const (
-	RoundRobin = "RoundRobin"
-)
+	// queue 等待调度的Pod
+	queue *queue.SchedulingQueue
+	// clusterState 上一次同步时集群中与调度相关的状态，发生变化时重新尝试调度无法调度的Pod
+	clusterState string
+	// assumedPods 在Permit阶段等待或者正在绑定的Pod的UUID到预留节点的映射，调度其他Pod时视为已经运行在该节点上
+	assumedLock sync.Mutex
+	assumedPods map[string]string
+}
 
-var globalCount int
-var lock sync.Mutex
+// ScheduleResult 调度结果
+type ScheduleResult struct {
+	// 选中的节点
+	SuggestedHost string
+	// 参与调度的节点数量
+	EvaluatedNodes int
+	// 通过过滤的节点数量
+	FeasibleNodes int
+	// 没有节点可以放置Pod时，PostFilter插件为Pod提名的节点
+	NominatedNodeName string
+	// 未通过过滤的节点及其被拒绝的原因
+	Diagnosis map[string]*framework.Status
+	// 通过过滤的节点的得分
+	Scores []framework.NodeScore
+	// Permit插件要求Pod等待，Pod已经预留了SuggestedHost但还没有绑定
+	WaitingOnPermit bool
 
-func (s *Scheduler) scheduleRequest() apiObject.Node {
-	// 从apiServer获取pod信息
-	podList := s.getNodesList()
-	// 调度pod
-	return s.schedule(podList)
+	// 本次调度的CycleState，等待结束后绑定或者撤销预留时使用
+	cycleState *framework.CycleState
 }
 
-func NewScheduler() *Scheduler {
-	globalCount = 0
-	return &Scheduler{
+func NewScheduler(cfg *framework.SchedulerConfiguration, registry framework.Registry, opts ...framework.Option) (*Scheduler, error) {
+	s := &Scheduler{
 		ApiServerConfig: config.NewAPIServerConfig(),
-		Policy:          RoundRobin,
+		Profiles:        make(map[string]*framework.Framework),
+		queue:           queue.NewSchedulingQueue(queue.DefaultPodInitialBackoff, queue.DefaultPodMaxBackoff, queue.DefaultPodMaxUnschedulableDuration),
+		assumedPods:     make(map[string]string),
+	}
+	for _, profile := range cfg.Profiles {
+		if _, ok := s.Profiles[profile.SchedulerName]; ok {
+			return nil, errors.New("duplicate profile " + profile.SchedulerName)
+		}
+		fw, err := framework.NewFramework(profile, registry, opts...)
+		if err != nil {
+			return nil, err
+		}
+		s.Profiles[profile.SchedulerName] = fw
 	}
+	binders := 0
+	for _, extenderConfig := range cfg.Extenders {
+		e, err := extender.NewHTTPExtender(extenderConfig)
+		if err != nil {
+			return nil, err
+		}
+		if e.IsBinder() {
+			binders++
+		}
+		s.Extenders = append(s.Extenders, e)
+	}
+	if binders > 1 {
+		return nil, errors.New("only one extender can implement bind")
+	}
+	return s, nil
 }
 
-func (s *Scheduler) getNodesList() []apiObject.Node {
-	// 从apiServer获取所有的pod信息
-	url := s.ApiServerConfig.APIServerURL() + config.NodesURI
-	var NodeList []apiObject.Node
-	resp, err := http.Get(url)
+// frameworkForPod 根据Pod的schedulerName选择调度框架
+func (s *Scheduler) frameworkForPod(pod *apiObject.Pod) (*framework.Framework, error) {
+	name := pod.Spec.SchedulerName
+	if name == "" {
+		name = framework.DefaultSchedulerName
+	}
+	fw, ok := s.Profiles[name]
+	if !ok {
+		return nil, errors.New("profile not found: " + name)
+	}
+	return fw, nil
+}
+
+// responsibleFor 判断Pod是否由该调度器负责调度：尚未绑定节点、处于Pending阶段，且schedulerName对应该调度器中的某个调度配置
+func (s *Scheduler) responsibleFor(pod *apiObject.Pod) bool {
+	if pod.Spec.NodeName != "" || pod.Status.Phase != apiObject.PodPending {
+		return false
+	}
+	_, err := s.frameworkForPod(pod)
+	return err == nil
+}
+
+// syncPendingPods 从apiServer同步等待调度的Pod到调度队列中，并移除已经被删除或者已经绑定的Pod
+func (s *Scheduler) syncPendingPods() {
+	nodeList, err := s.listNodes()
+	if err != nil {
+		log.ErrorLog("syncPendingPods: " + err.Error())
+		return
+	}
+	podList, err := s.listPods()
+	if err != nil {
+		log.ErrorLog("syncPendingPods: " + err.Error())
+		return
+	}
+	pending := make(map[string]bool)
+	for i := range podList {
+		pod := &podList[i]
+		if !s.responsibleFor(pod) {
+			continue
+		}
+		pending[pod.GetPodUUID()] = true
+		s.queue.Add(pod)
+	}
+	for _, pod := range s.queue.PendingPods() {
+		if !pending[pod.GetPodUUID()] {
+			s.queue.Delete(pod)
+		}
+	}
+	// 节点或者已经调度的Pod发生变化后，之前无法调度的Pod可能已经可以调度
+	if state := clusterState(nodeList, podList); state != s.clusterState {
+		s.clusterState = state
+		s.queue.MoveAllToActiveOrBackoffQueue()
+	}
+}
+
+// scheduleOne 从调度队列中取出一个Pod进行调度
+//
+//	调度成功时Bind插件创建Pod的binding子资源；没有可以放置Pod的节点时将PodScheduled状况设置为False，
+//	并记录抢占后提名的节点，Pod进入unschedulableQ等待集群发生变化；其余错误使Pod退避一段时间后重试
+//	Permit插件要求等待的Pod在后台等待，不阻塞其他Pod的调度
+func (s *Scheduler) scheduleOne() error {
+	info, err := s.queue.Pop()
 	if err != nil {
-		log.ErrorLog("http request.GetObjMsg err:" + err.Error())
+		return err
+	}
+	pod := info.Pod
+	var result ScheduleResult
+	nodeList, err := s.listNodes()
+	if err == nil {
+		var podList []apiObject.Pod
+		podList, err = s.listPods()
+		if err == nil {
+			podList = assumeNominatedPods(pod, s.applyAssumedPods(podList))
+			result, err = s.scheduleWithSnapshot(pod, framework.NewSnapshot(nodeList, podList))
+		}
+	}
+	if err == nil && result.WaitingOnPermit {
+		s.assumePod(pod, result.SuggestedHost)
+		go s.waitOnPermitAndBind(info, result)
+		return nil
+	}
+	if err == nil {
+		s.queue.Done(pod)
 		return nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		log.ErrorLog("http request.GetObjMsg StatusCode:" + fmt.Sprint(resp.StatusCode))
+	var fitErr *framework.FitError
+	if errors.As(err, &fitErr) {
+		log.WarnLog(fmt.Sprintf("pod %s/%s is unschedulable: %s", pod.Metadata.Namespace, pod.Metadata.Name, err.Error()))
+		s.recordUnschedulable(pod, err.Error(), result.NominatedNodeName)
+		s.queue.AddUnschedulable(info)
 		return nil
 	}
-	bodyBytes, err := io.ReadAll(resp.Body)
+	log.ErrorLog(fmt.Sprintf("schedule pod %s/%s failed (attempt %d): %s", pod.Metadata.Namespace, pod.Metadata.Name,
+		info.Attempts, err.Error()))
+	s.queue.AddBackoff(info)
+	return nil
+}
+
+func (s *Scheduler) scheduleWithSnapshot(pod *apiObject.Pod, snapshot *framework.Snapshot) (ScheduleResult, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	result, err := s.schedulePod(pod, snapshot)
 	if err != nil {
-		log.ErrorLog("io.ReadAll err:" + err.Error())
-		return nil
+		return result, err
+	}
+	if result.WaitingOnPermit {
+		log.InfoLog(fmt.Sprintf("pod %s/%s reserved node %s and is waiting on permit", pod.Metadata.Namespace,
+			pod.Metadata.Name, result.SuggestedHost))
+		return result, nil
+	}
+	log.InfoLog(fmt.Sprintf("schedule pod %s/%s to node %s, %d/%d nodes are feasible", pod.Metadata.Namespace,
+		pod.Metadata.Name, result.SuggestedHost, result.FeasibleNodes, result.EvaluatedNodes))
+	return result, nil
+}
+
+// waitOnPermitAndBind 等待Permit插件允许后绑定Pod，被拒绝或者绑定失败时撤销预留，Pod重新进入调度队列
+func (s *Scheduler) waitOnPermitAndBind(info *queue.QueuedPodInfo, result ScheduleResult) {
+	pod := info.Pod
+	defer s.forgetPod(pod)
+	fw, err := s.frameworkForPod(pod)
+	if err != nil {
+		log.ErrorLog("waitOnPermitAndBind: " + err.Error())
+		return
+	}
+	status := fw.WaitOnPermit(pod)
+	if status.IsSuccess() {
+		if err = s.bind(fw, result.cycleState, pod, result.SuggestedHost); err == nil {
+			log.InfoLog(fmt.Sprintf("schedule pod %s/%s to node %s after waiting on permit", pod.Metadata.Namespace,
+				pod.Metadata.Name, result.SuggestedHost))
+			s.queue.Done(pod)
+			return
+		}
+	}
+
+	s.lock.Lock()
+	fw.RunUnreservePlugins(result.cycleState, pod, result.SuggestedHost)
+	s.lock.Unlock()
+	if !status.IsSuccess() {
+		log.WarnLog(fmt.Sprintf("pod %s/%s is rejected on permit: %s", pod.Metadata.Namespace, pod.Metadata.Name, status.Message()))
+		s.recordUnschedulable(pod, status.Message(), "")
+		s.queue.AddUnschedulable(info)
+		return
+	}
+	log.ErrorLog(fmt.Sprintf("bind pod %s/%s failed (attempt %d): %s", pod.Metadata.Namespace, pod.Metadata.Name,
+		info.Attempts, err.Error()))
+	s.queue.AddBackoff(info)
+}
+
+// assumePod 记录在Permit阶段等待的Pod预留的节点
+func (s *Scheduler) assumePod(pod *apiObject.Pod, nodeName string) {
+	s.assumedLock.Lock()
+	defer s.assumedLock.Unlock()
+	s.assumedPods[pod.Metadata.UUID] = nodeName
+}
+
+// forgetPod Pod绑定完成或者撤销预留后不再视为运行在预留的节点上
+func (s *Scheduler) forgetPod(pod *apiObject.Pod) {
+	s.assumedLock.Lock()
+	defer s.assumedLock.Unlock()
+	delete(s.assumedPods, pod.Metadata.UUID)
+}
+
+// applyAssumedPods 将尚未绑定的等待中的Pod视为已经运行在预留的节点上，避免预留的资源被其他Pod占用
+func (s *Scheduler) applyAssumedPods(pods []apiObject.Pod) []apiObject.Pod {
+	s.assumedLock.Lock()
+	defer s.assumedLock.Unlock()
+	for i := range pods {
+		if nodeName, ok := s.assumedPods[pods[i].Metadata.UUID]; ok && pods[i].Spec.NodeName == "" {
+			pods[i].Spec.NodeName = nodeName
+		}
+	}
+	return pods
+}
+
+// assumeNominatedPods 将优先级不低于pod的其他被提名的Pod视为已经运行在提名的节点上，避免抢占得到的空间被其他Pod占用
+func assumeNominatedPods(pod *apiObject.Pod, pods []apiObject.Pod) []apiObject.Pod {
+	result := make([]apiObject.Pod, 0, len(pods))
+	for _, other := range pods {
+		if other.Spec.NodeName == "" && other.Status.NominatedNodeName != "" &&
+			other.Metadata.UUID != pod.Metadata.UUID && other.GetPriority() >= pod.GetPriority() {
+			other.Spec.NodeName = other.Status.NominatedNodeName
+		}
+		result = append(result, other)
+	}
+	return result
+}
+
+// recordUnschedulable 将Pod的PodScheduled状况设置为False，记录无法调度的原因以及抢占后提名的节点
+func (s *Scheduler) recordUnschedulable(pod *apiObject.Pod, message string, nominatedNodeName string) {
+	changed := pod.Status.SetCondition(apiObject.PodCondition{
+		Type:    apiObject.PodScheduled,
+		Status:  apiObject.ConditionFalse,
+		Reason:  apiObject.PodReasonUnschedulable,
+		Message: message,
+	})
+	// 抢占失败时保留之前提名的节点，被驱逐的Pod可能还没有完全退出
+	if nominatedNodeName != "" && nominatedNodeName != pod.Status.NominatedNodeName {
+		pod.Status.NominatedNodeName = nominatedNodeName
+		changed = true
+	}
+	if !changed {
+		return
 	}
-	log.DebugLog("getNodesList body:" + string(bodyBytes))
-	bodyString := string(bodyBytes)
-	err = json.Unmarshal([]byte(bodyString), &NodeList)
+	url := s.ApiServerConfig.APIServerURL() + config.PodStatusURI
+	url = strings.Replace(url, config.NameSpaceReplace, pod.Metadata.Namespace, -1)
+	url = strings.Replace(url, config.NameReplace, pod.Metadata.Name, -1)
+	resp, err := httprequest.PutObjMsg(url, pod.Status)
 	if err != nil {
-		log.ErrorLog("json.Unmarshal err:" + err.Error())
+		log.ErrorLog("recordUnschedulable: " + err.Error())
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.ErrorLog("recordUnschedulable: " + resp.Status)
+	}
+}
+
+// clusterState 提取节点与已经调度的Pod中影响调度结果的部分
+func clusterState(nodes []apiObject.Node, pods []apiObject.Pod) string {
+	type nodeState struct {
+		Name        string
+		Labels      map[string]string
+		Spec        apiObject.NodeSpec
+		Allocatable apiObject.ResourceList
+		Ready       bool
+	}
+	type podState struct {
+		UUID     string
+		NodeName string
+		Phase    apiObject.PodPhase
+	}
+	var state struct {
+		Nodes []nodeState
+		Pods  []podState
+	}
+	for _, node := range nodes {
+		state.Nodes = append(state.Nodes, nodeState{
+			Name:        node.Metadata.Name,
+			Labels:      node.Metadata.Labels,
+			Spec:        node.Spec,
+			Allocatable: node.Status.Allocatable,
+			Ready:       node.IsReady(),
+		})
+	}
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		state.Pods = append(state.Pods, podState{UUID: pod.Metadata.UUID, NodeName: pod.Spec.NodeName, Phase: pod.Status.Phase})
+	}
+	bytes, _ := json.Marshal(state)
+	return string(bytes)
+}
+
+// schedulePod 依次执行各个扩展点上的插件，为Pod选择节点
+func (s *Scheduler) schedulePod(pod *apiObject.Pod, snapshot *framework.Snapshot) (ScheduleResult, error) {
+	fw, err := s.frameworkForPod(pod)
+	if err != nil {
+		return ScheduleResult{}, err
+	}
+	if len(snapshot.NodeInfos) == 0 {
+		return ScheduleResult{}, errors.New("no nodes available to schedule pods")
+	}
+	state := framework.NewCycleState()
+
+	// 1. PreFilter
+	if status := fw.RunPreFilterPlugins(state, pod, snapshot); !status.IsSuccess() {
+		if status.IsUnschedulable() {
+			diagnosis := make(map[string]*framework.Status)
+			for _, nodeInfo := range snapshot.NodeInfos {
+				diagnosis[nodeInfo.Name()] = status
+			}
+			return ScheduleResult{Diagnosis: diagnosis}, &framework.FitError{Pod: pod, NumAllNodes: len(snapshot.NodeInfos), Diagnosis: diagnosis}
+		}
+		return ScheduleResult{}, errors.New(status.Plugin() + ": " + status.Message())
+	}
+
+	// 2. Filter，优先尝试之前抢占得到的节点，该节点可以放置Pod时不再评估其他节点
+	var feasible []*framework.NodeInfo
+	diagnosis := make(map[string]*framework.Status)
+	if nominated := snapshot.Get(pod.Status.NominatedNodeName); nominated != nil {
+		feasible, err = s.findNodesThatFit(fw, state, pod, []*framework.NodeInfo{nominated}, diagnosis)
+		if err != nil {
+			return ScheduleResult{}, err
+		}
+	}
+	if len(feasible) == 0 {
+		feasible, err = s.findNodesThatFit(fw, state, pod, snapshot.NodeInfos, diagnosis)
+		if err != nil {
+			return ScheduleResult{}, err
+		}
+	}
+	if len(feasible) == 0 {
+		fitErr := &framework.FitError{Pod: pod, NumAllNodes: len(snapshot.NodeInfos), Diagnosis: diagnosis}
+		// 没有节点可以放置Pod时执行PostFilter，尝试通过抢占为Pod腾出节点
+		postFilterResult, status := fw.RunPostFilterPlugins(state, pod, snapshot, diagnosis)
+		if status.Code() == framework.Error {
+			log.ErrorLog("postFilter: " + status.Plugin() + ": " + status.Message())
+		}
+		if status.IsSuccess() && postFilterResult != nil {
+			return ScheduleResult{NominatedNodeName: postFilterResult.NominatedNodeName, Diagnosis: diagnosis}, fitErr
+		}
+		return ScheduleResult{Diagnosis: diagnosis}, fitErr
+	}
+
+	// 3. Score，选择加权总分最高的节点，同分时选择名称最小的节点
+	scores, status := fw.RunScorePlugins(state, pod, feasible)
+	if !status.IsSuccess() {
+		return ScheduleResult{}, errors.New(status.Plugin() + ": " + status.Message())
+	}
+	s.runExtenderPrioritize(pod, feasible, scores)
+	best := scores[0]
+	for _, score := range scores[1:] {
+		if score.Score > best.Score {
+			best = score
+		}
+	}
+
+	// 4. Reserve
+	if status = fw.RunReservePlugins(state, pod, best.Name); !status.IsSuccess() {
+		return ScheduleResult{}, errors.New(status.Plugin() + ": " + status.Message())
+	}
+
+	result := ScheduleResult{
+		SuggestedHost:  best.Name,
+		EvaluatedNodes: len(snapshot.NodeInfos),
+		FeasibleNodes:  len(feasible),
+		Diagnosis:      diagnosis,
+		Scores:         scores,
+		cycleState:     state,
+	}
+
+	// 5. Permit，要求等待的Pod由调用方在等待结束后绑定
+	status = fw.RunPermitPlugins(state, pod, best.Name)
+	if status.IsWait() {
+		result.WaitingOnPermit = true
+		return result, nil
+	}
+	if !status.IsSuccess() {
+		fw.RunUnreservePlugins(state, pod, best.Name)
+		return ScheduleResult{}, errors.New(status.Plugin() + ": " + status.Message())
+	}
+
+	// 6. Bind，设置了bind接口的扩展程序代替Bind插件完成绑定
+	if err = s.bind(fw, state, pod, best.Name); err != nil {
+		fw.RunUnreservePlugins(state, pod, best.Name)
+		return ScheduleResult{}, err
+	}
+	return result, nil
+}
+
+// findNodesThatFit 依次执行Filter插件与扩展程序的filter接口，返回通过过滤的节点，并将被拒绝的原因记录到diagnosis中
+func (s *Scheduler) findNodesThatFit(fw *framework.Framework, state *framework.CycleState, pod *apiObject.Pod,
+	nodeInfos []*framework.NodeInfo, diagnosis map[string]*framework.Status) ([]*framework.NodeInfo, error) {
+	var feasible []*framework.NodeInfo
+	for _, nodeInfo := range nodeInfos {
+		status := fw.RunFilterPlugins(state, pod, nodeInfo)
+		if status.IsSuccess() {
+			feasible = append(feasible, nodeInfo)
+			continue
+		}
+		if status.Code() == framework.Error {
+			return nil, errors.New(status.Plugin() + ": " + status.Message())
+		}
+		diagnosis[nodeInfo.Name()] = status
+	}
+
+	for _, e := range s.Extenders {
+		if len(feasible) == 0 {
+			break
+		}
+		passed, failed, err := e.Filter(pod, feasible)
+		if err != nil {
+			if e.IsIgnorable() {
+				log.WarnLog("skipping extender " + e.Name() + " as it returned error " + err.Error() + " and has ignorable flag set")
+				continue
+			}
+			return nil, errors.New("extender " + e.Name() + ": " + err.Error())
+		}
+		for name, status := range failed {
+			diagnosis[name] = status
+		}
+		feasible = passed
+	}
+	return feasible, nil
+}
+
+// runExtenderPrioritize 将扩展程序给出的分数累加到节点的加权总分上，扩展程序出错时忽略其分数
+func (s *Scheduler) runExtenderPrioritize(pod *apiObject.Pod, nodeInfos []*framework.NodeInfo, scores []framework.NodeScore) {
+	for _, e := range s.Extenders {
+		extenderScores, err := e.Prioritize(pod, nodeInfos)
+		if err != nil {
+			log.WarnLog("extender " + e.Name() + " prioritize failed: " + err.Error())
+			continue
+		}
+		for i := range scores {
+			score := extenderScores[scores[i].Name]
+			scores[i].Score += score
+			scores[i].Scores = append(scores[i].Scores, framework.PluginScore{Name: e.Name(), Score: score})
+		}
+	}
+}
+
+// bind 由设置了bind接口的扩展程序或者Bind插件将Pod绑定到节点上
+func (s *Scheduler) bind(fw *framework.Framework, state *framework.CycleState, pod *apiObject.Pod, nodeName string) error {
+	for _, e := range s.Extenders {
+		if !e.IsBinder() {
+			continue
+		}
+		if err := e.Bind(pod, nodeName); err != nil {
+			return errors.New("extender " + e.Name() + ": " + err.Error())
+		}
+		pod.Spec.NodeName = nodeName
 		return nil
 	}
-	return NodeList
+	if status := fw.RunBindPlugins(state, pod, nodeName); !status.IsSuccess() {
+		return errors.New(status.Plugin() + ": " + status.Message())
+	}
+	return nil
 }
 
-func (s *Scheduler) schedule(nodeList []apiObject.Node) apiObject.Node {
-	switch s.Policy {
-	case RoundRobin:
-		return s.roundRobin(nodeList)
-	default:
-		return s.roundRobin(nodeList)
+func (s *Scheduler) listNodes() ([]apiObject.Node, error) {
+	// 从apiServer获取所有的node信息
+	url := s.ApiServerConfig.APIServerURL() + config.NodesURI
+	var NodeList []apiObject.Node
+	if err := s.getList(url, &NodeList); err != nil {
+		return nil, err
 	}
+	return NodeList, nil
 }
 
-func (s *Scheduler) roundRobin(nodeList []apiObject.Node) apiObject.Node {
-	lock.Lock()
-	defer lock.Unlock()
-	if globalCount >= len(nodeList) {
-		globalCount = 0
+func (s *Scheduler) listPods() ([]apiObject.Pod, error) {
+	// 从apiServer获取所有的pod信息，用于统计每个节点上已经运行的pod以及等待调度的pod
+	url := s.ApiServerConfig.APIServerURL() + config.PodsGlobalURI
+	var PodList []apiObject.Pod
+	if err := s.getList(url, &PodList); err != nil {
+		return nil, err
 	}
-	node := nodeList[globalCount]
-	globalCount++
-	return node
+	return PodList, nil
 }
 
-func Run() {
-	gin.SetMode(gin.ReleaseMode)
-	scheduler := NewScheduler()
-	r := gin.New()
+func (s *Scheduler) getList(url string, target interface{}) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
 
-	r.GET(config.SchedulerPath(), func(c *gin.Context) {
-		data := scheduler.scheduleRequest()
-		c.JSON(200, data)
-	})
+	if resp.StatusCode != 200 {
+		return errors.New("http request StatusCode: " + fmt.Sprint(resp.StatusCode))
+	}
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bodyBytes, target)
+}
 
-	log.InfoLog("Starting scheduler HTTP server on :7820")
-	r.Run(":" + config.SchedulerPort())
+// Run 调度器启动后不断从调度队列中取出Pod进行调度
+func (s *Scheduler) Run() {
+	go s.queue.Run(queueFlushInterval)
+	go executor.ExecuteInPeriod(0, []time.Duration{podSyncInterval}, s.syncPendingPods)
+	for {
+		if err := s.scheduleOne(); err != nil {
+			log.ErrorLog("scheduler stopped: " + err.Error())
+			return
+		}
+	}
+}
+
+func Run() {
+	cfg, err := framework.LoadConfiguration(config.SchedulerProfilePath)
+	if err != nil {
+		log.ErrorLog("load scheduler configuration failed: " + err.Error())
+		panic(err)
+	}
+	client := &apiServerClient{apiServerConfig: config.NewAPIServerConfig()}
+	scheduler, err := NewScheduler(cfg, plugins.NewInTreeRegistry(), framework.WithBinder(client), framework.WithPodEvictor(client),
+		framework.WithPodGroupLister(client))
+	if err != nil {
+		log.ErrorLog("create scheduler failed: " + err.Error())
+		panic(err)
+	}
+	log.InfoLog("Starting scheduler")
+	scheduler.Run()
 }
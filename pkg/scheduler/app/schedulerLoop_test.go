@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+)
+
+func TestScheduleOneBindsPendingPods(t *testing.T) {
+	apiServer := &fakeAPIServer{
+		nodes: []apiObject.Node{newNodeWithAllocatable("node-a", "1", "1Gi")},
+		pods: []apiObject.Pod{
+			newPodWithRequests("web", "", "600m", "256Mi"),
+			newPodWithRequests("big", "", "600m", "256Mi"),
+		},
+	}
+	for i := range apiServer.pods {
+		apiServer.pods[i].Metadata.Namespace = "default"
+		apiServer.pods[i].Status.Phase = apiObject.PodPending
+	}
+	s := newQueuedScheduler(t, apiServer)
+
+	// 第一个Pod绑定到node-a上
+	assert.Len(t, s.queue.PendingPods(), 2)
+	assert.Nil(t, s.scheduleOne())
+	assert.Equal(t, "node-a", apiServer.findPod("default", "web").Spec.NodeName)
+
+	// 第二个Pod资源不足，PodScheduled状况为False
+	assert.Nil(t, s.scheduleOne())
+	big := apiServer.findPod("default", "big")
+	assert.Equal(t, "", big.Spec.NodeName)
+	condition := big.Status.GetCondition(apiObject.PodScheduled)
+	assert.NotNil(t, condition)
+	assert.Equal(t, apiObject.ConditionFalse, condition.Status)
+	assert.Equal(t, apiObject.PodReasonUnschedulable, condition.Reason)
+	assert.Equal(t, "0/1 nodes are available: 1 Insufficient cpu.", condition.Message)
+
+	// 同步后已经绑定的Pod不再留在队列中，无法调度的Pod留在unschedulableQ中
+	s.syncPendingPods()
+	pending := s.queue.PendingPods()
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "big", pending[0].Metadata.Name)
+
+	// 新的节点加入后重新尝试调度
+	apiServer.lock.Lock()
+	apiServer.nodes = append(apiServer.nodes, newNodeWithAllocatable("node-b", "1", "1Gi"))
+	apiServer.lock.Unlock()
+	s.syncPendingPods()
+	assert.Nil(t, s.scheduleOne())
+	assert.Equal(t, "node-b", apiServer.findPod("default", "big").Spec.NodeName)
+}
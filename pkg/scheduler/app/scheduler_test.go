@@ -0,0 +1,344 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+	"minik8s/pkg/scheduler/plugins"
+)
+
+func TestSchedulePodRoundRobin(t *testing.T) {
+	s := newTestScheduler(t)
+	snapshot := framework.NewSnapshot([]apiObject.Node{
+		newNode("node-c", true), newNode("node-a", true), newNode("node-b", false),
+	}, nil)
+
+	var hosts []string
+	for i := 0; i < 4; i++ {
+		pod := &apiObject.Pod{}
+		result, err := s.schedulePod(pod, snapshot)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, result.EvaluatedNodes)
+		assert.Equal(t, 2, result.FeasibleNodes)
+		assert.Equal(t, result.SuggestedHost, pod.Spec.NodeName)
+		hosts = append(hosts, result.SuggestedHost)
+	}
+	assert.Equal(t, []string{"node-a", "node-c", "node-a", "node-c"}, hosts)
+}
+
+func TestSchedulePodNoFeasibleNode(t *testing.T) {
+	s := newTestScheduler(t)
+	snapshot := framework.NewSnapshot([]apiObject.Node{newNode("node-a", false)}, nil)
+
+	_, err := s.schedulePod(&apiObject.Pod{}, snapshot)
+	fitErr, ok := err.(*framework.FitError)
+	assert.True(t, ok)
+	assert.Equal(t, "0/1 nodes are available: 1 node(s) were not ready.", fitErr.Error())
+
+	_, err = s.schedulePod(&apiObject.Pod{}, framework.NewSnapshot(nil, nil))
+	assert.NotNil(t, err)
+}
+
+func TestSchedulePodUnknownProfile(t *testing.T) {
+	s := newTestScheduler(t)
+	pod := &apiObject.Pod{Spec: apiObject.PodSpec{SchedulerName: "batch-scheduler"}}
+	_, err := s.schedulePod(pod, framework.NewSnapshot([]apiObject.Node{newNode("node-a", true)}, nil))
+	assert.NotNil(t, err)
+}
+
+func TestNewFrameworkRejectsUnknownPlugin(t *testing.T) {
+	profile := framework.DefaultConfiguration().Profiles[0]
+	profile.Plugins.Filter = append(profile.Plugins.Filter, framework.PluginRef{Name: "NotExist"})
+	_, err := framework.NewFramework(profile, plugins.NewInTreeRegistry())
+	assert.NotNil(t, err)
+
+	// RoundRobin 没有实现 Filter 扩展点
+	profile = framework.DefaultConfiguration().Profiles[0]
+	profile.Plugins.Filter = []framework.PluginRef{{Name: plugins.RoundRobinName}}
+	_, err = framework.NewFramework(profile, plugins.NewInTreeRegistry())
+	assert.NotNil(t, err)
+}
+
+func TestSchedulePodNodeResourcesFit(t *testing.T) {
+	s := newTestScheduler(t)
+	small := newNode("node-a", true)
+	small.Status.Allocatable = apiObject.ResourceList{"cpu": "1", "memory": "1Gi", "pods": "110"}
+	large := newNode("node-b", true)
+	large.Status.Allocatable = apiObject.ResourceList{"cpu": "4", "memory": "8Gi", "pods": "110"}
+	existing := []apiObject.Pod{newPodWithRequests("existing", "node-a", "800m", "512Mi")}
+
+	// node-a 剩余 200m cpu，只能调度到 node-b
+	for i := 0; i < 2; i++ {
+		pod := newPodWithRequests("new", "", "500m", "256Mi")
+		result, err := s.schedulePod(&pod, framework.NewSnapshot([]apiObject.Node{small, large}, existing))
+		assert.Nil(t, err)
+		assert.Equal(t, "node-b", result.SuggestedHost)
+		assert.Equal(t, 1, result.FeasibleNodes)
+	}
+
+	// 没有资源请求的Pod可以调度到任意节点
+	pod := apiObject.Pod{Spec: apiObject.PodSpec{Containers: []apiObject.Container{{Name: "empty"}}}}
+	result, err := s.schedulePod(&pod, framework.NewSnapshot([]apiObject.Node{small, large}, existing))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, result.FeasibleNodes)
+
+	// 任何节点都放不下时返回 FitError
+	pod = newPodWithRequests("huge", "", "2", "16Gi")
+	_, err = s.schedulePod(&pod, framework.NewSnapshot([]apiObject.Node{small, large}, existing))
+	fitErr, ok := err.(*framework.FitError)
+	assert.True(t, ok)
+	assert.Equal(t, "0/2 nodes are available: 1 Insufficient cpu, 2 Insufficient memory.", fitErr.Error())
+
+	// 节点上的Pod数量达到上限
+	full := newNode("node-c", true)
+	full.Status.Allocatable = apiObject.ResourceList{"pods": "1"}
+	pod = newPodWithRequests("new", "", "100m", "1Mi")
+	_, err = s.schedulePod(&pod, framework.NewSnapshot([]apiObject.Node{full}, []apiObject.Pod{newPodWithRequests("existing", "node-c", "0", "0")}))
+	assert.Equal(t, "0/1 nodes are available: 1 Too many pods.", err.Error())
+}
+
+func TestSchedulePodNodePorts(t *testing.T) {
+	s := newTestScheduler(t)
+	nodes := []apiObject.Node{newNode("node-a", true), newNode("node-b", true)}
+	existing := []apiObject.Pod{
+		newPodWithHostPort("web", "node-a", apiObject.ContainerPort{ContainerPort: 80, HostPort: 8080}),
+		newPodWithHostPort("dns", "node-b", apiObject.ContainerPort{ContainerPort: 53, HostPort: 53, Protocol: apiObject.ProtocolUDP, HostIP: "10.0.0.2"}),
+	}
+
+	// node-a 的8080端口已经被占用
+	pod := newPodWithHostPort("new", "", apiObject.ContainerPort{ContainerPort: 80, HostPort: 8080})
+	result, err := s.schedulePod(&pod, framework.NewSnapshot(nodes, existing))
+	assert.Nil(t, err)
+	assert.Equal(t, "node-b", result.SuggestedHost)
+	assert.Equal(t, 1, result.FeasibleNodes)
+
+	// 协议不同或绑定不同的主机IP时不冲突
+	for _, port := range []apiObject.ContainerPort{
+		{ContainerPort: 80, HostPort: 8080, Protocol: apiObject.ProtocolUDP},
+		{ContainerPort: 53, HostPort: 53, Protocol: apiObject.ProtocolUDP, HostIP: "10.0.0.3"},
+		{ContainerPort: 53, HostPort: 53},
+	} {
+		pod = newPodWithHostPort("new", "", port)
+		result, err = s.schedulePod(&pod, framework.NewSnapshot(nodes, existing))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, result.FeasibleNodes)
+	}
+
+	// 绑定所有地址的端口与任意主机IP冲突
+	pod = newPodWithHostPort("new", "", apiObject.ContainerPort{ContainerPort: 53, HostPort: 53, Protocol: apiObject.ProtocolUDP})
+	result, err = s.schedulePod(&pod, framework.NewSnapshot(nodes, existing))
+	assert.Nil(t, err)
+	assert.Equal(t, "node-a", result.SuggestedHost)
+	assert.Equal(t, 1, result.FeasibleNodes)
+
+	// 已经结束的Pod不再占用端口
+	existing[0].Status.Phase = apiObject.PodSucceeded
+	existing = append(existing, newPodWithHostPort("other", "node-b", apiObject.ContainerPort{ContainerPort: 80, HostPort: 8080}))
+	pod = newPodWithHostPort("new", "", apiObject.ContainerPort{ContainerPort: 80, HostPort: 8080})
+	result, err = s.schedulePod(&pod, framework.NewSnapshot(nodes, existing))
+	assert.Nil(t, err)
+	assert.Equal(t, "node-a", result.SuggestedHost)
+
+	existing[0].Status.Phase = apiObject.PodRunning
+	_, err = s.schedulePod(&pod, framework.NewSnapshot(nodes, existing))
+	assert.Equal(t, "0/2 nodes are available: 2 node(s) didn't have free ports for the requested pod ports.", err.Error())
+}
+
+func TestSchedulePodNodeSelector(t *testing.T) {
+	s := newTestScheduler(t)
+	snapshot := framework.NewSnapshot([]apiObject.Node{
+		newLabeledNode("node-a", map[string]string{"disktype": "hdd"}),
+		newLabeledNode("node-b", map[string]string{"disktype": "ssd"}),
+		newLabeledNode("node-c", nil),
+	}, nil)
+
+	for i := 0; i < 3; i++ {
+		pod := &apiObject.Pod{Spec: apiObject.PodSpec{NodeSelector: map[string]string{"disktype": "ssd"}}}
+		result, err := s.schedulePod(pod, snapshot)
+		assert.Nil(t, err)
+		assert.Equal(t, "node-b", result.SuggestedHost)
+	}
+
+	pod := &apiObject.Pod{Spec: apiObject.PodSpec{NodeSelector: map[string]string{"disktype": "nvme"}}}
+	_, err := s.schedulePod(pod, snapshot)
+	assert.Equal(t, "0/3 nodes are available: 3 node(s) didn't match Pod's node affinity/selector.", err.Error())
+}
+
+func TestSchedulePodRequiredNodeAffinity(t *testing.T) {
+	s := newTestScheduler(t)
+	snapshot := framework.NewSnapshot([]apiObject.Node{
+		newLabeledNode("node-a", map[string]string{"zone": "east", "gpu": "2"}),
+		newLabeledNode("node-b", map[string]string{"zone": "west", "gpu": "8"}),
+		newLabeledNode("node-c", map[string]string{"zone": "west"}),
+		newLabeledNode("node-d", map[string]string{"zone": "north", "gpu": "4", "maintenance": "true"}),
+	}, nil)
+
+	tests := []struct {
+		terms    []apiObject.NodeSelectorTerm
+		expected []string
+	}{
+		{
+			terms:    []apiObject.NodeSelectorTerm{{MatchExpressions: []apiObject.NodeSelectorRequirement{{Key: "zone", Operator: "In", Values: []string{"west"}}}}},
+			expected: []string{"node-b", "node-c"},
+		},
+		{
+			terms:    []apiObject.NodeSelectorTerm{{MatchExpressions: []apiObject.NodeSelectorRequirement{{Key: "zone", Operator: "NotIn", Values: []string{"west", "east"}}}}},
+			expected: []string{"node-d"},
+		},
+		{
+			terms:    []apiObject.NodeSelectorTerm{{MatchExpressions: []apiObject.NodeSelectorRequirement{{Key: "gpu", Operator: "Exists"}, {Key: "maintenance", Operator: "DoesNotExist"}}}},
+			expected: []string{"node-a", "node-b"},
+		},
+		{
+			terms:    []apiObject.NodeSelectorTerm{{MatchExpressions: []apiObject.NodeSelectorRequirement{{Key: "gpu", Operator: "Gt", Values: []string{"3"}}}}},
+			expected: []string{"node-b", "node-d"},
+		},
+		{
+			// 多个条件之间为或的关系
+			terms: []apiObject.NodeSelectorTerm{
+				{MatchExpressions: []apiObject.NodeSelectorRequirement{{Key: "gpu", Operator: "Lt", Values: []string{"3"}}}},
+				{MatchExpressions: []apiObject.NodeSelectorRequirement{{Key: "zone", Operator: "In", Values: []string{"north"}}}},
+			},
+			expected: []string{"node-a", "node-d"},
+		},
+	}
+	for _, test := range tests {
+		var hosts []string
+		for i := 0; i < len(test.expected); i++ {
+			pod := &apiObject.Pod{Spec: apiObject.PodSpec{Affinity: &apiObject.Affinity{NodeAffinity: &apiObject.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &apiObject.NodeSelector{NodeSelectorTerms: test.terms},
+			}}}}
+			result, err := s.schedulePod(pod, snapshot)
+			assert.Nil(t, err)
+			assert.Equal(t, len(test.expected), result.FeasibleNodes)
+			hosts = append(hosts, result.SuggestedHost)
+		}
+		assert.ElementsMatch(t, test.expected, hosts)
+	}
+}
+
+func TestSchedulePodPreferredNodeAffinity(t *testing.T) {
+	s := newTestScheduler(t)
+	snapshot := framework.NewSnapshot([]apiObject.Node{
+		newLabeledNode("node-a", map[string]string{"zone": "east"}),
+		newLabeledNode("node-b", map[string]string{"zone": "west", "disktype": "ssd"}),
+		newLabeledNode("node-c", map[string]string{"zone": "west"}),
+	}, nil)
+
+	// 偏好优先于轮询，每次都选择满足权重最高的节点
+	for i := 0; i < 3; i++ {
+		pod := &apiObject.Pod{Spec: apiObject.PodSpec{Affinity: &apiObject.Affinity{NodeAffinity: &apiObject.NodeAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []apiObject.PreferredSchedulingTerm{
+				{Weight: 20, Preference: apiObject.NodeSelectorTerm{MatchExpressions: []apiObject.NodeSelectorRequirement{{Key: "zone", Operator: "In", Values: []string{"west"}}}}},
+				{Weight: 80, Preference: apiObject.NodeSelectorTerm{MatchExpressions: []apiObject.NodeSelectorRequirement{{Key: "disktype", Operator: "In", Values: []string{"ssd"}}}}},
+			},
+		}}}}
+		result, err := s.schedulePod(pod, snapshot)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, result.FeasibleNodes)
+		assert.Equal(t, "node-b", result.SuggestedHost)
+	}
+}
+
+func TestSchedulePodTaintsAndTolerations(t *testing.T) {
+	s := newTestScheduler(t)
+	master := newNode("node-a", true)
+	master.Spec.Taints = []apiObject.Taint{{Key: "node-role.kubernetes.io/master", Effect: apiObject.TaintEffectNoSchedule}}
+	gpu := newNode("node-b", true)
+	gpu.Spec.Taints = []apiObject.Taint{{Key: "gpu", Value: "true", Effect: apiObject.TaintEffectNoExecute}}
+	preferNot := newNode("node-c", true)
+	preferNot.Spec.Taints = []apiObject.Taint{{Key: "spot", Effect: apiObject.TaintEffectPreferNoSchedule}}
+	cordoned := newNode("node-d", true)
+	cordoned.Spec.Unschedulable = true
+	snapshot := framework.NewSnapshot([]apiObject.Node{master, gpu, preferNot, cordoned}, nil)
+
+	// 没有容忍的Pod只能调度到带有PreferNoSchedule污点的节点上
+	result, err := s.schedulePod(&apiObject.Pod{}, snapshot)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.FeasibleNodes)
+	assert.Equal(t, "node-c", result.SuggestedHost)
+
+	// 容忍所有污点的系统Pod可以调度到除不可调度节点外的任意节点，并且尽量避开PreferNoSchedule污点
+	system := &apiObject.Pod{Spec: apiObject.PodSpec{Tolerations: []apiObject.Toleration{{Operator: apiObject.TolerationOpExists}}}}
+	for i := 0; i < 3; i++ {
+		result, err = s.schedulePod(system, snapshot)
+		assert.Nil(t, err)
+		assert.NotEqual(t, "node-c", result.SuggestedHost)
+	}
+	assert.Equal(t, 4, result.FeasibleNodes)
+
+	// 容忍的值不匹配时无法调度到gpu节点
+	pod := &apiObject.Pod{Spec: apiObject.PodSpec{
+		NodeSelector: map[string]string{"kubernetes.io/hostname": "node-b"},
+		Tolerations:  []apiObject.Toleration{{Key: "gpu", Value: "false", Effect: apiObject.TaintEffectNoExecute}},
+	}}
+	snapshot.Get("node-b").Node.Metadata.Labels = map[string]string{"kubernetes.io/hostname": "node-b"}
+	_, err = s.schedulePod(pod, snapshot)
+	assert.Equal(t, "0/4 nodes are available: 1 node(s) didn't match Pod's node affinity/selector, "+
+		"1 node(s) had untolerated taint {gpu: true}, 1 node(s) had untolerated taint {node-role.kubernetes.io/master: }, "+
+		"1 node(s) were unschedulable.", err.Error())
+
+	pod.Spec.Tolerations[0].Value = "true"
+	result, err = s.schedulePod(pod, snapshot)
+	assert.Nil(t, err)
+	assert.Equal(t, "node-b", result.SuggestedHost)
+}
+
+func TestSchedulePodScoringStrategies(t *testing.T) {
+	cfg, err := framework.LoadConfiguration("../../../examples/scheduler/scheduler-config.yaml")
+	assert.Nil(t, err)
+	s, err := NewScheduler(cfg, plugins.NewInTreeRegistry())
+	assert.Nil(t, err)
+
+	nodes := []apiObject.Node{
+		newNodeWithAllocatable("node-a", "4", "8Gi"),
+		newNodeWithAllocatable("node-b", "4", "8Gi"),
+		newNodeWithAllocatable("node-c", "4", "8Gi"),
+	}
+	existing := []apiObject.Pod{
+		newPodWithRequests("busy-1", "node-a", "2", "4Gi"),
+		newPodWithRequests("busy-2", "node-b", "1", "1Gi"),
+	}
+	schedule := func(schedulerName string, pod apiObject.Pod, nodes []apiObject.Node) string {
+		pod.Spec.SchedulerName = schedulerName
+		result, err := s.schedulePod(&pod, framework.NewSnapshot(nodes, existing))
+		assert.Nil(t, err)
+		return result.SuggestedHost
+	}
+
+	// MostAllocated 选择已分配资源最多的节点
+	assert.Equal(t, "node-a", schedule("batch-scheduler", newPodWithRequests("batch", "", "500m", "512Mi"), nodes))
+	// LeastAllocated 选择已分配资源最少的节点
+	assert.Equal(t, "node-c", schedule("latency-sensitive-scheduler", newPodWithRequests("web", "", "500m", "512Mi"), nodes))
+
+	// 结合实际使用率时，避开负载很高但请求很少的节点
+	loaded := make([]apiObject.Node, len(nodes))
+	copy(loaded, nodes)
+	loaded[2].Status.CpuUsage = 90
+	loaded[2].Status.MemUsage = 90
+	assert.Equal(t, "node-b", schedule("latency-sensitive-scheduler", newPodWithRequests("web", "", "500m", "512Mi"), loaded))
+
+	// BalancedAllocation 选择放入Pod后cpu与内存分配比例最接近的节点
+	balanced := []apiObject.Node{newNodeWithAllocatable("node-a", "4", "8Gi"), newNodeWithAllocatable("node-b", "4", "8Gi")}
+	existing = []apiObject.Pod{
+		newPodWithRequests("cpu-heavy", "node-a", "3", "1Gi"),
+		newPodWithRequests("even", "node-b", "2", "4Gi"),
+	}
+	cfg = &framework.SchedulerConfiguration{Profiles: []framework.Profile{{
+		SchedulerName: "balanced",
+		Plugins: framework.Plugins{
+			Score: []framework.PluginRef{{Name: plugins.NodeResourcesBalancedAllocationName}},
+			Bind:  []framework.PluginRef{{Name: plugins.DefaultBinderName}},
+		},
+	}}}
+	s, err = NewScheduler(cfg, plugins.NewInTreeRegistry())
+	assert.Nil(t, err)
+	assert.Equal(t, "node-b", schedule("balanced", newPodWithRequests("web", "", "500m", "1Gi"), balanced))
+
+	// 不支持的打分策略
+	_, err = plugins.NewNodeResourcesFit(framework.PluginArgs{"scoringStrategy": map[string]interface{}{"type": "Random"}}, nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+// Simulator 在内存中运行调度框架，预测Pod会被调度到哪些节点上，不会修改集群的状态
+//
+//	调度成功的Pod被视为已经运行在选中的节点上，参与后续Pod的调度
+//	扩展程序只调用filter与prioritize接口，不会调用bind接口；抢占只记录提名的节点，不会驱逐Pod
+//	在Permit阶段等待的Pod先视为运行在预留的节点上，ScheduleAll结束时仍未被允许的Pod视为无法调度
+type Simulator struct {
+	scheduler *Scheduler
+	nodes     []apiObject.Node
+	pods      []apiObject.Pod
+	// 为没有UUID的Pod生成UUID
+	seq int
+}
+
+// SimulationResult 一个Pod的模拟调度结果
+type SimulationResult struct {
+	Pod *apiObject.Pod
+	ScheduleResult
+	// 无法调度的原因，调度成功时为nil
+	Err error
+}
+
+// NewSimulator 根据调度配置以及集群中的节点与Pod创建模拟器，只有已经绑定到节点上的Pod参与模拟
+//
+//	opts中不应该设置会修改集群状态的Binder与PodEvictor
+func NewSimulator(cfg *framework.SchedulerConfiguration, registry framework.Registry, nodes []apiObject.Node, pods []apiObject.Pod,
+	opts ...framework.Option) (*Simulator, error) {
+	simulationConfig := &framework.SchedulerConfiguration{Profiles: cfg.Profiles}
+	for _, extenderConfig := range cfg.Extenders {
+		extenderConfig.BindVerb = ""
+		simulationConfig.Extenders = append(simulationConfig.Extenders, extenderConfig)
+	}
+	s, err := NewScheduler(simulationConfig, registry, opts...)
+	if err != nil {
+		return nil, err
+	}
+	simulator := &Simulator{scheduler: s, nodes: nodes}
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" {
+			simulator.pods = append(simulator.pods, pod)
+		}
+	}
+	return simulator, nil
+}
+
+// Schedule 模拟调度一个Pod，已经指定nodeName的Pod直接放置到该节点上
+func (s *Simulator) Schedule(pod apiObject.Pod) SimulationResult {
+	if pod.Metadata.Namespace == "" {
+		pod.Metadata.Namespace = "default"
+	}
+	if pod.Metadata.UUID == "" {
+		s.seq++
+		pod.Metadata.UUID = fmt.Sprintf("simulated-%d", s.seq)
+	}
+	pod.Status.Phase = apiObject.PodPending
+
+	snapshot := framework.NewSnapshot(s.nodes, s.pods)
+	if pod.Spec.NodeName != "" {
+		if snapshot.Get(pod.Spec.NodeName) == nil {
+			return SimulationResult{Pod: &pod, Err: errors.New("node " + pod.Spec.NodeName + " not found")}
+		}
+		s.pods = append(s.pods, pod)
+		return SimulationResult{Pod: &pod, ScheduleResult: ScheduleResult{SuggestedHost: pod.Spec.NodeName}}
+	}
+
+	result, err := s.scheduler.schedulePod(&pod, snapshot)
+	if err == nil {
+		pod.Spec.NodeName = result.SuggestedHost
+		s.pods = append(s.pods, pod)
+	}
+	return SimulationResult{Pod: &pod, ScheduleResult: result, Err: err}
+}
+
+// ScheduleAll 按顺序模拟调度一组Pod
+func (s *Simulator) ScheduleAll(pods []apiObject.Pod) []SimulationResult {
+	results := make([]SimulationResult, 0, len(pods))
+	for _, pod := range pods {
+		results = append(results, s.Schedule(pod))
+	}
+	s.settleWaitingPods(results)
+	return results
+}
+
+// settleWaitingPods 拒绝仍在Permit阶段等待的Pod并撤销其预留，被允许的Pod视为调度成功
+func (s *Simulator) settleWaitingPods(results []SimulationResult) {
+	for i := range results {
+		result := &results[i]
+		if !result.WaitingOnPermit {
+			continue
+		}
+		result.WaitingOnPermit = false
+		fw, _ := s.scheduler.frameworkForPod(result.Pod)
+		if wp := fw.GetWaitingPod(result.Pod.Metadata.UUID); wp != nil {
+			wp.Reject("", "the simulation ended while the pod was waiting on permit: "+strings.Join(wp.GetPendingPlugins(), ", "))
+		}
+		status := fw.WaitOnPermit(result.Pod)
+		if status.IsSuccess() {
+			continue
+		}
+		fw.RunUnreservePlugins(result.cycleState, result.Pod, result.SuggestedHost)
+		result.Err = errors.New(status.Message())
+		result.SuggestedHost = ""
+		result.Pod.Spec.NodeName = ""
+		for j := range s.pods {
+			if s.pods[j].Metadata.UUID == result.Pod.Metadata.UUID {
+				s.pods = append(s.pods[:j], s.pods[j+1:]...)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+	"minik8s/pkg/scheduler/plugins"
+)
+
+func TestSimulatorPlacesPodsInOrder(t *testing.T) {
+	nodes := []apiObject.Node{newNodeWithAllocatable("node-a", "1", "1Gi"), newNodeWithAllocatable("node-b", "1", "1Gi")}
+	existing := newPodWithRequests("existing", "node-a", "600m", "256Mi")
+	existing.Metadata.Namespace = "default"
+	pending := newPodWithRequests("pending", "", "500m", "256Mi")
+	simulator, err := NewSimulator(framework.DefaultConfiguration(), plugins.NewInTreeRegistry(), nodes, []apiObject.Pod{existing, pending})
+	assert.Nil(t, err)
+
+	// node-a上的剩余资源不足，三个副本中只有两个可以放置到node-b上，之前的模拟结果参与后续Pod的调度
+	var replicas []apiObject.Pod
+	for _, name := range []string{"web-0", "web-1", "web-2"} {
+		pod := newPodWithRequests(name, "", "500m", "256Mi")
+		pod.Metadata.UUID = ""
+		replicas = append(replicas, pod)
+	}
+	results := simulator.ScheduleAll(replicas)
+	assert.Len(t, results, 3)
+	var hosts []string
+	for _, result := range results[:2] {
+		assert.Nil(t, result.Err)
+		hosts = append(hosts, result.SuggestedHost)
+	}
+	assert.Equal(t, []string{"node-b", "node-b"}, hosts)
+	assert.Equal(t, plugins.NodeResourcesFitName, results[0].Diagnosis["node-a"].Plugin())
+
+	// 每个插件的得分都会被记录
+	assert.Len(t, results[0].Scores, 1)
+	var pluginNames []string
+	for _, score := range results[0].Scores[0].Scores {
+		pluginNames = append(pluginNames, score.Name)
+	}
+	assert.Contains(t, pluginNames, plugins.RoundRobinName)
+
+	// 无法放置的Pod记录每个节点被哪个插件拒绝
+	unschedulable := results[2]
+	assert.Equal(t, "0/2 nodes are available: 2 Insufficient cpu.", unschedulable.Err.Error())
+	assert.Equal(t, "", unschedulable.SuggestedHost)
+	assert.Len(t, unschedulable.Diagnosis, 2)
+	for _, status := range unschedulable.Diagnosis {
+		assert.Equal(t, plugins.NodeResourcesFitName, status.Plugin())
+	}
+
+	// 指定了nodeName的Pod直接放置到该节点上
+	pinned := newPodWithRequests("pinned", "node-c", "100m", "64Mi")
+	assert.Equal(t, "node node-c not found", simulator.Schedule(pinned).Err.Error())
+}
@@ -0,0 +1,234 @@
+// 描述：extender包实现了通过HTTP调用外部调度扩展程序的客户端
+//	扩展程序可以过滤节点、为节点打分，以及代替Bind插件完成绑定
+// 参考：https://github.com/kubernetes/design-proposals-archive/blob/main/scheduling/scheduler_extender.md
+
+package extender
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const (
+	// DefaultHTTPTimeout 请求扩展程序的默认超时时间
+	DefaultHTTPTimeout = 5 * time.Second
+	// MaxExtenderPriority 扩展程序给出的最高分，最低分为0
+	MaxExtenderPriority int64 = 10
+)
+
+// ExtenderArgs 发送给扩展程序filter与prioritize接口的参数
+type ExtenderArgs struct {
+	// 待调度的Pod
+	Pod *apiObject.Pod `json:"pod"`
+	// 候选节点
+	Nodes []apiObject.Node `json:"nodes"`
+	// 候选节点的名称
+	NodeNames []string `json:"nodenames"`
+}
+
+// ExtenderFilterResult 扩展程序filter接口的返回结果
+type ExtenderFilterResult struct {
+	// 通过过滤的节点，与NodeNames二选一
+	Nodes []apiObject.Node `json:"nodes"`
+	// 通过过滤的节点的名称
+	NodeNames []string `json:"nodenames"`
+	// 被拒绝的节点及原因，驱逐节点上的Pod后可能可以调度
+	FailedNodes map[string]string `json:"failedNodes"`
+	// 被拒绝的节点及原因，驱逐节点上的Pod也无法调度
+	FailedAndUnresolvableNodes map[string]string `json:"failedAndUnresolvableNodes"`
+	// 扩展程序出错时的错误信息
+	Error string `json:"error"`
+}
+
+// HostPriority 扩展程序为节点给出的分数，范围为 [0, MaxExtenderPriority]
+type HostPriority struct {
+	Host  string `json:"host"`
+	Score int64  `json:"score"`
+}
+
+// ExtenderBindingArgs 发送给扩展程序bind接口的参数
+type ExtenderBindingArgs struct {
+	PodName      string `json:"podName"`
+	PodNamespace string `json:"podNamespace"`
+	PodUID       string `json:"podUID"`
+	Node         string `json:"node"`
+}
+
+// ExtenderBindingResult 扩展程序bind接口的返回结果
+type ExtenderBindingResult struct {
+	Error string `json:"error"`
+}
+
+// HTTPExtender 通过HTTP调用的调度扩展程序
+type HTTPExtender struct {
+	urlPrefix      string
+	filterVerb     string
+	prioritizeVerb string
+	bindVerb       string
+	weight         int64
+	ignorable      bool
+	client         *http.Client
+}
+
+func NewHTTPExtender(cfg framework.Extender) (*HTTPExtender, error) {
+	if cfg.URLPrefix == "" {
+		return nil, errors.New("extender urlPrefix is empty")
+	}
+	timeout := DefaultHTTPTimeout
+	if cfg.HTTPTimeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(cfg.HTTPTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("extender %s has invalid httpTimeout: %v", cfg.URLPrefix, err)
+		}
+	}
+	weight := cfg.Weight
+	if weight == 0 {
+		weight = 1
+	}
+	if weight < 0 {
+		return nil, errors.New("extender " + cfg.URLPrefix + " has negative weight")
+	}
+	return &HTTPExtender{
+		urlPrefix:      strings.TrimSuffix(cfg.URLPrefix, "/"),
+		filterVerb:     cfg.FilterVerb,
+		prioritizeVerb: cfg.PrioritizeVerb,
+		bindVerb:       cfg.BindVerb,
+		weight:         weight,
+		ignorable:      cfg.Ignorable,
+		client:         &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Name 扩展程序的名称，即其地址前缀
+func (e *HTTPExtender) Name() string {
+	return e.urlPrefix
+}
+
+// IsIgnorable 扩展程序出错时是否可以跳过
+func (e *HTTPExtender) IsIgnorable() bool {
+	return e.ignorable
+}
+
+// IsBinder 扩展程序是否代替Bind插件完成绑定
+func (e *HTTPExtender) IsBinder() bool {
+	return e.bindVerb != ""
+}
+
+// Filter 调用扩展程序过滤节点，返回通过过滤的节点，以及被拒绝的节点及其原因
+func (e *HTTPExtender) Filter(pod *apiObject.Pod, nodeInfos []*framework.NodeInfo) ([]*framework.NodeInfo, map[string]*framework.Status, error) {
+	if e.filterVerb == "" {
+		return nodeInfos, nil, nil
+	}
+	var result ExtenderFilterResult
+	if err := e.send(e.filterVerb, newExtenderArgs(pod, nodeInfos), &result); err != nil {
+		return nil, nil, err
+	}
+	if result.Error != "" {
+		return nil, nil, errors.New(result.Error)
+	}
+
+	passed := make(map[string]bool)
+	if result.NodeNames != nil {
+		for _, name := range result.NodeNames {
+			passed[name] = true
+		}
+	} else {
+		for _, node := range result.Nodes {
+			passed[node.Metadata.Name] = true
+		}
+	}
+	var feasible []*framework.NodeInfo
+	failed := make(map[string]*framework.Status)
+	for _, nodeInfo := range nodeInfos {
+		name := nodeInfo.Name()
+		if reason, ok := result.FailedAndUnresolvableNodes[name]; ok {
+			failed[name] = framework.NewStatus(framework.UnschedulableAndUnresolvable, reason).WithPlugin(e.Name())
+		} else if reason, ok = result.FailedNodes[name]; ok {
+			failed[name] = framework.NewStatus(framework.Unschedulable, reason).WithPlugin(e.Name())
+		} else if passed[name] {
+			feasible = append(feasible, nodeInfo)
+		} else {
+			failed[name] = framework.NewStatus(framework.Unschedulable, "node(s) were rejected by extender").WithPlugin(e.Name())
+		}
+	}
+	return feasible, failed, nil
+}
+
+// Prioritize 调用扩展程序为节点打分，返回节点名称到加权分数的映射，分数已经换算到 [0, MaxNodeScore * weight]
+func (e *HTTPExtender) Prioritize(pod *apiObject.Pod, nodeInfos []*framework.NodeInfo) (map[string]int64, error) {
+	scores := make(map[string]int64)
+	if e.prioritizeVerb == "" {
+		return scores, nil
+	}
+	var result []HostPriority
+	if err := e.send(e.prioritizeVerb, newExtenderArgs(pod, nodeInfos), &result); err != nil {
+		return nil, err
+	}
+	for _, priority := range result {
+		score := min(max(priority.Score, 0), MaxExtenderPriority)
+		scores[priority.Host] = score * (framework.MaxNodeScore / MaxExtenderPriority) * e.weight
+	}
+	return scores, nil
+}
+
+// Bind 调用扩展程序将Pod绑定到节点上
+func (e *HTTPExtender) Bind(pod *apiObject.Pod, nodeName string) error {
+	if e.bindVerb == "" {
+		return errors.New("extender " + e.Name() + " is not a binder")
+	}
+	args := ExtenderBindingArgs{
+		PodName:      pod.Metadata.Name,
+		PodNamespace: pod.Metadata.Namespace,
+		PodUID:       pod.Metadata.UUID,
+		Node:         nodeName,
+	}
+	var result ExtenderBindingResult
+	if err := e.send(e.bindVerb, args, &result); err != nil {
+		return err
+	}
+	if result.Error != "" {
+		return errors.New(result.Error)
+	}
+	return nil
+}
+
+// send 向 urlPrefix/verb 发送POST请求，并将响应解析到result中
+func (e *HTTPExtender) send(verb string, args interface{}, result interface{}) error {
+	body, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	url := e.urlPrefix + "/" + verb
+	resp, err := e.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed %v with extender at URL %v, code %v", verb, url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+func newExtenderArgs(pod *apiObject.Pod, nodeInfos []*framework.NodeInfo) ExtenderArgs {
+	args := ExtenderArgs{Pod: pod}
+	for _, nodeInfo := range nodeInfos {
+		args.Nodes = append(args.Nodes, *nodeInfo.Node)
+		args.NodeNames = append(args.NodeNames, nodeInfo.Name())
+	}
+	return args
+}
@@ -0,0 +1,400 @@
+package framework
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"minik8s/pkg/apiObject"
+)
+
+// PluginFactory 根据参数创建插件，fw为插件所在的调度框架
+type PluginFactory func(args PluginArgs, fw *Framework) (Plugin, error)
+
+// Registry 插件名称到插件构造函数的映射
+type Registry map[string]PluginFactory
+
+// skipPluginsKey CycleState中记录PreFilter阶段返回Skip的插件
+const skipPluginsKey = "framework/skipPlugins"
+
+// Framework 按照Profile实例化的一组插件
+type Framework struct {
+	profileName string
+
+	preFilterPlugins  []PreFilterPlugin
+	filterPlugins     []FilterPlugin
+	postFilterPlugins []PostFilterPlugin
+	scorePlugins      []ScorePlugin
+	reservePlugins    []ReservePlugin
+	permitPlugins     []PermitPlugin
+	bindPlugins       []BindPlugin
+
+	// 打分插件的权重
+	scoreWeights map[string]int64
+
+	// 将调度结果写回apiServer的方式，为nil时只记录调度结果
+	binder Binder
+	// 抢占时驱逐Pod的方式，为nil时不驱逐Pod
+	podEvictor PodEvictor
+	// 获取PodGroup的方式，为nil时无法调度属于PodGroup的Pod
+	podGroupLister PodGroupLister
+
+	// 在Permit阶段等待的Pod，以UUID为键
+	waitingPodsLock sync.RWMutex
+	waitingPods     map[string]*WaitingPod
+}
+
+// Binder 将Pod绑定到节点上，Bind插件通过它写回调度结果
+type Binder interface {
+	Bind(pod *apiObject.Pod, nodeName string) error
+}
+
+// PodEvictor 驱逐节点上的Pod，抢占插件通过它删除被抢占的Pod
+type PodEvictor interface {
+	EvictPod(pod *apiObject.Pod, message string) error
+}
+
+// PodGroupLister 获取PodGroup，Coscheduling插件通过它读取组的最小成员数量，PodGroup不存在时返回nil
+type PodGroupLister interface {
+	GetPodGroup(namespace, name string) (*apiObject.PodGroup, error)
+}
+
+// Option 创建调度框架时的可选配置
+type Option func(*Framework)
+
+// WithBinder 设置调度框架写回调度结果的方式
+func WithBinder(binder Binder) Option {
+	return func(f *Framework) {
+		f.binder = binder
+	}
+}
+
+// WithPodEvictor 设置调度框架驱逐Pod的方式
+func WithPodEvictor(evictor PodEvictor) Option {
+	return func(f *Framework) {
+		f.podEvictor = evictor
+	}
+}
+
+// WithPodGroupLister 设置调度框架获取PodGroup的方式
+func WithPodGroupLister(lister PodGroupLister) Option {
+	return func(f *Framework) {
+		f.podGroupLister = lister
+	}
+}
+
+// NewFramework 根据Profile从Registry中实例化插件，同一个插件在多个扩展点上共享同一个实例
+func NewFramework(profile Profile, registry Registry, opts ...Option) (*Framework, error) {
+	fw := &Framework{
+		profileName:  profile.SchedulerName,
+		scoreWeights: make(map[string]int64),
+		waitingPods:  make(map[string]*WaitingPod),
+	}
+	for _, opt := range opts {
+		opt(fw)
+	}
+	args := make(map[string]PluginArgs)
+	for _, pluginConfig := range profile.PluginConfig {
+		args[pluginConfig.Name] = pluginConfig.Args
+	}
+	instances := make(map[string]Plugin)
+	getPlugin := func(name string) (Plugin, error) {
+		if plugin, ok := instances[name]; ok {
+			return plugin, nil
+		}
+		factory, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("plugin %q is not registered", name)
+		}
+		plugin, err := factory(args[name], fw)
+		if err != nil {
+			return nil, fmt.Errorf("initialize plugin %q: %v", name, err)
+		}
+		instances[name] = plugin
+		return plugin, nil
+	}
+
+	for _, ref := range profile.Plugins.PreFilter {
+		plugin, err := getPlugin(ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		p, ok := plugin.(PreFilterPlugin)
+		if !ok {
+			return nil, fmt.Errorf("plugin %q does not extend preFilter", ref.Name)
+		}
+		fw.preFilterPlugins = append(fw.preFilterPlugins, p)
+	}
+	for _, ref := range profile.Plugins.Filter {
+		plugin, err := getPlugin(ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		p, ok := plugin.(FilterPlugin)
+		if !ok {
+			return nil, fmt.Errorf("plugin %q does not extend filter", ref.Name)
+		}
+		fw.filterPlugins = append(fw.filterPlugins, p)
+	}
+	for _, ref := range profile.Plugins.PostFilter {
+		plugin, err := getPlugin(ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		p, ok := plugin.(PostFilterPlugin)
+		if !ok {
+			return nil, fmt.Errorf("plugin %q does not extend postFilter", ref.Name)
+		}
+		fw.postFilterPlugins = append(fw.postFilterPlugins, p)
+	}
+	for _, ref := range profile.Plugins.Score {
+		plugin, err := getPlugin(ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		p, ok := plugin.(ScorePlugin)
+		if !ok {
+			return nil, fmt.Errorf("plugin %q does not extend score", ref.Name)
+		}
+		weight := ref.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("plugin %q has negative weight", ref.Name)
+		}
+		fw.scorePlugins = append(fw.scorePlugins, p)
+		fw.scoreWeights[ref.Name] = weight
+	}
+	for _, ref := range profile.Plugins.Reserve {
+		plugin, err := getPlugin(ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		p, ok := plugin.(ReservePlugin)
+		if !ok {
+			return nil, fmt.Errorf("plugin %q does not extend reserve", ref.Name)
+		}
+		fw.reservePlugins = append(fw.reservePlugins, p)
+	}
+	for _, ref := range profile.Plugins.Permit {
+		plugin, err := getPlugin(ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		p, ok := plugin.(PermitPlugin)
+		if !ok {
+			return nil, fmt.Errorf("plugin %q does not extend permit", ref.Name)
+		}
+		fw.permitPlugins = append(fw.permitPlugins, p)
+	}
+	for _, ref := range profile.Plugins.Bind {
+		plugin, err := getPlugin(ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		p, ok := plugin.(BindPlugin)
+		if !ok {
+			return nil, fmt.Errorf("plugin %q does not extend bind", ref.Name)
+		}
+		fw.bindPlugins = append(fw.bindPlugins, p)
+	}
+	if len(fw.bindPlugins) == 0 {
+		return nil, errors.New("at least one bind plugin is needed for profile " + profile.SchedulerName)
+	}
+	return fw, nil
+}
+
+// ProfileName 返回Framework对应的调度配置名称
+func (f *Framework) ProfileName() string {
+	return f.profileName
+}
+
+// Binder 返回调度框架写回调度结果的方式，可能为nil
+func (f *Framework) Binder() Binder {
+	return f.binder
+}
+
+// PodEvictor 返回调度框架驱逐Pod的方式，可能为nil
+func (f *Framework) PodEvictor() PodEvictor {
+	return f.podEvictor
+}
+
+// PodGroupLister 返回调度框架获取PodGroup的方式，可能为nil
+func (f *Framework) PodGroupLister() PodGroupLister {
+	return f.podGroupLister
+}
+
+// RunPreFilterPlugins 依次执行PreFilter插件，任意一个插件失败则Pod无法调度
+func (f *Framework) RunPreFilterPlugins(state *CycleState, pod *apiObject.Pod, snapshot *Snapshot) *Status {
+	skipped := make(map[string]bool)
+	for _, plugin := range f.preFilterPlugins {
+		status := plugin.PreFilter(state, pod, snapshot)
+		if status.IsSkip() {
+			skipped[plugin.Name()] = true
+			continue
+		}
+		if !status.IsSuccess() {
+			return status.WithPlugin(plugin.Name())
+		}
+	}
+	state.Write(skipPluginsKey, skipped)
+	return nil
+}
+
+// RunFilterPlugins 依次执行Filter插件，返回第一个拒绝该节点的结果
+func (f *Framework) RunFilterPlugins(state *CycleState, pod *apiObject.Pod, nodeInfo *NodeInfo) *Status {
+	for _, plugin := range f.filterPlugins {
+		if f.skipped(state, plugin.Name()) {
+			continue
+		}
+		status := plugin.Filter(state, pod, nodeInfo)
+		if !status.IsSuccess() {
+			return status.WithPlugin(plugin.Name())
+		}
+	}
+	return nil
+}
+
+// RunPostFilterPlugins 依次执行PostFilter插件，直到某个插件为Pod提名了节点
+//
+//	所有插件都没有提名节点时返回Unschedulable，插件出错时返回Error
+func (f *Framework) RunPostFilterPlugins(state *CycleState, pod *apiObject.Pod, snapshot *Snapshot, diagnosis map[string]*Status) (*PostFilterResult, *Status) {
+	var reasons []string
+	for _, plugin := range f.postFilterPlugins {
+		result, status := plugin.PostFilter(state, pod, snapshot, diagnosis)
+		if status.IsSuccess() {
+			return result, nil
+		}
+		if !status.IsUnschedulable() {
+			return nil, status.WithPlugin(plugin.Name())
+		}
+		reasons = append(reasons, status.Reasons()...)
+	}
+	return nil, NewStatus(Unschedulable, reasons...)
+}
+
+// RunScorePlugins 执行所有Score插件，返回每个节点的加权总分
+func (f *Framework) RunScorePlugins(state *CycleState, pod *apiObject.Pod, nodeInfos []*NodeInfo) ([]NodeScore, *Status) {
+	scores := make([]NodeScore, len(nodeInfos))
+	for i, nodeInfo := range nodeInfos {
+		scores[i].Name = nodeInfo.Name()
+	}
+	for _, plugin := range f.scorePlugins {
+		if f.skipped(state, plugin.Name()) {
+			continue
+		}
+		weight := f.scoreWeights[plugin.Name()]
+		for i, nodeInfo := range nodeInfos {
+			score, status := plugin.Score(state, pod, nodeInfo)
+			if !status.IsSuccess() {
+				return nil, status.WithPlugin(plugin.Name())
+			}
+			if score < 0 || score > MaxNodeScore {
+				return nil, NewStatus(Error, fmt.Sprintf("plugin %q returns an invalid score %d for node %q",
+					plugin.Name(), score, nodeInfo.Name())).WithPlugin(plugin.Name())
+			}
+			scores[i].Score += score * weight
+			scores[i].Scores = append(scores[i].Scores, PluginScore{Name: plugin.Name(), Score: score * weight})
+		}
+	}
+	return scores, nil
+}
+
+// RunReservePlugins 依次执行Reserve插件，任意一个失败时撤销已经执行的插件
+func (f *Framework) RunReservePlugins(state *CycleState, pod *apiObject.Pod, nodeName string) *Status {
+	for i, plugin := range f.reservePlugins {
+		status := plugin.Reserve(state, pod, nodeName)
+		if !status.IsSuccess() {
+			for j := i - 1; j >= 0; j-- {
+				f.reservePlugins[j].Unreserve(state, pod, nodeName)
+			}
+			return status.WithPlugin(plugin.Name())
+		}
+	}
+	return nil
+}
+
+// RunUnreservePlugins 按照与Reserve相反的顺序撤销预留
+func (f *Framework) RunUnreservePlugins(state *CycleState, pod *apiObject.Pod, nodeName string) {
+	for i := len(f.reservePlugins) - 1; i >= 0; i-- {
+		f.reservePlugins[i].Unreserve(state, pod, nodeName)
+	}
+}
+
+// RunPermitPlugins 依次执行Permit插件，任意一个插件拒绝时返回其结果
+//
+//	有插件要求等待时Pod被记录为等待中的Pod并返回Wait，调用方之后通过WaitOnPermit等待结果
+func (f *Framework) RunPermitPlugins(state *CycleState, pod *apiObject.Pod, nodeName string) *Status {
+	pluginsMaxWait := make(map[string]time.Duration)
+	for _, plugin := range f.permitPlugins {
+		if f.skipped(state, plugin.Name()) {
+			continue
+		}
+		status, timeout := plugin.Permit(state, pod, nodeName)
+		if status.IsWait() {
+			pluginsMaxWait[plugin.Name()] = min(timeout, maxPermitTimeout)
+			continue
+		}
+		if !status.IsSuccess() {
+			return status.WithPlugin(plugin.Name())
+		}
+	}
+	if len(pluginsMaxWait) == 0 {
+		return nil
+	}
+	f.waitingPodsLock.Lock()
+	f.waitingPods[pod.Metadata.UUID] = newWaitingPod(pod, nodeName, pluginsMaxWait)
+	f.waitingPodsLock.Unlock()
+	return NewStatus(Wait, "one or more plugins asked to wait")
+}
+
+// WaitOnPermit 阻塞直到等待中的Pod被允许或者被拒绝，Pod没有在等待时直接返回成功
+func (f *Framework) WaitOnPermit(pod *apiObject.Pod) *Status {
+	wp := f.GetWaitingPod(pod.Metadata.UUID)
+	if wp == nil {
+		return nil
+	}
+	status := <-wp.signal
+	f.waitingPodsLock.Lock()
+	delete(f.waitingPods, pod.Metadata.UUID)
+	f.waitingPodsLock.Unlock()
+	return status
+}
+
+// GetWaitingPod 获取等待中的Pod，不存在时返回nil
+func (f *Framework) GetWaitingPod(uid string) *WaitingPod {
+	f.waitingPodsLock.RLock()
+	defer f.waitingPodsLock.RUnlock()
+	return f.waitingPods[uid]
+}
+
+// IterateOverWaitingPods 遍历所有等待中的Pod
+func (f *Framework) IterateOverWaitingPods(callback func(*WaitingPod)) {
+	f.waitingPodsLock.RLock()
+	defer f.waitingPodsLock.RUnlock()
+	for _, wp := range f.waitingPods {
+		callback(wp)
+	}
+}
+
+// RunBindPlugins 依次执行Bind插件，直到某个插件完成绑定
+func (f *Framework) RunBindPlugins(state *CycleState, pod *apiObject.Pod, nodeName string) *Status {
+	for _, plugin := range f.bindPlugins {
+		status := plugin.Bind(state, pod, nodeName)
+		if status.IsSkip() {
+			continue
+		}
+		return status.WithPlugin(plugin.Name())
+	}
+	return NewStatus(Error, "no bind plugin bound the pod")
+}
+
+func (f *Framework) skipped(state *CycleState, name string) bool {
+	value, ok := state.Read(skipPluginsKey)
+	if !ok {
+		return false
+	}
+	return value.(map[string]bool)[name]
+}
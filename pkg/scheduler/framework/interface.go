@@ -0,0 +1,166 @@
+// 描述：framework包实现了调度框架，调度一个Pod时依次执行各个扩展点上的插件
+//	PreFilter -> Filter -> (PostFilter) -> Score -> Reserve -> Permit -> Bind
+//	PostFilter只在没有节点通过过滤时执行，Permit要求等待时Pod在后台等待允许后再绑定
+//	新的调度策略只需要实现对应扩展点的插件，并在调度配置文件中启用
+// 参考：https://kubernetes.io/zh-cn/docs/concepts/scheduling-eviction/scheduling-framework/
+
+package framework
+
+import (
+	"strings"
+	"time"
+
+	"minik8s/pkg/apiObject"
+)
+
+// MaxNodeScore 打分插件给出的最高分，最低分为0
+const MaxNodeScore int64 = 100
+
+// Code 插件的执行结果
+type Code int
+
+const (
+	// Success 插件执行成功
+	Success Code = iota
+	// Unschedulable Pod无法调度到该节点
+	Unschedulable
+	// Error 插件执行出错
+	Error
+	// Skip 插件不需要处理该Pod，后续扩展点上的同名插件也会被跳过
+	Skip
+	// UnschedulableAndUnresolvable Pod无法调度到该节点，且驱逐节点上的Pod也无法改变这一结果
+	UnschedulableAndUnresolvable
+	// Wait Permit插件要求Pod等待，直到插件允许或者拒绝该Pod
+	Wait
+)
+
+// Status 插件的执行结果以及原因
+type Status struct {
+	code    Code
+	reasons []string
+	plugin  string
+}
+
+// NewStatus 创建插件的执行结果
+func NewStatus(code Code, reasons ...string) *Status {
+	return &Status{code: code, reasons: reasons}
+}
+
+// Code 返回执行结果，nil表示成功
+func (s *Status) Code() Code {
+	if s == nil {
+		return Success
+	}
+	return s.code
+}
+
+// IsSuccess 判断插件是否执行成功，nil表示成功
+func (s *Status) IsSuccess() bool {
+	return s.Code() == Success
+}
+
+// IsUnschedulable 判断Pod是否因为节点不满足条件而无法调度
+func (s *Status) IsUnschedulable() bool {
+	return s.Code() == Unschedulable || s.Code() == UnschedulableAndUnresolvable
+}
+
+// IsWait 判断Permit插件是否要求Pod等待
+func (s *Status) IsWait() bool {
+	return s.Code() == Wait
+}
+
+// IsSkip 判断插件是否跳过了该Pod
+func (s *Status) IsSkip() bool {
+	return s.Code() == Skip
+}
+
+// Reasons 返回执行结果的原因
+func (s *Status) Reasons() []string {
+	if s == nil {
+		return nil
+	}
+	return s.reasons
+}
+
+// Plugin 返回给出该结果的插件名称
+func (s *Status) Plugin() string {
+	if s == nil {
+		return ""
+	}
+	return s.plugin
+}
+
+// Message 返回执行结果的描述
+func (s *Status) Message() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(s.reasons, ", ")
+}
+
+// WithPlugin 记录给出该结果的插件名称
+func (s *Status) WithPlugin(plugin string) *Status {
+	if s != nil {
+		s.plugin = plugin
+	}
+	return s
+}
+
+// Plugin 所有插件的公共接口
+type Plugin interface {
+	// Name 插件的名称，与调度配置文件中的名称一致
+	Name() string
+}
+
+// PreFilterPlugin 在过滤之前执行，用于预先计算Pod相关的信息或者提前拒绝Pod
+type PreFilterPlugin interface {
+	Plugin
+	PreFilter(state *CycleState, pod *apiObject.Pod, snapshot *Snapshot) *Status
+}
+
+// FilterPlugin 判断Pod能否调度到节点上
+type FilterPlugin interface {
+	Plugin
+	Filter(state *CycleState, pod *apiObject.Pod, nodeInfo *NodeInfo) *Status
+}
+
+// PostFilterResult PostFilter插件的执行结果
+type PostFilterResult struct {
+	// 为Pod提名的节点，Pod在之后的调度中优先尝试该节点
+	NominatedNodeName string
+}
+
+// PostFilterPlugin 没有节点通过过滤时执行，用于让Pod在之后的调度中可以被调度，例如抢占低优先级的Pod
+//
+//	diagnosis记录了每个节点被拒绝的原因，返回Success时表示插件为Pod提名了节点
+type PostFilterPlugin interface {
+	Plugin
+	PostFilter(state *CycleState, pod *apiObject.Pod, snapshot *Snapshot, diagnosis map[string]*Status) (*PostFilterResult, *Status)
+}
+
+// ScorePlugin 为通过过滤的节点打分，分数范围为 [0, MaxNodeScore]
+type ScorePlugin interface {
+	Plugin
+	Score(state *CycleState, pod *apiObject.Pod, nodeInfo *NodeInfo) (int64, *Status)
+}
+
+// ReservePlugin 选出节点后为Pod预留资源，后续步骤失败时调用Unreserve撤销
+type ReservePlugin interface {
+	Plugin
+	Reserve(state *CycleState, pod *apiObject.Pod, nodeName string) *Status
+	Unreserve(state *CycleState, pod *apiObject.Pod, nodeName string)
+}
+
+// PermitPlugin 在绑定之前执行，可以允许、拒绝Pod，或者返回Wait以及等待的超时时间
+//
+//	等待中的Pod通过WaitingPod的Allow或者Reject结束等待，超时后被拒绝
+type PermitPlugin interface {
+	Plugin
+	Permit(state *CycleState, pod *apiObject.Pod, nodeName string) (*Status, time.Duration)
+}
+
+// BindPlugin 将Pod绑定到节点上，返回Skip时交给下一个绑定插件处理
+type BindPlugin interface {
+	Plugin
+	Bind(state *CycleState, pod *apiObject.Pod, nodeName string) *Status
+}
@@ -0,0 +1,139 @@
+package framework
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultSchedulerName 未指定schedulerName的Pod使用的调度配置
+const DefaultSchedulerName = "default-scheduler"
+
+// SchedulerConfiguration 调度器的配置文件，每个Profile对应一套插件组合
+type SchedulerConfiguration struct {
+	Profiles []Profile `json:"profiles" yaml:"profiles"`
+	// 外部的调度扩展程序，对所有Profile生效，在插件之后依次调用
+	Extenders []Extender `json:"extenders" yaml:"extenders"`
+}
+
+// Extender 通过HTTP调用的调度扩展程序，用于实现不适合放在调度器中的调度规则
+//
+//	扩展程序的接口地址为 urlPrefix/verb，请求与响应均为JSON，verb为空时不调用对应的接口
+type Extender struct {
+	// 扩展程序的地址前缀，例如 http://127.0.0.1:8888/scheduler
+	URLPrefix string `json:"urlPrefix" yaml:"urlPrefix"`
+	// 过滤节点的接口
+	FilterVerb string `json:"filterVerb" yaml:"filterVerb"`
+	// 为节点打分的接口
+	PrioritizeVerb string `json:"prioritizeVerb" yaml:"prioritizeVerb"`
+	// 绑定Pod的接口，设置后由扩展程序代替Bind插件完成绑定，最多只能有一个扩展程序设置该接口
+	BindVerb string `json:"bindVerb" yaml:"bindVerb"`
+	// 打分的权重，未指定时为1
+	Weight int64 `json:"weight" yaml:"weight"`
+	// 请求的超时时间，例如 5s，未指定时为5秒
+	HTTPTimeout string `json:"httpTimeout" yaml:"httpTimeout"`
+	// 为true时，扩展程序不可用或者返回错误时跳过该扩展程序，否则Pod本次调度失败
+	Ignorable bool `json:"ignorable" yaml:"ignorable"`
+}
+
+// Profile 一套调度配置，Pod通过spec.schedulerName选择使用哪一套
+type Profile struct {
+	// 调度配置的名称
+	SchedulerName string `json:"schedulerName" yaml:"schedulerName"`
+	// 各个扩展点上启用的插件，按照列表顺序执行
+	Plugins Plugins `json:"plugins" yaml:"plugins"`
+	// 插件的参数
+	PluginConfig []PluginConfig `json:"pluginConfig" yaml:"pluginConfig"`
+}
+
+type Plugins struct {
+	PreFilter  []PluginRef `json:"preFilter" yaml:"preFilter"`
+	Filter     []PluginRef `json:"filter" yaml:"filter"`
+	PostFilter []PluginRef `json:"postFilter" yaml:"postFilter"`
+	Score      []PluginRef `json:"score" yaml:"score"`
+	Reserve    []PluginRef `json:"reserve" yaml:"reserve"`
+	Permit     []PluginRef `json:"permit" yaml:"permit"`
+	Bind       []PluginRef `json:"bind" yaml:"bind"`
+}
+
+type PluginRef struct {
+	// 插件的名称
+	Name string `json:"name" yaml:"name"`
+	// 打分插件的权重，未指定时为1，其他扩展点忽略该字段
+	Weight int64 `json:"weight" yaml:"weight"`
+}
+
+type PluginConfig struct {
+	// 插件的名称
+	Name string `json:"name" yaml:"name"`
+	// 插件的参数，由插件自行解析
+	Args PluginArgs `json:"args" yaml:"args"`
+}
+
+// PluginArgs 插件的参数
+type PluginArgs map[string]interface{}
+
+// Decode 将插件的参数解析到target中
+func (a PluginArgs) Decode(target interface{}) error {
+	if len(a) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// LoadConfiguration 从文件中读取调度器的配置，path为空或者文件不存在时使用默认配置
+func LoadConfiguration(path string) (*SchedulerConfiguration, error) {
+	if path == "" {
+		return DefaultConfiguration(), nil
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultConfiguration(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg := &SchedulerConfiguration{}
+	if err = yaml.Unmarshal(content, cfg); err != nil {
+		return nil, err
+	}
+	if len(cfg.Profiles) == 0 {
+		return nil, errors.New("scheduler configuration has no profile")
+	}
+	for i := range cfg.Profiles {
+		if cfg.Profiles[i].SchedulerName == "" {
+			cfg.Profiles[i].SchedulerName = DefaultSchedulerName
+		}
+	}
+	return cfg, nil
+}
+
+// DefaultConfiguration 默认的调度配置，与原来的轮询调度保持一致
+func DefaultConfiguration() *SchedulerConfiguration {
+	return &SchedulerConfiguration{
+		Profiles: []Profile{
+			{
+				SchedulerName: DefaultSchedulerName,
+				Plugins: Plugins{
+					PreFilter: []PluginRef{{Name: "Coscheduling"}, {Name: "NodeResourcesFit"}, {Name: "InterPodAffinity"},
+						{Name: "PodTopologySpread"}, {Name: "TaintToleration"}},
+					Filter: []PluginRef{{Name: "NodeReady"}, {Name: "NodeUnschedulable"}, {Name: "TaintToleration"},
+						{Name: "NodeAffinity"}, {Name: "NodePorts"}, {Name: "NodeResourcesFit"}, {Name: "InterPodAffinity"},
+						{Name: "PodTopologySpread"}},
+					PostFilter: []PluginRef{{Name: "DefaultPreemption"}},
+					Score: []PluginRef{{Name: "TaintToleration", Weight: 2}, {Name: "NodeAffinity", Weight: 2},
+						{Name: "InterPodAffinity", Weight: 2}, {Name: "PodTopologySpread", Weight: 2}, {Name: "RoundRobin", Weight: 1}},
+					Reserve: []PluginRef{{Name: "RoundRobin"}, {Name: "Coscheduling"}},
+					Permit:  []PluginRef{{Name: "Coscheduling"}},
+					Bind:    []PluginRef{{Name: "DefaultBinder"}},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,135 @@
+package framework
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"minik8s/pkg/apiObject"
+)
+
+// CycleState 一次调度过程中插件之间共享的数据
+type CycleState struct {
+	lock sync.RWMutex
+	data map[string]interface{}
+}
+
+// NewCycleState 创建一次调度过程使用的CycleState
+func NewCycleState() *CycleState {
+	return &CycleState{data: make(map[string]interface{})}
+}
+
+// Read 读取key对应的数据
+func (c *CycleState) Read(key string) (interface{}, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	value, ok := c.data[key]
+	return value, ok
+}
+
+// Write 写入key对应的数据
+func (c *CycleState) Write(key string, value interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.data[key] = value
+}
+
+// NodeInfo 节点以及已经绑定到该节点上的Pod
+type NodeInfo struct {
+	Node *apiObject.Node
+	Pods []*apiObject.Pod
+}
+
+// Name 返回节点的名称
+func (n *NodeInfo) Name() string {
+	return n.Node.Metadata.Name
+}
+
+// Snapshot 调度开始时集群中所有节点与Pod的快照，一次调度过程中保持不变
+type Snapshot struct {
+	// 按节点名称排序的节点列表
+	NodeInfos []*NodeInfo
+	// 节点名称到NodeInfo的映射
+	nodeInfoMap map[string]*NodeInfo
+}
+
+// NewSnapshot 根据节点与Pod列表创建快照，Pod根据Spec.NodeName归属到对应的节点
+func NewSnapshot(nodes []apiObject.Node, pods []apiObject.Pod) *Snapshot {
+	snapshot := &Snapshot{nodeInfoMap: make(map[string]*NodeInfo)}
+	for i := range nodes {
+		nodeInfo := &NodeInfo{Node: &nodes[i]}
+		snapshot.NodeInfos = append(snapshot.NodeInfos, nodeInfo)
+		snapshot.nodeInfoMap[nodes[i].Metadata.Name] = nodeInfo
+	}
+	sort.Slice(snapshot.NodeInfos, func(i, j int) bool {
+		return snapshot.NodeInfos[i].Name() < snapshot.NodeInfos[j].Name()
+	})
+	for i := range pods {
+		if nodeInfo, ok := snapshot.nodeInfoMap[pods[i].Spec.NodeName]; ok {
+			nodeInfo.Pods = append(nodeInfo.Pods, &pods[i])
+		}
+	}
+	return snapshot
+}
+
+// Get 获取指定节点的NodeInfo，不存在时返回nil
+func (s *Snapshot) Get(nodeName string) *NodeInfo {
+	return s.nodeInfoMap[nodeName]
+}
+
+// WithoutPods 返回移除了指定Pod之后的快照，removed为被移除的Pod的UUID集合，原快照保持不变
+func (s *Snapshot) WithoutPods(removed map[string]bool) *Snapshot {
+	var nodes []apiObject.Node
+	var pods []apiObject.Pod
+	for _, nodeInfo := range s.NodeInfos {
+		nodes = append(nodes, *nodeInfo.Node)
+		for _, pod := range nodeInfo.Pods {
+			if !removed[pod.Metadata.UUID] {
+				pods = append(pods, *pod)
+			}
+		}
+	}
+	return NewSnapshot(nodes, pods)
+}
+
+// NodeScore 节点的加权总分
+type NodeScore struct {
+	Name  string
+	Score int64
+	// 每个打分插件给出的加权分数
+	Scores []PluginScore
+}
+
+// PluginScore 打分插件为节点给出的加权分数
+type PluginScore struct {
+	Name  string
+	Score int64
+}
+
+// FitError Pod无法调度到任何节点时返回的错误，记录每个节点被拒绝的原因
+type FitError struct {
+	Pod         *apiObject.Pod
+	NumAllNodes int
+	// 节点名称到拒绝原因的映射
+	Diagnosis map[string]*Status
+}
+
+func (f *FitError) Error() string {
+	reasons := make(map[string]int)
+	for _, status := range f.Diagnosis {
+		for _, reason := range status.Reasons() {
+			reasons[reason]++
+		}
+	}
+	var msgs []string
+	for reason, count := range reasons {
+		msgs = append(msgs, strconv.Itoa(count)+" "+reason)
+	}
+	sort.Strings(msgs)
+	msg := "0/" + strconv.Itoa(f.NumAllNodes) + " nodes are available"
+	if len(msgs) > 0 {
+		msg += ": " + strings.Join(msgs, ", ")
+	}
+	return msg + "."
+}
@@ -0,0 +1,94 @@
+package framework
+
+import (
+	"sync"
+	"time"
+
+	"minik8s/pkg/apiObject"
+)
+
+// maxPermitTimeout Permit插件要求的等待时间的上限
+const maxPermitTimeout = 15 * time.Minute
+
+// WaitingPod 在Permit阶段等待的Pod，所有要求等待的插件都允许后结束等待，任意一个插件拒绝或者超时时立即结束等待
+type WaitingPod struct {
+	pod      *apiObject.Pod
+	nodeName string
+
+	lock sync.Mutex
+	// 尚未允许该Pod的插件及其超时定时器
+	pendingPlugins map[string]*time.Timer
+	// 等待的结果，nil表示允许，只有第一次写入的结果生效
+	signal chan *Status
+}
+
+func newWaitingPod(pod *apiObject.Pod, nodeName string, pluginsMaxWait map[string]time.Duration) *WaitingPod {
+	wp := &WaitingPod{
+		pod:            pod,
+		nodeName:       nodeName,
+		pendingPlugins: make(map[string]*time.Timer),
+		signal:         make(chan *Status, 1),
+	}
+	wp.lock.Lock()
+	defer wp.lock.Unlock()
+	for plugin, wait := range pluginsMaxWait {
+		wp.pendingPlugins[plugin] = time.AfterFunc(wait, func() {
+			wp.Reject(plugin, "rejected due to timeout after waiting "+wait.String()+" at plugin "+plugin)
+		})
+	}
+	return wp
+}
+
+// GetPod 返回等待中的Pod
+func (w *WaitingPod) GetPod() *apiObject.Pod {
+	return w.pod
+}
+
+// NodeName 返回为Pod预留的节点
+func (w *WaitingPod) NodeName() string {
+	return w.nodeName
+}
+
+// GetPendingPlugins 返回尚未允许该Pod的插件
+func (w *WaitingPod) GetPendingPlugins() []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	var plugins []string
+	for plugin := range w.pendingPlugins {
+		plugins = append(plugins, plugin)
+	}
+	return plugins
+}
+
+// Allow 插件允许Pod绑定，所有要求等待的插件都允许后Pod结束等待
+func (w *WaitingPod) Allow(pluginName string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	timer, ok := w.pendingPlugins[pluginName]
+	if !ok {
+		return
+	}
+	timer.Stop()
+	delete(w.pendingPlugins, pluginName)
+	if len(w.pendingPlugins) == 0 {
+		w.send(nil)
+	}
+}
+
+// Reject 插件拒绝Pod，Pod立即结束等待
+func (w *WaitingPod) Reject(pluginName, msg string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for _, timer := range w.pendingPlugins {
+		timer.Stop()
+	}
+	w.send(NewStatus(Unschedulable, msg).WithPlugin(pluginName))
+}
+
+// send 写入等待的结果，已经有结果时忽略
+func (w *WaitingPod) send(status *Status) {
+	select {
+	case w.signal <- status:
+	default:
+	}
+}
@@ -0,0 +1,67 @@
+package plugins
+
+import (
+	"math"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const NodeResourcesBalancedAllocationName = "NodeResourcesBalancedAllocation"
+
+// NodeResourcesBalancedAllocation 将Pod放到节点上之后，各项资源的分配比例越接近，节点得分越高
+//
+//	得分为 (1 - 各项资源分配比例的标准差) * MaxNodeScore，避免节点的cpu已经用完而内存仍有大量剩余
+type NodeResourcesBalancedAllocation struct {
+	scorer *resourceAllocationScorer
+}
+
+// BalancedAllocationArgs NodeResourcesBalancedAllocation插件的参数
+type BalancedAllocationArgs struct {
+	// 参与计算的资源，默认为cpu与memory，权重不影响得分
+	Resources []ResourceSpec `json:"resources" yaml:"resources"`
+	// 是否结合节点上报的实际使用量计算已分配资源
+	UseLiveUsage bool `json:"useLiveUsage" yaml:"useLiveUsage"`
+}
+
+func NewNodeResourcesBalancedAllocation(args framework.PluginArgs, _ *framework.Framework) (framework.Plugin, error) {
+	balancedArgs := &BalancedAllocationArgs{}
+	if err := args.Decode(balancedArgs); err != nil {
+		return nil, err
+	}
+	scorer, err := newResourceAllocationScorer(balancedArgs.Resources, balancedArgs.UseLiveUsage)
+	if err != nil {
+		return nil, err
+	}
+	return &NodeResourcesBalancedAllocation{scorer: scorer}, nil
+}
+
+func (p *NodeResourcesBalancedAllocation) Name() string {
+	return NodeResourcesBalancedAllocationName
+}
+
+func (p *NodeResourcesBalancedAllocation) Score(_ *framework.CycleState, pod *apiObject.Pod, nodeInfo *framework.NodeInfo) (int64, *framework.Status) {
+	requested, allocatable := p.scorer.allocation(pod, nodeInfo)
+	var fractions []float64
+	for _, resource := range p.scorer.resources {
+		value, ok := allocatable[resource.Name]
+		if !ok {
+			continue
+		}
+		fractions = append(fractions, min(float64(requested[resource.Name])/float64(value), 1))
+	}
+	if len(fractions) < 2 {
+		return framework.MaxNodeScore, nil
+	}
+
+	var mean, variance float64
+	for _, fraction := range fractions {
+		mean += fraction
+	}
+	mean /= float64(len(fractions))
+	for _, fraction := range fractions {
+		variance += (fraction - mean) * (fraction - mean)
+	}
+	std := math.Sqrt(variance / float64(len(fractions)))
+	return int64((1 - std) * float64(framework.MaxNodeScore)), nil
+}
@@ -0,0 +1,141 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const CoschedulingName = "Coscheduling"
+
+// coschedulingStateKey CycleState中记录PreFilter阶段读取的PodGroup以及组内已经调度的成员数量
+const coschedulingStateKey = "PreFilter" + CoschedulingName
+
+// DefaultPermitWaitingTimeSeconds 成员在Permit阶段等待其他成员的默认时间
+const DefaultPermitWaitingTimeSeconds = 60
+
+// Coscheduling 同一PodGroup中的Pod要么同时被调度，要么都不被调度
+//
+//	组内的Pod预留节点后在Permit阶段等待，已经绑定以及正在等待的成员达到minMember时，整组一起被允许绑定
+//	等待超时或者任意一个成员撤销预留时，组内其他正在等待的成员也被拒绝并撤销预留，
+//	避免只有部分成员的组长期占用节点，使其他组也无法凑齐成员
+type Coscheduling struct {
+	fw *framework.Framework
+	// 成员在Permit阶段等待的默认时间，PodGroup可以通过scheduleTimeoutSeconds覆盖
+	permitWaitingTime time.Duration
+}
+
+// CoschedulingArgs Coscheduling插件的参数
+type CoschedulingArgs struct {
+	// 成员在Permit阶段等待的默认时间（秒），未指定时为60秒
+	PermitWaitingTimeSeconds int64 `json:"permitWaitingTimeSeconds" yaml:"permitWaitingTimeSeconds"`
+}
+
+type coschedulingState struct {
+	podGroup *apiObject.PodGroup
+	// 已经绑定或者正在等待的其他成员数量
+	scheduled int32
+}
+
+func NewCoscheduling(args framework.PluginArgs, fw *framework.Framework) (framework.Plugin, error) {
+	coschedulingArgs := &CoschedulingArgs{}
+	if err := args.Decode(coschedulingArgs); err != nil {
+		return nil, err
+	}
+	if coschedulingArgs.PermitWaitingTimeSeconds < 0 {
+		return nil, errors.New("permitWaitingTimeSeconds must not be negative")
+	}
+	if coschedulingArgs.PermitWaitingTimeSeconds == 0 {
+		coschedulingArgs.PermitWaitingTimeSeconds = DefaultPermitWaitingTimeSeconds
+	}
+	return &Coscheduling{
+		fw:                fw,
+		permitWaitingTime: time.Duration(coschedulingArgs.PermitWaitingTimeSeconds) * time.Second,
+	}, nil
+}
+
+func (p *Coscheduling) Name() string {
+	return CoschedulingName
+}
+
+func (p *Coscheduling) PreFilter(state *framework.CycleState, pod *apiObject.Pod, snapshot *framework.Snapshot) *framework.Status {
+	name := pod.GetPodGroupName()
+	if name == "" {
+		return framework.NewStatus(framework.Skip)
+	}
+	lister := p.fw.PodGroupLister()
+	if lister == nil {
+		return framework.NewStatus(framework.Error, "no pod group lister is configured")
+	}
+	podGroup, err := lister.GetPodGroup(pod.Metadata.Namespace, name)
+	if err != nil {
+		return framework.NewStatus(framework.Error, err.Error())
+	}
+	if podGroup == nil {
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable,
+			fmt.Sprintf("pod group %s/%s not found", pod.Metadata.Namespace, name))
+	}
+
+	s := &coschedulingState{podGroup: podGroup}
+	for _, nodeInfo := range snapshot.NodeInfos {
+		for _, other := range nodeInfo.Pods {
+			if other.Metadata.UUID != pod.Metadata.UUID && inSamePodGroup(other, pod) {
+				s.scheduled++
+			}
+		}
+	}
+	state.Write(coschedulingStateKey, s)
+	return nil
+}
+
+// Permit 加上该Pod后组内的成员达到minMember时允许所有正在等待的成员，否则等待其他成员
+func (p *Coscheduling) Permit(state *framework.CycleState, pod *apiObject.Pod, _ string) (*framework.Status, time.Duration) {
+	value, ok := state.Read(coschedulingStateKey)
+	if !ok {
+		return framework.NewStatus(framework.Error, "coscheduling state not found"), 0
+	}
+	s := value.(*coschedulingState)
+	if s.scheduled+1 >= s.podGroup.Spec.MinMember {
+		p.fw.IterateOverWaitingPods(func(wp *framework.WaitingPod) {
+			if inSamePodGroup(wp.GetPod(), pod) {
+				wp.Allow(p.Name())
+			}
+		})
+		return nil, 0
+	}
+
+	timeout := p.permitWaitingTime
+	if seconds := s.podGroup.Spec.ScheduleTimeoutSeconds; seconds != nil {
+		timeout = time.Duration(*seconds) * time.Second
+	}
+	return framework.NewStatus(framework.Wait, fmt.Sprintf("pod group %s/%s has %d/%d members scheduled",
+		pod.Metadata.Namespace, s.podGroup.Metadata.Name, s.scheduled+1, s.podGroup.Spec.MinMember)), timeout
+}
+
+func (p *Coscheduling) Reserve(_ *framework.CycleState, _ *apiObject.Pod, _ string) *framework.Status {
+	return nil
+}
+
+// Unreserve 组内一个成员放弃预留的节点时，拒绝其他正在等待的成员，使整组一起重新调度
+func (p *Coscheduling) Unreserve(_ *framework.CycleState, pod *apiObject.Pod, _ string) {
+	if pod.GetPodGroupName() == "" {
+		return
+	}
+	message := fmt.Sprintf("rejected because pod %s/%s of pod group %s was unreserved",
+		pod.Metadata.Namespace, pod.Metadata.Name, pod.GetPodGroupName())
+	p.fw.IterateOverWaitingPods(func(wp *framework.WaitingPod) {
+		other := wp.GetPod()
+		if other.Metadata.UUID != pod.Metadata.UUID && inSamePodGroup(other, pod) {
+			wp.Reject(p.Name(), message)
+		}
+	})
+}
+
+// inSamePodGroup 判断两个Pod是否属于同一个PodGroup
+func inSamePodGroup(a, b *apiObject.Pod) bool {
+	name := a.GetPodGroupName()
+	return name != "" && a.Metadata.Namespace == b.Metadata.Namespace && name == b.GetPodGroupName()
+}
@@ -0,0 +1,34 @@
+package plugins
+
+import (
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const DefaultBinderName = "DefaultBinder"
+
+// DefaultBinder 默认的绑定插件
+//
+//	通过调度框架的Binder创建Pod的binding子资源，kubelet拉取到绑定到本节点的Pod后创建该Pod
+//	调度框架没有设置Binder时只记录调度结果
+type DefaultBinder struct {
+	fw *framework.Framework
+}
+
+func NewDefaultBinder(_ framework.PluginArgs, fw *framework.Framework) (framework.Plugin, error) {
+	return &DefaultBinder{fw: fw}, nil
+}
+
+func (p *DefaultBinder) Name() string {
+	return DefaultBinderName
+}
+
+func (p *DefaultBinder) Bind(_ *framework.CycleState, pod *apiObject.Pod, nodeName string) *framework.Status {
+	if binder := p.fw.Binder(); binder != nil {
+		if err := binder.Bind(pod, nodeName); err != nil {
+			return framework.NewStatus(framework.Error, err.Error())
+		}
+	}
+	pod.Spec.NodeName = nodeName
+	return nil
+}
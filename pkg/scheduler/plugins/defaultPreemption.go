@@ -0,0 +1,152 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const DefaultPreemptionName = "DefaultPreemption"
+
+// DefaultPreemption 没有节点可以放置Pod时，驱逐节点上优先级更低的Pod为其腾出空间
+//
+//	只有被Filter插件以Unschedulable拒绝的节点才是候选节点，UnschedulableAndUnresolvable表示驱逐Pod也无法改变结果
+//	在每个候选节点上先移除所有优先级更低的Pod，确认Pod可以放置后，再按照优先级从高到低尽量保留这些Pod，
+//	剩下的Pod即为需要驱逐的Pod
+//	选择候选节点时依次比较：被驱逐的Pod中的最高优先级、被驱逐的Pod的优先级之和、被驱逐的Pod的数量，均为越小越好
+//	抢占策略为Never的Pod不会抢占其他Pod
+type DefaultPreemption struct {
+	fw *framework.Framework
+}
+
+// preemptionCandidate 候选节点以及需要驱逐的Pod，victims按照优先级从高到低排列
+type preemptionCandidate struct {
+	nodeName string
+	victims  []*apiObject.Pod
+}
+
+func NewDefaultPreemption(_ framework.PluginArgs, fw *framework.Framework) (framework.Plugin, error) {
+	return &DefaultPreemption{fw: fw}, nil
+}
+
+func (p *DefaultPreemption) Name() string {
+	return DefaultPreemptionName
+}
+
+func (p *DefaultPreemption) PostFilter(_ *framework.CycleState, pod *apiObject.Pod, snapshot *framework.Snapshot, diagnosis map[string]*framework.Status) (*framework.PostFilterResult, *framework.Status) {
+	if pod.Spec.PreemptionPolicy == apiObject.PreemptNever {
+		return nil, framework.NewStatus(framework.Unschedulable, "not eligible due to preemptionPolicy=Never")
+	}
+
+	var candidates []preemptionCandidate
+	for _, nodeInfo := range snapshot.NodeInfos {
+		if diagnosis[nodeInfo.Name()].Code() != framework.Unschedulable {
+			continue
+		}
+		victims, status := p.selectVictimsOnNode(pod, snapshot, nodeInfo)
+		if status.Code() == framework.Error {
+			return nil, status
+		}
+		if status.IsSuccess() {
+			candidates = append(candidates, preemptionCandidate{nodeName: nodeInfo.Name(), victims: victims})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, framework.NewStatus(framework.Unschedulable, "preemption: no preemption victims found for incoming pod")
+	}
+
+	best := candidates[0]
+	for _, candidate := range candidates[1:] {
+		if betterCandidate(candidate, best) {
+			best = candidate
+		}
+	}
+
+	if evictor := p.fw.PodEvictor(); evictor != nil {
+		message := fmt.Sprintf("preempted by pod %s/%s on node %s", pod.Metadata.Namespace, pod.Metadata.Name, best.nodeName)
+		for _, victim := range best.victims {
+			if err := evictor.EvictPod(victim, message); err != nil {
+				return nil, framework.NewStatus(framework.Error, err.Error())
+			}
+		}
+	}
+	return &framework.PostFilterResult{NominatedNodeName: best.nodeName}, nil
+}
+
+// selectVictimsOnNode 找出节点上为了放置Pod需要驱逐的最少的低优先级Pod，无法通过驱逐放置Pod时返回Unschedulable
+func (p *DefaultPreemption) selectVictimsOnNode(pod *apiObject.Pod, snapshot *framework.Snapshot, nodeInfo *framework.NodeInfo) ([]*apiObject.Pod, *framework.Status) {
+	priority := pod.GetPriority()
+	var potentialVictims []*apiObject.Pod
+	removed := make(map[string]bool)
+	for _, existing := range nodeInfo.Pods {
+		if occupiesNode(pod, existing) && existing.GetPriority() < priority {
+			potentialVictims = append(potentialVictims, existing)
+			removed[existing.Metadata.UUID] = true
+		}
+	}
+	if len(potentialVictims) == 0 {
+		return nil, framework.NewStatus(framework.Unschedulable)
+	}
+	if status := p.fits(pod, snapshot.WithoutPods(removed), nodeInfo.Name()); !status.IsSuccess() {
+		return nil, status
+	}
+
+	// 优先保留优先级高的Pod
+	sort.SliceStable(potentialVictims, func(i, j int) bool {
+		return potentialVictims[i].GetPriority() > potentialVictims[j].GetPriority()
+	})
+	var victims []*apiObject.Pod
+	for _, victim := range potentialVictims {
+		delete(removed, victim.Metadata.UUID)
+		status := p.fits(pod, snapshot.WithoutPods(removed), nodeInfo.Name())
+		if status.Code() == framework.Error {
+			return nil, status
+		}
+		if !status.IsSuccess() {
+			removed[victim.Metadata.UUID] = true
+			victims = append(victims, victim)
+		}
+	}
+	return victims, nil
+}
+
+// fits 在快照上重新执行PreFilter与Filter，判断Pod能否放置到指定节点上
+func (p *DefaultPreemption) fits(pod *apiObject.Pod, snapshot *framework.Snapshot, nodeName string) *framework.Status {
+	state := framework.NewCycleState()
+	if status := p.fw.RunPreFilterPlugins(state, pod, snapshot); !status.IsSuccess() {
+		return status
+	}
+	return p.fw.RunFilterPlugins(state, pod, snapshot.Get(nodeName))
+}
+
+// betterCandidate 判断候选节点a是否优于b
+func betterCandidate(a, b preemptionCandidate) bool {
+	// 集群发生变化后可能不需要驱逐任何Pod
+	if len(a.victims) == 0 || len(b.victims) == 0 {
+		if len(a.victims) != len(b.victims) {
+			return len(a.victims) == 0
+		}
+		return a.nodeName < b.nodeName
+	}
+	// victims按照优先级从高到低排列，第一个即为最高优先级
+	if highestA, highestB := a.victims[0].GetPriority(), b.victims[0].GetPriority(); highestA != highestB {
+		return highestA < highestB
+	}
+	if sumA, sumB := prioritySum(a.victims), prioritySum(b.victims); sumA != sumB {
+		return sumA < sumB
+	}
+	if len(a.victims) != len(b.victims) {
+		return len(a.victims) < len(b.victims)
+	}
+	return a.nodeName < b.nodeName
+}
+
+func prioritySum(pods []*apiObject.Pod) int64 {
+	var sum int64
+	for _, pod := range pods {
+		sum += int64(pod.GetPriority())
+	}
+	return sum
+}
@@ -0,0 +1,237 @@
+package plugins
+
+import (
+	"slices"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const InterPodAffinityName = "InterPodAffinity"
+
+// interPodAffinityStateKey CycleState中记录PreFilter阶段根据快照统计的拓扑域信息
+const interPodAffinityStateKey = "PreFilter" + InterPodAffinityName
+
+const (
+	errReasonAffinityRulesNotMatch             = "node(s) didn't match pod affinity rules"
+	errReasonAntiAffinityRulesNotMatch         = "node(s) didn't match pod anti-affinity rules"
+	errReasonExistingAntiAffinityRulesNotMatch = "node(s) didn't satisfy existing pods anti-affinity rules"
+)
+
+// InterPodAffinity 根据节点上已有的Pod评估Pod之间的亲和性与反亲和性
+//
+//	拓扑域由节点标签 topologyKey 的取值确定，同一拓扑域内所有节点上的Pod都会被统计
+//	Filter 要求节点所在拓扑域中存在满足必需亲和性条件的Pod，且不存在满足必需反亲和性条件的Pod，
+//	同时Pod不能违反已有Pod的必需反亲和性条件
+//	Score 节点所在拓扑域中满足偏好亲和性条件的Pod越多得分越高，满足偏好反亲和性条件的Pod越多得分越低
+type InterPodAffinity struct{}
+
+// topologyPair 拓扑域，由节点标签的键值对表示
+type topologyPair struct {
+	key   string
+	value string
+}
+
+type interPodAffinityState struct {
+	// 每个必需亲和性条件对应的拓扑域中匹配的Pod数量
+	affinityCounts []map[topologyPair]int64
+	// 每个必需反亲和性条件对应的拓扑域中匹配的Pod数量
+	antiAffinityCounts []map[topologyPair]int64
+	// 已有Pod的必需反亲和性条件匹配该Pod时，这些Pod所在的拓扑域
+	existingAntiAffinityCounts map[topologyPair]int64
+	// 集群中没有任何Pod满足必需亲和性条件，且Pod自身满足这些条件时，允许调度到任意节点
+	//	否则一组相互亲和的Pod中的第一个将永远无法被调度
+	selfAffinity bool
+	// 每个节点偏好条件的原始得分，以及所有节点中的最高分与最低分，用于归一化
+	rawScores map[string]int64
+	minScore  int64
+	maxScore  int64
+}
+
+func NewInterPodAffinity(_ framework.PluginArgs, _ *framework.Framework) (framework.Plugin, error) {
+	return &InterPodAffinity{}, nil
+}
+
+func (p *InterPodAffinity) Name() string {
+	return InterPodAffinityName
+}
+
+func (p *InterPodAffinity) PreFilter(state *framework.CycleState, pod *apiObject.Pod, snapshot *framework.Snapshot) *framework.Status {
+	var affinityTerms, antiAffinityTerms []apiObject.PodAffinityTerm
+	var preferredAffinityTerms, preferredAntiAffinityTerms []apiObject.WeightedPodAffinityTerm
+	if affinity := pod.Spec.Affinity; affinity != nil {
+		if affinity.PodAffinity != nil {
+			affinityTerms = affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+			preferredAffinityTerms = affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+		}
+		if affinity.PodAntiAffinity != nil {
+			antiAffinityTerms = affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+			preferredAntiAffinityTerms = affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+		}
+	}
+
+	s := &interPodAffinityState{
+		affinityCounts:             newTopologyCounts(len(affinityTerms)),
+		antiAffinityCounts:         newTopologyCounts(len(antiAffinityTerms)),
+		existingAntiAffinityCounts: make(map[topologyPair]int64),
+		rawScores:                  make(map[string]int64),
+	}
+	preferredScores := make(map[topologyPair]int64)
+
+	for _, nodeInfo := range snapshot.NodeInfos {
+		labels := nodeInfo.Node.Metadata.Labels
+		for _, existing := range nodeInfo.Pods {
+			if !occupiesNode(pod, existing) {
+				continue
+			}
+			// 1. 统计该Pod的亲和性条件在各个拓扑域中匹配的Pod
+			for i := range affinityTerms {
+				countTerm(s.affinityCounts[i], &affinityTerms[i], pod, existing, labels, 1)
+			}
+			for i := range antiAffinityTerms {
+				countTerm(s.antiAffinityCounts[i], &antiAffinityTerms[i], pod, existing, labels, 1)
+			}
+			for i := range preferredAffinityTerms {
+				term := &preferredAffinityTerms[i]
+				countTerm(preferredScores, &term.PodAffinityTerm, pod, existing, labels, int64(term.Weight))
+			}
+			for i := range preferredAntiAffinityTerms {
+				term := &preferredAntiAffinityTerms[i]
+				countTerm(preferredScores, &term.PodAffinityTerm, pod, existing, labels, -int64(term.Weight))
+			}
+			// 2. 已有Pod的必需反亲和性条件是对称的，待调度的Pod不能进入这些Pod所在的拓扑域
+			if existing.Spec.Affinity != nil && existing.Spec.Affinity.PodAntiAffinity != nil {
+				terms := existing.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+				for i := range terms {
+					countTerm(s.existingAntiAffinityCounts, &terms[i], existing, pod, labels, 1)
+				}
+			}
+		}
+	}
+
+	if len(affinityTerms) == 0 && len(antiAffinityTerms) == 0 && len(preferredAffinityTerms) == 0 &&
+		len(preferredAntiAffinityTerms) == 0 && len(s.existingAntiAffinityCounts) == 0 {
+		return framework.NewStatus(framework.Skip)
+	}
+
+	s.selfAffinity = len(affinityTerms) > 0
+	for i := range affinityTerms {
+		if len(s.affinityCounts[i]) > 0 || !termMatches(&affinityTerms[i], pod, pod) {
+			s.selfAffinity = false
+			break
+		}
+	}
+
+	// 3. 计算每个节点偏好条件的原始得分
+	for i, nodeInfo := range snapshot.NodeInfos {
+		var score int64
+		for pair, value := range preferredScores {
+			if nodeInfo.Node.Metadata.Labels[pair.key] == pair.value {
+				score += value
+			}
+		}
+		s.rawScores[nodeInfo.Name()] = score
+		if i == 0 || score < s.minScore {
+			s.minScore = score
+		}
+		if i == 0 || score > s.maxScore {
+			s.maxScore = score
+		}
+	}
+
+	state.Write(interPodAffinityStateKey, s)
+	return nil
+}
+
+func (p *InterPodAffinity) Filter(state *framework.CycleState, pod *apiObject.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	s, status := getInterPodAffinityState(state)
+	if !status.IsSuccess() {
+		return status
+	}
+	labels := nodeInfo.Node.Metadata.Labels
+
+	affinity := pod.Spec.Affinity
+	if affinity != nil && affinity.PodAntiAffinity != nil {
+		for i, term := range affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			value, ok := labels[term.TopologyKey]
+			if ok && s.antiAffinityCounts[i][topologyPair{key: term.TopologyKey, value: value}] > 0 {
+				return framework.NewStatus(framework.Unschedulable, errReasonAntiAffinityRulesNotMatch)
+			}
+		}
+	}
+
+	for pair := range s.existingAntiAffinityCounts {
+		if value, ok := labels[pair.key]; ok && value == pair.value {
+			return framework.NewStatus(framework.Unschedulable, errReasonExistingAntiAffinityRulesNotMatch)
+		}
+	}
+
+	if affinity != nil && affinity.PodAffinity != nil {
+		for i, term := range affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			value, ok := labels[term.TopologyKey]
+			if !ok {
+				return framework.NewStatus(framework.UnschedulableAndUnresolvable, errReasonAffinityRulesNotMatch)
+			}
+			if s.selfAffinity {
+				continue
+			}
+			if s.affinityCounts[i][topologyPair{key: term.TopologyKey, value: value}] == 0 {
+				return framework.NewStatus(framework.UnschedulableAndUnresolvable, errReasonAffinityRulesNotMatch)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *InterPodAffinity) Score(state *framework.CycleState, _ *apiObject.Pod, nodeInfo *framework.NodeInfo) (int64, *framework.Status) {
+	s, status := getInterPodAffinityState(state)
+	if !status.IsSuccess() {
+		return 0, status
+	}
+	if s.maxScore == s.minScore {
+		return 0, nil
+	}
+	return (s.rawScores[nodeInfo.Name()] - s.minScore) * framework.MaxNodeScore / (s.maxScore - s.minScore), nil
+}
+
+func getInterPodAffinityState(state *framework.CycleState) (*interPodAffinityState, *framework.Status) {
+	value, ok := state.Read(interPodAffinityStateKey)
+	if !ok {
+		return nil, framework.NewStatus(framework.Error, "inter pod affinity state not found in cycle state")
+	}
+	return value.(*interPodAffinityState), nil
+}
+
+func newTopologyCounts(n int) []map[topologyPair]int64 {
+	counts := make([]map[topologyPair]int64, n)
+	for i := range counts {
+		counts[i] = make(map[topologyPair]int64)
+	}
+	return counts
+}
+
+// countTerm owner的条件term匹配target时，将value累加到target所在节点的拓扑域上
+func countTerm(counts map[topologyPair]int64, term *apiObject.PodAffinityTerm, owner *apiObject.Pod, target *apiObject.Pod, nodeLabels map[string]string, value int64) {
+	topologyValue, ok := nodeLabels[term.TopologyKey]
+	if !ok || !termMatches(term, owner, target) {
+		return
+	}
+	counts[topologyPair{key: term.TopologyKey, value: topologyValue}] += value
+}
+
+// termMatches 判断target是否满足owner的Pod亲和性条件term
+func termMatches(term *apiObject.PodAffinityTerm, owner *apiObject.Pod, target *apiObject.Pod) bool {
+	namespaces := term.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{owner.Metadata.Namespace}
+	}
+	return slices.Contains(namespaces, target.Metadata.Namespace) && term.LabelSelector.Matches(target.Metadata.Labels)
+}
+
+// occupiesNode 判断节点上已有的Pod是否需要参与统计，已经终止的Pod以及待调度的Pod本身不参与统计
+func occupiesNode(pod *apiObject.Pod, existing *apiObject.Pod) bool {
+	if existing.Status.Phase == apiObject.PodFailed || existing.Status.Phase == apiObject.PodSucceeded {
+		return false
+	}
+	return pod.Metadata.UUID == "" || existing.Metadata.UUID != pod.Metadata.UUID
+}
@@ -0,0 +1,65 @@
+package plugins
+
+import (
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const NodeAffinityName = "NodeAffinity"
+
+// NodeAffinity 根据Pod的nodeSelector与节点亲和性选择节点
+//
+//	Filter 过滤掉标签不满足nodeSelector或requiredDuringSchedulingIgnoredDuringExecution的节点
+//	Score 节点满足的preferredDuringSchedulingIgnoredDuringExecution权重之和占总权重的比例越高，得分越高
+type NodeAffinity struct{}
+
+func NewNodeAffinity(_ framework.PluginArgs, _ *framework.Framework) (framework.Plugin, error) {
+	return &NodeAffinity{}, nil
+}
+
+func (p *NodeAffinity) Name() string {
+	return NodeAffinityName
+}
+
+func (p *NodeAffinity) Filter(_ *framework.CycleState, pod *apiObject.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	if !matchesNodeSelectorAndAffinity(pod, nodeInfo.Node.Metadata.Labels) {
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable, "node(s) didn't match Pod's node affinity/selector")
+	}
+	return nil
+}
+
+func (p *NodeAffinity) Score(_ *framework.CycleState, pod *apiObject.Pod, nodeInfo *framework.NodeInfo) (int64, *framework.Status) {
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil {
+		return 0, nil
+	}
+	var matched, total int64
+	for i := range affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		term := &affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution[i]
+		if term.Weight <= 0 {
+			continue
+		}
+		total += int64(term.Weight)
+		if term.Preference.Matches(nodeInfo.Node.Metadata.Labels) {
+			matched += int64(term.Weight)
+		}
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	return matched * framework.MaxNodeScore / total, nil
+}
+
+// matchesNodeSelectorAndAffinity 判断节点标签是否满足Pod的nodeSelector与requiredDuringSchedulingIgnoredDuringExecution
+func matchesNodeSelectorAndAffinity(pod *apiObject.Pod, labels map[string]string) bool {
+	for key, value := range pod.Spec.NodeSelector {
+		if nodeValue, ok := labels[key]; !ok || nodeValue != value {
+			return false
+		}
+	}
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true
+	}
+	return affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.Matches(labels)
+}
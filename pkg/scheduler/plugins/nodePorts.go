@@ -0,0 +1,73 @@
+package plugins
+
+import (
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const NodePortsName = "NodePorts"
+
+// NodePorts 过滤掉主机端口已经被其他Pod占用的节点
+//
+//	协议与端口相同，且主机IP相同或其中一方绑定所有地址时视为冲突
+type NodePorts struct{}
+
+func NewNodePorts(_ framework.PluginArgs, _ *framework.Framework) (framework.Plugin, error) {
+	return &NodePorts{}, nil
+}
+
+func (p *NodePorts) Name() string {
+	return NodePortsName
+}
+
+func (p *NodePorts) Filter(_ *framework.CycleState, pod *apiObject.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	wanted := getHostPorts(pod)
+	if len(wanted) == 0 {
+		return nil
+	}
+	for _, existing := range nodeInfo.Pods {
+		if !occupiesNode(pod, existing) {
+			continue
+		}
+		for _, used := range getHostPorts(existing) {
+			for _, port := range wanted {
+				if hostPortsConflict(port, used) {
+					return framework.NewStatus(framework.Unschedulable, "node(s) didn't have free ports for the requested pod ports")
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// getHostPorts 返回Pod中所有指定了hostPort的容器端口
+func getHostPorts(pod *apiObject.Pod) []apiObject.ContainerPort {
+	var ports []apiObject.ContainerPort
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.HostPort > 0 {
+				ports = append(ports, port)
+			}
+		}
+	}
+	return ports
+}
+
+// hostPortsConflict 判断两个主机端口是否冲突，未指定协议时为TCP，未指定主机IP时为0.0.0.0
+func hostPortsConflict(a apiObject.ContainerPort, b apiObject.ContainerPort) bool {
+	if a.HostPort != b.HostPort || portProtocol(a) != portProtocol(b) {
+		return false
+	}
+	return isWildcardIP(a.HostIP) || isWildcardIP(b.HostIP) || a.HostIP == b.HostIP
+}
+
+func portProtocol(port apiObject.ContainerPort) apiObject.Protocol {
+	if port.Protocol == "" {
+		return apiObject.ProtocolTCP
+	}
+	return port.Protocol
+}
+
+func isWildcardIP(ip string) bool {
+	return ip == "" || ip == "0.0.0.0" || ip == "::"
+}
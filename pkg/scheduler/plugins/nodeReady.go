@@ -0,0 +1,27 @@
+package plugins
+
+import (
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const NodeReadyName = "NodeReady"
+
+// NodeReady 过滤掉未就绪或带有NotReady污点的节点
+type NodeReady struct{}
+
+func NewNodeReady(_ framework.PluginArgs, _ *framework.Framework) (framework.Plugin, error) {
+	return &NodeReady{}, nil
+}
+
+func (p *NodeReady) Name() string {
+	return NodeReadyName
+}
+
+func (p *NodeReady) Filter(_ *framework.CycleState, _ *apiObject.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	node := nodeInfo.Node
+	if !node.IsReady() || node.HasTaint(apiObject.TaintNodeNotReady, apiObject.TaintEffectNoExecute) {
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable, "node(s) were not ready")
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package plugins
+
+import (
+	"errors"
+	"strconv"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+	"minik8s/tools/conversion"
+)
+
+const NodeResourcesFitName = "NodeResourcesFit"
+
+// nodeResourcesFitStateKey CycleState中记录PreFilter阶段计算出的Pod资源请求
+const nodeResourcesFitStateKey = "PreFilter" + NodeResourcesFitName
+
+// NodeResourcesFit 过滤掉剩余可分配资源无法满足Pod资源请求的节点，并按照打分策略为节点打分
+//
+//	节点的剩余资源为Allocatable减去节点上已有Pod的资源请求之和，cpu 单位为毫核，memory 单位为 KB
+//	节点没有上报某项可分配资源时不检查该项资源
+//	打分策略通过插件参数 scoringStrategy 指定，包括LeastAllocated与MostAllocated，默认为LeastAllocated
+type NodeResourcesFit struct {
+	scorer *resourceAllocationScorer
+	// 单项资源的打分函数
+	scoreFunc func(requested, allocatable int64) int64
+}
+
+// NodeResourcesFitArgs NodeResourcesFit插件的参数
+type NodeResourcesFitArgs struct {
+	ScoringStrategy *ScoringStrategy `json:"scoringStrategy" yaml:"scoringStrategy"`
+}
+
+// ScoringStrategy 资源打分策略
+type ScoringStrategy struct {
+	// 策略类型，包括：LeastAllocated、MostAllocated
+	Type string `json:"type" yaml:"type"`
+	// 参与打分的资源及其权重，默认为权重相同的cpu与memory
+	Resources []ResourceSpec `json:"resources" yaml:"resources"`
+	// 是否结合节点上报的实际使用量计算已分配资源
+	UseLiveUsage bool `json:"useLiveUsage" yaml:"useLiveUsage"`
+}
+
+// podRequest Pod的cpu与memory资源请求
+type podRequest struct {
+	MilliCPU int64
+	Memory   int64
+}
+
+func NewNodeResourcesFit(args framework.PluginArgs, _ *framework.Framework) (framework.Plugin, error) {
+	fitArgs := &NodeResourcesFitArgs{}
+	if err := args.Decode(fitArgs); err != nil {
+		return nil, err
+	}
+	strategy := fitArgs.ScoringStrategy
+	if strategy == nil {
+		strategy = &ScoringStrategy{Type: LeastAllocated}
+	}
+	p := &NodeResourcesFit{}
+	switch strategy.Type {
+	case "", LeastAllocated:
+		p.scoreFunc = leastRequestedScore
+	case MostAllocated:
+		p.scoreFunc = mostRequestedScore
+	default:
+		return nil, errors.New("unsupported scoring strategy " + strategy.Type)
+	}
+	scorer, err := newResourceAllocationScorer(strategy.Resources, strategy.UseLiveUsage)
+	if err != nil {
+		return nil, err
+	}
+	p.scorer = scorer
+	return p, nil
+}
+
+func (p *NodeResourcesFit) Name() string {
+	return NodeResourcesFitName
+}
+
+func (p *NodeResourcesFit) PreFilter(state *framework.CycleState, pod *apiObject.Pod, _ *framework.Snapshot) *framework.Status {
+	request, err := computePodRequest(pod)
+	if err != nil {
+		return framework.NewStatus(framework.Error, err.Error())
+	}
+	state.Write(nodeResourcesFitStateKey, request)
+	return nil
+}
+
+func (p *NodeResourcesFit) Filter(state *framework.CycleState, pod *apiObject.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	value, ok := state.Read(nodeResourcesFitStateKey)
+	if !ok {
+		return framework.NewStatus(framework.Error, "pod request not found in cycle state")
+	}
+	request := value.(podRequest)
+
+	// 统计节点上已有Pod的资源请求，已经失败的Pod不再占用资源
+	var requested podRequest
+	podCount := int64(0)
+	for _, existing := range nodeInfo.Pods {
+		if !occupiesNode(pod, existing) {
+			continue
+		}
+		podCount++
+		if existingRequest, err := computePodRequest(existing); err == nil {
+			requested.MilliCPU += existingRequest.MilliCPU
+			requested.Memory += existingRequest.Memory
+		}
+	}
+
+	allocatable := nodeInfo.Node.Status.Allocatable
+	var reasons []string
+	if quantity, ok := allocatable[apiObject.ResourcePods]; ok {
+		if maxPods, err := strconv.ParseInt(quantity, 10, 64); err == nil && podCount+1 > maxPods {
+			reasons = append(reasons, "Too many pods")
+		}
+	}
+	if quantity, ok := allocatable[apiObject.ResourceCPU]; ok && request.MilliCPU > 0 {
+		if milliCPU, err := conversion.ParseCPU(quantity); err == nil && requested.MilliCPU+request.MilliCPU > milliCPU {
+			reasons = append(reasons, "Insufficient cpu")
+		}
+	}
+	if quantity, ok := allocatable[apiObject.ResourceMemory]; ok && request.Memory > 0 {
+		if memory, err := conversion.ParseMemory(quantity); err == nil && requested.Memory+request.Memory > memory {
+			reasons = append(reasons, "Insufficient memory")
+		}
+	}
+	if len(reasons) > 0 {
+		return framework.NewStatus(framework.Unschedulable, reasons...)
+	}
+	return nil
+}
+
+func (p *NodeResourcesFit) Score(_ *framework.CycleState, pod *apiObject.Pod, nodeInfo *framework.NodeInfo) (int64, *framework.Status) {
+	return p.scorer.score(pod, nodeInfo, p.scoreFunc), nil
+}
+
+// computePodRequest 计算Pod中所有容器的资源请求之和
+func computePodRequest(pod *apiObject.Pod) (podRequest, error) {
+	var request podRequest
+	for _, container := range pod.Spec.Containers {
+		if cpu, ok := container.Resources.Requests[apiObject.ResourceCPU]; ok {
+			value, err := conversion.ParseCPU(cpu)
+			if err != nil {
+				return podRequest{}, err
+			}
+			request.MilliCPU += value
+		}
+		if memory, ok := container.Resources.Requests[apiObject.ResourceMemory]; ok {
+			value, err := conversion.ParseMemory(memory)
+			if err != nil {
+				return podRequest{}, err
+			}
+			request.Memory += value
+		}
+	}
+	return request, nil
+}
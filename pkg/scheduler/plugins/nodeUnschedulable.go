@@ -0,0 +1,30 @@
+package plugins
+
+import (
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const NodeUnschedulableName = "NodeUnschedulable"
+
+// NodeUnschedulable 过滤掉被标记为不可调度的节点，容忍node.kubernetes.io/unschedulable污点的Pod除外
+type NodeUnschedulable struct{}
+
+func NewNodeUnschedulable(_ framework.PluginArgs, _ *framework.Framework) (framework.Plugin, error) {
+	return &NodeUnschedulable{}, nil
+}
+
+func (p *NodeUnschedulable) Name() string {
+	return NodeUnschedulableName
+}
+
+func (p *NodeUnschedulable) Filter(_ *framework.CycleState, pod *apiObject.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	if !nodeInfo.Node.Spec.Unschedulable {
+		return nil
+	}
+	taint := &apiObject.Taint{Key: apiObject.TaintNodeUnschedulable, Effect: apiObject.TaintEffectNoSchedule}
+	if apiObject.FindMatchingToleration(pod.Spec.Tolerations, taint) != nil {
+		return nil
+	}
+	return framework.NewStatus(framework.UnschedulableAndUnresolvable, "node(s) were unschedulable")
+}
@@ -0,0 +1,205 @@
+package plugins
+
+import (
+	"fmt"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const PodTopologySpreadName = "PodTopologySpread"
+
+// podTopologySpreadStateKey CycleState中记录PreFilter阶段统计的各个拓扑域中匹配的Pod数量
+const podTopologySpreadStateKey = "PreFilter" + PodTopologySpreadName
+
+const (
+	errReasonTopologySpreadNotMatch = "node(s) didn't match pod topology spread constraints"
+	errReasonTopologySpreadNoLabel  = "node(s) didn't match pod topology spread constraints (missing required label)"
+	errReasonTopologySpreadInvalid  = "invalid topology spread constraint: %s"
+)
+
+// PodTopologySpread 根据Pod的topologySpreadConstraints使匹配的Pod在各个拓扑域之间均匀分布
+//
+//	只有满足Pod的nodeSelector与必需节点亲和性、且带有topologyKey标签的节点参与统计
+//	分布偏差为节点所在拓扑域中匹配的Pod数量（包括待调度的Pod）减去所有拓扑域中的最小数量
+//	Filter 过滤掉会使DoNotSchedule约束的分布偏差超过maxSkew的节点，以及没有topologyKey标签的节点
+//	Score 节点所在拓扑域中满足ScheduleAnyway约束的Pod越少得分越高
+type PodTopologySpread struct{}
+
+// spreadConstraint 统计后的拓扑分布约束
+type spreadConstraint struct {
+	maxSkew     int64
+	topologyKey string
+	selector    *apiObject.LabelSelector
+	// 拓扑域（标签值）中匹配的Pod数量
+	counts map[string]int64
+	// 所有拓扑域中匹配的Pod数量的最小值
+	minCount int64
+	// 待调度的Pod自身是否满足选择器
+	selfMatch int64
+}
+
+type podTopologySpreadState struct {
+	// DoNotSchedule约束
+	constraints []*spreadConstraint
+	// 每个节点ScheduleAnyway约束的原始得分，以及所有节点中的最高分与最低分，用于归一化
+	//	节点缺少某个约束的topologyKey标签时不在rawScores中
+	rawScores map[string]int64
+	minScore  int64
+	maxScore  int64
+}
+
+func NewPodTopologySpread(_ framework.PluginArgs, _ *framework.Framework) (framework.Plugin, error) {
+	return &PodTopologySpread{}, nil
+}
+
+func (p *PodTopologySpread) Name() string {
+	return PodTopologySpreadName
+}
+
+func (p *PodTopologySpread) PreFilter(state *framework.CycleState, pod *apiObject.Pod, snapshot *framework.Snapshot) *framework.Status {
+	if len(pod.Spec.TopologySpreadConstraints) == 0 {
+		return framework.NewStatus(framework.Skip)
+	}
+	s := &podTopologySpreadState{rawScores: make(map[string]int64)}
+	var softConstraints []*spreadConstraint
+	for _, constraint := range pod.Spec.TopologySpreadConstraints {
+		if err := validateSpreadConstraint(&constraint); err != nil {
+			return framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf(errReasonTopologySpreadInvalid, err.Error()))
+		}
+		c := countSpreadConstraint(&constraint, pod, snapshot)
+		if constraint.WhenUnsatisfiable == apiObject.ScheduleAnyway {
+			softConstraints = append(softConstraints, c)
+		} else {
+			s.constraints = append(s.constraints, c)
+		}
+	}
+
+	if len(softConstraints) > 0 {
+		first := true
+		for _, nodeInfo := range snapshot.NodeInfos {
+			score, ok := rawSpreadScore(softConstraints, nodeInfo.Node.Metadata.Labels)
+			if !ok {
+				continue
+			}
+			s.rawScores[nodeInfo.Name()] = score
+			if first || score < s.minScore {
+				s.minScore = score
+			}
+			if first || score > s.maxScore {
+				s.maxScore = score
+			}
+			first = false
+		}
+	}
+
+	state.Write(podTopologySpreadStateKey, s)
+	return nil
+}
+
+func (p *PodTopologySpread) Filter(state *framework.CycleState, _ *apiObject.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	s, status := getPodTopologySpreadState(state)
+	if !status.IsSuccess() {
+		return status
+	}
+	labels := nodeInfo.Node.Metadata.Labels
+	for _, c := range s.constraints {
+		value, ok := labels[c.topologyKey]
+		if !ok {
+			return framework.NewStatus(framework.UnschedulableAndUnresolvable, errReasonTopologySpreadNoLabel)
+		}
+		if c.counts[value]+c.selfMatch-c.minCount > c.maxSkew {
+			return framework.NewStatus(framework.Unschedulable, errReasonTopologySpreadNotMatch)
+		}
+	}
+	return nil
+}
+
+func (p *PodTopologySpread) Score(state *framework.CycleState, _ *apiObject.Pod, nodeInfo *framework.NodeInfo) (int64, *framework.Status) {
+	s, status := getPodTopologySpreadState(state)
+	if !status.IsSuccess() {
+		return 0, status
+	}
+	score, ok := s.rawScores[nodeInfo.Name()]
+	if !ok {
+		return 0, nil
+	}
+	if s.maxScore == s.minScore {
+		return framework.MaxNodeScore, nil
+	}
+	return (s.maxScore - score) * framework.MaxNodeScore / (s.maxScore - s.minScore), nil
+}
+
+func getPodTopologySpreadState(state *framework.CycleState) (*podTopologySpreadState, *framework.Status) {
+	value, ok := state.Read(podTopologySpreadStateKey)
+	if !ok {
+		return nil, framework.NewStatus(framework.Error, "pod topology spread state not found in cycle state")
+	}
+	return value.(*podTopologySpreadState), nil
+}
+
+func validateSpreadConstraint(constraint *apiObject.TopologySpreadConstraint) error {
+	if constraint.MaxSkew <= 0 {
+		return fmt.Errorf("maxSkew must be greater than zero, got %d", constraint.MaxSkew)
+	}
+	if constraint.TopologyKey == "" {
+		return fmt.Errorf("topologyKey is empty")
+	}
+	switch constraint.WhenUnsatisfiable {
+	case apiObject.DoNotSchedule, apiObject.ScheduleAnyway:
+	default:
+		return fmt.Errorf("unsupported whenUnsatisfiable %q", constraint.WhenUnsatisfiable)
+	}
+	return nil
+}
+
+// countSpreadConstraint 统计约束在各个拓扑域中匹配的Pod数量
+func countSpreadConstraint(constraint *apiObject.TopologySpreadConstraint, pod *apiObject.Pod, snapshot *framework.Snapshot) *spreadConstraint {
+	c := &spreadConstraint{
+		maxSkew:     int64(constraint.MaxSkew),
+		topologyKey: constraint.TopologyKey,
+		selector:    constraint.LabelSelector,
+		counts:      make(map[string]int64),
+	}
+	if c.selector.Matches(pod.Metadata.Labels) {
+		c.selfMatch = 1
+	}
+	for _, nodeInfo := range snapshot.NodeInfos {
+		labels := nodeInfo.Node.Metadata.Labels
+		value, ok := labels[c.topologyKey]
+		if !ok || !matchesNodeSelectorAndAffinity(pod, labels) {
+			continue
+		}
+		// 没有匹配的Pod的拓扑域数量为0，同样参与最小值的计算
+		if _, ok := c.counts[value]; !ok {
+			c.counts[value] = 0
+		}
+		for _, existing := range nodeInfo.Pods {
+			if occupiesNode(pod, existing) && existing.Metadata.Namespace == pod.Metadata.Namespace &&
+				c.selector.Matches(existing.Metadata.Labels) {
+				c.counts[value]++
+			}
+		}
+	}
+	first := true
+	for _, count := range c.counts {
+		if first || count < c.minCount {
+			c.minCount = count
+		}
+		first = false
+	}
+	return c
+}
+
+// rawSpreadScore 节点所在拓扑域中匹配的Pod数量之和，节点缺少任意一个topologyKey标签时返回false
+func rawSpreadScore(constraints []*spreadConstraint, labels map[string]string) (int64, bool) {
+	var score int64
+	for _, c := range constraints {
+		value, ok := labels[c.topologyKey]
+		if !ok {
+			return 0, false
+		}
+		score += c.counts[value]
+	}
+	return score, true
+}
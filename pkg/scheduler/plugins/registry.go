@@ -0,0 +1,25 @@
+// 描述：plugins包实现了调度框架的内置插件
+//	新增插件时实现framework中对应扩展点的接口，并在NewInTreeRegistry中注册
+
+package plugins
+
+import "minik8s/pkg/scheduler/framework"
+
+// NewInTreeRegistry 返回所有内置插件
+func NewInTreeRegistry() framework.Registry {
+	return framework.Registry{
+		NodeReadyName:                       NewNodeReady,
+		NodeAffinityName:                    NewNodeAffinity,
+		NodeUnschedulableName:               NewNodeUnschedulable,
+		TaintTolerationName:                 NewTaintToleration,
+		NodePortsName:                       NewNodePorts,
+		NodeResourcesFitName:                NewNodeResourcesFit,
+		NodeResourcesBalancedAllocationName: NewNodeResourcesBalancedAllocation,
+		InterPodAffinityName:                NewInterPodAffinity,
+		PodTopologySpreadName:               NewPodTopologySpread,
+		DefaultPreemptionName:               NewDefaultPreemption,
+		CoschedulingName:                    NewCoscheduling,
+		RoundRobinName:                      NewRoundRobin,
+		DefaultBinderName:                   NewDefaultBinder,
+	}
+}
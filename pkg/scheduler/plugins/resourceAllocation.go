@@ -0,0 +1,151 @@
+package plugins
+
+import (
+	"errors"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+	"minik8s/tools/conversion"
+)
+
+// 打分时没有设置资源请求的Pod按照以下默认值计算，避免大量没有请求的Pod被调度到同一节点上
+const (
+	// DefaultMilliCPURequest 默认的cpu请求，单位为毫核
+	DefaultMilliCPURequest int64 = 100
+	// DefaultMemoryRequest 默认的内存请求，单位为 KB
+	DefaultMemoryRequest int64 = 200 * 1024
+)
+
+// 资源打分策略
+const (
+	// LeastAllocated 优先选择剩余资源多的节点，使负载分散到各个节点上
+	LeastAllocated = "LeastAllocated"
+	// MostAllocated 优先选择剩余资源少的节点，将Pod集中到少数节点上以空出整个节点
+	MostAllocated = "MostAllocated"
+)
+
+// ResourceSpec 参与打分的资源及其权重
+type ResourceSpec struct {
+	// 资源名称，包括：cpu、memory
+	Name string `json:"name" yaml:"name"`
+	// 资源的权重，未指定时为1
+	Weight int64 `json:"weight" yaml:"weight"`
+}
+
+// resourceAllocationScorer 根据节点的已分配资源与可分配资源为节点打分
+type resourceAllocationScorer struct {
+	// 参与打分的资源
+	resources []ResourceSpec
+	// 为true时，节点的已分配资源取Pod请求之和与节点上报的实际使用量中的较大者
+	useLiveUsage bool
+}
+
+func newResourceAllocationScorer(resources []ResourceSpec, useLiveUsage bool) (*resourceAllocationScorer, error) {
+	if len(resources) == 0 {
+		resources = []ResourceSpec{{Name: apiObject.ResourceCPU, Weight: 1}, {Name: apiObject.ResourceMemory, Weight: 1}}
+	}
+	for i := range resources {
+		if resources[i].Name != apiObject.ResourceCPU && resources[i].Name != apiObject.ResourceMemory {
+			return nil, errors.New("unsupported resource " + resources[i].Name)
+		}
+		if resources[i].Weight == 0 {
+			resources[i].Weight = 1
+		}
+		if resources[i].Weight < 0 {
+			return nil, errors.New("resource " + resources[i].Name + " has negative weight")
+		}
+	}
+	return &resourceAllocationScorer{resources: resources, useLiveUsage: useLiveUsage}, nil
+}
+
+// allocation 计算将Pod放到节点上之后每种资源的已分配量与可分配量，节点没有上报的资源不会出现在结果中
+func (r *resourceAllocationScorer) allocation(pod *apiObject.Pod, nodeInfo *framework.NodeInfo) (requested map[string]int64, allocatable map[string]int64) {
+	requested = make(map[string]int64)
+	allocatable = make(map[string]int64)
+	node := nodeInfo.Node
+	for _, resource := range r.resources {
+		quantity, ok := node.Status.Allocatable[resource.Name]
+		if !ok {
+			continue
+		}
+		value, err := conversion.ParseQuantity(resource.Name, quantity)
+		if err != nil || value == 0 {
+			continue
+		}
+		allocatable[resource.Name] = value
+	}
+
+	request := nonZeroRequest(pod)
+	var nodeRequest podRequest
+	for _, existing := range nodeInfo.Pods {
+		if !occupiesNode(pod, existing) {
+			continue
+		}
+		existingRequest := nonZeroRequest(existing)
+		nodeRequest.MilliCPU += existingRequest.MilliCPU
+		nodeRequest.Memory += existingRequest.Memory
+	}
+
+	if r.useLiveUsage {
+		// 节点上报的使用率是相对于资源容量的百分比
+		if capacity, err := conversion.ParseCPU(node.Status.Capacity[apiObject.ResourceCPU]); err == nil {
+			nodeRequest.MilliCPU = max(nodeRequest.MilliCPU, int64(node.Status.CpuUsage*float64(capacity)/100))
+		}
+		if capacity, err := conversion.ParseMemory(node.Status.Capacity[apiObject.ResourceMemory]); err == nil {
+			nodeRequest.Memory = max(nodeRequest.Memory, int64(node.Status.MemUsage*float64(capacity)/100))
+		}
+	}
+
+	requested[apiObject.ResourceCPU] = nodeRequest.MilliCPU + request.MilliCPU
+	requested[apiObject.ResourceMemory] = nodeRequest.Memory + request.Memory
+	return requested, allocatable
+}
+
+// score 按照权重对每种资源的得分求加权平均，scorer根据已分配量与可分配量计算单项资源的得分
+func (r *resourceAllocationScorer) score(pod *apiObject.Pod, nodeInfo *framework.NodeInfo, scorer func(requested, allocatable int64) int64) int64 {
+	requested, allocatable := r.allocation(pod, nodeInfo)
+	var score, weightSum int64
+	for _, resource := range r.resources {
+		value, ok := allocatable[resource.Name]
+		if !ok {
+			continue
+		}
+		score += scorer(requested[resource.Name], value) * resource.Weight
+		weightSum += resource.Weight
+	}
+	if weightSum == 0 {
+		return 0
+	}
+	return score / weightSum
+}
+
+// leastRequestedScore 剩余资源占可分配资源的比例越高得分越高
+func leastRequestedScore(requested, allocatable int64) int64 {
+	if requested > allocatable {
+		return 0
+	}
+	return (allocatable - requested) * framework.MaxNodeScore / allocatable
+}
+
+// mostRequestedScore 已分配资源占可分配资源的比例越高得分越高
+func mostRequestedScore(requested, allocatable int64) int64 {
+	if requested > allocatable {
+		return framework.MaxNodeScore
+	}
+	return requested * framework.MaxNodeScore / allocatable
+}
+
+// nonZeroRequest 计算Pod的资源请求，没有设置的资源使用默认值
+func nonZeroRequest(pod *apiObject.Pod) podRequest {
+	request, err := computePodRequest(pod)
+	if err != nil {
+		request = podRequest{}
+	}
+	if request.MilliCPU == 0 {
+		request.MilliCPU = DefaultMilliCPURequest
+	}
+	if request.Memory == 0 {
+		request.Memory = DefaultMemoryRequest
+	}
+	return request
+}
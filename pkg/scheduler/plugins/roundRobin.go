@@ -0,0 +1,56 @@
+package plugins
+
+import (
+	"sync"
+
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const RoundRobinName = "RoundRobin"
+
+// roundRobinStateKey CycleState中记录Reserve之前上一次选中的节点，用于Unreserve时恢复
+const roundRobinStateKey = "RoundRobin/previous"
+
+// RoundRobin 按照节点名称的顺序轮流选择节点
+//
+//	名称排在上一次选中节点之后的节点得到满分，其余节点得0分
+//	调度器在同分的节点中选择名称最小的节点，因此依次选中上一次节点的下一个节点，到达末尾后回到第一个节点
+type RoundRobin struct {
+	lock sync.Mutex
+	// 上一次选中的节点名称
+	last string
+}
+
+func NewRoundRobin(_ framework.PluginArgs, _ *framework.Framework) (framework.Plugin, error) {
+	return &RoundRobin{}, nil
+}
+
+func (p *RoundRobin) Name() string {
+	return RoundRobinName
+}
+
+func (p *RoundRobin) Score(_ *framework.CycleState, _ *apiObject.Pod, nodeInfo *framework.NodeInfo) (int64, *framework.Status) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if nodeInfo.Name() > p.last {
+		return framework.MaxNodeScore, nil
+	}
+	return 0, nil
+}
+
+func (p *RoundRobin) Reserve(state *framework.CycleState, _ *apiObject.Pod, nodeName string) *framework.Status {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	state.Write(roundRobinStateKey, p.last)
+	p.last = nodeName
+	return nil
+}
+
+func (p *RoundRobin) Unreserve(state *framework.CycleState, _ *apiObject.Pod, nodeName string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if previous, ok := state.Read(roundRobinStateKey); ok && p.last == nodeName {
+		p.last = previous.(string)
+	}
+}
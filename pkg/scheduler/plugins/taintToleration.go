@@ -0,0 +1,74 @@
+package plugins
+
+import (
+	"minik8s/pkg/apiObject"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const TaintTolerationName = "TaintToleration"
+
+// taintTolerationStateKey CycleState中记录每个节点上Pod无法容忍的PreferNoSchedule污点数量
+const taintTolerationStateKey = "PreFilter" + TaintTolerationName
+
+// TaintToleration 根据节点的污点与Pod的容忍选择节点
+//
+//	Filter 过滤掉带有Pod无法容忍的NoSchedule、NoExecute污点的节点
+//	Score 节点上Pod无法容忍的PreferNoSchedule污点越少，得分越高
+type TaintToleration struct{}
+
+type taintTolerationState struct {
+	// 每个节点上无法容忍的PreferNoSchedule污点数量
+	intolerable map[string]int64
+	// 所有节点中最多的无法容忍的PreferNoSchedule污点数量
+	maxIntolerable int64
+}
+
+func NewTaintToleration(_ framework.PluginArgs, _ *framework.Framework) (framework.Plugin, error) {
+	return &TaintToleration{}, nil
+}
+
+func (p *TaintToleration) Name() string {
+	return TaintTolerationName
+}
+
+func (p *TaintToleration) PreFilter(state *framework.CycleState, pod *apiObject.Pod, snapshot *framework.Snapshot) *framework.Status {
+	s := &taintTolerationState{intolerable: make(map[string]int64)}
+	for _, nodeInfo := range snapshot.NodeInfos {
+		var count int64
+		for i := range nodeInfo.Node.Spec.Taints {
+			taint := &nodeInfo.Node.Spec.Taints[i]
+			if taint.Effect == apiObject.TaintEffectPreferNoSchedule && apiObject.FindMatchingToleration(pod.Spec.Tolerations, taint) == nil {
+				count++
+			}
+		}
+		s.intolerable[nodeInfo.Name()] = count
+		s.maxIntolerable = max(s.maxIntolerable, count)
+	}
+	state.Write(taintTolerationStateKey, s)
+	return nil
+}
+
+func (p *TaintToleration) Filter(_ *framework.CycleState, pod *apiObject.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	for i := range nodeInfo.Node.Spec.Taints {
+		taint := &nodeInfo.Node.Spec.Taints[i]
+		if taint.Effect != apiObject.TaintEffectNoSchedule && taint.Effect != apiObject.TaintEffectNoExecute {
+			continue
+		}
+		if apiObject.FindMatchingToleration(pod.Spec.Tolerations, taint) == nil {
+			return framework.NewStatus(framework.UnschedulableAndUnresolvable, "node(s) had untolerated taint {"+taint.Key+": "+taint.Value+"}")
+		}
+	}
+	return nil
+}
+
+func (p *TaintToleration) Score(state *framework.CycleState, _ *apiObject.Pod, nodeInfo *framework.NodeInfo) (int64, *framework.Status) {
+	value, ok := state.Read(taintTolerationStateKey)
+	if !ok {
+		return 0, framework.NewStatus(framework.Error, "taint toleration state not found in cycle state")
+	}
+	s := value.(*taintTolerationState)
+	if s.maxIntolerable == 0 {
+		return framework.MaxNodeScore, nil
+	}
+	return (s.maxIntolerable - s.intolerable[nodeInfo.Name()]) * framework.MaxNodeScore / s.maxIntolerable, nil
+}
@@ -0,0 +1,272 @@
+package queue
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"minik8s/pkg/apiObject"
+)
+
+const (
+	// DefaultPodInitialBackoff Pod第一次调度失败后的退避时间，之后每次失败退避时间翻倍
+	DefaultPodInitialBackoff = 1 * time.Second
+	// DefaultPodMaxBackoff Pod退避时间的上限
+	DefaultPodMaxBackoff = 10 * time.Second
+	// DefaultPodMaxUnschedulableDuration Pod在unschedulableQ中停留的最长时间，超时后重新尝试调度
+	DefaultPodMaxUnschedulableDuration = 30 * time.Second
+)
+
+// ErrQueueClosed 队列关闭后Pop返回该错误
+var ErrQueueClosed = errors.New("scheduling queue is closed")
+
+// QueuedPodInfo 队列中的Pod及其调度记录
+type QueuedPodInfo struct {
+	Pod *apiObject.Pod
+	// 尝试调度的次数
+	Attempts int
+	// 最近一次尝试调度的时间
+	Timestamp time.Time
+}
+
+// SchedulingQueue 待调度Pod的队列，Pod以UUID为键，同一个Pod在同一时间只会出现在一个子队列中
+//
+//	activeQ 等待调度的Pod，优先级高的Pod先弹出，优先级相同时按照入队顺序弹出
+//	backoffQ 调度失败的Pod，退避时间结束后移回activeQ
+//	unschedulableQ 集群中没有可以放置的节点的Pod，集群发生变化或者停留超时后移回activeQ或backoffQ
+type SchedulingQueue struct {
+	lock sync.Mutex
+	cond *sync.Cond
+
+	activeQ        []*QueuedPodInfo
+	backoffQ       map[string]*QueuedPodInfo
+	unschedulableQ map[string]*QueuedPodInfo
+	// 已经弹出、正在调度的Pod
+	inFlight map[string]*QueuedPodInfo
+
+	initialBackoff           time.Duration
+	maxBackoff               time.Duration
+	maxUnschedulableDuration time.Duration
+
+	closed bool
+	// now 获取当前时间，测试时可以替换
+	now func() time.Time
+}
+
+func NewSchedulingQueue(initialBackoff, maxBackoff, maxUnschedulableDuration time.Duration) *SchedulingQueue {
+	q := &SchedulingQueue{
+		backoffQ:                 make(map[string]*QueuedPodInfo),
+		unschedulableQ:           make(map[string]*QueuedPodInfo),
+		inFlight:                 make(map[string]*QueuedPodInfo),
+		initialBackoff:           initialBackoff,
+		maxBackoff:               maxBackoff,
+		maxUnschedulableDuration: maxUnschedulableDuration,
+		now:                      time.Now,
+	}
+	q.cond = sync.NewCond(&q.lock)
+	return q
+}
+
+// Add 将新的待调度Pod放入activeQ，已经在队列中的Pod只更新其内容
+func (q *SchedulingQueue) Add(pod *apiObject.Pod) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	key := pod.GetPodUUID()
+	if info := q.find(key); info != nil {
+		info.Pod = pod
+		return
+	}
+	q.activate(&QueuedPodInfo{Pod: pod, Timestamp: q.now()})
+}
+
+// Delete 将Pod从队列中移除，正在调度的Pod在调度结束后不会再回到队列中
+func (q *SchedulingQueue) Delete(pod *apiObject.Pod) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	key := pod.GetPodUUID()
+	q.removeActive(key)
+	delete(q.backoffQ, key)
+	delete(q.unschedulableQ, key)
+	delete(q.inFlight, key)
+}
+
+// Pop 弹出activeQ中优先级最高的Pod，activeQ为空时阻塞
+func (q *SchedulingQueue) Pop() (*QueuedPodInfo, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for len(q.activeQ) == 0 {
+		if q.closed {
+			return nil, ErrQueueClosed
+		}
+		q.cond.Wait()
+	}
+	info := q.activeQ[0]
+	q.activeQ = q.activeQ[1:]
+	info.Attempts++
+	info.Timestamp = q.now()
+	q.inFlight[info.Pod.GetPodUUID()] = info
+	return info, nil
+}
+
+// Done 调度成功，Pod不再需要留在队列中
+func (q *SchedulingQueue) Done(pod *apiObject.Pod) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	delete(q.inFlight, pod.GetPodUUID())
+}
+
+// AddBackoff 调度过程中出现错误，Pod退避一段时间后重试
+func (q *SchedulingQueue) AddBackoff(info *QueuedPodInfo) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	key := info.Pod.GetPodUUID()
+	if _, ok := q.inFlight[key]; !ok {
+		return
+	}
+	delete(q.inFlight, key)
+	q.backoffQ[key] = info
+}
+
+// AddUnschedulable 集群中没有可以放置Pod的节点，Pod等待集群发生变化后重试
+func (q *SchedulingQueue) AddUnschedulable(info *QueuedPodInfo) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	key := info.Pod.GetPodUUID()
+	if _, ok := q.inFlight[key]; !ok {
+		return
+	}
+	delete(q.inFlight, key)
+	q.unschedulableQ[key] = info
+}
+
+// MoveAllToActiveOrBackoffQueue 集群发生变化时，unschedulableQ中的Pod可能已经可以调度
+//
+//	退避时间已经结束的Pod移入activeQ，其余的移入backoffQ
+func (q *SchedulingQueue) MoveAllToActiveOrBackoffQueue() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for key, info := range q.unschedulableQ {
+		delete(q.unschedulableQ, key)
+		q.activateOrBackoff(key, info)
+	}
+}
+
+// Flush 将退避结束的Pod移入activeQ，并将在unschedulableQ中停留超时的Pod移出
+func (q *SchedulingQueue) Flush() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	now := q.now()
+	for key, info := range q.backoffQ {
+		if !now.Before(q.backoffExpiry(info)) {
+			delete(q.backoffQ, key)
+			q.activate(info)
+		}
+	}
+	for key, info := range q.unschedulableQ {
+		if now.Sub(info.Timestamp) >= q.maxUnschedulableDuration {
+			delete(q.unschedulableQ, key)
+			q.activateOrBackoff(key, info)
+		}
+	}
+}
+
+// Run 定时执行Flush，直到队列关闭
+func (q *SchedulingQueue) Run(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		q.lock.Lock()
+		closed := q.closed
+		q.lock.Unlock()
+		if closed {
+			return
+		}
+		q.Flush()
+	}
+}
+
+// Close 关闭队列，阻塞在Pop上的调用方会收到ErrQueueClosed
+func (q *SchedulingQueue) Close() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// PendingPods 返回队列中所有的Pod，不包括正在调度的Pod
+func (q *SchedulingQueue) PendingPods() []*apiObject.Pod {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	var pods []*apiObject.Pod
+	for _, info := range q.activeQ {
+		pods = append(pods, info.Pod)
+	}
+	for _, info := range q.backoffQ {
+		pods = append(pods, info.Pod)
+	}
+	for _, info := range q.unschedulableQ {
+		pods = append(pods, info.Pod)
+	}
+	return pods
+}
+
+// backoffDuration 退避时间从initialBackoff开始，每次失败翻倍，不超过maxBackoff
+func (q *SchedulingQueue) backoffDuration(info *QueuedPodInfo) time.Duration {
+	duration := q.initialBackoff
+	for i := 1; i < info.Attempts && duration < q.maxBackoff; i++ {
+		duration *= 2
+	}
+	return min(duration, q.maxBackoff)
+}
+
+func (q *SchedulingQueue) backoffExpiry(info *QueuedPodInfo) time.Time {
+	return info.Timestamp.Add(q.backoffDuration(info))
+}
+
+func (q *SchedulingQueue) activateOrBackoff(key string, info *QueuedPodInfo) {
+	if q.now().Before(q.backoffExpiry(info)) {
+		q.backoffQ[key] = info
+		return
+	}
+	q.activate(info)
+}
+
+// activate 将Pod按照优先级插入activeQ，优先级相同的Pod中先入队的排在前面
+func (q *SchedulingQueue) activate(info *QueuedPodInfo) {
+	priority := info.Pod.GetPriority()
+	i := sort.Search(len(q.activeQ), func(i int) bool {
+		other := q.activeQ[i]
+		if otherPriority := other.Pod.GetPriority(); otherPriority != priority {
+			return otherPriority < priority
+		}
+		return other.Timestamp.After(info.Timestamp)
+	})
+	q.activeQ = append(q.activeQ, nil)
+	copy(q.activeQ[i+1:], q.activeQ[i:])
+	q.activeQ[i] = info
+	q.cond.Signal()
+}
+
+func (q *SchedulingQueue) find(key string) *QueuedPodInfo {
+	for _, info := range q.activeQ {
+		if info.Pod.GetPodUUID() == key {
+			return info
+		}
+	}
+	if info, ok := q.backoffQ[key]; ok {
+		return info
+	}
+	if info, ok := q.unschedulableQ[key]; ok {
+		return info
+	}
+	return q.inFlight[key]
+}
+
+func (q *SchedulingQueue) removeActive(key string) {
+	for i, info := range q.activeQ {
+		if info.Pod.GetPodUUID() == key {
+			q.activeQ = append(q.activeQ[:i], q.activeQ[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"minik8s/pkg/apiObject"
+)
+
+// fakeClock 可以手动推进的时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestQueue() (*SchedulingQueue, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	q := NewSchedulingQueue(time.Second, 4*time.Second, time.Minute)
+	q.now = clock.Now
+	return q, clock
+}
+
+func newPod(uuid string) *apiObject.Pod {
+	return &apiObject.Pod{Metadata: apiObject.ObjectMeta{Name: uuid, Namespace: "default", UUID: uuid}}
+}
+
+func TestSchedulingQueueFIFO(t *testing.T) {
+	q, _ := newTestQueue()
+	q.Add(newPod("a"))
+	q.Add(newPod("b"))
+	// 重复添加的Pod只更新内容
+	q.Add(newPod("a"))
+	assert.Len(t, q.PendingPods(), 2)
+
+	info, err := q.Pop()
+	assert.Nil(t, err)
+	assert.Equal(t, "a", info.Pod.Metadata.UUID)
+	assert.Equal(t, 1, info.Attempts)
+
+	// 正在调度的Pod不会被重复加入队列
+	q.Add(newPod("a"))
+	assert.Len(t, q.PendingPods(), 1)
+	q.Done(info.Pod)
+
+	info, err = q.Pop()
+	assert.Nil(t, err)
+	assert.Equal(t, "b", info.Pod.Metadata.UUID)
+}
+
+func TestSchedulingQueuePriority(t *testing.T) {
+	q, clock := newTestQueue()
+	newPriorityPod := func(uuid string, priority int32) *apiObject.Pod {
+		pod := newPod(uuid)
+		pod.Spec.Priority = &priority
+		return pod
+	}
+	q.Add(newPod("batch"))
+	clock.now = clock.now.Add(time.Second)
+	q.Add(newPriorityPod("web-1", 1000))
+	clock.now = clock.now.Add(time.Second)
+	q.Add(newPriorityPod("critical", 2000))
+	clock.now = clock.now.Add(time.Second)
+	q.Add(newPriorityPod("web-2", 1000))
+
+	// 优先级高的Pod先弹出，优先级相同时先入队的Pod先弹出
+	var order []string
+	for i := 0; i < 4; i++ {
+		info, err := q.Pop()
+		assert.Nil(t, err)
+		order = append(order, info.Pod.Metadata.UUID)
+	}
+	assert.Equal(t, []string{"critical", "web-1", "web-2", "batch"}, order)
+}
+
+func TestSchedulingQueueBackoff(t *testing.T) {
+	q, clock := newTestQueue()
+	q.Add(newPod("a"))
+
+	// 每次失败退避时间翻倍：1s、2s、4s、4s
+	for _, backoff := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		info, err := q.Pop()
+		assert.Nil(t, err)
+		q.AddBackoff(info)
+
+		clock.now = clock.now.Add(backoff - time.Millisecond)
+		q.Flush()
+		assert.Len(t, q.activeQ, 0)
+		clock.now = clock.now.Add(time.Millisecond)
+		q.Flush()
+		assert.Len(t, q.activeQ, 1)
+	}
+}
+
+func TestSchedulingQueueUnschedulable(t *testing.T) {
+	q, clock := newTestQueue()
+	q.Add(newPod("a"))
+	q.Add(newPod("b"))
+	infoA, _ := q.Pop()
+	infoB, _ := q.Pop()
+	q.AddUnschedulable(infoA)
+	q.AddUnschedulable(infoB)
+	assert.Len(t, q.unschedulableQ, 2)
+
+	// 集群发生变化时，退避未结束的Pod进入backoffQ，结束的进入activeQ
+	clock.now = clock.now.Add(time.Second)
+	q.Delete(infoB.Pod)
+	q.MoveAllToActiveOrBackoffQueue()
+	assert.Len(t, q.activeQ, 1)
+	assert.Len(t, q.unschedulableQ, 0)
+
+	// 在unschedulableQ中停留超时的Pod重新尝试调度
+	infoA, _ = q.Pop()
+	q.AddUnschedulable(infoA)
+	clock.now = clock.now.Add(30 * time.Second)
+	q.Flush()
+	assert.Len(t, q.unschedulableQ, 1)
+	clock.now = clock.now.Add(30 * time.Second)
+	q.Flush()
+	assert.Len(t, q.activeQ, 1)
+}
+
+func TestSchedulingQueueDeleteInFlight(t *testing.T) {
+	q, _ := newTestQueue()
+	q.Add(newPod("a"))
+	info, _ := q.Pop()
+	// 调度过程中被删除的Pod不会再回到队列中
+	q.Delete(info.Pod)
+	q.AddBackoff(info)
+	assert.Len(t, q.PendingPods(), 0)
+
+	q.Close()
+	_, err := q.Pop()
+	assert.Equal(t, ErrQueueClosed, err)
+}
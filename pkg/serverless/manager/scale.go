@@ -111,6 +111,13 @@ func (s *ScaleManagerImpl) IncreaseInstance(name string) {
 	instanceName := name + "-" + fmt.Sprint(s.FunctionInstanceNum[name])
 	pod.Metadata.Name = instanceName
 	pod.Spec.Containers[0].Name = instanceName
+	// 为实例打上函数名称的标签，kubectl drain据此识别Serverless实例；复制标签以免修改模板Pod
+	labels := make(map[string]string)
+	for key, value := range pod.Metadata.Labels {
+		labels[key] = value
+	}
+	labels[apiObject.ServerlessFunctionLabel] = name
+	pod.Metadata.Labels = labels
 	// 转发给 apiServer 创建一个 Pod
 	url := config.APIServerURL() + config.PodsURI
 	url = strings.Replace(url, config.NameSpaceReplace, pod.Metadata.Namespace, -1)
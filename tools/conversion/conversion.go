@@ -1,8 +1,6 @@
 package conversion
 
 import (
-	"strconv"
-
 	"minik8s/pkg/apiObject"
 	"minik8s/tools/log"
 
@@ -59,34 +57,12 @@ func MountsToMounts(mounts []*apiObject.Mount) []*runtimeapi.Mount {
 	return configMounts
 }
 
-// ResourcesConvert 将一个 Resources 转化为单位为 KB 的大小
+// ResourcesConvert 将一个 Resources 转化为单位为 KB 的大小，解析规则与 ParseMemory 相同，无法解析时返回 0
 func ResourcesConvert(resources string) int {
-	// resources 中第一个非数字字符之后的部分都是单位
-	unit := ""
-	number := ""
-	for _, r := range resources {
-		if r >= '0' && r <= '9' {
-			number += string(r)
-		} else {
-			unit += string(r)
-		}
-	}
-	// 将资源转化为 KB
-	switch unit {
-	case "Ki":
-		numberInt, _ := strconv.Atoi(number)
-		return 1 * numberInt
-	case "Mi":
-		numberInt, _ := strconv.Atoi(number)
-		return 1024 * numberInt
-	case "Gi":
-		numberInt, _ := strconv.Atoi(number)
-		return 1024 * 1024 * numberInt
-	case "Ti":
-		numberInt, _ := strconv.Atoi(number)
-		return 1024 * 1024 * 1024 * numberInt
-	default:
-		log.ErrorLog("Unknown unit: " + unit)
+	kb, err := ParseMemory(resources)
+	if err != nil {
+		log.ErrorLog(err.Error())
 		return 0
 	}
+	return int(kb)
 }
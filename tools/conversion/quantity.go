@@ -0,0 +1,141 @@
+package conversion
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"minik8s/pkg/apiObject"
+)
+
+// ParseCPU 将cpu数量转化为毫核，支持 0.5、1、500m 等写法
+func ParseCPU(cpu string) (int64, error) {
+	cpu = strings.TrimSpace(cpu)
+	if cpu == "" {
+		return 0, errors.New("empty cpu quantity")
+	}
+	if strings.HasSuffix(cpu, "m") {
+		milli, err := strconv.ParseInt(strings.TrimSuffix(cpu, "m"), 10, 64)
+		if err != nil || milli < 0 {
+			return 0, fmt.Errorf("invalid cpu quantity: %s", cpu)
+		}
+		return milli, nil
+	}
+	cores, err := strconv.ParseFloat(cpu, 64)
+	if err != nil || cores < 0 {
+		return 0, fmt.Errorf("invalid cpu quantity: %s", cpu)
+	}
+	milli, ok := floatToInt64(cores*1000 + 0.5)
+	if !ok {
+		return 0, fmt.Errorf("invalid cpu quantity: %s", cpu)
+	}
+	return milli, nil
+}
+
+// ParseMemory 将内存数量转化为单位为 KB 的大小，支持 Ki、Mi、Gi、Ti、K、M、G、T 以及不带单位的字节数
+func ParseMemory(memory string) (int64, error) {
+	bytes, err := ParseMemoryBytes(memory)
+	if err != nil {
+		return 0, err
+	}
+	// 向上取整到 KB
+	kilobytes := bytes / 1024
+	if bytes%1024 != 0 {
+		kilobytes++
+	}
+	return kilobytes, nil
+}
+
+// ParseMemoryBytes 将内存数量转化为字节数，不足一字节的部分向上取整
+func ParseMemoryBytes(memory string) (int64, error) {
+	memory = strings.TrimSpace(memory)
+	if memory == "" {
+		return 0, errors.New("empty memory quantity")
+	}
+	// memory 中第一个非数字字符之后的部分都是单位
+	idx := strings.IndexFunc(memory, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	number, unit := memory, ""
+	if idx >= 0 {
+		number, unit = memory[:idx], memory[idx:]
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory quantity: %s", memory)
+	}
+	var bytes float64
+	switch unit {
+	case "":
+		bytes = value
+	case "Ki":
+		bytes = value * (1 << 10)
+	case "Mi":
+		bytes = value * (1 << 20)
+	case "Gi":
+		bytes = value * (1 << 30)
+	case "Ti":
+		bytes = value * (1 << 40)
+	case "K", "k":
+		bytes = value * 1e3
+	case "M":
+		bytes = value * 1e6
+	case "G":
+		bytes = value * 1e9
+	case "T":
+		bytes = value * 1e12
+	default:
+		return 0, fmt.Errorf("invalid memory unit: %s", memory)
+	}
+	result, ok := floatToInt64(math.Ceil(bytes))
+	if !ok {
+		return 0, fmt.Errorf("invalid memory quantity: %s", memory)
+	}
+	return result, nil
+}
+
+// floatToInt64 将浮点数转化为int64，负数、Inf、NaN以及超出int64范围的值返回false
+func floatToInt64(value float64) (int64, bool) {
+	if math.IsNaN(value) || math.IsInf(value, 0) || value < 0 || value >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(value), true
+}
+
+// ParseQuantity 根据资源名称解析资源数量，cpu 返回毫核，memory 返回 KB，其余资源返回对象个数
+func ParseQuantity(name string, quantity string) (int64, error) {
+	switch {
+	case isCPUResource(name):
+		return ParseCPU(quantity)
+	case isMemoryResource(name):
+		return ParseMemory(quantity)
+	default:
+		count, err := strconv.ParseInt(strings.TrimSpace(quantity), 10, 64)
+		if err != nil || count < 0 {
+			return 0, fmt.Errorf("invalid quantity for %s: %s", name, quantity)
+		}
+		return count, nil
+	}
+}
+
+// FormatQuantity 将 ParseQuantity 得到的数值转化回字符串
+func FormatQuantity(name string, value int64) string {
+	switch {
+	case isCPUResource(name):
+		return fmt.Sprintf("%dm", value)
+	case isMemoryResource(name):
+		return fmt.Sprintf("%dKi", value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func isCPUResource(name string) bool {
+	return name == apiObject.ResourceCPU || strings.HasSuffix(name, "."+apiObject.ResourceCPU)
+}
+
+func isMemoryResource(name string) bool {
+	return name == apiObject.ResourceMemory || strings.HasSuffix(name, "."+apiObject.ResourceMemory)
+}
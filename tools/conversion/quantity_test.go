@@ -0,0 +1,47 @@
+// 测试资源数量的解析
+
+package conversion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCPU(t *testing.T) {
+	cases := map[string]int64{"500m": 500, "1": 1000, "0.5": 500, "2.25": 2250}
+	for quantity, expected := range cases {
+		value, err := ParseCPU(quantity)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, value, quantity)
+	}
+	// 非有限值、负数与超出范围的值都是非法的
+	for _, quantity := range []string{"abc", "Inf", "-Inf", "NaN", "1e30", "-1", "99999999999999999999m"} {
+		_, err := ParseCPU(quantity)
+		assert.Error(t, err, quantity)
+	}
+}
+
+func TestParseMemory(t *testing.T) {
+	cases := map[string]int64{"128Mi": 128 * 1024, "1Gi": 1024 * 1024, "64Ki": 64, "1024": 1, "1M": 977}
+	for quantity, expected := range cases {
+		value, err := ParseMemory(quantity)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, value, quantity)
+	}
+	for _, quantity := range []string{"1Xi", "Inf", "NaN", "1e30", "99999999999Ti", "99999999999999999999"} {
+		_, err := ParseMemory(quantity)
+		assert.Error(t, err, quantity)
+	}
+	// ResourcesConvert 与 ParseMemory 使用相同的解析规则
+	assert.Equal(t, 977, ResourcesConvert("1M"))
+	assert.Equal(t, 1536*1024, ResourcesConvert("1.5Gi"))
+	assert.Equal(t, 0, ResourcesConvert("1Xi"))
+
+	bytes, err := ParseMemoryBytes("1Ki")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), bytes)
+	bytes, err = ParseMemoryBytes("1.5")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), bytes)
+}
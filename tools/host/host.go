@@ -70,3 +70,8 @@ func GetCPULoad() ([]float64, error) {
 	}
 	return loads, nil
 }
+
+// GetCPUCount 获取当前主机的逻辑CPU数量
+func GetCPUCount() (int, error) {
+	return cpu.Counts(true)
+}
@@ -44,3 +44,10 @@ func TestGetCPULoad(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, cpuLoad)
 }
+
+func TestGetCPUCount(t *testing.T) {
+	cpuCount, err := GetCPUCount()
+	log.InfoLog(strconv.Itoa(cpuCount))
+	assert.NoError(t, err)
+	assert.True(t, cpuCount > 0)
+}
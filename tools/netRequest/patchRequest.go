@@ -0,0 +1,38 @@
+package netRequest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+// PatchRequestByTarget Patch请求，target中只包含需要更新的字段，header为额外的请求头，例如If-Match
+func PatchRequestByTarget(uri string, target interface{}, header http.Header) (int, interface{}, error) {
+	jsonData, err := json.Marshal(target)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	request, err := http.NewRequest("PATCH", uri, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, nil, err
+	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer response.Body.Close()
+
+	var bodyJson interface{}
+	if err := json.NewDecoder(response.Body).Decode(&bodyJson); err != nil {
+		return 0, nil, err
+	}
+
+	return response.StatusCode, bodyJson, nil
+}
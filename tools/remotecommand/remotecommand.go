@@ -0,0 +1,259 @@
+// 描述: 交互式exec与attach使用的WebSocket流协议，与容器运行时的流服务器保持一致
+// 参考：https://github.com/kubernetes/apimachinery/blob/master/pkg/util/remotecommand/constants.go
+
+package remotecommand
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ProtocolV4 WebSocket子协议，每条二进制消息的第一个字节为通道编号，其余部分为该通道的数据
+const ProtocolV4 = "v4.channel.k8s.io"
+
+// 通道编号
+const (
+	StreamStdin  = 0
+	StreamStdout = 1
+	StreamStderr = 2
+	// StreamError 命令结束后发送执行结果
+	StreamError = 3
+	// StreamResize 客户端发送终端窗口大小的变化
+	StreamResize = 4
+)
+
+const (
+	StatusSuccess = "Success"
+	StatusFailure = "Failure"
+	// NonZeroExitCodeReason 命令以非0退出码退出，退出码记录在Details中
+	NonZeroExitCodeReason = "NonZeroExitCode"
+	// ExitCodeCauseType 记录退出码的Cause类型
+	ExitCodeCauseType = "ExitCode"
+)
+
+// TerminalSize 终端窗口的大小
+type TerminalSize struct {
+	Width  uint16
+	Height uint16
+}
+
+// Status 命令结束后通过error通道发送的执行结果
+type Status struct {
+	Status  string         `json:"status"`
+	Message string         `json:"message"`
+	Reason  string         `json:"reason"`
+	Details *StatusDetails `json:"details"`
+}
+
+type StatusDetails struct {
+	Causes []StatusCause `json:"causes"`
+}
+
+type StatusCause struct {
+	Type    string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// ExitError 远程命令以非0退出码退出
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return "command terminated with exit code " + strconv.Itoa(e.Code)
+}
+
+// Upgrader 将HTTP请求升级为使用ProtocolV4的WebSocket连接
+var Upgrader = websocket.Upgrader{
+	Subprotocols: []string{ProtocolV4},
+	CheckOrigin:  func(*http.Request) bool { return true },
+}
+
+// Dial 使用ProtocolV4连接WebSocket流，url可以使用http或https协议
+//
+//	握手失败时返回对端响应中的错误信息
+func Dial(url string) (*websocket.Conn, error) {
+	if strings.HasPrefix(url, "http") {
+		url = "ws" + strings.TrimPrefix(url, "http")
+	}
+	dialer := websocket.Dialer{
+		Subprotocols:     []string{ProtocolV4},
+		HandshakeTimeout: 30 * time.Second,
+	}
+	conn, resp, err := dialer.Dial(url, nil)
+	if err != nil && resp != nil {
+		defer resp.Body.Close()
+		var body struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&body) == nil && body.Error != "" {
+			return nil, errors.New(body.Error)
+		}
+		return nil, errors.New("unable to upgrade connection: " + resp.Status)
+	}
+	return conn, err
+}
+
+// Proxy 在两个WebSocket连接之间原样转发消息，任意一端关闭后关闭两个连接
+//
+//	后端先关闭时将其关闭原因转发给客户端，客户端据此判断没有执行结果的命令是否正常结束
+func Proxy(client *websocket.Conn, backend *websocket.Conn) {
+	clientDone := make(chan error, 1)
+	backendDone := make(chan error, 1)
+	go func() {
+		clientDone <- copyMessages(backend, client)
+	}()
+	go func() {
+		backendDone <- copyMessages(client, backend)
+	}()
+	select {
+	case <-clientDone:
+		closeConn(client, nil)
+		closeConn(backend, nil)
+		<-backendDone
+	case err := <-backendDone:
+		closeConn(client, err)
+		closeConn(backend, nil)
+		<-clientDone
+	}
+}
+
+func copyMessages(dst *websocket.Conn, src *websocket.Conn) error {
+	for {
+		messageType, data, err := src.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if err = dst.WriteMessage(messageType, data); err != nil {
+			return err
+		}
+	}
+}
+
+// closeConn 以对端连接结束的原因关闭连接，cause为空时正常关闭
+func closeConn(conn *websocket.Conn, cause error) {
+	code, text := websocket.CloseNormalClosure, ""
+	var closeErr *websocket.CloseError
+	switch {
+	case cause == nil:
+	case errors.As(cause, &closeErr) && closeErr.Code != websocket.CloseNoStatusReceived &&
+		closeErr.Code != websocket.CloseAbnormalClosure && closeErr.Code != websocket.CloseTLSHandshake:
+		// 以上三种关闭码不能出现在关闭帧中
+		code, text = closeErr.Code, closeErr.Text
+	default:
+		code, text = websocket.CloseInternalServerErr, cause.Error()
+	}
+	message := websocket.FormatCloseMessage(code, text)
+	_ = conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+	_ = conn.Close()
+}
+
+// StreamOptions 客户端的输入输出，为nil的流不会被使用
+type StreamOptions struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// 终端窗口大小发生变化时发送新的大小
+	Resize <-chan TerminalSize
+}
+
+// Stream 作为客户端在WebSocket连接上收发各个通道的数据，直到对端关闭连接
+//
+//	命令以非0退出码退出时返回*ExitError
+func Stream(conn *websocket.Conn, opts StreamOptions) error {
+	defer conn.Close()
+	var writeLock sync.Mutex
+	send := func(channel byte, data []byte) error {
+		writeLock.Lock()
+		defer writeLock.Unlock()
+		return conn.WriteMessage(websocket.BinaryMessage, append([]byte{channel}, data...))
+	}
+	if opts.Stdin != nil {
+		go func() {
+			buf := make([]byte, 32*1024)
+			for {
+				n, err := opts.Stdin.Read(buf)
+				if n > 0 && send(StreamStdin, buf[:n]) != nil {
+					return
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	if opts.Resize != nil {
+		go func() {
+			for size := range opts.Resize {
+				data, _ := json.Marshal(size)
+				if send(StreamResize, data) != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	var status []byte
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			// 对端在发送执行结果后关闭连接，没有执行结果时只有正常关闭表示命令成功
+			if len(status) == 0 && !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return err
+			}
+			break
+		}
+		if len(data) == 0 {
+			continue
+		}
+		switch data[0] {
+		case StreamStdout:
+			if opts.Stdout != nil {
+				_, _ = opts.Stdout.Write(data[1:])
+			}
+		case StreamStderr:
+			if opts.Stderr != nil {
+				_, _ = opts.Stderr.Write(data[1:])
+			}
+		case StreamError:
+			status = append(status, data[1:]...)
+		}
+	}
+	return statusError(status)
+}
+
+// statusError 将error通道中的执行结果转换为错误
+func statusError(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var status Status
+	if err := json.Unmarshal(data, &status); err != nil {
+		return fmt.Errorf("error stream protocol error: %s", string(data))
+	}
+	if status.Status == StatusSuccess {
+		return nil
+	}
+	if status.Reason == NonZeroExitCodeReason && status.Details != nil {
+		for _, cause := range status.Details.Causes {
+			if cause.Type != ExitCodeCauseType {
+				continue
+			}
+			code, err := strconv.Atoi(cause.Message)
+			if err != nil {
+				return fmt.Errorf("error stream protocol error: invalid exit code value %q", cause.Message)
+			}
+			return &ExitError{Code: code}
+		}
+	}
+	return errors.New(status.Message)
+}
@@ -0,0 +1,155 @@
+package remotecommand
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// newBackend 模拟容器运行时的流服务器：将stdin原样输出到stdout，将终端大小输出到stderr，输入exit后以status结束
+func newBackend(t *testing.T, status Status, waitResize bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrader.Upgrade(w, r, nil)
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer conn.Close()
+		assert.Equal(t, ProtocolV4, conn.Subprotocol())
+		// 与流服务器相同，连接建立后在各个输出通道上发送空消息
+		for _, channel := range []byte{StreamStdout, StreamStderr, StreamError} {
+			_ = conn.WriteMessage(websocket.BinaryMessage, []byte{channel})
+		}
+		exit := func() {
+			message, _ := json.Marshal(status)
+			_ = conn.WriteMessage(websocket.BinaryMessage, append([]byte{StreamError}, message...))
+			_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		}
+		// 需要等待终端大小时，收到exit与终端大小后才结束
+		resized, exiting := !waitResize, false
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			switch data[0] {
+			case StreamStdin:
+				if string(data[1:]) != "exit" {
+					_ = conn.WriteMessage(websocket.BinaryMessage, append([]byte{StreamStdout}, data[1:]...))
+					continue
+				}
+				exiting = true
+			case StreamResize:
+				_ = conn.WriteMessage(websocket.BinaryMessage, append([]byte{StreamStderr}, data[1:]...))
+				resized = true
+			}
+			if exiting && resized {
+				exit()
+				return
+			}
+		}
+	}))
+}
+
+// newProxy 与apiServer和kubelet相同，升级客户端的连接后转发给后端
+func newProxy(t *testing.T, backendURL string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backend, err := Dial(backendURL)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error":"` + err.Error() + `"}`))
+			return
+		}
+		client, err := Upgrader.Upgrade(w, r, nil)
+		if !assert.Nil(t, err) {
+			return
+		}
+		Proxy(client, backend)
+	}))
+}
+
+func TestStreamThroughProxy(t *testing.T) {
+	backend := newBackend(t, Status{
+		Status:  StatusFailure,
+		Reason:  NonZeroExitCodeReason,
+		Details: &StatusDetails{Causes: []StatusCause{{Type: ExitCodeCauseType, Message: "3"}}},
+	}, true)
+	defer backend.Close()
+	proxy := newProxy(t, backend.URL)
+	defer proxy.Close()
+
+	conn, err := Dial(proxy.URL)
+	assert.Nil(t, err)
+	stdinReader, stdinWriter := newPipe()
+	resize := make(chan TerminalSize, 1)
+	resize <- TerminalSize{Width: 80, Height: 24}
+	var stdout, stderr bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- Stream(conn, StreamOptions{Stdin: stdinReader, Stdout: &stdout, Stderr: &stderr, Resize: resize})
+	}()
+	stdinWriter <- "hello"
+	stdinWriter <- "exit"
+
+	err = <-done
+	assert.Equal(t, &ExitError{Code: 3}, err)
+	assert.Equal(t, "hello", stdout.String())
+	assert.Equal(t, `{"Width":80,"Height":24}`, stderr.String())
+}
+
+func TestStreamStatus(t *testing.T) {
+	backend := newBackend(t, Status{Status: StatusSuccess}, false)
+	defer backend.Close()
+	conn, err := Dial(backend.URL)
+	assert.Nil(t, err)
+	assert.Nil(t, Stream(conn, StreamOptions{Stdin: strings.NewReader("exit")}))
+
+	backend = newBackend(t, Status{Status: StatusFailure, Message: "container not running"}, false)
+	defer backend.Close()
+	conn, err = Dial(backend.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "container not running", Stream(conn, StreamOptions{Stdin: strings.NewReader("exit")}).Error())
+
+	// 没有执行结果时，只有正常关闭表示命令成功，经过代理时保留后端的关闭原因
+	aborted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrader.Upgrade(w, r, nil)
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer conn.Close()
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "runtime error"))
+	}))
+	defer aborted.Close()
+	abortedProxy := newProxy(t, aborted.URL)
+	defer abortedProxy.Close()
+	for _, url := range []string{aborted.URL, abortedProxy.URL} {
+		conn, err = Dial(url)
+		assert.Nil(t, err)
+		err = Stream(conn, StreamOptions{})
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseInternalServerErr))
+	}
+
+	// 握手失败时返回对端的错误信息
+	proxy := newProxy(t, "http://127.0.0.1:1")
+	defer proxy.Close()
+	_, err = Dial(proxy.URL)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "connection refused")
+}
+
+// pipeReader 每次Read返回一条完整的消息，保证stdin的每次写入对应一条WebSocket消息
+type pipeReader chan string
+
+func newPipe() (pipeReader, chan<- string) {
+	ch := make(chan string)
+	return ch, ch
+}
+
+func (p pipeReader) Read(buf []byte) (int, error) {
+	return copy(buf, <-p), nil
+}